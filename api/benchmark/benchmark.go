@@ -0,0 +1,107 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
+	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// ConnectionStringEnv names the database the benchmarks seed and query, they are skipped without it
+const ConnectionStringEnv = "BENCHMARK_PGSQL_CONNECTIONSTRING"
+
+// Fixture is what Seed inserted, every tracker has every user as a member
+type Fixture struct {
+	Users    []model.User
+	Trackers []model.Tracker
+}
+
+// Seed inserts users, trackers shared by all of them and spends for each user on each tracker
+func Seed(ctx context.Context, db *sql.DB, users int, trackers int, spendsPerUser int) (Fixture, error) {
+
+	userRepository := userrepository.NewPostgresUserRepository(infrastructure.NilLogger{}, db)
+	trackerRepository := trackerrepository.NewPostgresTrackerRepository(infrastructure.NilLogger{}, db)
+	spendRepository := spendrepository.NewPostgresSpendRepository(infrastructure.NilLogger{}, db)
+
+	fixture := Fixture{}
+	run := strconv.FormatInt(time.Now().UnixNano(), 10)
+	userIDs := []int64{}
+
+	for i := 0; i < users; i++ {
+		user, err := userRepository.Insert(ctx, model.User{
+			Name:             "Benchmark " + strconv.Itoa(i),
+			AuthenticationID: "benchmark|" + run + "|" + strconv.Itoa(i),
+			EmailAddress:     "benchmark+" + run + "-" + strconv.Itoa(i) + "@godutch.example",
+			DateCreated:      time.Now(),
+		})
+		if err != nil {
+			return fixture, err
+		}
+		fixture.Users = append(fixture.Users, user)
+		userIDs = append(userIDs, user.ID)
+	}
+
+	for i := 0; i < trackers; i++ {
+		tracker, err := trackerRepository.Insert(ctx, model.Tracker{
+			AdminUserID:    userIDs[0],
+			TrackerUserIDs: userIDs,
+			Name:           "Benchmark " + strconv.Itoa(i),
+			Currency:       "GBP",
+			DateCreated:    time.Now(),
+		})
+		if err != nil {
+			return fixture, err
+		}
+		fixture.Trackers = append(fixture.Trackers, tracker)
+
+		for _, userID := range userIDs {
+			for s := 0; s < spendsPerUser; s++ {
+				_, err := spendRepository.Insert(ctx, model.Spend{
+					Value:       decimal.NewFromFloat(float64(10 + s)),
+					TrackerID:   tracker.ID,
+					Name:        "Benchmark " + strconv.Itoa(s),
+					UserID:      userID,
+					Currency:    "GBP",
+					DateCreated: time.Now(),
+				})
+				if err != nil {
+					return fixture, err
+				}
+			}
+		}
+	}
+
+	return fixture, nil
+}
+
+// Cleanup deletes everything Seed inserted and what the services wrote for its trackers
+func Cleanup(ctx context.Context, db *sql.DB, fixture Fixture) error {
+
+	trackerIDs := []int64{}
+	for _, tracker := range fixture.Trackers {
+		trackerIDs = append(trackerIDs, tracker.ID)
+	}
+	userIDs := []int64{}
+	for _, user := range fixture.Users {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	for _, table := range []string{"Transfers", "SpendSummaries", "Spends", "TrackerUsers"} {
+		if _, err := db.ExecContext(ctx, "DELETE FROM \""+table+"\" WHERE \"TrackerID\" = ANY($1)", pq.Array(trackerIDs)); err != nil {
+			return err
+		}
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM \"Trackers\" WHERE \"ID\" = ANY($1)", pq.Array(trackerIDs)); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, "DELETE FROM \"Users\" WHERE \"ID\" = ANY($1)", pq.Array(userIDs))
+	return err
+}
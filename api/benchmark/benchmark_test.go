@@ -0,0 +1,123 @@
+package benchmark_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/benchmark"
+	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
+	"github.com/TomPallister/godutch-api/api/domain/transferservice"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/querycount"
+	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
+	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
+	"github.com/TomPallister/godutch-api/api/repository/transferrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/lib/pq"
+)
+
+// members are the tracker sizes each benchmark runs with, the queries per op should not grow with them
+var members = []int{2, 10, 50}
+
+var db *sql.DB
+var counter *querycount.Counter
+
+func BenchmarkFindTrackersForUser(b *testing.B) {
+	forEachSize(b, 5, func(b *testing.B, fixture benchmark.Fixture) {
+		repository := trackerrepository.NewPostgresTrackerRepository(infrastructure.NilLogger{}, db)
+		measure(b, func() error {
+			_, err := repository.GetForUserID(context.Background(), fixture.Users[0].ID)
+			return err
+		})
+	})
+}
+
+func BenchmarkFindTrackerUsers(b *testing.B) {
+	forEachSize(b, 1, func(b *testing.B, fixture benchmark.Fixture) {
+		trackerRepository := trackerrepository.NewPostgresTrackerRepository(infrastructure.NilLogger{}, db)
+		userRepository := userrepository.NewPostgresUserRepository(infrastructure.NilLogger{}, db)
+		measure(b, func() error {
+			tracker, err := trackerRepository.GetByID(context.Background(), fixture.Trackers[0].ID)
+			if err != nil {
+				return err
+			}
+			_, err = userRepository.GetByIDs(context.Background(), tracker.TrackerUserIDs)
+			return err
+		})
+	})
+}
+
+func BenchmarkUpsertTransfers(b *testing.B) {
+	forEachSize(b, 1, func(b *testing.B, fixture benchmark.Fixture) {
+		service := transferservice.NewGoDutchTransferService(
+			spendrepository.NewPostgresSpendRepository(infrastructure.NilLogger{}, db),
+			transferrepository.NewPostgresTransferRepository(infrastructure.NilLogger{}, db),
+			trackerrepository.NewPostgresTrackerRepository(infrastructure.NilLogger{}, db),
+			userrepository.NewPostgresUserRepository(infrastructure.NilLogger{}, db))
+		measure(b, func() error {
+			_, err := service.UpsertTransfers(context.Background(), fixture.Trackers[0].ID)
+			return err
+		})
+	})
+}
+
+func BenchmarkUpsertSpendSummaries(b *testing.B) {
+	forEachSize(b, 1, func(b *testing.B, fixture benchmark.Fixture) {
+		service := spendsummaryservice.NewGoDutchSpendSummaryService(
+			spendrepository.NewPostgresSpendRepository(infrastructure.NilLogger{}, db),
+			spendsummaryrepository.NewPostgresSpendSummaryRepository(infrastructure.NilLogger{}, db),
+			trackerrepository.NewPostgresTrackerRepository(infrastructure.NilLogger{}, db),
+			userrepository.NewPostgresUserRepository(infrastructure.NilLogger{}, db))
+		measure(b, func() error {
+			_, err := service.UpsertSpendSummaries(context.Background(), fixture.Trackers[0].ID)
+			return err
+		})
+	})
+}
+
+// forEachSize seeds trackers with each number of members and runs benchmark against them
+func forEachSize(b *testing.B, trackers int, run func(b *testing.B, fixture benchmark.Fixture)) {
+	givenIHaveACountingPool(b)
+	for _, size := range members {
+		b.Run(fmt.Sprintf("members=%v", size), func(b *testing.B) {
+			fixture, err := benchmark.Seed(context.Background(), db, size, trackers, 2)
+			defer benchmark.Cleanup(context.Background(), db, fixture)
+			if err != nil {
+				b.Fatal(err)
+			}
+			run(b, fixture)
+		})
+	}
+}
+
+// measure runs request b.N times and reports the statements each one sent
+func measure(b *testing.B, request func() error) {
+	counter.Reset()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := request(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(counter.Count())/float64(b.N), "queries/op")
+}
+
+func givenIHaveACountingPool(b *testing.B) {
+	connectionString := os.Getenv(benchmark.ConnectionStringEnv)
+	if connectionString == "" {
+		b.Skip(benchmark.ConnectionStringEnv + " is not set")
+	}
+	if db != nil {
+		return
+	}
+	connector, err := pq.NewConnector(connectionString)
+	if err != nil {
+		b.Fatal(err)
+	}
+	db, counter = querycount.Open(connector)
+}
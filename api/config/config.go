@@ -0,0 +1,325 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/idempotency"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/encryption"
+	"github.com/TomPallister/godutch-api/api/infrastructure/jwtauth"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/repository"
+	"github.com/TomPallister/godutch-api/api/security"
+	"github.com/TomPallister/godutch-api/api/server"
+	"github.com/TomPallister/godutch-api/api/timeout"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/TomPallister/godutch-api/api/webhooks"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the optional YAML config file when -config is not given
+const FileEnv = "CONFIG_FILE"
+
+// Redacted replaces secrets when the config is printed
+const Redacted = "[redacted]"
+
+// ErrorDatabaseConnectionString ...
+var ErrorDatabaseConnectionString = errors.New("PGSQL_CONNECTIONSTRING is required")
+
+// ErrorGoDutchURL ...
+var ErrorGoDutchURL = errors.New("GODUTCH_URL, the root of the web app, is required")
+
+// ErrorAuthMode ...
+var ErrorAuthMode = errors.New("AUTH_MODE must be local or left unset for JWKS or Auth0 tokens")
+
+// ErrorLocalAuthSigningKey ...
+var ErrorLocalAuthSigningKey = errors.New("LOCAL_AUTH_SIGNING_KEY is required when AUTH_MODE is local")
+
+// ErrorEventsBroker ...
+var ErrorEventsBroker = errors.New("EVENTS_BROKER must be inprocess or postgres")
+
+// ErrorInvalidDuration ...
+var ErrorInvalidDuration = errors.New("REQUEST_TIMEOUT and IDEMPOTENCY_RETENTION must be positive durations")
+
+// Config is everything the api is configured with. Values are the defaults, then the YAML
+// file, then environment variables, later ones winning.
+type Config struct {
+	Server     server.Options         `yaml:"server"`
+	Database   repository.Options     `yaml:"database"`
+	Log        Log                    `yaml:"log"`
+	Tracing    tracing.Options        `yaml:"tracing"`
+	Auth       Auth                   `yaml:"auth"`
+	Email      Email                  `yaml:"email"`
+	Encryption encryption.KeySource   `yaml:"encryption"`
+	Events     Events                 `yaml:"events"`
+	CORS       security.CORSOptions   `yaml:"cors"`
+	Headers    security.HeaderOptions `yaml:"security_headers"`
+	RateLimits ratelimit.Options      `yaml:"rate_limits"`
+	Webhooks   webhooks.Options       `yaml:"webhooks"`
+
+	// GoDutchURL is the root of the web app, links in emails point at it
+	GoDutchURL           string        `yaml:"godutch_url" env:"GODUTCH_URL"`
+	GRPCAddr             string        `yaml:"grpc_addr" env:"GRPC_PORT"`
+	MetricsAddr          string        `yaml:"metrics_addr" env:"METRICS_PORT"`
+	RequestTimeout       time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	IdempotencyRetention time.Duration `yaml:"idempotency_retention" env:"IDEMPOTENCY_RETENTION"`
+}
+
+// Log ...
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// Auth is Mode local, where the api signs its own tokens, or tokens from the Source
+type Auth struct {
+	Mode              string            `yaml:"mode" env:"AUTH_MODE"`
+	LocalSigningKey   string            `yaml:"local_signing_key" env:"LOCAL_AUTH_SIGNING_KEY" secret:"true"`
+	LocalTokenTimeout time.Duration     `yaml:"local_token_timeout" env:"LOCAL_AUTH_TOKEN_TIMEOUT"`
+	Source            jwtauth.KeySource `yaml:"source"`
+}
+
+// Email ...
+type Email struct {
+	SendGridAPIKey string `yaml:"sendgrid_api_key" env:"SENDGRID_API_KEY" secret:"true"`
+	// CheckReachable adds SendGrid to /readyz
+	CheckReachable bool `yaml:"check_reachable" env:"READYZ_CHECK_EMAIL"`
+}
+
+// Events is Broker inprocess, for a single instance, or postgres
+type Events struct {
+	Broker string `yaml:"broker" env:"EVENTS_BROKER"`
+}
+
+// Default ...
+func Default() Config {
+	return Config{
+		Server:               server.DefaultOptions,
+		Database:             repository.DefaultOptions,
+		Log:                  Log{Level: "info", Format: "json"},
+		Tracing:              tracing.DefaultOptions,
+		Auth:                 Auth{LocalTokenTimeout: time.Hour},
+		Events:               Events{Broker: "inprocess"},
+		CORS:                 security.DefaultCORSOptions,
+		Headers:              security.DefaultHeaderOptions,
+		RateLimits:           ratelimit.DefaultOptions,
+		Webhooks:             webhooks.DefaultOptions,
+		RequestTimeout:       timeout.DefaultTimeout,
+		IdempotencyRetention: idempotency.DefaultRetention,
+	}
+}
+
+// Load reads the config at path, see Read, and validates it
+func Load(path string) (Config, error) {
+	config, err := Read(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return config, config.Validate()
+}
+
+// Read loads .env into the environment if it exists, then builds the config from the defaults, the
+// YAML file at path, or the one CONFIG_FILE names, and the environment
+func Read(path string) (Config, error) {
+
+	if err := godotenv.Load(); err != nil && errors.Is(err, os.ErrNotExist) == false {
+		return Config{}, err
+	}
+
+	config := Default()
+
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return Config{}, err
+		}
+		defer file.Close()
+		if err := Decode(file, &config); err != nil {
+			return Config{}, fmt.Errorf("%v: %w", path, err)
+		}
+	}
+
+	if err := FromEnv(&config); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// Decode reads YAML over config, keys that are not fields are an error
+func Decode(r io.Reader, config *Config) error {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// FromEnv sets each field that has an env tag from the variable it names, when that is set. Lists
+// are comma separated.
+func FromEnv(config *Config) error {
+	return walk(reflect.ValueOf(config).Elem(), func(field reflect.Value, tag reflect.StructTag) error {
+		name := tag.Get("env")
+		value, ok := os.LookupEnv(name)
+		if name == "" || ok == false || value == "" {
+			return nil
+		}
+		if err := set(field, value); err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Validate ...
+func (config Config) Validate() error {
+
+	if err := config.Server.Validate(); err != nil {
+		return err
+	}
+	if config.Database.ConnectionString == "" {
+		return ErrorDatabaseConnectionString
+	}
+	if err := config.Database.Validate(); err != nil {
+		return err
+	}
+	if _, err := infrastructure.NewStructuredLogger(io.Discard, config.Log.Level, config.Log.Format); err != nil {
+		return err
+	}
+	if err := config.Tracing.Validate(); err != nil {
+		return err
+	}
+	if config.GoDutchURL == "" {
+		return ErrorGoDutchURL
+	}
+
+	switch config.Auth.Mode {
+	case "local":
+		if config.Auth.LocalSigningKey == "" {
+			return ErrorLocalAuthSigningKey
+		}
+	case "":
+		source := config.Auth.Source
+		if source.JWKSURL == "" && source.JWKSFile == "" && source.ClientSecret == "" {
+			return jwtauth.ErrorNoKeySource
+		}
+	default:
+		return ErrorAuthMode
+	}
+
+	if err := config.CORS.Validate(); err != nil {
+		return err
+	}
+	if err := config.Headers.Validate(); err != nil {
+		return err
+	}
+	if err := config.RateLimits.Validate(); err != nil {
+		return err
+	}
+
+	switch config.Events.Broker {
+	case "", "inprocess", "postgres":
+	default:
+		return ErrorEventsBroker
+	}
+
+	if config.RequestTimeout <= 0 || config.IdempotencyRetention <= 0 {
+		return ErrorInvalidDuration
+	}
+
+	return nil
+}
+
+// Print writes the config as YAML with the secrets that are set replaced by Redacted
+func (config Config) Print(w io.Writer) error {
+
+	walk(reflect.ValueOf(&config).Elem(), func(field reflect.Value, tag reflect.StructTag) error {
+		if tag.Get("secret") == "true" && field.String() != "" {
+			field.SetString(Redacted)
+		}
+		return nil
+	})
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// walk calls visit with every field in value that is not itself a struct, structs that read
+// themselves from text, such as rate limits, are visited rather than walked
+func walk(value reflect.Value, visit func(field reflect.Value, tag reflect.StructTag) error) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		tag := value.Type().Field(i).Tag
+		if field.Kind() == reflect.Struct && field.Addr().Type().Implements(textUnmarshalerType) == false {
+			if err := walk(field, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := visit(field, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func set(field reflect.Value, value string) error {
+
+	switch {
+	case field.Addr().Type().Implements(textUnmarshalerType):
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	case field.Type() == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("fields of type %v cannot be set from the environment", field.Type())
+	}
+
+	return nil
+}
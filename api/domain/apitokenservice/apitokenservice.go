@@ -0,0 +1,183 @@
+package apitokenservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/apitokenauth"
+	"github.com/TomPallister/godutch-api/api/infrastructure/token"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/apitokenrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorInvalidName ...
+var ErrorInvalidName = domainerror.NewValidation("invalid_name", "name", "Invalid name")
+
+// ErrorInvalidScope ...
+var ErrorInvalidScope = domainerror.NewValidation("invalid_scope", "scopes", "Invalid scope, must be one of read, spends:write or write")
+
+// ErrorInvalidAPIToken ...
+var ErrorInvalidAPIToken = domainerror.NewUnauthorized("invalid_api_token", "The API token is invalid")
+
+// ErrorAPITokenRevoked ...
+var ErrorAPITokenRevoked = domainerror.NewUnauthorized("api_token_revoked", "The API token has been revoked")
+
+// ErrorPermissionsToRevokeAPIToken ...
+var ErrorPermissionsToRevokeAPIToken = domainerror.NewForbidden("cannot_revoke_api_token", "You do not have permission to revoke this API token")
+
+// lastUsedResolution stops every request with an API token writing to the database
+const lastUsedResolution = time.Minute
+
+// APITokenService ...
+type APITokenService interface {
+	CreateAPIToken(ctx context.Context, sub string, apiToken model.APIToken) (model.APIToken, string, error)
+
+	FindByUser(ctx context.Context, sub string) ([]model.APIToken, error)
+
+	RevokeAPIToken(ctx context.Context, sub string, id int64) (bool, error)
+
+	CheckAPIToken(ctx context.Context, apiToken string) (model.APIToken, model.User, error)
+}
+
+// GoDutchAPITokenService ...
+type GoDutchAPITokenService struct {
+	apiTokenRepository apitokenrepository.APITokenRepository
+	userRepository     userrepository.UserRepository
+	logger             infrastructure.Logger
+}
+
+// NewGoDutchAPITokenService ...
+func NewGoDutchAPITokenService(apiTokenRepository apitokenrepository.APITokenRepository,
+	userRepository userrepository.UserRepository,
+	logger infrastructure.Logger) *GoDutchAPITokenService {
+
+	service := GoDutchAPITokenService{}
+	service.apiTokenRepository = apiTokenRepository
+	service.userRepository = userRepository
+	service.logger = logger
+	return &service
+}
+
+// CreateAPIToken returns the saved token and the token itself, which is only available now
+func (goDutchAPITokenService *GoDutchAPITokenService) CreateAPIToken(ctx context.Context, sub string,
+	apiToken model.APIToken) (model.APIToken, string, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.CreateAPIToken")
+	defer span.End()
+
+	user, err := goDutchAPITokenService.userRepository.GetBySub(ctx, sub)
+	if err != nil {
+		return model.APIToken{}, "", err
+	}
+
+	if len(strings.TrimSpace(apiToken.Name)) <= 0 {
+		return model.APIToken{}, "", ErrorInvalidName
+	}
+
+	if len(apiToken.Scopes) <= 0 {
+		return model.APIToken{}, "", ErrorInvalidScope
+	}
+
+	for _, s := range apiToken.Scopes {
+		if !infrastructure.StringsContains(model.APITokenScopes, s) {
+			return model.APIToken{}, "", ErrorInvalidScope
+		}
+	}
+
+	newToken, err := token.New()
+	if err != nil {
+		return model.APIToken{}, "", err
+	}
+	newToken = apitokenauth.TokenPrefix + newToken
+
+	apiToken.ID = 0
+	apiToken.UserID = user.ID
+	apiToken.TokenHash = token.Hash(newToken)
+	apiToken.DateCreated = time.Now()
+	apiToken.DateLastUsed = nil
+	apiToken.DateRevoked = nil
+
+	apiToken, err = goDutchAPITokenService.apiTokenRepository.Insert(ctx, apiToken)
+	if err != nil {
+		return model.APIToken{}, "", err
+	}
+
+	return apiToken, newToken, nil
+}
+
+// FindByUser ...
+func (goDutchAPITokenService *GoDutchAPITokenService) FindByUser(ctx context.Context, sub string) ([]model.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.FindByUser")
+	defer span.End()
+
+	user, err := goDutchAPITokenService.userRepository.GetBySub(ctx, sub)
+	if err != nil {
+		return []model.APIToken{}, err
+	}
+
+	return goDutchAPITokenService.apiTokenRepository.GetForUserID(ctx, user.ID)
+}
+
+// RevokeAPIToken ...
+func (goDutchAPITokenService *GoDutchAPITokenService) RevokeAPIToken(ctx context.Context, sub string, id int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.RevokeAPIToken")
+	defer span.End()
+
+	user, err := goDutchAPITokenService.userRepository.GetBySub(ctx, sub)
+	if err != nil {
+		return false, err
+	}
+
+	apiToken, err := goDutchAPITokenService.apiTokenRepository.GetByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if apiToken.UserID != user.ID {
+		return false, ErrorPermissionsToRevokeAPIToken
+	}
+
+	return goDutchAPITokenService.apiTokenRepository.Revoke(ctx, id, time.Now())
+}
+
+// CheckAPIToken ...
+func (goDutchAPITokenService *GoDutchAPITokenService) CheckAPIToken(ctx context.Context, apiToken string) (model.APIToken, model.User, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.CheckAPIToken")
+	defer span.End()
+
+	if err := token.Validate(strings.TrimPrefix(apiToken, apitokenauth.TokenPrefix)); err != nil {
+		return model.APIToken{}, model.User{}, ErrorInvalidAPIToken
+	}
+
+	storedAPIToken, err := goDutchAPITokenService.apiTokenRepository.GetByTokenHash(ctx, token.Hash(apiToken))
+	if err == apitokenrepository.ErrorNotFound {
+		return model.APIToken{}, model.User{}, ErrorInvalidAPIToken
+	}
+	if err != nil {
+		return model.APIToken{}, model.User{}, err
+	}
+
+	if storedAPIToken.DateRevoked != nil {
+		return model.APIToken{}, model.User{}, ErrorAPITokenRevoked
+	}
+
+	user, err := goDutchAPITokenService.userRepository.GetByID(ctx, storedAPIToken.UserID)
+	if err != nil {
+		return model.APIToken{}, model.User{}, err
+	}
+
+	now := time.Now()
+	if storedAPIToken.DateLastUsed == nil || now.Sub(*storedAPIToken.DateLastUsed) > lastUsedResolution {
+		// failing to record last used should not stop the request
+		if _, err := goDutchAPITokenService.apiTokenRepository.UpdateLastUsed(ctx, storedAPIToken.ID, now); err != nil {
+			infrastructure.LoggerFrom(ctx, goDutchAPITokenService.logger).Error("Could not update API token last used", err)
+		}
+		storedAPIToken.DateLastUsed = &now
+	}
+
+	return storedAPIToken, user, nil
+}
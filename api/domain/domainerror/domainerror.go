@@ -0,0 +1,213 @@
+package domainerror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Error is a failure the api can explain to the caller. Code is stable and safe for clients to
+// switch on, Status is the HTTP status it maps to and Field names the input at fault, if any.
+type Error struct {
+	Code    string
+	Status  int
+	Field   string
+	Message string
+	Details map[string]interface{}
+}
+
+func (domainError *Error) Error() string {
+	return domainError.Message
+}
+
+// Is matches any error with the same code, so errors.Is works on copies made by WithDetails
+func (domainError *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == domainError.Code
+}
+
+// WithDetails returns a copy of the error carrying extra information for the caller
+func (domainError *Error) WithDetails(details map[string]interface{}) *Error {
+	withDetails := *domainError
+	withDetails.Details = details
+	return &withDetails
+}
+
+// WithField returns a copy of the error for a different input, e.g. when the same rule applies in another request body
+func (domainError *Error) WithField(field string) *Error {
+	withField := *domainError
+	withField.Field = field
+	return &withField
+}
+
+// NewBadRequest is for requests that cannot be understood
+func NewBadRequest(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusBadRequest, Message: message}
+}
+
+// NewUnauthorized is for callers who are not who they say they are
+func NewUnauthorized(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusUnauthorized, Message: message}
+}
+
+// NewForbidden is for callers who are not allowed to do what they asked
+func NewForbidden(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusForbidden, Message: message}
+}
+
+// NewNotFound ...
+func NewNotFound(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusNotFound, Message: message}
+}
+
+// NewConflict is for requests that clash with the current state of a resource
+func NewConflict(code string, field string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusConflict, Field: field, Message: message}
+}
+
+// NewPreconditionFailed is for conditional requests whose condition no longer holds
+func NewPreconditionFailed(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusPreconditionFailed, Message: message}
+}
+
+// NewPreconditionRequired is for requests that must be made conditional
+func NewPreconditionRequired(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusPreconditionRequired, Message: message}
+}
+
+// NewRequestEntityTooLarge is for request bodies larger than the api reads
+func NewRequestEntityTooLarge(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusRequestEntityTooLarge, Message: message}
+}
+
+// NewUnsupportedMediaType is for request bodies sent in a format the api does not accept
+func NewUnsupportedMediaType(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusUnsupportedMediaType, Message: message}
+}
+
+// NewValidation is for well formed requests whose values break a rule
+func NewValidation(code string, field string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusUnprocessableEntity, Field: field, Message: message}
+}
+
+// NewInternal is for failures that are not the caller's fault
+func NewInternal(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusInternalServerError, Message: message}
+}
+
+// NewServiceUnavailable is for requests the api could not finish in time, they can be retried
+func NewServiceUnavailable(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusServiceUnavailable, Message: message}
+}
+
+// NewTooManyRequests ...
+func NewTooManyRequests(code string, message string) *Error {
+	return &Error{Code: code, Status: http.StatusTooManyRequests, Message: message}
+}
+
+// StatusClientClosedRequest is the status, borrowed from nginx, of requests the caller gave up on
+const StatusClientClosedRequest = 499
+
+// ErrorNotFound is what a missing row becomes when a repository does not say what was missing
+var ErrorNotFound = NewNotFound("not_found", "The resource was not found")
+
+// ErrorTimeout is what a request that ran past its deadline becomes
+var ErrorTimeout = NewServiceUnavailable("request_timeout", "The request took too long and was stopped")
+
+// ErrorInternal is what an error the api cannot explain becomes, its text is logged rather than returned
+var ErrorInternal = NewInternal("internal_error", "The request could not be completed")
+
+// ErrorCanceled is what a request the caller went away from becomes
+var ErrorCanceled = &Error{Code: "request_canceled", Status: StatusClientClosedRequest, Message: "The request was canceled"}
+
+// sqlStateQueryCanceled is the SQLSTATE of a query Postgres stopped, because it ran past
+// statement_timeout or because the driver cancelled it when its context was done
+const sqlStateQueryCanceled = "57014"
+
+// CodeValidationFailed is the code of the error returned for more than one violation
+const CodeValidationFailed = "validation_failed"
+
+// Errors collects every violation found in a request so they can be reported together
+type Errors []*Error
+
+func (domainErrors Errors) Error() string {
+	messages := make([]string, len(domainErrors))
+	for index, domainError := range domainErrors {
+		messages[index] = domainError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is find any of the collected errors
+func (domainErrors Errors) Unwrap() []error {
+	unwrapped := make([]error, len(domainErrors))
+	for index, domainError := range domainErrors {
+		unwrapped[index] = domainError
+	}
+	return unwrapped
+}
+
+// Err returns nil when nothing was collected, the error itself when there was one, or all of them
+func (domainErrors Errors) Err() error {
+	switch len(domainErrors) {
+	case 0:
+		return nil
+	case 1:
+		return domainErrors[0]
+	}
+	return domainErrors
+}
+
+// Summary is the single error a caller sees for the collection. Each violation is listed in its
+// details, and the status is shared by all of them or 422 if they differ.
+func (domainErrors Errors) Summary() *Error {
+	status := domainErrors[0].Status
+	violations := make([]map[string]interface{}, len(domainErrors))
+	for index, domainError := range domainErrors {
+		if domainError.Status != status {
+			status = http.StatusUnprocessableEntity
+		}
+		violations[index] = map[string]interface{}{
+			"field":   domainError.Field,
+			"code":    domainError.Code,
+			"message": domainError.Message,
+		}
+	}
+	return &Error{
+		Code:    CodeValidationFailed,
+		Status:  status,
+		Message: fmt.Sprintf("The request has %v problems", len(domainErrors)),
+		Details: map[string]interface{}{"errors": violations},
+	}
+}
+
+// From returns the domain error for err, or nil if err is not one
+func From(err error) *Error {
+	var domainErrors Errors
+	if errors.As(err, &domainErrors) && len(domainErrors) > 0 {
+		return domainErrors.Summary()
+	}
+	var domainError *Error
+	if errors.As(err, &domainError) {
+		return domainError
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrorNotFound
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+	var pqError *pq.Error
+	if errors.As(err, &pqError) && pqError.Code == sqlStateQueryCanceled {
+		return ErrorTimeout
+	}
+	return nil
+}
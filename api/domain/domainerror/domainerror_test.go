@@ -0,0 +1,90 @@
+package domainerror_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/lib/pq"
+)
+
+var errorNotMember = domainerror.NewForbidden("not_a_tracker_user", "User does not belong to tracker")
+
+func TestDomainErrorIsFoundWhenWrapped(t *testing.T) {
+	wrapped := fmt.Errorf("finding spends: %w", errorNotMember)
+	domainError := domainerror.From(wrapped)
+	if domainError == nil || domainError.Status != http.StatusForbidden {
+		t.Fatalf("expected a 403 domain error but got %v", domainError)
+	}
+}
+
+func TestMissingRowIsNotFound(t *testing.T) {
+	domainError := domainerror.From(sql.ErrNoRows)
+	if domainError == nil || domainError.Status != http.StatusNotFound {
+		t.Fatalf("expected a 404 domain error but got %v", domainError)
+	}
+}
+
+func TestContextErrorsAreDomainErrors(t *testing.T) {
+	if domainError := domainerror.From(fmt.Errorf("finding spends: %w", context.DeadlineExceeded)); domainError != domainerror.ErrorTimeout {
+		t.Fatalf("expected %v but got %v", domainerror.ErrorTimeout, domainError)
+	}
+	if domainError := domainerror.From(context.Canceled); domainError != domainerror.ErrorCanceled {
+		t.Fatalf("expected %v but got %v", domainerror.ErrorCanceled, domainError)
+	}
+}
+
+func TestCanceledQueriesAreTimeouts(t *testing.T) {
+	canceled := &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}
+	if domainError := domainerror.From(fmt.Errorf("finding spends: %w", canceled)); domainError != domainerror.ErrorTimeout {
+		t.Fatalf("expected %v but got %v", domainerror.ErrorTimeout, domainError)
+	}
+}
+
+func TestOtherErrorsAreNotDomainErrors(t *testing.T) {
+	if domainError := domainerror.From(errors.New("connection refused")); domainError != nil {
+		t.Fatalf("expected nil but got %v", domainError)
+	}
+}
+
+func TestErrorWithDetailsIsStillTheSameError(t *testing.T) {
+	withDetails := errorNotMember.WithDetails(map[string]interface{}{"trackerId": 1})
+	if errors.Is(withDetails, errorNotMember) == false {
+		t.Fatal("expected errors.Is to match on code")
+	}
+	if errorNotMember.Details != nil {
+		t.Fatal("expected the original error to be unchanged")
+	}
+}
+
+func TestCollectedErrorsAreReportedTogether(t *testing.T) {
+	invalidName := domainerror.NewValidation("invalid_name", "spend.name", "Invalid name")
+	invalidCurrency := domainerror.NewValidation("invalid_currency", "spend.currency", "Invalid currency")
+	err := domainerror.Errors{invalidName, invalidCurrency}.Err()
+
+	if errors.Is(err, invalidName) == false || errors.Is(err, invalidCurrency) == false {
+		t.Fatal("expected errors.Is to find each collected error")
+	}
+
+	domainError := domainerror.From(err)
+	if domainError.Code != domainerror.CodeValidationFailed || domainError.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected summary %v", domainError)
+	}
+	if violations := domainError.Details["errors"].([]map[string]interface{}); len(violations) != 2 || violations[1]["field"] != "spend.currency" {
+		t.Fatalf("unexpected violations %v", domainError.Details)
+	}
+}
+
+func TestNoCollectedErrorsIsNil(t *testing.T) {
+	var collected domainerror.Errors
+	if collected.Err() != nil {
+		t.Fatal("expected nil")
+	}
+	if collected = append(collected, errorNotMember); collected.Err() != errorNotMember {
+		t.Fatal("expected a single error to be returned as it is")
+	}
+}
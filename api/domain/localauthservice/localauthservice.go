@@ -0,0 +1,302 @@
+package localauthservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/token"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/localcredentialrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/nu7hatch/gouuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrorInvalidCredentials ...
+var ErrorInvalidCredentials = domainerror.NewUnauthorized("invalid_credentials", "The email address or password is incorrect")
+
+// ErrorAccountLocked ...
+var ErrorAccountLocked = domainerror.NewForbidden("account_locked", "The account is locked, try again later")
+
+// ErrorPasswordTooShort ...
+var ErrorPasswordTooShort = domainerror.NewValidation("password_too_short", "password", "Passwords must be at least 8 characters")
+
+// ErrorPasswordTooLong ...
+var ErrorPasswordTooLong = domainerror.NewValidation("password_too_long", "password", "Passwords must be at most 72 bytes")
+
+// ErrorInvalidResetToken ...
+var ErrorInvalidResetToken = domainerror.NewValidation("invalid_reset_token", "token", "The password reset token is invalid or has expired")
+
+// MinimumPasswordLength ...
+const MinimumPasswordLength = 8
+
+// MaximumPasswordLength is the most bytes bcrypt will hash
+const MaximumPasswordLength = 72
+
+// dummyPasswordHash is compared against when there is no account, so logging in takes as long
+// whether or not the email address has one
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not the password of any account"), bcrypt.DefaultCost)
+
+// MaxFailedLoginAttempts is how many wrong passwords in a row lock the account
+const MaxFailedLoginAttempts = 5
+
+// LockoutDuration ...
+const LockoutDuration = 15 * time.Minute
+
+// ResetTokenExpiry ...
+const ResetTokenExpiry = time.Hour
+
+// AuthenticationIDPrefix marks users that sign in with a local password
+const AuthenticationIDPrefix = "local|"
+
+// TokenIssuer ...
+type TokenIssuer interface {
+	Issue(sub string) (string, time.Time, error)
+}
+
+// LocalAuthService ...
+type LocalAuthService interface {
+	Register(ctx context.Context, register model.Register) (model.User, error)
+
+	Login(ctx context.Context, login model.Login) (model.LoginResult, error)
+
+	ForgotPassword(ctx context.Context, forgotPassword model.ForgotPassword, rootURL string) error
+
+	ResetPassword(ctx context.Context, resetPassword model.ResetPassword) error
+}
+
+// GoDutchLocalAuthService ...
+type GoDutchLocalAuthService struct {
+	userRepository       userrepository.UserRepository
+	credentialRepository localcredentialrepository.LocalCredentialRepository
+	validator            uservalidation.UserValidator
+	logger               infrastructure.Logger
+	emailService         infrastructure.EmailService
+	tokenIssuer          TokenIssuer
+}
+
+// NewGoDutchLocalAuthService ...
+func NewGoDutchLocalAuthService(userRepository userrepository.UserRepository,
+	credentialRepository localcredentialrepository.LocalCredentialRepository,
+	validator uservalidation.UserValidator,
+	logger infrastructure.Logger,
+	emailService infrastructure.EmailService,
+	tokenIssuer TokenIssuer) *GoDutchLocalAuthService {
+
+	service := GoDutchLocalAuthService{}
+	service.userRepository = userRepository
+	service.credentialRepository = credentialRepository
+	service.validator = validator
+	service.logger = logger
+	service.emailService = emailService
+	service.tokenIssuer = tokenIssuer
+	return &service
+}
+
+// Register ...
+func (goDutchLocalAuthService *GoDutchLocalAuthService) Register(ctx context.Context, register model.Register) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "LocalAuthService.Register")
+	defer span.End()
+
+	if err := validatePassword(register.Password); err != nil {
+		return model.User{}, err
+	}
+
+	authID, err := uuid.NewV4()
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user := model.User{
+		AuthenticationID: AuthenticationIDPrefix + authID.String(),
+		DateCreated:      time.Now(),
+		EmailAddress:     register.EmailAddress,
+		Name:             register.Name,
+	}
+
+	valid, err := goDutchLocalAuthService.validator.IsValidCreateUser(ctx, user, infrastructure.LoggerFrom(ctx, goDutchLocalAuthService.logger), goDutchLocalAuthService.userRepository)
+	if valid == false {
+		return model.User{}, err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(register.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user, err = goDutchLocalAuthService.userRepository.Insert(ctx, user)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	_, err = goDutchLocalAuthService.credentialRepository.Insert(ctx, model.LocalCredential{
+		UserID:       user.ID,
+		PasswordHash: string(passwordHash),
+	})
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// Login ...
+func (goDutchLocalAuthService *GoDutchLocalAuthService) Login(ctx context.Context, login model.Login) (model.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "LocalAuthService.Login")
+	defer span.End()
+
+	if len(login.Password) > MaximumPasswordLength {
+		return model.LoginResult{}, ErrorPasswordTooLong
+	}
+
+	user, err := goDutchLocalAuthService.userRepository.GetByEmail(ctx, login.EmailAddress)
+	if err != nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(login.Password))
+		return model.LoginResult{}, ErrorInvalidCredentials
+	}
+
+	credential, err := goDutchLocalAuthService.credentialRepository.GetByUserID(ctx, user.ID)
+	if err == localcredentialrepository.ErrorNotFound {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(login.Password))
+		return model.LoginResult{}, ErrorInvalidCredentials
+	}
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+
+	now := time.Now()
+	if credential.DateLockedUntil != nil && now.Before(*credential.DateLockedUntil) {
+		return model.LoginResult{}, ErrorAccountLocked
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(login.Password)) != nil {
+		credential.FailedLoginAttempts++
+		if credential.FailedLoginAttempts >= MaxFailedLoginAttempts {
+			lockedUntil := now.Add(LockoutDuration)
+			credential.DateLockedUntil = &lockedUntil
+			credential.FailedLoginAttempts = 0
+			infrastructure.LoggerFrom(ctx, goDutchLocalAuthService.logger).Info("Locked local account", "user_id", user.ID)
+		}
+		if _, err := goDutchLocalAuthService.credentialRepository.Update(ctx, credential); err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{}, ErrorInvalidCredentials
+	}
+
+	if credential.FailedLoginAttempts > 0 || credential.DateLockedUntil != nil {
+		credential.FailedLoginAttempts = 0
+		credential.DateLockedUntil = nil
+		if _, err := goDutchLocalAuthService.credentialRepository.Update(ctx, credential); err != nil {
+			return model.LoginResult{}, err
+		}
+	}
+
+	signed, expires, err := goDutchLocalAuthService.tokenIssuer.Issue(user.AuthenticationID)
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+
+	return model.LoginResult{Token: signed, DateExpires: expires}, nil
+}
+
+// ForgotPassword emails a reset link. It does not say whether the email address exists, so a
+// failure to send is logged rather than returned.
+func (goDutchLocalAuthService *GoDutchLocalAuthService) ForgotPassword(ctx context.Context, forgotPassword model.ForgotPassword,
+	rootURL string) error {
+	ctx, span := tracing.Start(ctx, "LocalAuthService.ForgotPassword")
+	defer span.End()
+
+	user, err := goDutchLocalAuthService.userRepository.GetByEmail(ctx, forgotPassword.EmailAddress)
+	if err != nil {
+		return nil
+	}
+
+	credential, err := goDutchLocalAuthService.credentialRepository.GetByUserID(ctx, user.ID)
+	if err == localcredentialrepository.ErrorNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := token.New()
+	if err != nil {
+		return err
+	}
+
+	resetTokenHash := token.Hash(resetToken)
+	expires := time.Now().Add(ResetTokenExpiry)
+	credential.ResetTokenHash = &resetTokenHash
+	credential.DateResetTokenExpires = &expires
+
+	_, err = goDutchLocalAuthService.credentialRepository.Update(ctx, credential)
+	if err != nil {
+		return err
+	}
+
+	_, err = goDutchLocalAuthService.emailService.SendEmail(user.EmailAddress,
+		"Reset your GoDutch password",
+		fmt.Sprintf("go to %vresetpassword/%v to reset your password..", rootURL, resetToken),
+		"computer@godutch.money", "GoDutch")
+	if err != nil {
+		infrastructure.LoggerFrom(ctx, goDutchLocalAuthService.logger).Error("Could not send password reset email", err, "user_id", user.ID)
+	}
+
+	return nil
+}
+
+// ResetPassword ...
+func (goDutchLocalAuthService *GoDutchLocalAuthService) ResetPassword(ctx context.Context, resetPassword model.ResetPassword) error {
+	ctx, span := tracing.Start(ctx, "LocalAuthService.ResetPassword")
+	defer span.End()
+
+	if token.Validate(resetPassword.Token) != nil {
+		return ErrorInvalidResetToken
+	}
+
+	if err := validatePassword(resetPassword.Password); err != nil {
+		return err
+	}
+
+	credential, err := goDutchLocalAuthService.credentialRepository.GetByResetTokenHash(ctx, token.Hash(resetPassword.Token))
+	if err == localcredentialrepository.ErrorNotFound {
+		return ErrorInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if credential.DateResetTokenExpires == nil || !time.Now().Before(*credential.DateResetTokenExpires) {
+		return ErrorInvalidResetToken
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(resetPassword.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	credential.PasswordHash = string(passwordHash)
+	credential.ResetTokenHash = nil
+	credential.DateResetTokenExpires = nil
+	credential.FailedLoginAttempts = 0
+	credential.DateLockedUntil = nil
+
+	_, err = goDutchLocalAuthService.credentialRepository.Update(ctx, credential)
+	return err
+}
+
+// validatePassword checks a new password is long enough to be safe and short enough for bcrypt
+func validatePassword(password string) error {
+	if len(password) < MinimumPasswordLength {
+		return ErrorPasswordTooShort
+	}
+	if len(password) > MaximumPasswordLength {
+		return ErrorPasswordTooLong
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package localauthservice_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/localauthservice"
+	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/jwtauth"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/localcredentialrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+)
+
+var registeredUser model.User
+var loginResult model.LoginResult
+var err error
+var emailService = &infrastructure.FakeEmailService{}
+var logger = infrastructure.ConsoleLogger{}
+var userRepository = userrepository.NewInMemoryUserRepository()
+var credentialRepository = localcredentialrepository.NewInMemoryLocalCredentialRepository()
+var secret = []byte("local-auth-test-secret")
+var localAuthService = localauthservice.NewGoDutchLocalAuthService(userRepository, credentialRepository,
+	uservalidation.NewGoDutchUserValidator(), logger, emailService,
+	jwtauth.NewHMACTokenIssuer(secret, "godutch-local", time.Hour))
+
+func TestCanRegisterAndLogin(t *testing.T) {
+	givenIHaveRegistered("register@godutch.money", "correct horse", t)
+	whenILogin("register@godutch.money", "correct horse")
+	thenTheLoginSucceeds(t)
+	thenTheTokenIsForTheRegisteredUser(t)
+}
+
+func TestCannotRegisterWithShortPassword(t *testing.T) {
+	_, err = localAuthService.Register(context.Background(), model.Register{Name: "Short", EmailAddress: "short@godutch.money", Password: "short"})
+	thenTheErrorIs(localauthservice.ErrorPasswordTooShort, t)
+}
+
+func TestCannotRegisterWithPasswordTooLongToHash(t *testing.T) {
+	_, err = localAuthService.Register(context.Background(), model.Register{Name: "Local", EmailAddress: "long@godutch.money", Password: strings.Repeat("a", 73)})
+	thenTheErrorIs(localauthservice.ErrorPasswordTooLong, t)
+}
+
+func TestLoginWithUnknownEmailTakesAsLongAsWithWrongPassword(t *testing.T) {
+	givenIHaveRegistered("timing@godutch.money", "password123", t)
+	start := time.Now()
+	whenILogin("timing@godutch.money", "wrong-password")
+	known := time.Since(start)
+	start = time.Now()
+	whenILogin("nobody@godutch.money", "wrong-password")
+	unknown := time.Since(start)
+	thenTheErrorIs(localauthservice.ErrorInvalidCredentials, t)
+	if unknown < known/2 {
+		t.Fatalf("expected unknown emails to be compared against a password hash, took %v against %v", unknown, known)
+	}
+}
+
+func TestCannotLoginWithWrongPassword(t *testing.T) {
+	givenIHaveRegistered("wrong@godutch.money", "correct horse", t)
+	whenILogin("wrong@godutch.money", "battery staple")
+	thenTheErrorIs(localauthservice.ErrorInvalidCredentials, t)
+}
+
+func TestCannotLoginWithUnknownEmail(t *testing.T) {
+	whenILogin("nobody@godutch.money", "correct horse")
+	thenTheErrorIs(localauthservice.ErrorInvalidCredentials, t)
+}
+
+func TestAccountIsLockedAfterTooManyFailedLogins(t *testing.T) {
+	givenIHaveRegistered("locked@godutch.money", "correct horse", t)
+	for i := 0; i < localauthservice.MaxFailedLoginAttempts; i++ {
+		whenILogin("locked@godutch.money", "battery staple")
+	}
+	whenILogin("locked@godutch.money", "correct horse")
+	thenTheErrorIs(localauthservice.ErrorAccountLocked, t)
+}
+
+func TestCanResetPassword(t *testing.T) {
+	givenIHaveRegistered("reset@godutch.money", "correct horse", t)
+	resetToken := givenIHaveForgottenMyPassword("reset@godutch.money", t)
+	err = localAuthService.ResetPassword(context.Background(), model.ResetPassword{Token: resetToken, Password: "battery staple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	whenILogin("reset@godutch.money", "battery staple")
+	thenTheLoginSucceeds(t)
+}
+
+func TestCannotResetPasswordTwiceWithSameToken(t *testing.T) {
+	givenIHaveRegistered("resettwice@godutch.money", "correct horse", t)
+	resetToken := givenIHaveForgottenMyPassword("resettwice@godutch.money", t)
+	err = localAuthService.ResetPassword(context.Background(), model.ResetPassword{Token: resetToken, Password: "battery staple"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = localAuthService.ResetPassword(context.Background(), model.ResetPassword{Token: resetToken, Password: "another password"})
+	thenTheErrorIs(localauthservice.ErrorInvalidResetToken, t)
+}
+
+func TestForgotPasswordForUnknownEmailDoesNotError(t *testing.T) {
+	err = localAuthService.ForgotPassword(context.Background(), model.ForgotPassword{EmailAddress: "unknown@godutch.money"}, "https://godutch.money/")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func givenIHaveRegistered(emailAddress string, password string, t *testing.T) {
+	registeredUser, err = localAuthService.Register(context.Background(), model.Register{Name: "Local", EmailAddress: emailAddress, Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func givenIHaveForgottenMyPassword(emailAddress string, t *testing.T) string {
+	err = localAuthService.ForgotPassword(context.Background(), model.ForgotPassword{EmailAddress: emailAddress}, "https://godutch.money/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emailService.LastToAddress != emailAddress {
+		t.Fatalf("expected reset email to %v but was sent to %v", emailAddress, emailService.LastToAddress)
+	}
+	parts := strings.Fields(emailService.LastText)
+	link := parts[2]
+	return link[strings.LastIndex(link, "/")+1:]
+}
+
+func whenILogin(emailAddress string, password string) {
+	loginResult, err = localAuthService.Login(context.Background(), model.Login{EmailAddress: emailAddress, Password: password})
+}
+
+func thenTheLoginSucceeds(t *testing.T) {
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loginResult.Token == "" {
+		t.Fatal("expected a token")
+	}
+}
+
+func thenTheTokenIsForTheRegisteredUser(t *testing.T) {
+	authenticator := jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"HS256"},
+		KeyGetter:  jwtauth.NewHMACKeyGetter(secret),
+		Issuer:     "godutch-local",
+	})
+	subjectFinder := jwtauth.NewJwtSubjectFinder()
+	r := httptest.NewRequest("GET", "/api/v1/trackers", nil)
+	r.Header.Set("Authorization", "Bearer "+loginResult.Token)
+	r, err = authenticator.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := subjectFinder.FindSubject(r, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub != registeredUser.AuthenticationID {
+		t.Fatalf("expected sub %v but got %v", registeredUser.AuthenticationID, sub)
+	}
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("expected %v but got %v", expected, err)
+	}
+}
@@ -1,37 +1,43 @@
 package spendservice
 
 import (
-	"errors"
+	"context"
 	"time"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
 	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
 	"github.com/TomPallister/godutch-api/api/domain/transferservice"
 	"github.com/TomPallister/godutch-api/api/domain/userservice"
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/metrics"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
 )
 
 // ErrorCreateSpend ...
-var ErrorCreateSpend = errors.New("Could not create Spend")
+var ErrorCreateSpend = domainerror.NewBadRequest("could_not_create_spend", "Could not create Spend")
 
 // ErrorUpdateSpend ...
-var ErrorUpdateSpend = errors.New("Could not update Spend")
+var ErrorUpdateSpend = domainerror.NewBadRequest("could_not_update_spend", "Could not update Spend")
 
 // ErrorUserDoesNotBelongToTracker ...
-var ErrorUserDoesNotBelongToTracker = errors.New("User does not belong to tracker")
+var ErrorUserDoesNotBelongToTracker = domainerror.NewForbidden("not_a_tracker_user", "User does not belong to tracker")
 
 // SpendService ...
 type SpendService interface {
-	FindByTrackerID(sub string, id int64) ([]model.Spend, error)
+	FindByTrackerID(ctx context.Context, sub string, id int64) ([]model.Spend, error)
 
-	CreateSpend(sub string, spend model.Spend) (model.Spend, error)
+	FindByID(ctx context.Context, sub string, id int64) (model.Spend, error)
 
-	UpdateSpend(sub string, spend model.Spend) (model.Spend, error)
+	CreateSpend(ctx context.Context, sub string, spend model.Spend) (model.Spend, error)
 
-	DeleteSpend(sub string, id int64) (bool, error)
+	UpdateSpend(ctx context.Context, sub string, spend model.Spend) (model.Spend, error)
+
+	DeleteSpend(ctx context.Context, sub string, id int64, version int64) (bool, error)
 }
 
 //GoDutchSpendService ...
@@ -43,6 +49,7 @@ type GoDutchSpendService struct {
 	validator           spendvalidation.SpendValidator
 	transferService     transferservice.TransferService
 	spendSummaryService spendsummaryservice.SpendSummaryService
+	publisher           events.Publisher
 }
 
 // NewGoDutchSpendService ...
@@ -52,7 +59,8 @@ func NewGoDutchSpendService(spendRepository spendrepository.SpendRepository,
 	validator spendvalidation.SpendValidator,
 	logger infrastructure.Logger,
 	transferService transferservice.TransferService,
-	spendSummaryService spendsummaryservice.SpendSummaryService) *GoDutchSpendService {
+	spendSummaryService spendsummaryservice.SpendSummaryService,
+	publisher events.Publisher) *GoDutchSpendService {
 
 	service := GoDutchSpendService{}
 	service.spendRepository = spendRepository
@@ -62,138 +70,164 @@ func NewGoDutchSpendService(spendRepository spendrepository.SpendRepository,
 	service.logger = logger
 	service.transferService = transferService
 	service.spendSummaryService = spendSummaryService
+	service.publisher = publisher
 	return &service
 }
 
 // CreateSpend ...
-func (goDutchSpendService *GoDutchSpendService) CreateSpend(sub string,
+func (goDutchSpendService *GoDutchSpendService) CreateSpend(ctx context.Context, sub string,
 	spend model.Spend) (model.Spend, error) {
+	ctx, span := tracing.Start(ctx, "SpendService.CreateSpend")
+	defer span.End()
 
-	user, err := goDutchSpendService.userService.FindBySub(sub)
+	user, err := goDutchSpendService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	tracker, err := goDutchSpendService.trackerService.FindByID(sub, spend.TrackerID)
+	tracker, err := goDutchSpendService.trackerService.FindByID(ctx, sub, spend.TrackerID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
 	spend.DateCreated = time.Now()
 
-	valid, err := goDutchSpendService.validator.IsValidCreateSpend(spend, goDutchSpendService.logger, user, tracker)
+	valid, err := goDutchSpendService.validator.IsValidCreateSpend(spend, infrastructure.LoggerFrom(ctx, goDutchSpendService.logger), user, tracker)
 	if valid == false {
 		return model.Spend{}, err
 	}
 
-	spend, err = goDutchSpendService.spendRepository.Insert(spend)
+	spend, err = goDutchSpendService.spendRepository.Insert(ctx, spend)
 	if err != nil {
 		return model.Spend{}, err
 	}
+	metrics.SpendsCreated.Inc()
 
-	_, err = goDutchSpendService.transferService.UpsertTransfers(tracker.ID)
+	transfers, err := goDutchSpendService.transferService.UpsertTransfers(ctx, tracker.ID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(tracker.ID)
+	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(ctx, tracker.ID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
+	goDutchSpendService.publish(ctx, events.SpendCreated, tracker.ID, spend)
+	goDutchSpendService.publish(ctx, events.TransfersUpdated, tracker.ID, transfers)
+
 	return spend, nil
 }
 
-// UpdateSpend ...
-func (goDutchSpendService *GoDutchSpendService) UpdateSpend(sub string,
+// UpdateSpend ...spend.Version must be the version the caller last read
+func (goDutchSpendService *GoDutchSpendService) UpdateSpend(ctx context.Context, sub string,
 	spend model.Spend) (model.Spend, error) {
+	ctx, span := tracing.Start(ctx, "SpendService.UpdateSpend")
+	defer span.End()
 
-	user, err := goDutchSpendService.userService.FindBySub(sub)
+	user, err := goDutchSpendService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	existingSpend, err := goDutchSpendService.spendRepository.GetByID(spend.ID)
+	existingSpend, err := goDutchSpendService.spendRepository.GetByID(ctx, spend.ID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	tracker, err := goDutchSpendService.trackerService.FindByID(sub, spend.TrackerID)
+	tracker, err := goDutchSpendService.trackerService.FindByID(ctx, sub, spend.TrackerID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	valid, err := goDutchSpendService.validator.IsValidUpdateSpend(spend, goDutchSpendService.logger, user, existingSpend, tracker)
+	valid, err := goDutchSpendService.validator.IsValidUpdateSpend(spend, infrastructure.LoggerFrom(ctx, goDutchSpendService.logger), user, existingSpend, tracker)
 	if valid == false {
 		return model.Spend{}, err
 	}
 
-	spend, err = goDutchSpendService.spendRepository.Update(spend.ID, spend)
+	spend, err = goDutchSpendService.spendRepository.Update(ctx, spend.ID, spend)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	_, err = goDutchSpendService.transferService.UpsertTransfers(tracker.ID)
+	transfers, err := goDutchSpendService.transferService.UpsertTransfers(ctx, tracker.ID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
-	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(tracker.ID)
+	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(ctx, tracker.ID)
 	if err != nil {
 		return model.Spend{}, err
 	}
 
+	goDutchSpendService.publish(ctx, events.SpendUpdated, tracker.ID, spend)
+	goDutchSpendService.publish(ctx, events.TransfersUpdated, tracker.ID, transfers)
+
 	return spend, nil
 
 }
 
-// DeleteSpend ...
-func (goDutchSpendService *GoDutchSpendService) DeleteSpend(sub string,
-	id int64) (bool, error) {
+// DeleteSpend ...version must be the version the caller last read
+func (goDutchSpendService *GoDutchSpendService) DeleteSpend(ctx context.Context, sub string,
+	id int64, version int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "SpendService.DeleteSpend")
+	defer span.End()
 
-	user, err := goDutchSpendService.userService.FindBySub(sub)
+	user, err := goDutchSpendService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return false, err
 	}
 
-	existingSpend, err := goDutchSpendService.spendRepository.GetByID(id)
+	existingSpend, err := goDutchSpendService.spendRepository.GetByID(ctx, id)
 	if err != nil {
 		return false, err
 	}
 
-	valid, err := goDutchSpendService.validator.IsValidDeleteSpend(id, goDutchSpendService.logger, user, existingSpend)
+	valid, err := goDutchSpendService.validator.IsValidDeleteSpend(id, infrastructure.LoggerFrom(ctx, goDutchSpendService.logger), user, existingSpend)
 	if valid == false {
 		return false, err
 	} 
 
-	result, err := goDutchSpendService.spendRepository.Delete(existingSpend.ID)
+	result, err := goDutchSpendService.spendRepository.Delete(ctx, existingSpend.ID, version)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = goDutchSpendService.transferService.UpsertTransfers(existingSpend.TrackerID)
+	transfers, err := goDutchSpendService.transferService.UpsertTransfers(ctx, existingSpend.TrackerID)
 	if err != nil {
 		return false, err 
 	}
 
-	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(existingSpend.TrackerID)
+	_, err = goDutchSpendService.spendSummaryService.UpsertSpendSummaries(ctx, existingSpend.TrackerID)
 	if err != nil {
 		return false, err
 	}
 
+	goDutchSpendService.publish(ctx, events.SpendDeleted, existingSpend.TrackerID, existingSpend)
+	goDutchSpendService.publish(ctx, events.TransfersUpdated, existingSpend.TrackerID, transfers)
+
 	return result, nil
 }
 
+// publish tells the tracker's subscribers about a change that has already been saved, so failures are only logged
+func (goDutchSpendService *GoDutchSpendService) publish(ctx context.Context, eventType string, trackerID int64, data interface{}) {
+	if err := events.Publish(goDutchSpendService.publisher, eventType, trackerID, data); err != nil {
+		infrastructure.LoggerFrom(ctx, goDutchSpendService.logger).Error("Could not publish event", err, "event_type", eventType, "tracker_id", trackerID)
+	}
+}
+
 // FindByTrackerID ...
-func (goDutchSpendService *GoDutchSpendService) FindByTrackerID(sub string,
+func (goDutchSpendService *GoDutchSpendService) FindByTrackerID(ctx context.Context, sub string,
 	id int64) ([]model.Spend, error) {
+	ctx, span := tracing.Start(ctx, "SpendService.FindByTrackerID")
+	defer span.End()
 
-	user, err := goDutchSpendService.userService.FindBySub(sub)
+	user, err := goDutchSpendService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return []model.Spend{}, err
 	}
 
-	tracker, err := goDutchSpendService.trackerService.FindByID(user.AuthenticationID, id)
+	tracker, err := goDutchSpendService.trackerService.FindByID(ctx, user.AuthenticationID, id)
 	if err != nil {
 		return []model.Spend{}, err
 	}
@@ -202,5 +236,33 @@ func (goDutchSpendService *GoDutchSpendService) FindByTrackerID(sub string,
 		return []model.Spend{}, ErrorUserDoesNotBelongToTracker
 	}
 
-	return goDutchSpendService.spendRepository.GetForTrackerID(tracker.ID)
+	return goDutchSpendService.spendRepository.GetForTrackerID(ctx, tracker.ID)
+}
+
+// FindByID ...
+func (goDutchSpendService *GoDutchSpendService) FindByID(ctx context.Context, sub string,
+	id int64) (model.Spend, error) {
+	ctx, span := tracing.Start(ctx, "SpendService.FindByID")
+	defer span.End()
+
+	user, err := goDutchSpendService.userService.FindBySub(ctx, sub)
+	if err != nil {
+		return model.Spend{}, err
+	}
+
+	spend, err := goDutchSpendService.spendRepository.GetByID(ctx, id)
+	if err != nil {
+		return model.Spend{}, err
+	}
+
+	tracker, err := goDutchSpendService.trackerService.FindByID(ctx, user.AuthenticationID, spend.TrackerID)
+	if err != nil {
+		return model.Spend{}, err
+	}
+
+	if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, user.ID) {
+		return model.Spend{}, ErrorUserDoesNotBelongToTracker
+	}
+
+	return spend, nil
 }
@@ -1,6 +1,7 @@
 package spendservice_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
@@ -26,6 +29,7 @@ var logger = infrastructure.ConsoleLogger{}
 var emailService = &infrastructure.FakeEmailService{}
 var trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 var userRepository = userrepository.NewInMemoryUserRepository()
+var inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 var spendRepository = spendrepository.NewInMemorySpendRepository()
 var transferRepository = transferrepository.NewInMemoryTransferRepository()
 var spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -34,11 +38,11 @@ var spendSummaryService = spendsummaryservice.
 var transferService = transferservice.
 	NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 var userService = userservice.
-	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 var trackerService = trackerservice.
-	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 var spendService = spendservice.
-	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 
 var savedUser = model.User{}
 var savedTracker = model.Tracker{}
@@ -65,7 +69,7 @@ func TestCanCreateSpend(t *testing.T) {
 		AdminUserID:    savedUser.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 		Currency:       "£",
 	}
 
@@ -111,7 +115,7 @@ func TestCanUpdateSpend(t *testing.T) {
 		AdminUserID:    savedUser.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 		Currency:       "£",
 	}
 
@@ -137,6 +141,7 @@ func TestCanUpdateSpend(t *testing.T) {
 		UserID:      savedUser.ID,
 		Value:       decimal.NewFromFloat(25.99),
 		ID:          savedSpend.ID,
+		Version:     savedSpend.Version,
 	}
 
 	whenIUpdateTheSpend(updatedSpend, t)
@@ -171,7 +176,7 @@ func TestCanDeleteSpend(t *testing.T) {
 		AdminUserID:    savedUser.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 		Currency:       "£",
 	}
 
@@ -208,7 +213,7 @@ func TestCanGetSpendsForTracker(t *testing.T) {
 		AdminUserID:    savedUser.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 		Currency:       "£",
 	}
 
@@ -242,7 +247,7 @@ func TestCanGetSpendsForTracker(t *testing.T) {
 }
 
 func whenIFindTheSpendsByTrackerID(t *testing.T) {
-	savedSpends, err = spendService.FindByTrackerID(savedUser.AuthenticationID, savedTracker.ID)
+	savedSpends, err = spendService.FindByTrackerID(context.Background(), savedUser.AuthenticationID, savedTracker.ID)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
@@ -255,7 +260,7 @@ func thenTheSpendsAreReturnedForTheTracker(t *testing.T) {
 }
 
 func whenIDeleteTheSpend(t *testing.T) {
-	result, err = spendService.DeleteSpend(savedUser.AuthenticationID, savedSpend.ID)
+	result, err = spendService.DeleteSpend(context.Background(), savedUser.AuthenticationID, savedSpend.ID, savedSpend.Version)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
@@ -271,6 +276,7 @@ func givenIHaveCleanDependencies() {
 	logger = infrastructure.ConsoleLogger{}
 	trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 	userRepository = userrepository.NewInMemoryUserRepository()
+	inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 	spendRepository = spendrepository.NewInMemorySpendRepository()
 	transferRepository = transferrepository.NewInMemoryTransferRepository()
 	spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -279,15 +285,15 @@ func givenIHaveCleanDependencies() {
 	transferService = transferservice.
 		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 	userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 	trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 	spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 }
 
 func whenIUpdateTheSpend(spend model.Spend, t *testing.T) {
-	savedSpend, err = spendService.UpdateSpend(savedUser.AuthenticationID, spend)
+	savedSpend, err = spendService.UpdateSpend(context.Background(), savedUser.AuthenticationID, spend)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 
@@ -295,14 +301,14 @@ func whenIUpdateTheSpend(spend model.Spend, t *testing.T) {
 }
 
 func givenIHaveAUser(user model.User, t *testing.T) {
-	savedUser, err = userService.CreateUser(user.AuthenticationID, user)
+	savedUser, err = userService.CreateUser(context.Background(), user.AuthenticationID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveATracker(tracker model.Tracker, t *testing.T) {
-	savedTracker, err = trackerService.CreateTracker(savedUser.AuthenticationID, tracker)
+	savedTracker, err = trackerService.CreateTracker(context.Background(), savedUser.AuthenticationID, tracker)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -313,14 +319,14 @@ func givenIHaveASpend(spend model.Spend) {
 }
 
 func whenICreateTheSpend(t *testing.T) {
-	savedSpend, err = spendService.CreateSpend(savedUser.AuthenticationID, newSpend)
+	savedSpend, err = spendService.CreateSpend(context.Background(), savedUser.AuthenticationID, newSpend)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func thenICreateTheSpend(t *testing.T) {
-	savedSpend, err = spendService.CreateSpend(savedUser.AuthenticationID, newSpend)
+	savedSpend, err = spendService.CreateSpend(context.Background(), savedUser.AuthenticationID, newSpend)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
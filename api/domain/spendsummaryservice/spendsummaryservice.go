@@ -1,28 +1,29 @@
 package spendsummaryservice
 
 import (
-	"errors"
-
+	"context"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
 	"github.com/shopspring/decimal"
 )
 
 // ErrorCreateSpendSummaries ...
-var ErrorCreateSpendSummaries = errors.New("Could not create spend summaries")
+var ErrorCreateSpendSummaries = domainerror.NewBadRequest("could_not_create_spend_summaries", "Could not create spend summaries")
 
 // ErrorFindSpendSummaries ...
-var ErrorFindSpendSummaries = errors.New("Could not find spend summaries")
+var ErrorFindSpendSummaries = domainerror.NewForbidden("not_a_tracker_user", "Could not find spend summaries")
 
 // SpendSummaryService ...Dont call this from anything that hasnt already been authenticated and authorised
 type SpendSummaryService interface {
-	UpsertSpendSummaries(trackerID int64) ([]model.SpendSummary, error)
-	FindSpendSummariesForTrackerID(sub string, trackerID int64) ([]model.SpendSummary, error)
-	DeleteSpendSummaries(trackerID int64) (bool, error)
+	UpsertSpendSummaries(ctx context.Context, trackerID int64) ([]model.SpendSummary, error)
+	FindSpendSummariesForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.SpendSummary, error)
+	DeleteSpendSummaries(ctx context.Context, trackerID int64) (bool, error)
 }
 
 // GoDutchSpendSummaryService ...
@@ -47,20 +48,22 @@ func NewGoDutchSpendSummaryService(spendRepository spendrepository.SpendReposito
 }
 
 // UpsertSpendSummaries ...
-func (service *GoDutchSpendSummaryService) UpsertSpendSummaries(trackerID int64) ([]model.SpendSummary, error) {
+func (service *GoDutchSpendSummaryService) UpsertSpendSummaries(ctx context.Context, trackerID int64) ([]model.SpendSummary, error) {
+	ctx, span := tracing.Start(ctx, "SpendSummaryService.UpsertSpendSummaries")
+	defer span.End()
 
-	spendSummaries, err := makeSpendSummaries(trackerID, service)
+	spendSummaries, err := makeSpendSummaries(ctx, trackerID, service)
 	if err != nil {
 		return nil, err
 	}
 
-	existingSpendSummaries, err := service.spendSummaryRepository.GetForTrackerID(trackerID)
+	existingSpendSummaries, err := service.spendSummaryRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(existingSpendSummaries) <= 0 {
-		spendSummaries, err = service.spendSummaryRepository.Insert(spendSummaries)
+		spendSummaries, err = service.spendSummaryRepository.Insert(ctx, spendSummaries)
 		if err != nil {
 			return nil, err
 		}
@@ -70,7 +73,7 @@ func (service *GoDutchSpendSummaryService) UpsertSpendSummaries(trackerID int64)
 
 	summariesToUpdate := matchNewSummariesWithOld(existingSpendSummaries, spendSummaries)
 
-	spendSummaries, err = service.spendSummaryRepository.Update(summariesToUpdate)
+	spendSummaries, err = service.spendSummaryRepository.Update(ctx, summariesToUpdate)
 	if err != nil {
 		return nil, err
 	}
@@ -103,19 +106,23 @@ func matchNewSummariesWithOld(existing []model.SpendSummary, updated []model.Spe
 }
 
 // DeleteSpendSummaries ..
-func (service *GoDutchSpendSummaryService) DeleteSpendSummaries(trackerID int64) (bool, error) {
-	return service.spendSummaryRepository.Delete(trackerID)
+func (service *GoDutchSpendSummaryService) DeleteSpendSummaries(ctx context.Context, trackerID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "SpendSummaryService.DeleteSpendSummaries")
+	defer span.End()
+	return service.spendSummaryRepository.Delete(ctx, trackerID)
 }
 
 // FindSpendSummariesForTrackerID ...
-func (service *GoDutchSpendSummaryService) FindSpendSummariesForTrackerID(sub string, trackerID int64) ([]model.SpendSummary, error) {
+func (service *GoDutchSpendSummaryService) FindSpendSummariesForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.SpendSummary, error) {
+	ctx, span := tracing.Start(ctx, "SpendSummaryService.FindSpendSummariesForTrackerID")
+	defer span.End()
 
-	user, err := service.userRepository.GetBySub(sub)
+	user, err := service.userRepository.GetBySub(ctx, sub)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
 
-	tracker, err := service.trackerRepository.GetByID(trackerID)
+	tracker, err := service.trackerRepository.GetByID(ctx, trackerID)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
@@ -124,25 +131,25 @@ func (service *GoDutchSpendSummaryService) FindSpendSummariesForTrackerID(sub st
 		return []model.SpendSummary{}, ErrorFindSpendSummaries
 	}
 
-	spendSummaries, err := service.spendSummaryRepository.GetForTrackerID(trackerID)
+	spendSummaries, err := service.spendSummaryRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return []model.SpendSummary{}, nil
 	}
 	return spendSummaries, nil
 }
 
-func makeSpendSummaries(trackerID int64, service *GoDutchSpendSummaryService) ([]model.SpendSummary, error) {
-	spends, err := service.spendRepository.GetForTrackerID(trackerID)
+func makeSpendSummaries(ctx context.Context, trackerID int64, service *GoDutchSpendSummaryService) ([]model.SpendSummary, error) {
+	spends, err := service.spendRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
 
-	tracker, err := service.trackerRepository.GetByID(trackerID)
+	tracker, err := service.trackerRepository.GetByID(ctx, trackerID)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
 
-	users, err := getUsersForTracker(tracker.TrackerUserIDs, service.userRepository)
+	users, err := service.userRepository.GetByIDs(ctx, tracker.TrackerUserIDs)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
@@ -163,20 +170,6 @@ func makeSpendSummaries(trackerID int64, service *GoDutchSpendSummaryService) ([
 	return spendSummaries, nil
 }
 
-func getUsersForTracker(trackerUserIDs []int64, repo userrepository.UserRepository) ([]model.User, error) {
-	var users = []model.User{}
-
-	for i := 0; i < len(trackerUserIDs); i++ {
-		usr, err := repo.GetByID(trackerUserIDs[i])
-		if err != nil {
-			return users, nil
-		}
-		users = append(users, usr)
-	}
-
-	return users, nil
-}
-
 func getUsersTotalSpend(spends []model.Spend, userID int64) decimal.Decimal {
 	total := decimal.NewFromFloat(0)
 
@@ -1,6 +1,7 @@
 package spendsummaryservice_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
@@ -37,6 +40,7 @@ var emailService = &infrastructure.FakeEmailService{}
 var logger = infrastructure.ConsoleLogger{}
 var trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 var userRepository = userrepository.NewInMemoryUserRepository()
+var inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 var spendRepository = spendrepository.NewInMemorySpendRepository()
 var transferRepository = transferrepository.NewInMemoryTransferRepository()
 var spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -45,11 +49,11 @@ var spendSummaryService = spendsummaryservice.
 var transferService = transferservice.
 	NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 var userService = userservice.
-	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 var trackerService = trackerservice.
-	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 var spendService = spendservice.
-	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 
 func TestCanCreateBasicSpendSummaries(t *testing.T) {
 	givenIHaveCleanDependencies()
@@ -80,18 +84,16 @@ func TestCanCreateBasicSpendSummaries(t *testing.T) {
 
 	expectedSpendSummaries := []model.SpendSummary{
 		model.SpendSummary{
-			UserID:       savedUserOne.ID,
-			EmailAddress: savedUserOne.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserOne.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserOne.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserOne.Value,
+			Currency:  savedTracker.Currency,
 		},
 		model.SpendSummary{
-			UserID:       savedUserTwo.ID,
-			EmailAddress: savedUserTwo.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserTwo.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserTwo.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserTwo.Value,
+			Currency:  savedTracker.Currency,
 		},
 	}
 
@@ -117,18 +119,16 @@ func TestCanUpdateSpendSummariesForTrackerID(t *testing.T) {
 
 	expectedSpendSummaries := []model.SpendSummary{
 		model.SpendSummary{
-			UserID:       savedUserOne.ID,
-			EmailAddress: savedUserOne.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserOne.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserOne.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserOne.Value,
+			Currency:  savedTracker.Currency,
 		},
 		model.SpendSummary{
-			UserID:       savedUserTwo.ID,
-			EmailAddress: savedUserTwo.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        decimal.NewFromFloat(11),
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserTwo.ID,
+			TrackerID: savedTracker.ID,
+			Value:     decimal.NewFromFloat(11),
+			Currency:  savedTracker.Currency,
 		},
 	}
 
@@ -145,18 +145,16 @@ func TestCanFindSpendSummariesForTrackerID(t *testing.T) {
 
 	expectedSpendSummaries := []model.SpendSummary{
 		model.SpendSummary{
-			UserID:       savedUserOne.ID,
-			EmailAddress: savedUserOne.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserOne.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserOne.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserOne.Value,
+			Currency:  savedTracker.Currency,
 		},
 		model.SpendSummary{
-			UserID:       savedUserTwo.ID,
-			EmailAddress: savedUserTwo.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserTwo.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserTwo.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserTwo.Value,
+			Currency:  savedTracker.Currency,
 		},
 	}
 
@@ -171,7 +169,7 @@ func TestCanDeleteSpendSummariesForTrackerID(t *testing.T) {
 }
 
 func whenIDeleteTheSpendSummaries(t *testing.T) {
-	result, err = spendSummaryService.DeleteSpendSummaries(savedTracker.ID)
+	result, err = spendSummaryService.DeleteSpendSummaries(context.Background(), savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error: %v", err)
 	}
@@ -184,7 +182,7 @@ func thenNoSpendSummariesAreReturned(t *testing.T) {
 }
 
 func whenIGetTheSpendSummaries(sub string, t *testing.T) {
-	savedSpendSummaries, err = spendSummaryService.FindSpendSummariesForTrackerID(sub, savedTracker.ID)
+	savedSpendSummaries, err = spendSummaryService.FindSpendSummariesForTrackerID(context.Background(), sub, savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error: %v", err)
 	}
@@ -219,18 +217,16 @@ func givenThereAreSpendSummariesForATracker(t *testing.T) {
 
 	expectedSpendSummaries := []model.SpendSummary{
 		model.SpendSummary{
-			UserID:       savedUserOne.ID,
-			EmailAddress: savedUserOne.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserOne.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserOne.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserOne.Value,
+			Currency:  savedTracker.Currency,
 		},
 		model.SpendSummary{
-			UserID:       savedUserTwo.ID,
-			EmailAddress: savedUserTwo.EmailAddress,
-			TrackerID:    savedTracker.ID,
-			Value:        spendUserTwo.Value,
-			Currency:     savedTracker.Currency,
+			UserID:    savedUserTwo.ID,
+			TrackerID: savedTracker.ID,
+			Value:     spendUserTwo.Value,
+			Currency:  savedTracker.Currency,
 		},
 	}
 
@@ -248,9 +244,6 @@ func thenTheFollowingSpendSummariesreReturned(expectedSpendSummary []model.Spend
 		if savedSpendSummaries[i].TrackerID != expectedSpendSummary[i].TrackerID {
 			t.Fatalf("Expected %v, got %v", expectedSpendSummary[i].TrackerID, savedSpendSummaries[i].TrackerID)
 		}
-		if savedSpendSummaries[i].EmailAddress != expectedSpendSummary[i].EmailAddress {
-			t.Fatalf("Expected %v, got %v", expectedSpendSummary[i].EmailAddress, savedSpendSummaries[i].EmailAddress)
-		}
 		if savedSpendSummaries[i].UserID != expectedSpendSummary[i].UserID {
 			t.Fatalf("Expected %v, got %v", expectedSpendSummary[i].UserID, savedSpendSummaries[i].UserID)
 		}
@@ -261,7 +254,7 @@ func thenTheFollowingSpendSummariesreReturned(expectedSpendSummary []model.Spend
 }
 
 func whenICreateTheSpendSummaries(t *testing.T) {
-	savedSpendSummaries, err = spendSummaryService.UpsertSpendSummaries(savedTracker.ID)
+	savedSpendSummaries, err = spendSummaryService.UpsertSpendSummaries(context.Background(), savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -271,6 +264,7 @@ func givenIHaveCleanDependencies() {
 	logger = infrastructure.ConsoleLogger{}
 	trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 	userRepository = userrepository.NewInMemoryUserRepository()
+	inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 	spendRepository = spendrepository.NewInMemorySpendRepository()
 	transferRepository = transferrepository.NewInMemoryTransferRepository()
 	spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -279,11 +273,11 @@ func givenIHaveCleanDependencies() {
 	transferService = transferservice.
 		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 	userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 	trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 	spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 }
 
 func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
@@ -310,7 +304,7 @@ func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
 		AdminUserID:    savedUserOne.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUserOne.ID, savedUserTwo.ID},
+		TrackerUserIDs: []int64{savedUserOne.ID, savedUserTwo.ID},
 		Currency:       "£",
 	}
 
@@ -319,33 +313,33 @@ func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
 }
 
 func givenIHaveUserOne(user model.User, t *testing.T) {
-	savedUserOne, err = userService.CreateUser(user.AuthenticationID, user)
+	savedUserOne, err = userService.CreateUser(context.Background(), user.AuthenticationID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveUserTwo(user model.User, t *testing.T) {
-	savedUserTwo, err = userService.CreateUser(user.AuthenticationID, user)
+	savedUserTwo, err = userService.CreateUser(context.Background(), user.AuthenticationID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveATracker(tracker model.Tracker, t *testing.T) {
-	savedTracker, err = trackerService.CreateTracker(savedUserOne.AuthenticationID, tracker)
+	savedTracker, err = trackerService.CreateTracker(context.Background(), savedUserOne.AuthenticationID, tracker)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveASpend(sub string, spend model.Spend, t *testing.T) {
-	savedSpend, err = spendService.CreateSpend(sub, spend)
+	savedSpend, err = spendService.CreateSpend(context.Background(), sub, spend)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIUpdateTheSpendSummaries(t *testing.T) {
-	savedSpendSummaries, err = spendSummaryService.UpsertSpendSummaries(savedTracker.ID)
+	savedSpendSummaries, err = spendSummaryService.UpsertSpendSummaries(context.Background(), savedTracker.ID)
 }
@@ -1,41 +1,43 @@
 package trackerservice
 
 import (
-	"errors"
+	"context"
 	"time"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
 	"github.com/TomPallister/godutch-api/api/domain/transferservice"
 	"github.com/TomPallister/godutch-api/api/domain/userservice"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
-	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
 )
 
 // ErrorCreateTracker ...
-var ErrorCreateTracker = errors.New("Could not create tracker")
+var ErrorCreateTracker = domainerror.NewBadRequest("could_not_create_tracker", "Could not create tracker")
 
 // ErrorUpdateTracker ...
-var ErrorUpdateTracker = errors.New("Could not update tracker")
+var ErrorUpdateTracker = domainerror.NewBadRequest("could_not_update_tracker", "Could not update tracker")
 
 // ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker ...
-var ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker = errors.New("You dont have permission to see these tracker users")
+var ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker = domainerror.NewForbidden("not_a_tracker_user", "You dont have permission to see these tracker users")
 
 // TrackerService ...
 type TrackerService interface {
-	FindByUser(sub string) ([]model.Tracker, error)
+	FindByUser(ctx context.Context, sub string) ([]model.Tracker, error)
 
-	FindByID(sub string, id int64) (model.Tracker, error)
+	FindByID(ctx context.Context, sub string, id int64) (model.Tracker, error)
 
-	CreateTracker(sub string, tracker model.Tracker) (model.Tracker, error)
+	CreateTracker(ctx context.Context, sub string, tracker model.Tracker) (model.Tracker, error)
 
-	UpdateTracker(sub string, tracker model.Tracker) (model.Tracker, error)
+	UpdateTracker(ctx context.Context, sub string, tracker model.Tracker) (model.Tracker, error)
 
-	DeleteTracker(sub string, id int64) (bool, error)
+	DeleteTracker(ctx context.Context, sub string, id int64, version int64) (bool, error)
 
-	FindUsersForTracker(sub string, id int64) ([]model.User, error)
+	FindUsersForTracker(ctx context.Context, sub string, id int64) ([]model.User, error)
 }
 
 //GoDutchTrackerService ...
@@ -46,7 +48,7 @@ type GoDutchTrackerService struct {
 	validator           trackervalidation.TrackerValidator
 	transferService     transferservice.TransferService
 	spendSummaryService spendsummaryservice.SpendSummaryService
-	spendRepository     spendrepository.SpendRepository
+	publisher           events.Publisher
 }
 
 // NewGoDutchTrackerService ...
@@ -56,7 +58,7 @@ func NewGoDutchTrackerService(trackerRepository trackerrepository.TrackerReposit
 	validator trackervalidation.TrackerValidator,
 	transferService transferservice.TransferService,
 	spendSummaryService spendsummaryservice.SpendSummaryService,
-	spendRepository spendrepository.SpendRepository) *GoDutchTrackerService {
+	publisher events.Publisher) *GoDutchTrackerService {
 	service := GoDutchTrackerService{}
 	service.trackerRepository = trackerRepository
 	service.userService = userService
@@ -64,30 +66,34 @@ func NewGoDutchTrackerService(trackerRepository trackerrepository.TrackerReposit
 	service.validator = validator
 	service.transferService = transferService
 	service.spendSummaryService = spendSummaryService
-	service.spendRepository = spendRepository
+	service.publisher = publisher
 	return &service
 }
 
 // FindByUser ...
-func (goDutchTrackerService *GoDutchTrackerService) FindByUser(sub string) ([]model.Tracker, error) {
+func (goDutchTrackerService *GoDutchTrackerService) FindByUser(ctx context.Context, sub string) ([]model.Tracker, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.FindByUser")
+	defer span.End()
 
-	adminUser, err := goDutchTrackerService.userService.FindBySub(sub)
+	adminUser, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return []model.Tracker{}, err
 	}
 
-	return goDutchTrackerService.trackerRepository.GetForUserID(adminUser.ID)
+	return goDutchTrackerService.trackerRepository.GetForUserID(ctx, adminUser.ID)
 }
 
 // FindUsersForTracker ...
-func (goDutchTrackerService *GoDutchTrackerService) FindUsersForTracker(sub string, id int64) ([]model.User, error) {
+func (goDutchTrackerService *GoDutchTrackerService) FindUsersForTracker(ctx context.Context, sub string, id int64) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.FindUsersForTracker")
+	defer span.End()
 
-	adminUser, err := goDutchTrackerService.userService.FindBySub(sub)
+	adminUser, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return []model.User{}, err
 	}
 
-	tracker, err := goDutchTrackerService.trackerRepository.GetByID(id)
+	tracker, err := goDutchTrackerService.trackerRepository.GetByID(ctx, id)
 	if err != nil {
 		return []model.User{}, err
 	}
@@ -96,58 +102,56 @@ func (goDutchTrackerService *GoDutchTrackerService) FindUsersForTracker(sub stri
 		return []model.User{}, ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker
 	}
 
-	users := []model.User{}
-
-	for _, u := range tracker.TrackerUserIDs {
-
-		user, err := goDutchTrackerService.userService.FindByIDForTracker(u)
-		if err != nil {
-			return []model.User{}, err
-		}
-		users = append(users, user)
+	users, err := goDutchTrackerService.userService.FindByIDsForTracker(ctx, tracker.TrackerUserIDs)
+	if err != nil {
+		return []model.User{}, err
 	}
 
 	return users, nil
 }
 
 // FindByID ...
-func (goDutchTrackerService *GoDutchTrackerService) FindByID(sub string, id int64) (model.Tracker, error) {
+func (goDutchTrackerService *GoDutchTrackerService) FindByID(ctx context.Context, sub string, id int64) (model.Tracker, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.FindByID")
+	defer span.End()
 
-	_, err := goDutchTrackerService.userService.FindBySub(sub)
+	_, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	return goDutchTrackerService.trackerRepository.GetByID(id)
+	return goDutchTrackerService.trackerRepository.GetByID(ctx, id)
 }
 
 // CreateTracker ...
-func (goDutchTrackerService *GoDutchTrackerService) CreateTracker(sub string,
+func (goDutchTrackerService *GoDutchTrackerService) CreateTracker(ctx context.Context, sub string,
 	tracker model.Tracker) (model.Tracker, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.CreateTracker")
+	defer span.End()
 
-	adminUser, err := goDutchTrackerService.userService.FindBySub(sub)
+	adminUser, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
 	tracker.DateCreated = time.Now()
 
-	valid, err := goDutchTrackerService.validator.IsValidCreateTracker(tracker, goDutchTrackerService.logger, adminUser)
+	valid, err := goDutchTrackerService.validator.IsValidCreateTracker(tracker, infrastructure.LoggerFrom(ctx, goDutchTrackerService.logger), adminUser)
 	if valid == false {
 		return model.Tracker{}, err
 	}
 
-	tracker, err = goDutchTrackerService.trackerRepository.Insert(tracker)
+	tracker, err = goDutchTrackerService.trackerRepository.Insert(ctx, tracker)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	_, err = goDutchTrackerService.transferService.UpsertTransfers(tracker.ID)
+	_, err = goDutchTrackerService.transferService.UpsertTransfers(ctx, tracker.ID)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	_, err = goDutchTrackerService.spendSummaryService.UpsertSpendSummaries(tracker.ID)
+	_, err = goDutchTrackerService.spendSummaryService.UpsertSpendSummaries(ctx, tracker.ID)
 	if err != nil {
 		return model.Tracker{}, err
 	}
@@ -155,81 +159,83 @@ func (goDutchTrackerService *GoDutchTrackerService) CreateTracker(sub string,
 	return tracker, nil
 }
 
-// UpdateTracker ...
-func (goDutchTrackerService *GoDutchTrackerService) UpdateTracker(sub string,
+// UpdateTracker ...tracker.Version must be the version the caller last read
+func (goDutchTrackerService *GoDutchTrackerService) UpdateTracker(ctx context.Context, sub string,
 	tracker model.Tracker) (model.Tracker, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.UpdateTracker")
+	defer span.End()
 
-	adminUser, err := goDutchTrackerService.userService.FindBySub(sub)
+	adminUser, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	existingTracker, err := goDutchTrackerService.trackerRepository.GetByID(tracker.ID)
+	existingTracker, err := goDutchTrackerService.trackerRepository.GetByID(ctx, tracker.ID)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	valid, err := goDutchTrackerService.validator.IsValidUpdateTracker(tracker, goDutchTrackerService.logger, adminUser, existingTracker)
+	valid, err := goDutchTrackerService.validator.IsValidUpdateTracker(tracker, infrastructure.LoggerFrom(ctx, goDutchTrackerService.logger), adminUser, existingTracker)
 	if valid == false {
 		return model.Tracker{}, err
 	}
 
-	tracker, err = goDutchTrackerService.trackerRepository.Update(tracker.ID, tracker)
+	tracker, err = goDutchTrackerService.trackerRepository.Update(ctx, tracker.ID, tracker)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	_, err = goDutchTrackerService.transferService.UpsertTransfers(tracker.ID)
+	transfers, err := goDutchTrackerService.transferService.UpsertTransfers(ctx, tracker.ID)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
-	_, err = goDutchTrackerService.spendSummaryService.UpsertSpendSummaries(tracker.ID)
+	_, err = goDutchTrackerService.spendSummaryService.UpsertSpendSummaries(ctx, tracker.ID)
 	if err != nil {
 		return model.Tracker{}, err
 	}
 
+	goDutchTrackerService.publish(ctx, events.TrackerUpdated, tracker.ID, tracker)
+	goDutchTrackerService.publish(ctx, events.TransfersUpdated, tracker.ID, transfers)
+
 	return tracker, nil
 }
 
-// DeleteTracker ...
-func (goDutchTrackerService *GoDutchTrackerService) DeleteTracker(sub string,
-	id int64) (bool, error) {
+// DeleteTracker ...version must be the version the caller last read, the tracker's spends, transfers
+// and summaries go with it
+func (goDutchTrackerService *GoDutchTrackerService) DeleteTracker(ctx context.Context, sub string,
+	id int64, version int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TrackerService.DeleteTracker")
+	defer span.End()
 
-	adminUser, err := goDutchTrackerService.userService.FindBySub(sub)
+	adminUser, err := goDutchTrackerService.userService.FindBySub(ctx, sub)
 	if err != nil {
 		return false, err
 	}
 
-	existingTracker, err := goDutchTrackerService.trackerRepository.GetByID(id)
+	existingTracker, err := goDutchTrackerService.trackerRepository.GetByID(ctx, id)
 	if err != nil {
 		return false, err
 	}
 
-	valid, err := goDutchTrackerService.validator.IsValidDeleteTracker(id, goDutchTrackerService.logger, adminUser, existingTracker)
+	valid, err := goDutchTrackerService.validator.IsValidDeleteTracker(id, infrastructure.LoggerFrom(ctx, goDutchTrackerService.logger), adminUser, existingTracker)
 	if valid == false {
 		return false, err
 	}
 
-	_, err = goDutchTrackerService.transferService.DeleteTransfers(id)
+	result, err := goDutchTrackerService.trackerRepository.Delete(ctx, id, version)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = goDutchTrackerService.spendSummaryService.DeleteSpendSummaries(id)
-	if err != nil {
-		return false, err
-	}
+	goDutchTrackerService.publish(ctx, events.TrackerDeleted, id, existingTracker)
 
-	_, err = goDutchTrackerService.spendRepository.DeleteForTrackerID(id)
-	if err != nil {
-		return false, err
-	}
+	return result, nil
+}
 
-	result, err := goDutchTrackerService.trackerRepository.Delete(id)
-	if err != nil {
-		return false, err
+// publish tells the tracker's subscribers about a change that has already been saved, so failures are only logged
+func (goDutchTrackerService *GoDutchTrackerService) publish(ctx context.Context, eventType string, trackerID int64, data interface{}) {
+	if err := events.Publish(goDutchTrackerService.publisher, eventType, trackerID, data); err != nil {
+		infrastructure.LoggerFrom(ctx, goDutchTrackerService.logger).Error("Could not publish event", err, "event_type", eventType, "tracker_id", trackerID)
 	}
-
-	return result, nil
 }
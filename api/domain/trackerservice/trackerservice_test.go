@@ -1,6 +1,7 @@
 package trackerservice_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
@@ -31,6 +34,7 @@ var emailService = &infrastructure.FakeEmailService{}
 var logger = infrastructure.ConsoleLogger{}
 var trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 var userRepository = userrepository.NewInMemoryUserRepository()
+var inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 var spendRepository = spendrepository.NewInMemorySpendRepository()
 var transferRepository = transferrepository.NewInMemoryTransferRepository()
 var spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -39,11 +43,11 @@ var spendSummaryService = spendsummaryservice.
 var transferService = transferservice.
 	NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 var userService = userservice.
-	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 var trackerService = trackerservice.
-	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 var spendService = spendservice.
-	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 
 func TestCanFindTrackersForUserId(t *testing.T) {
 
@@ -51,7 +55,7 @@ func TestCanFindTrackersForUserId(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	givenThereAreCleanDependecies()
@@ -66,7 +70,7 @@ func TestCanFindUsersForTracker(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	givenThereAreCleanDependecies()
@@ -82,14 +86,14 @@ func TestCanFindTrackerForTrackerId(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	expectedTracker := model.Tracker{
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 		ID:             1,
 	}
 
@@ -106,14 +110,14 @@ func TestCanCreateTracker(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	expectedTracker := model.Tracker{
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 		ID:             1,
 	}
 
@@ -130,7 +134,7 @@ func TestCanUpdateTracker(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	givenThereAreCleanDependecies()
@@ -141,8 +145,9 @@ func TestCanUpdateTracker(t *testing.T) {
 		Name:           "Tom and Laura 2",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 		ID:             savedTracker.ID,
+		Version:        savedTracker.Version,
 	}
 
 	whenIUpdateTheTracker("sub", updatedTracker, t)
@@ -155,7 +160,7 @@ func TestCanDeleteTracker(t *testing.T) {
 		Name:           "Tom and Laura",
 		AdminUserID:    1,
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{1},
+		TrackerUserIDs: []int64{1},
 	}
 
 	givenThereAreCleanDependecies()
@@ -169,6 +174,7 @@ func givenThereAreCleanDependecies() {
 	logger = infrastructure.ConsoleLogger{}
 	trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 	userRepository = userrepository.NewInMemoryUserRepository()
+	inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 	spendRepository = spendrepository.NewInMemorySpendRepository()
 	transferRepository = transferrepository.NewInMemoryTransferRepository()
 	spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -177,11 +183,11 @@ func givenThereAreCleanDependecies() {
 	transferService = transferservice.
 		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 	userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 	trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 	spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 }
 
 func thenTheTrackersForTheUserAreReturned(t *testing.T) {
@@ -197,22 +203,22 @@ func thenTheUsersForTheTrackerAreReturned(t *testing.T) {
 }
 
 func whenIGetTheTrackersForAUser(sub string) {
-	trackersForUser, err = trackerService.FindByUser(sub)
+	trackersForUser, err = trackerService.FindByUser(context.Background(), sub)
 }
 
 func whenIGetTheUsersForATracker(sub string, t *testing.T) {
-	savedTrackerUsers, err = trackerService.FindUsersForTracker(sub, savedTracker.ID)
+	savedTrackerUsers, err = trackerService.FindUsersForTracker(context.Background(), sub, savedTracker.ID)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
 }
 
-func whenIGetTheTrackerByID(sub string, id int) {
-	savedTracker, err = trackerService.FindByID(sub, id)
+func whenIGetTheTrackerByID(sub string, id int64) {
+	savedTracker, err = trackerService.FindByID(context.Background(), sub, id)
 }
 
-func givenThereIsAUserWithTheSubAndID(sub string, id int) {
-	userRepository.Insert(model.User{
+func givenThereIsAUserWithTheSubAndID(sub string, id int64) {
+	userRepository.Insert(context.Background(), model.User{
 		AuthenticationID: sub,
 		ID:               id,
 	})
@@ -223,25 +229,25 @@ func givenIHaveATracker(tracker model.Tracker) {
 }
 
 func givenATrackerAlreadyExistsInTheRepository(tracker model.Tracker) {
-	savedTracker, err = trackerRepository.Insert(tracker)
+	savedTracker, err = trackerRepository.Insert(context.Background(), tracker)
 }
 
 func whenICreateTheTracker(sub string, t *testing.T) {
-	savedTracker, err = trackerService.CreateTracker(sub, newTracker)
+	savedTracker, err = trackerService.CreateTracker(context.Background(), sub, newTracker)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
 }
 
 func whenIUpdateTheTracker(sub string, tracker model.Tracker, t *testing.T) {
-	savedTracker, err = trackerService.UpdateTracker(sub, tracker)
+	savedTracker, err = trackerService.UpdateTracker(context.Background(), sub, tracker)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
 }
 
-func whenIDeleteTheTracker(sub string, id int, t *testing.T) {
-	result, err = trackerService.DeleteTracker(sub, id)
+func whenIDeleteTheTracker(sub string, id int64, t *testing.T) {
+	result, err = trackerService.DeleteTracker(context.Background(), sub, id, savedTracker.Version)
 	if err != nil {
 		t.Fatalf("Error was %v", err)
 	}
@@ -1,28 +1,29 @@
 package transferservice
 
 import (
-	"errors"
-
+	"context"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
 	"github.com/TomPallister/godutch-api/api/repository/transferrepository"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
 	"github.com/shopspring/decimal"
 )
 
 // ErrorCreateTransfers ...
-var ErrorCreateTransfers = errors.New("Could not create transfers")
+var ErrorCreateTransfers = domainerror.NewBadRequest("could_not_create_transfers", "Could not create transfers")
 
 // ErrorFindTransfers ...
-var ErrorFindTransfers = errors.New("Could not find transfers")
+var ErrorFindTransfers = domainerror.NewForbidden("not_a_tracker_user", "Could not find transfers")
 
 // TransferService ...Dont call this from anything that hasnt already been authenticated and authorised
 type TransferService interface {
-	UpsertTransfers(trackerID int64) ([]model.Transfer, error)
-	FindTransfersForTrackerID(sub string, trackerID int64) ([]model.Transfer, error)
-	DeleteTransfers(trackerID int64) (bool, error)
+	UpsertTransfers(ctx context.Context, trackerID int64) ([]model.Transfer, error)
+	FindTransfersForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.Transfer, error)
+	DeleteTransfers(ctx context.Context, trackerID int64) (bool, error)
 }
 
 // GoDutchTransferService ...
@@ -47,20 +48,22 @@ func NewGoDutchTransferService(spendRepository spendrepository.SpendRepository,
 }
 
 // UpsertTransfers ...
-func (service *GoDutchTransferService) UpsertTransfers(trackerID int64) ([]model.Transfer, error) {
+func (service *GoDutchTransferService) UpsertTransfers(ctx context.Context, trackerID int64) ([]model.Transfer, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.UpsertTransfers")
+	defer span.End()
 
-	transfers, err := makeTransfers(trackerID, service)
+	transfers, err := makeTransfers(ctx, trackerID, service)
 	if err != nil {
 		return nil, err
 	}
 
-	existingTransfers, err := service.transferRepository.GetForTrackerID(trackerID)
+	existingTransfers, err := service.transferRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(existingTransfers) <= 0 {
-		transfers, err = service.transferRepository.Insert(transfers)
+		transfers, err = service.transferRepository.Insert(ctx, transfers)
 		if err != nil {
 			return nil, err
 		}
@@ -69,13 +72,13 @@ func (service *GoDutchTransferService) UpsertTransfers(trackerID int64) ([]model
 	}
 
 	for _, t := range existingTransfers {
-		_, err := service.transferRepository.Delete(t.TrackerID)
+		_, err := service.transferRepository.Delete(ctx, t.TrackerID)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	transfers, err = service.transferRepository.Insert(transfers)
+	transfers, err = service.transferRepository.Insert(ctx, transfers)
 	if err != nil {
 		return nil, err
 	}
@@ -84,19 +87,23 @@ func (service *GoDutchTransferService) UpsertTransfers(trackerID int64) ([]model
 }
 
 // DeleteTransfers ..
-func (service *GoDutchTransferService) DeleteTransfers(trackerID int64) (bool, error) {
-	return service.transferRepository.Delete(trackerID)
+func (service *GoDutchTransferService) DeleteTransfers(ctx context.Context, trackerID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.DeleteTransfers")
+	defer span.End()
+	return service.transferRepository.Delete(ctx, trackerID)
 }
 
 // FindTransfersForTrackerID ...
-func (service *GoDutchTransferService) FindTransfersForTrackerID(sub string, trackerID int64) ([]model.Transfer, error) {
+func (service *GoDutchTransferService) FindTransfersForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.Transfer, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.FindTransfersForTrackerID")
+	defer span.End()
 
-	user, err := service.userRepository.GetBySub(sub)
+	user, err := service.userRepository.GetBySub(ctx, sub)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
 
-	tracker, err := service.trackerRepository.GetByID(trackerID)
+	tracker, err := service.trackerRepository.GetByID(ctx, trackerID)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
@@ -105,25 +112,25 @@ func (service *GoDutchTransferService) FindTransfersForTrackerID(sub string, tra
 		return []model.Transfer{}, ErrorFindTransfers
 	}
 
-	transfers, err := service.transferRepository.GetForTrackerID(trackerID)
+	transfers, err := service.transferRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return []model.Transfer{}, nil
 	}
 	return transfers, nil
 }
 
-func makeTransfers(trackerID int64, service *GoDutchTransferService) ([]model.Transfer, error) {
-	spends, err := service.spendRepository.GetForTrackerID(trackerID)
+func makeTransfers(ctx context.Context, trackerID int64, service *GoDutchTransferService) ([]model.Transfer, error) {
+	spends, err := service.spendRepository.GetForTrackerID(ctx, trackerID)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
 
-	tracker, err := service.trackerRepository.GetByID(trackerID)
+	tracker, err := service.trackerRepository.GetByID(ctx, trackerID)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
 
-	users, err := getUsersForTracker(tracker.TrackerUserIDs, service.userRepository)
+	users, err := service.userRepository.GetByIDs(ctx, tracker.TrackerUserIDs)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
@@ -248,19 +255,6 @@ func getTracksGroupedByUser(users []model.User, spends []model.Spend) map[int64]
 	return tracksGroupedByUser
 }
 
-func getUsersForTracker(trackerUserIDs []int64, repo userrepository.UserRepository) ([]model.User, error) {
-	var users = []model.User{}
-
-	for i := 0; i < len(trackerUserIDs); i++ {
-		usr, err := repo.GetByID(trackerUserIDs[i])
-		if err != nil {
-			return users, nil
-		}
-		users = append(users, usr)
-	}
-	return users, nil
-}
-
 func sumOfSpends(spends []model.Spend) decimal.Decimal {
 
 	total := decimal.NewFromFloat(0)
@@ -1,6 +1,7 @@
 package transferservice_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
@@ -35,6 +38,7 @@ var emailService = &infrastructure.FakeEmailService{}
 var logger = infrastructure.ConsoleLogger{}
 var trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 var userRepository = userrepository.NewInMemoryUserRepository()
+var inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 var spendRepository = spendrepository.NewInMemorySpendRepository()
 var transferRepository = transferrepository.NewInMemoryTransferRepository()
 var spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -43,11 +47,11 @@ var spendSummaryService = spendsummaryservice.
 var transferService = transferservice.
 	NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 var userService = userservice.
-	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 var trackerService = trackerservice.
-	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 var spendService = spendservice.
-	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 
 func TestCanCreateBasicTransfers(t *testing.T) {
 	givenIHaveCleanDependencies()
@@ -78,13 +82,11 @@ func TestCanCreateBasicTransfers(t *testing.T) {
 
 	expectedTransfers := []model.Transfer{
 		model.Transfer{
-			FromUserID:       savedUserTwo.ID,
-			FromEmailAddress: savedUserTwo.EmailAddress,
-			ToUserID:         savedUserOne.ID,
-			ToEmailAddress:   savedUserOne.EmailAddress,
-			Value:            decimal.NewFromFloat(27.5),
-			Currency:         savedTracker.Currency,
-			TrackerID:        savedTracker.ID,
+			FromUserID: savedUserTwo.ID,
+			ToUserID:   savedUserOne.ID,
+			Value:      decimal.NewFromFloat(27.5),
+			Currency:   savedTracker.Currency,
+			TrackerID:  savedTracker.ID,
 		},
 	}
 
@@ -110,13 +112,11 @@ func TestCanUpdateTransfersForTrackerID(t *testing.T) {
 
 	expectedTransfers := []model.Transfer{
 		model.Transfer{
-			FromUserID:       savedUserTwo.ID,
-			FromEmailAddress: savedUserTwo.EmailAddress,
-			ToUserID:         savedUserOne.ID,
-			ToEmailAddress:   savedUserOne.EmailAddress,
-			Value:            decimal.NewFromFloat(22.5),
-			Currency:         savedTracker.Currency,
-			TrackerID:        savedTracker.ID,
+			FromUserID: savedUserTwo.ID,
+			ToUserID:   savedUserOne.ID,
+			Value:      decimal.NewFromFloat(22.5),
+			Currency:   savedTracker.Currency,
+			TrackerID:  savedTracker.ID,
 		},
 	}
 
@@ -133,13 +133,11 @@ func TestCanFindTransfersForTrackerID(t *testing.T) {
 
 	expectedTransfers := []model.Transfer{
 		model.Transfer{
-			FromUserID:       savedUserTwo.ID,
-			FromEmailAddress: savedUserTwo.EmailAddress,
-			ToUserID:         savedUserOne.ID,
-			ToEmailAddress:   savedUserOne.EmailAddress,
-			Value:            decimal.NewFromFloat(27.5),
-			Currency:         savedTracker.Currency,
-			TrackerID:        savedTracker.ID,
+			FromUserID: savedUserTwo.ID,
+			ToUserID:   savedUserOne.ID,
+			Value:      decimal.NewFromFloat(27.5),
+			Currency:   savedTracker.Currency,
+			TrackerID:  savedTracker.ID,
 		},
 	}
 
@@ -154,7 +152,7 @@ func TestCanDeleteTransfersForTrackerID(t *testing.T) {
 }
 
 func whenIDeleteTheTransfers(t *testing.T) {
-	result, err = transferService.DeleteTransfers(savedTracker.ID)
+	result, err = transferService.DeleteTransfers(context.Background(), savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error: %v", err)
 	}
@@ -167,7 +165,7 @@ func thenNoTransfersAreReturned(t *testing.T) {
 }
 
 func whenIGetTheTransfers(sub string, t *testing.T) {
-	savedTransfers, err = transferService.FindTransfersForTrackerID(sub, savedTracker.ID)
+	savedTransfers, err = transferService.FindTransfersForTrackerID(context.Background(), sub, savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error: %v", err)
 	}
@@ -202,13 +200,11 @@ func givenThereAreTransferForATracker(t *testing.T) {
 
 	expectedTransfers := []model.Transfer{
 		model.Transfer{
-			FromUserID:       savedUserTwo.ID,
-			FromEmailAddress: savedUserTwo.EmailAddress,
-			ToUserID:         savedUserOne.ID,
-			ToEmailAddress:   savedUserOne.EmailAddress,
-			Value:            decimal.NewFromFloat(27.5),
-			Currency:         savedTracker.Currency,
-			TrackerID:        savedTracker.ID,
+			FromUserID: savedUserTwo.ID,
+			ToUserID:   savedUserOne.ID,
+			Value:      decimal.NewFromFloat(27.5),
+			Currency:   savedTracker.Currency,
+			TrackerID:  savedTracker.ID,
 		},
 	}
 
@@ -226,15 +222,9 @@ func thenTheFollowingTransfersAreReturned(expectedTransfers []model.Transfer, t
 		if savedTransfers[i].TrackerID != expectedTransfers[i].TrackerID {
 			t.Fatalf("Expected %v, got %v", expectedTransfers[i].TrackerID, savedTransfers[i].TrackerID)
 		}
-		if savedTransfers[i].FromEmailAddress != expectedTransfers[i].FromEmailAddress {
-			t.Fatalf("Expected %v, got %v", expectedTransfers[i].FromEmailAddress, savedTransfers[i].FromEmailAddress)
-		}
 		if savedTransfers[i].FromUserID != expectedTransfers[i].FromUserID {
 			t.Fatalf("Expected %v, got %v", expectedTransfers[i].FromUserID, savedTransfers[i].FromUserID)
 		}
-		if savedTransfers[i].ToEmailAddress != expectedTransfers[i].ToEmailAddress {
-			t.Fatalf("Expected %v, got %v", expectedTransfers[i].ToEmailAddress, savedTransfers[i].ToEmailAddress)
-		}
 		if savedTransfers[i].ToUserID != expectedTransfers[i].ToUserID {
 			t.Fatalf("Expected %v, got %v", expectedTransfers[i].ToUserID, savedTransfers[i].ToUserID)
 		}
@@ -245,7 +235,7 @@ func thenTheFollowingTransfersAreReturned(expectedTransfers []model.Transfer, t
 }
 
 func whenICreateTheTransfers(t *testing.T) {
-	savedTransfers, err = transferService.UpsertTransfers(savedTracker.ID)
+	savedTransfers, err = transferService.UpsertTransfers(context.Background(), savedTracker.ID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -255,6 +245,7 @@ func givenIHaveCleanDependencies() {
 	logger = infrastructure.ConsoleLogger{}
 	trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 	userRepository = userrepository.NewInMemoryUserRepository()
+	inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
 	spendRepository = spendrepository.NewInMemorySpendRepository()
 	transferRepository = transferrepository.NewInMemoryTransferRepository()
 	spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
@@ -263,11 +254,11 @@ func givenIHaveCleanDependencies() {
 	transferService = transferservice.
 		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 	userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, events.NilPublisher{})
 	trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
+		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, events.NilPublisher{})
 	spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, events.NilPublisher{})
 }
 
 func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
@@ -294,7 +285,7 @@ func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
 		AdminUserID:    savedUserOne.ID,
 		DateCreated:    time.Now(),
 		Name:           "Tom and Laura",
-		TrackerUserIDs: []int{savedUserOne.ID, savedUserTwo.ID},
+		TrackerUserIDs: []int64{savedUserOne.ID, savedUserTwo.ID},
 		Currency:       "£",
 	}
 
@@ -303,33 +294,33 @@ func givenUsersAndTrackerHaveBeenCreated(t *testing.T) {
 }
 
 func givenIHaveUserOne(user model.User, t *testing.T) {
-	savedUserOne, err = userService.CreateUser(user.AuthenticationID, user)
+	savedUserOne, err = userService.CreateUser(context.Background(), user.AuthenticationID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveUserTwo(user model.User, t *testing.T) {
-	savedUserTwo, err = userService.CreateUser(user.AuthenticationID, user)
+	savedUserTwo, err = userService.CreateUser(context.Background(), user.AuthenticationID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveATracker(tracker model.Tracker, t *testing.T) {
-	savedTracker, err = trackerService.CreateTracker(savedUserOne.AuthenticationID, tracker)
+	savedTracker, err = trackerService.CreateTracker(context.Background(), savedUserOne.AuthenticationID, tracker)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenIHaveASpend(sub string, spend model.Spend, t *testing.T) {
-	savedSpend, err = spendService.CreateSpend(sub, spend)
+	savedSpend, err = spendService.CreateSpend(context.Background(), sub, spend)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIUpdateTheTransfers(t *testing.T) {
-	savedTransfers, err = transferService.UpsertTransfers(savedTracker.ID)
+	savedTransfers, err = transferService.UpsertTransfers(context.Background(), savedTracker.ID)
 }
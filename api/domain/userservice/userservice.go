@@ -1,45 +1,75 @@
 package userservice
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
-	"github.com/TomPallister/godutch-api/api/infrastructure/encryption"
+	"github.com/TomPallister/godutch-api/api/infrastructure/token"
+	"github.com/TomPallister/godutch-api/api/metrics"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
 	"github.com/nu7hatch/gouuid"
 )
 
 // ErrorCreateUser ...
-var ErrorCreateUser = errors.New("Could not create user")
+var ErrorCreateUser = domainerror.NewBadRequest("could_not_create_user", "Could not create user")
 
 // ErrorAcceptInviteUser ...
-var ErrorAcceptInviteUser = errors.New("Could not accept invite user")
+var ErrorAcceptInviteUser = domainerror.NewForbidden("invite_email_mismatch", "Could not accept invite user")
 
 // ErrorPermissionsToViewUser ...
-var ErrorPermissionsToViewUser = errors.New("You do not have permission to view this user")
+var ErrorPermissionsToViewUser = domainerror.NewForbidden("cannot_view_user", "You do not have permission to view this user")
 
 // ErrorDoNotHavePermissionToInvite ...
-var ErrorDoNotHavePermissionToInvite = errors.New("You do not have permission to invite to thsi tracker")
+var ErrorDoNotHavePermissionToInvite = domainerror.NewForbidden("cannot_invite_to_tracker", "You do not have permission to invite to thsi tracker")
+
+// ErrorInvalidInviteToken ...
+var ErrorInvalidInviteToken = domainerror.NewNotFound("invite_not_found", "The invite token is invalid")
+
+// ErrorInviteExpired ...
+var ErrorInviteExpired = domainerror.NewConflict("invite_expired", "", "The invite has expired")
+
+// ErrorInviteRevoked ...
+var ErrorInviteRevoked = domainerror.NewConflict("invite_revoked", "", "The invite has been revoked")
+
+// ErrorInviteAlreadyAccepted ...
+var ErrorInviteAlreadyAccepted = domainerror.NewConflict("invite_already_accepted", "", "The invite has already been accepted")
+
+// ErrorCouldNotSendInvite ...
+var ErrorCouldNotSendInvite = domainerror.NewInternal("could_not_send_invite", "Could not send invite")
+
+// InviteExpiry is how long an invite token can be used for after it is sent
+const InviteExpiry = 7 * 24 * time.Hour
 
 // UserService ...
 type UserService interface {
-	FindBySub(sub string) (model.User, error)
+	FindBySub(ctx context.Context, sub string) (model.User, error)
 
-	FindByID(sub string, id int64) (model.User, error)
+	FindByID(ctx context.Context, sub string, id int64) (model.User, error)
 
-	CreateUser(sub string,
+	CreateUser(ctx context.Context, sub string,
 		user model.User) (model.User, error)
 
-	InviteUser(sub string, inviteUser model.InviteUser, rootURL string) (model.User, error)
+	InviteUser(ctx context.Context, sub string, inviteUser model.InviteUser, rootURL string) (model.User, error)
+
+	AcceptInvite(ctx context.Context, sub string, user model.User, inviteToken string) (model.User, error)
+
+	FindPendingInvites(ctx context.Context, sub string, trackerID int64) ([]model.Invite, error)
 
-	AcceptInvite(sub string, user model.User, cryptoText string) (model.User, error)
+	ResendInvite(ctx context.Context, sub string, id int64, rootURL string) (model.Invite, error)
 
-	FindByIDForTracker(id int64) (model.User, error)
+	RevokeInvite(ctx context.Context, sub string, id int64) (model.Invite, error)
+
+	FindByIDForTracker(ctx context.Context, id int64) (model.User, error)
+	FindByIDsForTracker(ctx context.Context, ids []int64) ([]model.User, error)
 }
 
 // NewGoDutchUserService ...
@@ -47,7 +77,9 @@ func NewGoDutchUserService(userRepository userrepository.UserRepository,
 	validator uservalidation.UserValidator,
 	logger infrastructure.Logger,
 	emailService infrastructure.EmailService,
-	trackerRepository trackerrepository.TrackerRepository) *GoDutchUserService {
+	trackerRepository trackerrepository.TrackerRepository,
+	inviteRepository inviterepository.InviteRepository,
+	publisher events.Publisher) *GoDutchUserService {
 
 	service := GoDutchUserService{}
 	service.userRepository = userRepository
@@ -55,6 +87,8 @@ func NewGoDutchUserService(userRepository userrepository.UserRepository,
 	service.logger = logger
 	service.emailService = emailService
 	service.trackerRepository = trackerRepository
+	service.inviteRepository = inviteRepository
+	service.publisher = publisher
 	return &service
 }
 
@@ -65,22 +99,28 @@ type GoDutchUserService struct {
 	logger            infrastructure.Logger
 	emailService      infrastructure.EmailService
 	trackerRepository trackerrepository.TrackerRepository
+	inviteRepository  inviterepository.InviteRepository
+	publisher         events.Publisher
 }
 
 // FindBySub ...
-func (godutchUserService *GoDutchUserService) FindBySub(sub string) (model.User, error) {
-	return godutchUserService.userRepository.GetBySub(sub)
+func (godutchUserService *GoDutchUserService) FindBySub(ctx context.Context, sub string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindBySub")
+	defer span.End()
+	return godutchUserService.userRepository.GetBySub(ctx, sub)
 }
 
 // FindByID ...
-func (godutchUserService *GoDutchUserService) FindByID(sub string,
+func (godutchUserService *GoDutchUserService) FindByID(ctx context.Context, sub string,
 	id int64) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindByID")
+	defer span.End()
 
-	currentUser, err := godutchUserService.userRepository.GetBySub(sub)
+	currentUser, err := godutchUserService.userRepository.GetBySub(ctx, sub)
 	if err != nil {
 		return model.User{}, err
 	}
-	cuerrenUsersTrackers, err := godutchUserService.trackerRepository.GetForUserID(currentUser.ID)
+	cuerrenUsersTrackers, err := godutchUserService.trackerRepository.GetForUserID(ctx, currentUser.ID)
 	if err != nil {
 		return model.User{}, err
 	}
@@ -97,43 +137,57 @@ func (godutchUserService *GoDutchUserService) FindByID(sub string,
 		return model.User{}, ErrorPermissionsToViewUser
 	}
 
-	user, err := godutchUserService.userRepository.GetByID(id)
+	user, err := godutchUserService.userRepository.GetByID(ctx, id)
 	return user, err
 }
 
 // FindByIDForTracker ...
-func (godutchUserService *GoDutchUserService) FindByIDForTracker(id int64) (model.User, error) {
+func (godutchUserService *GoDutchUserService) FindByIDForTracker(ctx context.Context, id int64) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindByIDForTracker")
+	defer span.End()
 
-	user, err := godutchUserService.userRepository.GetByID(id)
+	user, err := godutchUserService.userRepository.GetByID(ctx, id)
 	return user, err
 }
 
+// FindByIDsForTracker ...finds a tracker's users in one query, in the order of ids
+func (godutchUserService *GoDutchUserService) FindByIDsForTracker(ctx context.Context, ids []int64) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindByIDsForTracker")
+	defer span.End()
+
+	return godutchUserService.userRepository.GetByIDs(ctx, ids)
+}
+
 // CreateUser ...
-func (godutchUserService *GoDutchUserService) CreateUser(sub string,
+func (godutchUserService *GoDutchUserService) CreateUser(ctx context.Context, sub string,
 	user model.User) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
 
 	user.DateCreated = time.Now()
 
 	user.AuthenticationID = sub
 
-	valid, err := godutchUserService.validator.IsValidCreateUser(user, godutchUserService.logger, godutchUserService.userRepository)
+	valid, err := godutchUserService.validator.IsValidCreateUser(ctx, user, infrastructure.LoggerFrom(ctx, godutchUserService.logger), godutchUserService.userRepository)
 	if valid == false {
 		return model.User{}, err
 	}
 
-	return godutchUserService.userRepository.Insert(user)
+	return godutchUserService.userRepository.Insert(ctx, user)
 }
 
 // InviteUser ...
-func (godutchUserService *GoDutchUserService) InviteUser(sub string, inviteUser model.InviteUser,
+func (godutchUserService *GoDutchUserService) InviteUser(ctx context.Context, sub string, inviteUser model.InviteUser,
 	rootURL string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.InviteUser")
+	defer span.End()
 
-	invitingUser, err := godutchUserService.FindBySub(sub)
+	invitingUser, err := godutchUserService.FindBySub(ctx, sub)
 	if err != nil {
 		return model.User{}, err
 	}
 
-	tracker, err := godutchUserService.trackerRepository.GetByID(inviteUser.TrackerID)
+	tracker, err := godutchUserService.trackerRepository.GetByID(ctx, inviteUser.TrackerID)
 	if err != nil {
 		return model.User{}, err
 	}
@@ -150,31 +204,35 @@ func (godutchUserService *GoDutchUserService) InviteUser(sub string, inviteUser
 		Name:             "",
 	}
 
-	valid, err := godutchUserService.validator.IsValidInviteUser(invitedUser, godutchUserService.logger, godutchUserService.userRepository)
+	valid, err := godutchUserService.validator.IsValidInviteUser(ctx, invitedUser, infrastructure.LoggerFrom(ctx, godutchUserService.logger), godutchUserService.userRepository)
 	if valid == false {
 		return model.User{}, err
 	}
 
-	invitedUser, err = godutchUserService.userRepository.Insert(invitedUser)
+	invitedUser, err = godutchUserService.userRepository.Insert(ctx, invitedUser)
 	if err != nil {
 		return model.User{}, err
 	}
 
 	tracker.TrackerUserIDs = append(tracker.TrackerUserIDs, invitedUser.ID)
 
-	tracker, err = godutchUserService.trackerRepository.Update(tracker.ID, tracker)
+	tracker, err = godutchUserService.trackerRepository.Update(ctx, tracker.ID, tracker)
 	if err != nil {
 		return model.User{}, err
 	}
 
-	ciphertext := encryption.Encrypt(invitedUser.EmailAddress)
+	godutchUserService.publish(ctx, events.TrackerUpdated, tracker.ID, tracker)
 
-	result, err := godutchUserService.emailService.SendEmail(invitedUser.EmailAddress,
-		fmt.Sprintf("You have been invited to GoDutch by %v", invitingUser.EmailAddress),
-		fmt.Sprintf("go to %vacceptinvite/%v to sign up..", rootURL, ciphertext),
-		"computer@godutch.money", "GoDutch")
+	invite := model.Invite{
+		TrackerID:       tracker.ID,
+		UserID:          invitedUser.ID,
+		InvitedByUserID: invitingUser.ID,
+		EmailAddress:    invitedUser.EmailAddress,
+		DateCreated:     time.Now(),
+	}
 
-	if result == false {
+	_, err = godutchUserService.sendInvite(ctx, invite, invitingUser, rootURL)
+	if err != nil {
 		return model.User{}, err
 	}
 
@@ -183,17 +241,33 @@ func (godutchUserService *GoDutchUserService) InviteUser(sub string, inviteUser
 }
 
 // AcceptInvite ...
-func (godutchUserService *GoDutchUserService) AcceptInvite(sub string, user model.User,
-	cryptoEmail string) (model.User, error) {
+func (godutchUserService *GoDutchUserService) AcceptInvite(ctx context.Context, sub string, user model.User,
+	inviteToken string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AcceptInvite")
+	defer span.End()
 
-	emailAddress := encryption.Decrypt(cryptoEmail)
+	if err := token.Validate(inviteToken); err != nil {
+		return model.User{}, ErrorInvalidInviteToken
+	}
 
-	invitedUser, err := godutchUserService.userRepository.GetByEmail(emailAddress)
+	invite, err := godutchUserService.inviteRepository.GetByTokenHash(ctx, token.Hash(inviteToken))
+	if err == inviterepository.ErrorNotFound {
+		return model.User{}, ErrorInvalidInviteToken
+	}
 	if err != nil {
 		return model.User{}, err
 	}
 
-	if emailAddress != invitedUser.EmailAddress {
+	if err := notPending(invite, time.Now()); err != nil {
+		return model.User{}, err
+	}
+
+	invitedUser, err := godutchUserService.userRepository.GetByID(ctx, invite.UserID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if invite.EmailAddress != invitedUser.EmailAddress {
 		return model.User{}, ErrorAcceptInviteUser
 	}
 
@@ -204,10 +278,218 @@ func (godutchUserService *GoDutchUserService) AcceptInvite(sub string, user mode
 		invitedUser.EmailAddress = user.EmailAddress
 	}
 
-	invitedUser, err = godutchUserService.userRepository.Update(invitedUser.ID, invitedUser)
+	invitedUser, err = godutchUserService.inviteRepository.Accept(ctx, invite.ID, invitedUser, time.Now())
+	if err == inviterepository.ErrorNotPending {
+		return model.User{}, godutchUserService.whyNotPending(ctx, invite.ID)
+	}
 	if err != nil {
 		return model.User{}, err
 	}
 
 	return invitedUser, nil
 }
+
+// notPending returns why the invite can no longer be used, or nil if it can
+func notPending(invite model.Invite, now time.Time) error {
+	switch {
+	case invite.DateRevoked != nil:
+		return ErrorInviteRevoked
+	case invite.DateAccepted != nil:
+		return ErrorInviteAlreadyAccepted
+	case !now.Before(invite.DateExpires):
+		return ErrorInviteExpired
+	}
+	return nil
+}
+
+// whyNotPending reads the invite again after another request accepted, revoked or expired it first
+func (godutchUserService *GoDutchUserService) whyNotPending(ctx context.Context, id int64) error {
+	invite, err := godutchUserService.inviteRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := notPending(invite, time.Now()); err != nil {
+		return err
+	}
+	return ErrorInviteExpired
+}
+
+// FindPendingInvites ...
+func (godutchUserService *GoDutchUserService) FindPendingInvites(ctx context.Context, sub string, trackerID int64) ([]model.Invite, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FindPendingInvites")
+	defer span.End()
+
+	currentUser, err := godutchUserService.FindBySub(ctx, sub)
+	if err != nil {
+		return []model.Invite{}, err
+	}
+
+	tracker, err := godutchUserService.trackerRepository.GetByID(ctx, trackerID)
+	if err != nil {
+		return []model.Invite{}, err
+	}
+
+	if currentUser.ID != tracker.AdminUserID {
+		return []model.Invite{}, ErrorDoNotHavePermissionToInvite
+	}
+
+	return godutchUserService.inviteRepository.GetPendingForTrackerID(ctx, tracker.ID, time.Now())
+}
+
+// ResendInvite ...
+func (godutchUserService *GoDutchUserService) ResendInvite(ctx context.Context, sub string, id int64,
+	rootURL string) (model.Invite, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ResendInvite")
+	defer span.End()
+
+	invitingUser, invite, err := godutchUserService.findInviteForAdmin(ctx, sub, id)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	switch {
+	case invite.DateRevoked != nil:
+		return model.Invite{}, ErrorInviteRevoked
+	case invite.DateAccepted != nil:
+		return model.Invite{}, ErrorInviteAlreadyAccepted
+	}
+
+	return godutchUserService.sendInvite(ctx, invite, invitingUser, rootURL)
+}
+
+// RevokeInvite ...
+func (godutchUserService *GoDutchUserService) RevokeInvite(ctx context.Context, sub string, id int64) (model.Invite, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeInvite")
+	defer span.End()
+
+	_, invite, err := godutchUserService.findInviteForAdmin(ctx, sub, id)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	if invite.DateAccepted != nil {
+		return model.Invite{}, ErrorInviteAlreadyAccepted
+	}
+
+	if invite.DateRevoked != nil {
+		return invite, nil
+	}
+
+	revoked, err := godutchUserService.inviteRepository.Revoke(ctx, invite.ID, time.Now())
+	if err == inviterepository.ErrorNotPending {
+		return godutchUserService.revokedOrAccepted(ctx, invite.ID)
+	}
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	tracker, err := godutchUserService.trackerRepository.GetByID(ctx, invite.TrackerID)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	trackerUserIDs := []int64{}
+	for _, u := range tracker.TrackerUserIDs {
+		if u != invite.UserID {
+			trackerUserIDs = append(trackerUserIDs, u)
+		}
+	}
+	tracker.TrackerUserIDs = trackerUserIDs
+
+	tracker, err = godutchUserService.trackerRepository.Update(ctx, tracker.ID, tracker)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	// the stream of the user who was removed closes when it sees the tracker without them
+	godutchUserService.publish(ctx, events.TrackerUpdated, tracker.ID, tracker)
+
+	return revoked, nil
+}
+
+// revokedOrAccepted reads the invite again after another request accepted or revoked it first
+func (godutchUserService *GoDutchUserService) revokedOrAccepted(ctx context.Context, id int64) (model.Invite, error) {
+	invite, err := godutchUserService.inviteRepository.GetByID(ctx, id)
+	if err != nil {
+		return model.Invite{}, err
+	}
+	if invite.DateAccepted != nil {
+		return model.Invite{}, ErrorInviteAlreadyAccepted
+	}
+	return invite, nil
+}
+
+// findInviteForAdmin returns the invite if the subject is the admin of its tracker
+func (godutchUserService *GoDutchUserService) findInviteForAdmin(ctx context.Context, sub string,
+	id int64) (model.User, model.Invite, error) {
+
+	currentUser, err := godutchUserService.FindBySub(ctx, sub)
+	if err != nil {
+		return model.User{}, model.Invite{}, err
+	}
+
+	invite, err := godutchUserService.inviteRepository.GetByID(ctx, id)
+	if err != nil {
+		return model.User{}, model.Invite{}, err
+	}
+
+	tracker, err := godutchUserService.trackerRepository.GetByID(ctx, invite.TrackerID)
+	if err != nil {
+		return model.User{}, model.Invite{}, err
+	}
+
+	if currentUser.ID != tracker.AdminUserID {
+		return model.User{}, model.Invite{}, ErrorDoNotHavePermissionToInvite
+	}
+
+	return currentUser, invite, nil
+}
+
+// sendInvite gives the invite a new token and expiry, saves it and emails the token.
+// Only the hash of the token is stored so a resend always issues a new token.
+func (godutchUserService *GoDutchUserService) sendInvite(ctx context.Context, invite model.Invite,
+	invitingUser model.User, rootURL string) (model.Invite, error) {
+
+	inviteToken, err := token.New()
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	invite.TokenHash = token.Hash(inviteToken)
+	invite.DateExpires = time.Now().Add(InviteExpiry)
+
+	if invite.ID == 0 {
+		invite, err = godutchUserService.inviteRepository.Insert(ctx, invite)
+	} else {
+		id := invite.ID
+		invite, err = godutchUserService.inviteRepository.Renew(ctx, id, invite.TokenHash, invite.DateExpires)
+		if err == inviterepository.ErrorNotPending {
+			return model.Invite{}, godutchUserService.whyNotPending(ctx, id)
+		}
+	}
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	result, err := godutchUserService.emailService.SendEmail(invite.EmailAddress,
+		fmt.Sprintf("You have been invited to GoDutch by %v", invitingUser.EmailAddress),
+		fmt.Sprintf("go to %vacceptinvite/%v to sign up..", rootURL, inviteToken),
+		"computer@godutch.money", "GoDutch")
+
+	if result == false {
+		if err == nil {
+			err = ErrorCouldNotSendInvite
+		}
+		return model.Invite{}, err
+	}
+	metrics.InvitesSent.Inc()
+
+	return invite, nil
+}
+
+// publish tells the tracker's subscribers about a change that has already been saved, so failures are only logged
+func (godutchUserService *GoDutchUserService) publish(ctx context.Context, eventType string, trackerID int64, data interface{}) {
+	if err := events.Publish(godutchUserService.publisher, eventType, trackerID, data); err != nil {
+		infrastructure.LoggerFrom(ctx, godutchUserService.logger).Error("Could not publish event", err, "event_type", eventType, "tracker_id", trackerID)
+	}
+}
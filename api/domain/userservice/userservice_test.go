@@ -1,52 +1,44 @@
 package userservice_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/TomPallister/godutch-api/api/domain/spendservice"
-	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
-	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
-	"github.com/TomPallister/godutch-api/api/domain/transferservice"
 	"github.com/TomPallister/godutch-api/api/domain/userservice"
-	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
-	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
-	"github.com/TomPallister/godutch-api/api/infrastructure/encryption"
 	"github.com/TomPallister/godutch-api/api/model"
-	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
-	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
-	"github.com/TomPallister/godutch-api/api/repository/transferrepository"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
 )
 
 var savedTracker model.Tracker
 var savedUser model.User
 var invitedUser model.User
+var adminUser model.User
+var pendingInvites []model.Invite
+var savedInvite model.Invite
 var err error
 var emailService = &infrastructure.FakeEmailService{}
 var logger = infrastructure.ConsoleLogger{}
 var trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 var userRepository = userrepository.NewInMemoryUserRepository()
-var spendRepository = spendrepository.NewInMemorySpendRepository()
-var transferRepository = transferrepository.NewInMemoryTransferRepository()
-var spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
-var spendSummaryService = spendsummaryservice.
-	NewGoDutchSpendSummaryService(spendRepository, spendSummaryRepository, trackerRepository, userRepository)
-var transferService = transferservice.
-	NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
+var inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
+var publisher = events.NewInProcessBroker(logger, events.DefaultBufferSize)
 var userService = userservice.
-	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
-var trackerService = trackerservice.
-	NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
-var spendService = spendservice.
-	NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+	NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, publisher)
 
 func TestCanGetUser(t *testing.T) {
 	givenThereAreCleanDependencies()
 	givenThereIsAUserInTheRepository(model.User{ID: 0, Name: "Tom", AuthenticationID: "asdf"}, t)
+	givenTheUserIsOnATracker(t)
 	whenIGetAUserWithTheID("asdf", 1, t)
 	thenTheFollowingIsReturned(model.User{ID: 1, Name: "Tom", AuthenticationID: "asdf"}, t)
 }
@@ -68,6 +60,7 @@ func TestCanGetUserById(t *testing.T) {
 func TestCanCreateUser(t *testing.T) {
 	givenThereAreCleanDependencies()
 	givenIHaveCreatedAUser("asd2", model.User{Name: "Tom", AuthenticationID: "asd2", EmailAddress: "email@", DateCreated: time.Now()}, t)
+	givenTheUserIsOnATracker(t)
 	whenIGetAUserWithTheID("asd2", 1, t)
 	thenTheFollowingIsReturned(model.User{ID: 1, Name: "Tom", AuthenticationID: "asd2"}, t)
 }
@@ -80,7 +73,7 @@ func TestCanInviteUser(t *testing.T) {
 		Currency:       "£",
 		Name:           "test",
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 	}
 	givenIHaveCreatedATracker(t, tracker)
 	inviteUser := model.InviteUser{
@@ -99,7 +92,7 @@ func TestCanAcceptInviteUser(t *testing.T) {
 		Currency:       "£",
 		Name:           "test",
 		DateCreated:    time.Now(),
-		TrackerUserIDs: []int{savedUser.ID},
+		TrackerUserIDs: []int64{savedUser.ID},
 	}
 	givenIHaveCreatedATracker(t, tracker)
 	inviteUser := model.InviteUser{
@@ -116,11 +109,192 @@ func TestCanAcceptInviteUser(t *testing.T) {
 		Name:             "Tom",
 	}
 
-	cryptoEmailAddress := encryption.Encrypt(inviteUser.EmailAddress)
-	whenTheUserAcceptsTheInvite(t, userAcceptsInvite.AuthenticationID, userAcceptsInvite, cryptoEmailAddress)
+	whenTheUserAcceptsTheInvite(t, userAcceptsInvite.AuthenticationID, userAcceptsInvite, theInviteTokenThatWasEmailed())
 	thenTheUserIsCreated(t)
 }
 
+func TestCannotAcceptInviteTwice(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	inviteToken := theInviteTokenThatWasEmailed()
+	whenTheUserAcceptsTheInvite(t, "anysub", model.User{Name: "Tom"}, inviteToken)
+	whenTheUserTriesToAcceptTheInvite("anysub", model.User{Name: "Tom"}, inviteToken)
+	thenTheAcceptIsRejectedWithError(userservice.ErrorInviteAlreadyAccepted, t)
+}
+
+func TestOnlyOneOfManyConcurrentAcceptsSucceeds(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	inviteToken := theInviteTokenThatWasEmailed()
+	accepted := whenManyUsersAcceptTheInviteAtOnce(10, inviteToken)
+	thenThisManyAcceptsSucceeded(1, accepted, t)
+}
+
+func TestCannotAcceptRevokedInvite(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	inviteToken := theInviteTokenThatWasEmailed()
+	whenIRevokeTheInvite(t)
+	whenTheUserTriesToAcceptTheInvite("anysub", model.User{Name: "Tom"}, inviteToken)
+	thenTheAcceptIsRejectedWithError(userservice.ErrorInviteRevoked, t)
+}
+
+func TestCannotAcceptMalformedInvite(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	whenTheUserTriesToAcceptTheInvite("anysub", model.User{Name: "Tom"}, "not-a-token")
+	thenTheAcceptIsRejectedWithError(userservice.ErrorInvalidInviteToken, t)
+}
+
+func TestResendInviteReplacesToken(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	oldToken := theInviteTokenThatWasEmailed()
+	whenIResendTheInvite(t)
+	whenTheUserTriesToAcceptTheInvite("anysub", model.User{Name: "Tom"}, oldToken)
+	thenTheAcceptIsRejectedWithError(userservice.ErrorInvalidInviteToken, t)
+	whenTheUserAcceptsTheInvite(t, "anysub", model.User{Name: "Tom"}, theInviteTokenThatWasEmailed())
+}
+
+func TestResendingCannotReopenAnInviteRevokedMeanwhile(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	givenTheInviteIsRevokedAsItIsResent()
+	_, err = userService.ResendInvite(context.Background(), adminUser.AuthenticationID, savedInvite.ID, "some root url")
+	thenTheAcceptIsRejectedWithError(userservice.ErrorInviteRevoked, t)
+	whenIFindThePendingInvites(t)
+	thenThereAreThisManyPendingInvites(0, t)
+}
+
+func TestCanFindPendingInvites(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	whenIFindThePendingInvites(t)
+	thenThereAreThisManyPendingInvites(1, t)
+	whenIRevokeTheInvite(t)
+	whenIFindThePendingInvites(t)
+	thenThereAreThisManyPendingInvites(0, t)
+}
+
+func TestRevokingAnInviteTellsTheTrackersSubscribers(t *testing.T) {
+	givenThereAreCleanDependencies()
+	givenIHaveInvitedAUser(t)
+	subscription := publisher.Subscribe(savedTracker.ID)
+	defer subscription.Close()
+	whenIRevokeTheInvite(t)
+	thenTheTrackerIsUpdatedWithout(subscription, savedInvite.UserID, t)
+}
+
+// racingInviteRepository revokes the invite just after it has been read, as a concurrent request could
+type racingInviteRepository struct {
+	*inviterepository.InMemoryInviteRepository
+	revokeAfterRead bool
+}
+
+func (repository *racingInviteRepository) GetByID(ctx context.Context, id int64) (model.Invite, error) {
+	invite, err := repository.InMemoryInviteRepository.GetByID(ctx, id)
+	if repository.revokeAfterRead {
+		repository.revokeAfterRead = false
+		repository.Revoke(ctx, id, time.Now())
+	}
+	return invite, err
+}
+
+func givenTheInviteIsRevokedAsItIsResent() {
+	racing := &racingInviteRepository{InMemoryInviteRepository: inviteRepository, revokeAfterRead: true}
+	userService = userservice.
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, racing, publisher)
+}
+
+func givenIHaveInvitedAUser(t *testing.T) {
+	givenIHaveCreatedAUser("asd2", model.User{Name: "Tom", AuthenticationID: "asd2", EmailAddress: "email@", DateCreated: time.Now()}, t)
+	adminUser = savedUser
+	tracker := model.Tracker{
+		AdminUserID:    savedUser.ID,
+		Currency:       "£",
+		Name:           "test",
+		DateCreated:    time.Now(),
+		TrackerUserIDs: []int64{savedUser.ID},
+	}
+	givenIHaveCreatedATracker(t, tracker)
+	whenIInviteTheUserWith(model.InviteUser{EmailAddress: "invited@godutch.money", TrackerID: savedTracker.ID}, t)
+	whenIFindThePendingInvites(t)
+	savedInvite = pendingInvites[0]
+}
+
+func theInviteTokenThatWasEmailed() string {
+	text := emailService.LastText
+	text = text[strings.Index(text, "acceptinvite/")+len("acceptinvite/"):]
+	return strings.TrimSuffix(text, " to sign up..")
+}
+
+func whenIFindThePendingInvites(t *testing.T) {
+	pendingInvites, err = userService.FindPendingInvites(context.Background(), adminUser.AuthenticationID, savedTracker.ID)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenIRevokeTheInvite(t *testing.T) {
+	_, err = userService.RevokeInvite(context.Background(), adminUser.AuthenticationID, savedInvite.ID)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenIResendTheInvite(t *testing.T) {
+	_, err = userService.ResendInvite(context.Background(), adminUser.AuthenticationID, savedInvite.ID, "some root url")
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenTheUserTriesToAcceptTheInvite(sub string, user model.User, inviteToken string) {
+	invitedUser, err = userService.AcceptInvite(context.Background(), sub, user, inviteToken)
+}
+
+func whenManyUsersAcceptTheInviteAtOnce(users int, inviteToken string) []error {
+	errs := make([]error, users)
+	var wait sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			_, errs[i] = userService.AcceptInvite(context.Background(), fmt.Sprintf("sub%v", i), model.User{Name: "Tom"}, inviteToken)
+		}(i)
+	}
+	wait.Wait()
+	return errs
+}
+
+func thenThisManyAcceptsSucceeded(expected int, errs []error, t *testing.T) {
+	succeeded := 0
+	for _, e := range errs {
+		switch e {
+		case nil:
+			succeeded++
+		case userservice.ErrorInviteAlreadyAccepted:
+		default:
+			t.Fatalf("Error should be %v but was %v", userservice.ErrorInviteAlreadyAccepted, e)
+		}
+	}
+	if succeeded != expected {
+		t.Fatalf("Expected %v, got %v", expected, succeeded)
+	}
+}
+
+func thenTheAcceptIsRejectedWithError(e error, t *testing.T) {
+	if err != e {
+		t.Fatalf("Error should be %v but was %v", e, err)
+	}
+}
+
+func thenThereAreThisManyPendingInvites(expected int, t *testing.T) {
+	if len(pendingInvites) != expected {
+		t.Fatalf("Expected %v, got %v", expected, len(pendingInvites))
+	}
+}
+
 func thenTheUserIsCreated(t *testing.T) {
 	if savedUser.ID != invitedUser.ID {
 		t.Fatalf("Expected %v, got %v", invitedUser.ID, savedUser.ID)
@@ -128,14 +302,25 @@ func thenTheUserIsCreated(t *testing.T) {
 }
 
 func whenTheUserAcceptsTheInvite(t *testing.T, sub string, user model.User, cryptoEmail string) {
-	invitedUser, err = userService.AcceptInvite(sub, user, cryptoEmail)
+	invitedUser, err = userService.AcceptInvite(context.Background(), sub, user, cryptoEmail)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
+// givenTheUserIsOnATracker lets the saved user see themselves, users can only see the members of their trackers
+func givenTheUserIsOnATracker(t *testing.T) {
+	givenIHaveCreatedATracker(t, model.Tracker{
+		AdminUserID:    savedUser.ID,
+		Currency:       "£",
+		Name:           "test",
+		DateCreated:    time.Now(),
+		TrackerUserIDs: []int64{savedUser.ID},
+	})
+}
+
 func givenIHaveCreatedATracker(t *testing.T, tracker model.Tracker) {
-	savedTracker, err = trackerRepository.Insert(tracker)
+	savedTracker, err = trackerRepository.Insert(context.Background(), tracker)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -151,7 +336,7 @@ func thenTheUserIsInvited(email string, t *testing.T) {
 }
 
 func whenIInviteTheUserWith(inviteUserModel model.InviteUser, t *testing.T) {
-	savedUser, err = userService.InviteUser(savedUser.AuthenticationID, inviteUserModel, "some root url")
+	savedUser, err = userService.InviteUser(context.Background(), savedUser.AuthenticationID, inviteUserModel, "some root url")
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -162,52 +347,42 @@ func givenThereAreCleanDependencies() {
 	logger = infrastructure.ConsoleLogger{}
 	trackerRepository = trackerrepository.NewInMemoryTrackerRepository()
 	userRepository = userrepository.NewInMemoryUserRepository()
-	spendRepository = spendrepository.NewInMemorySpendRepository()
-	transferRepository = transferrepository.NewInMemoryTransferRepository()
-	spendSummaryRepository = spendsummaryrepository.NewInMemorySpendSummaryRepository()
-	spendSummaryService = spendsummaryservice.
-		NewGoDutchSpendSummaryService(spendRepository, spendSummaryRepository, trackerRepository, userRepository)
-	transferService = transferservice.
-		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
+	inviteRepository = inviterepository.NewInMemoryInviteRepository(userRepository)
+	publisher = events.NewInProcessBroker(logger, events.DefaultBufferSize)
 	userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
-	trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService)
-	spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
-
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, publisher)
 }
 
 func givenIHaveCreatedAUser(sub string, user model.User, t *testing.T) {
-	savedUser, err = userService.CreateUser(sub, user)
+	savedUser, err = userService.CreateUser(context.Background(), sub, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenThereIsAUserInTheRepository(newUser model.User, t *testing.T) {
-	savedUser, err = userRepository.Insert(newUser)
+	savedUser, err = userRepository.Insert(context.Background(), newUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
-func whenIGetAUserWithTheID(sub string, id int, t *testing.T) {
-	savedUser, err = userService.FindByID(sub, id)
+func whenIGetAUserWithTheID(sub string, id int64, t *testing.T) {
+	savedUser, err = userService.FindByID(context.Background(), sub, id)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIGetAUserWithTheSub(sub string, t *testing.T) {
-	savedUser, err = userService.FindBySub(sub)
+	savedUser, err = userService.FindBySub(context.Background(), sub)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
-func whenIGetAUserByIDForATracker(id int, t *testing.T) {
-	savedUser, err = userService.FindByIDForTracker(id)
+func whenIGetAUserByIDForATracker(id int64, t *testing.T) {
+	savedUser, err = userService.FindByIDForTracker(context.Background(), id)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -224,3 +399,18 @@ func thenTheFollowingIsReturned(expectedUser model.User, t *testing.T) {
 		t.Fatalf("Expected %v, got %v", expectedUser.AuthenticationID, savedUser.AuthenticationID)
 	}
 }
+
+func thenTheTrackerIsUpdatedWithout(subscription *events.Subscription, userID int64, t *testing.T) {
+	select {
+	case event := <-subscription.Events:
+		var tracker model.Tracker
+		if err := json.Unmarshal(event.Data, &tracker); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != events.TrackerUpdated || infrastructure.Ints64Contains(tracker.TrackerUserIDs, userID) {
+			t.Fatalf("Expected %v without user %v got %v %v", events.TrackerUpdated, userID, event.Type, tracker.TrackerUserIDs)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected %v to be published", events.TrackerUpdated)
+	}
+}
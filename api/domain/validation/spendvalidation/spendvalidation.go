@@ -1,42 +1,41 @@
 package spendvalidation
 
 import (
-	"errors"
-
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/shopspring/decimal"
 )
 
 // ErrorInvalidName ...
-var ErrorInvalidName = errors.New("Invalid name")
+var ErrorInvalidName = domainerror.NewValidation("invalid_name", "spend.name", "Invalid name")
 
 // ErrorInvalidCurrency ...
-var ErrorInvalidCurrency = errors.New("Invalid currency")
+var ErrorInvalidCurrency = domainerror.NewValidation("invalid_currency", "spend.currency", "Invalid currency")
 
 // ErrorSpendCannotBeLessThanZero ...
-var ErrorSpendCannotBeLessThanZero = errors.New("Invalid spend amount")
+var ErrorSpendCannotBeLessThanZero = domainerror.NewValidation("invalid_spend_amount", "spend.value", "Invalid spend amount")
 
 // ErrorInvalidTrackerID ...
-var ErrorInvalidTrackerID = errors.New("Invalid tracker id")
+var ErrorInvalidTrackerID = domainerror.NewValidation("invalid_tracker_id", "spend.trackerId", "Invalid tracker id")
 
 // ErrorInvalidUserID ...
-var ErrorInvalidUserID = errors.New("Invalid user id")
- 
+var ErrorInvalidUserID = domainerror.NewValidation("invalid_user_id", "spend.userId", "Invalid user id")
+
 // ErrorAdminUserIDIsDifferentToSubjectID ...
-var ErrorAdminUserIDIsDifferentToSubjectID = errors.New("user id is different to subject id")
+var ErrorAdminUserIDIsDifferentToSubjectID = domainerror.NewForbidden("user_is_not_subject", "user id is different to subject id")
 
 // ErrorInvalidDateCreated ...
-var ErrorInvalidDateCreated = errors.New("Invalid date created")
+var ErrorInvalidDateCreated = domainerror.NewValidation("invalid_date_created", "spend.dateCreated", "Invalid date created")
 
 // ErrorTrackerIDIsDifferntToTrackTrackerID ...
-var ErrorTrackerIDIsDifferntToTrackTrackerID = errors.New("tracker id is different to track tracker id")
+var ErrorTrackerIDIsDifferntToTrackTrackerID = domainerror.NewValidation("tracker_id_mismatch", "spend.trackerId", "tracker id is different to track tracker id")
 
 // ErrorTheSpendDoesNotExist ...
-var ErrorTheSpendDoesNotExist = errors.New("The spend does not exist")
+var ErrorTheSpendDoesNotExist = domainerror.NewNotFound("spend_not_found", "The spend does not exist")
 
 // ErrorSpendCurrencyDoesNotMatchTrackerCurrency ...
-var ErrorSpendCurrencyDoesNotMatchTrackerCurrency = errors.New("The spend currency does not match the tracker currency")
+var ErrorSpendCurrencyDoesNotMatchTrackerCurrency = domainerror.NewValidation("currency_mismatch", "spend.currency", "The spend currency does not match the tracker currency")
 
 // SpendValidator ...
 type SpendValidator interface {
@@ -68,54 +67,48 @@ func NewGoDutchSpendValidator() *GoDutchSpendValidator {
 func (validator *GoDutchSpendValidator) IsValidCreateSpend(spend model.Spend,
 	logger infrastructure.Logger, user model.User, tracker model.Tracker) (bool, error) {
 
-	if len(spend.Name) <= 0 {
-		logger.Error("Error: ", ErrorInvalidName)
-		return false, ErrorInvalidName
-	}
+	var validationErrors domainerror.Errors
 
-	if spend.Value.Cmp(decimal.NewFromFloat(0)) == -1 {
-		logger.Error("Error: ", ErrorSpendCannotBeLessThanZero)
-		return false, ErrorSpendCannotBeLessThanZero
+	if len(spend.Name) <= 0 {
+		validationErrors = append(validationErrors, ErrorInvalidName)
 	}
 
-	if spend.Value.Cmp(decimal.NewFromFloat(0)) == 0 {
-		logger.Error("Error: ", ErrorSpendCannotBeLessThanZero)
-		return false, ErrorSpendCannotBeLessThanZero
+	if spend.Value.Cmp(decimal.NewFromFloat(0)) <= 0 {
+		validationErrors = append(validationErrors, ErrorSpendCannotBeLessThanZero)
 	}
 
 	if spend.TrackerID <= 0 {
-		logger.Error("Error: ", ErrorInvalidTrackerID)
-		return false, ErrorInvalidTrackerID
+		validationErrors = append(validationErrors, ErrorInvalidTrackerID)
 	}
 
 	if spend.UserID <= 0 {
-		logger.Error("Error: ", ErrorInvalidUserID)
-		return false, ErrorInvalidUserID
+		validationErrors = append(validationErrors, ErrorInvalidUserID)
 	}
 
 	if len(spend.Currency) <= 0 {
-		logger.Error("Error: ", ErrorInvalidCurrency)
-		return false, ErrorInvalidCurrency
+		validationErrors = append(validationErrors, ErrorInvalidCurrency)
 	}
 
 	if spend.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
 	}
 
-	if spend.UserID != user.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+	if spend.UserID > 0 && spend.UserID != user.ID {
+		logger.Debug("Rejected invalid spend", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
-	if spend.TrackerID != tracker.ID {
-		logger.Error("Error: ", ErrorTrackerIDIsDifferntToTrackTrackerID)
-		return false, ErrorTrackerIDIsDifferntToTrackTrackerID
+	if spend.TrackerID > 0 && spend.TrackerID != tracker.ID {
+		validationErrors = append(validationErrors, ErrorTrackerIDIsDifferntToTrackTrackerID)
+	}
+
+	if len(spend.Currency) > 0 && spend.Currency != tracker.Currency {
+		validationErrors = append(validationErrors, ErrorSpendCurrencyDoesNotMatchTrackerCurrency)
 	}
 
-	if spend.Currency != tracker.Currency {
-		logger.Error("Error: ", ErrorSpendCurrencyDoesNotMatchTrackerCurrency)
-		return false, ErrorSpendCurrencyDoesNotMatchTrackerCurrency
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid spend", "error", err)
+		return false, err
 	}
 
 	return true, nil
@@ -127,55 +120,50 @@ func (validator *GoDutchSpendValidator) IsValidUpdateSpend(spend model.Spend,
 	user model.User,
 	existingSpend model.Spend, tracker model.Tracker) (bool, error) {
 
-	if len(spend.Name) <= 0 {
-		logger.Error("Error: ", ErrorInvalidName)
-		return false, ErrorInvalidName
-	}
+	var validationErrors domainerror.Errors
 
-	if spend.Value.Cmp(decimal.NewFromFloat(0)) == -1 {
-		logger.Error("Error: ", ErrorSpendCannotBeLessThanZero)
-		return false, ErrorSpendCannotBeLessThanZero
+	if len(spend.Name) <= 0 {
+		validationErrors = append(validationErrors, ErrorInvalidName)
 	}
 
-	if spend.Value.Cmp(decimal.NewFromFloat(0)) == 0 {
-		logger.Error("Error: ", ErrorSpendCannotBeLessThanZero)
-		return false, ErrorSpendCannotBeLessThanZero
+	if spend.Value.Cmp(decimal.NewFromFloat(0)) <= 0 {
+		validationErrors = append(validationErrors, ErrorSpendCannotBeLessThanZero)
 	}
 
 	if spend.TrackerID <= 0 {
-		logger.Error("Error: ", ErrorInvalidTrackerID)
-		return false, ErrorInvalidTrackerID
+		validationErrors = append(validationErrors, ErrorInvalidTrackerID)
 	}
 
 	if spend.UserID <= 0 {
-		logger.Error("Error: ", ErrorInvalidUserID)
-		return false, ErrorInvalidUserID
+		validationErrors = append(validationErrors, ErrorInvalidUserID)
 	}
 
 	if len(spend.Currency) <= 0 {
-		logger.Error("Error: ", ErrorInvalidCurrency)
-		return false, ErrorInvalidCurrency
+		validationErrors = append(validationErrors, ErrorInvalidCurrency)
 	}
 
 	if spend.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
 	}
 
-	if spend.UserID != user.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+	if spend.UserID > 0 && spend.UserID != user.ID {
+		logger.Debug("Rejected invalid spend", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
 	if spend.ID != existingSpend.ID {
-		logger.Error("Error: ", ErrorTheSpendDoesNotExist)
+		logger.Debug("Rejected invalid spend", "error", ErrorTheSpendDoesNotExist)
 		return false, ErrorTheSpendDoesNotExist
 	}
 
-	if spend.Currency != tracker.Currency {
-		logger.Error("Error: ", ErrorSpendCurrencyDoesNotMatchTrackerCurrency)
-		return false, ErrorSpendCurrencyDoesNotMatchTrackerCurrency
-	} 
+	if len(spend.Currency) > 0 && spend.Currency != tracker.Currency {
+		validationErrors = append(validationErrors, ErrorSpendCurrencyDoesNotMatchTrackerCurrency)
+	}
+
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid spend", "error", err)
+		return false, err
+	}
 
 	return true, nil
 }
@@ -187,12 +175,12 @@ func (validator *GoDutchSpendValidator) IsValidDeleteSpend(id int64,
 	existingSpend model.Spend) (bool, error) {
 
 	if existingSpend.UserID != user.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+		logger.Debug("Rejected invalid spend", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
 	if id != existingSpend.ID {
-		logger.Error("Error: ", ErrorTheSpendDoesNotExist)
+		logger.Debug("Rejected invalid spend", "error", ErrorTheSpendDoesNotExist)
 		return false, ErrorTheSpendDoesNotExist
 	}
 
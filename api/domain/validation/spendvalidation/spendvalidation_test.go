@@ -1,6 +1,7 @@
 package spendvalidation_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -267,7 +268,7 @@ func whenICallTheUpdateSpendValidator() {
 }
 
 func thenTheCommandIsRejectedWithError(e error, t *testing.T) {
-	if err != e {
+	if !errors.Is(err, e) {
 		t.Fatalf("Error should be %v but was %v", e, err)
 	}
 }
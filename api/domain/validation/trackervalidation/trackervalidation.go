@@ -1,33 +1,32 @@
 package trackervalidation
 
 import (
-	"errors"
-
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
 )
 
 // ErrorInvalidName ...
-var ErrorInvalidName = errors.New("Invalid name")
+var ErrorInvalidName = domainerror.NewValidation("invalid_name", "tracker.name", "Invalid name")
 
 // ErrorInvalidAdminUserID ...
-var ErrorInvalidAdminUserID = errors.New("Invalid admin user id")
+var ErrorInvalidAdminUserID = domainerror.NewValidation("invalid_admin_user_id", "tracker.adminUserId", "Invalid admin user id")
 
 // ErrorAdminUserIDIsDifferentToSubjectID ...
-var ErrorAdminUserIDIsDifferentToSubjectID = errors.New("Admin user id is different to subject id")
+var ErrorAdminUserIDIsDifferentToSubjectID = domainerror.NewForbidden("admin_user_is_not_subject", "Admin user id is different to subject id")
 
 // ErrorNoTrackerUsers ...
-var ErrorNoTrackerUsers = errors.New("No tracker users")
+var ErrorNoTrackerUsers = domainerror.NewValidation("no_tracker_users", "tracker.trackerUserIds", "No tracker users")
 
 // ErrorAdminUserNotInTrackerUsersList ...
-var ErrorAdminUserNotInTrackerUsersList = errors.New("Admin user id not in tracker user ids")
+var ErrorAdminUserNotInTrackerUsersList = domainerror.NewValidation("admin_user_not_in_tracker_users", "tracker.trackerUserIds", "Admin user id not in tracker user ids")
 
 // ErrorInvalidDateCreated ...
-var ErrorInvalidDateCreated = errors.New("Invalid date created")
+var ErrorInvalidDateCreated = domainerror.NewValidation("invalid_date_created", "tracker.dateCreated", "Invalid date created")
 
 // ErrorTheTrackerDoesNotExist ...
-var ErrorTheTrackerDoesNotExist = errors.New("Tracker does not exist")
- 
+var ErrorTheTrackerDoesNotExist = domainerror.NewNotFound("tracker_not_found", "Tracker does not exist")
+
 // TrackerValidator ...
 type TrackerValidator interface {
 	IsValidCreateTracker(tracker model.Tracker, logger infrastructure.Logger, adminUser model.User) (bool, error)
@@ -50,75 +49,77 @@ func NewGoDutchTrackerValidator() *GoDutchTrackerValidator {
 // IsValidCreateTracker ...
 func (validator *GoDutchTrackerValidator) IsValidCreateTracker(tracker model.Tracker, logger infrastructure.Logger, adminUser model.User) (bool, error) {
 
+	var validationErrors domainerror.Errors
+
 	if len(tracker.Name) <= 0 {
-		logger.Error("Error: ", ErrorInvalidName)
-		return false, ErrorInvalidName
+		validationErrors = append(validationErrors, ErrorInvalidName)
 	}
+
 	if tracker.AdminUserID <= 0 {
-		logger.Error("Error: ", ErrorInvalidAdminUserID)
-		return false, ErrorInvalidAdminUserID
+		validationErrors = append(validationErrors, ErrorInvalidAdminUserID)
 	}
 
 	if len(tracker.TrackerUserIDs) <= 0 {
-		logger.Error("Error: ", ErrorNoTrackerUsers)
-		return false, ErrorNoTrackerUsers
-	}
-
-	if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, tracker.AdminUserID) {
-		logger.Error("Error: ", ErrorAdminUserNotInTrackerUsersList)
-		return false, ErrorAdminUserNotInTrackerUsersList
+		validationErrors = append(validationErrors, ErrorNoTrackerUsers)
+	} else if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, tracker.AdminUserID) {
+		validationErrors = append(validationErrors, ErrorAdminUserNotInTrackerUsersList)
 	}
 
 	if tracker.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
 	}
 
-	if tracker.AdminUserID != adminUser.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+	if tracker.AdminUserID > 0 && tracker.AdminUserID != adminUser.ID {
+		logger.Debug("Rejected invalid tracker", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid tracker", "error", err)
+		return false, err
+	}
+
 	return true, nil
 }
 
 // IsValidUpdateTracker ...
 func (validator *GoDutchTrackerValidator) IsValidUpdateTracker(tracker model.Tracker, logger infrastructure.Logger, adminUser model.User, existingTracker model.Tracker) (bool, error) {
 
+	var validationErrors domainerror.Errors
+
 	if len(tracker.Name) <= 0 {
-		logger.Error("Error: ", ErrorInvalidName)
-		return false, ErrorInvalidName
+		validationErrors = append(validationErrors, ErrorInvalidName)
 	}
+
 	if tracker.AdminUserID <= 0 {
-		logger.Error("Error: ", ErrorInvalidAdminUserID)
-		return false, ErrorInvalidAdminUserID
+		validationErrors = append(validationErrors, ErrorInvalidAdminUserID)
 	}
 
 	if len(tracker.TrackerUserIDs) <= 0 {
-		logger.Error("Error: ", ErrorNoTrackerUsers)
-		return false, ErrorNoTrackerUsers
-	}
-
-	if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, tracker.AdminUserID) {
-		logger.Error("Error: ", ErrorAdminUserNotInTrackerUsersList)
-		return false, ErrorAdminUserNotInTrackerUsersList
+		validationErrors = append(validationErrors, ErrorNoTrackerUsers)
+	} else if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, tracker.AdminUserID) {
+		validationErrors = append(validationErrors, ErrorAdminUserNotInTrackerUsersList)
 	}
 
 	if tracker.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
 	}
 
-	if tracker.AdminUserID != adminUser.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+	if tracker.AdminUserID > 0 && tracker.AdminUserID != adminUser.ID {
+		logger.Debug("Rejected invalid tracker", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
 	if tracker.ID != existingTracker.ID {
-		logger.Error("Error: ", ErrorTheTrackerDoesNotExist)
+		logger.Debug("Rejected invalid tracker", "error", ErrorTheTrackerDoesNotExist)
 		return false, ErrorTheTrackerDoesNotExist
 	}
 
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid tracker", "error", err)
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -126,12 +127,12 @@ func (validator *GoDutchTrackerValidator) IsValidUpdateTracker(tracker model.Tra
 func (validator *GoDutchTrackerValidator) IsValidDeleteTracker(id int64, logger infrastructure.Logger, adminUser model.User, existingTracker model.Tracker) (bool, error) {
 
 	if existingTracker.AdminUserID != adminUser.ID {
-		logger.Error("Error: ", ErrorAdminUserIDIsDifferentToSubjectID)
+		logger.Debug("Rejected invalid tracker", "error", ErrorAdminUserIDIsDifferentToSubjectID)
 		return false, ErrorAdminUserIDIsDifferentToSubjectID
 	}
 
 	if id != existingTracker.ID {
-		logger.Error("Error: ", ErrorTheTrackerDoesNotExist)
+		logger.Debug("Rejected invalid tracker", "error", ErrorTheTrackerDoesNotExist)
 		return false, ErrorTheTrackerDoesNotExist
 	}
 
@@ -1,6 +1,7 @@
 package trackervalidation_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -201,7 +202,7 @@ func whenIValidateTheDeleteTracker() {
 }
 
 func thenTheCreateTrackerCommandIsRejectedWithError(e error, t *testing.T) {
-	if err != e {
+	if !errors.Is(err, e) {
 		t.Fatalf("Error should be %v but was %v", e, err)
 	}
 }
@@ -1,36 +1,37 @@
 package uservalidation
 
 import (
-	"errors"
+	"context"
 	"strings"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
 )
 
 // ErrorInvalidName ...
-var ErrorInvalidName = errors.New("Invalid name")
+var ErrorInvalidName = domainerror.NewValidation("invalid_name", "user.name", "Invalid name")
 
 // ErrorInvalidEmail ...
-var ErrorInvalidEmail = errors.New("Invalid email")
+var ErrorInvalidEmail = domainerror.NewValidation("invalid_email", "user.emailAddress", "Invalid email")
 
 // ErrorInvalidAuthenticationID ...
-var ErrorInvalidAuthenticationID = errors.New("Invalid authentication ID")
+var ErrorInvalidAuthenticationID = domainerror.NewValidation("invalid_authentication_id", "user.authenticationID", "Invalid authentication ID")
 
 // ErrorInvalidDateCreated ...
-var ErrorInvalidDateCreated = errors.New("Invalid date created")
+var ErrorInvalidDateCreated = domainerror.NewValidation("invalid_date_created", "user.dateCreated", "Invalid date created")
 
 // ErrorEmailAlreadyInUse ...
-var ErrorEmailAlreadyInUse = errors.New("Email already in use.")
+var ErrorEmailAlreadyInUse = domainerror.NewConflict("email_already_in_use", "user.emailAddress", "Email already in use.")
 
 // ErrorAuthIDAlreadyInUse ...
-var ErrorAuthIDAlreadyInUse = errors.New("AuthID already in use.")
+var ErrorAuthIDAlreadyInUse = domainerror.NewConflict("authentication_id_already_in_use", "user.authenticationID", "AuthID already in use.")
 
 // UserValidator ...
 type UserValidator interface {
-	IsValidCreateUser(user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error)
-	IsValidInviteUser(user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error)
+	IsValidCreateUser(ctx context.Context, user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error)
+	IsValidInviteUser(ctx context.Context, user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error)
 }
 
 // GoDutchUserValidator ...
@@ -46,70 +47,72 @@ func NewGoDutchUserValidator() *GoDutchUserValidator {
 }
 
 // IsValidInviteUser ...
-func (validator *GoDutchUserValidator) IsValidInviteUser(user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error) {
+func (validator *GoDutchUserValidator) IsValidInviteUser(ctx context.Context, user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error) {
 
-	if len(user.EmailAddress) <= 0 {
-		logger.Error("Error: ", ErrorInvalidEmail)
-		return false, ErrorInvalidEmail
-	}
+	var validationErrors domainerror.Errors
 
-	if !strings.Contains(user.EmailAddress, "@") {
-		logger.Error("Error: ", ErrorInvalidEmail)
-		return false, ErrorInvalidEmail
+	if len(user.EmailAddress) <= 0 || !strings.Contains(user.EmailAddress, "@") {
+		validationErrors = append(validationErrors, ErrorInvalidEmail.WithField("emailAddress"))
 	}
 
 	if user.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
+	}
+
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid user", "error", err)
+		return false, err
 	}
 
-	userAlreadyExisits, _ := userRepo.GetByEmail(user.EmailAddress)
+	userAlreadyExisits, _ := userRepo.GetByEmail(ctx, user.EmailAddress)
 	if userAlreadyExisits.ID > 0 {
-		logger.Error("Error: ", ErrorEmailAlreadyInUse)
-		return false, ErrorEmailAlreadyInUse
+		err := ErrorEmailAlreadyInUse.WithField("emailAddress")
+		logger.Debug("Rejected invalid user", "error", err)
+		return false, err
 	}
 
 	return true, nil
 }
 
-// IsValidCreateUser ...
-func (validator *GoDutchUserValidator) IsValidCreateUser(user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error) {
+// IsValidCreateUser checks every field before looking for existing users, so one request reports all its problems
+func (validator *GoDutchUserValidator) IsValidCreateUser(ctx context.Context, user model.User, logger infrastructure.Logger, userRepo userrepository.UserRepository) (bool, error) {
 
-	if len(user.Name) <= 0 {
-		logger.Error("Error: ", ErrorInvalidName)
-		return false, ErrorInvalidName
-	}
+	var validationErrors domainerror.Errors
 
-	if len(user.EmailAddress) <= 0 {
-		logger.Error("Error: ", ErrorInvalidEmail)
-		return false, ErrorInvalidEmail
+	if len(user.Name) <= 0 {
+		validationErrors = append(validationErrors, ErrorInvalidName)
 	}
 
-	if !strings.Contains(user.EmailAddress, "@") {
-		logger.Error("Error: ", ErrorInvalidEmail)
-		return false, ErrorInvalidEmail
+	if len(user.EmailAddress) <= 0 || !strings.Contains(user.EmailAddress, "@") {
+		validationErrors = append(validationErrors, ErrorInvalidEmail)
 	}
 
 	if len(user.AuthenticationID) <= 0 {
-		logger.Error("Error: ", ErrorInvalidAuthenticationID)
-		return false, ErrorInvalidAuthenticationID
+		validationErrors = append(validationErrors, ErrorInvalidAuthenticationID)
 	}
 
 	if user.DateCreated.IsZero() {
-		logger.Error("Error: ", ErrorInvalidDateCreated)
-		return false, ErrorInvalidDateCreated
+		validationErrors = append(validationErrors, ErrorInvalidDateCreated)
+	}
+
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid user", "error", err)
+		return false, err
 	}
 
-	userAlreadyExisits, _ := userRepo.GetByEmail(user.EmailAddress)
+	userAlreadyExisits, _ := userRepo.GetByEmail(ctx, user.EmailAddress)
 	if userAlreadyExisits.ID > 0 {
-		logger.Error("Error: ", ErrorEmailAlreadyInUse)
-		return false, ErrorEmailAlreadyInUse
+		validationErrors = append(validationErrors, ErrorEmailAlreadyInUse)
 	}
 
-	userAlreadyExisits, _ = userRepo.GetBySub(user.AuthenticationID)
+	userAlreadyExisits, _ = userRepo.GetBySub(ctx, user.AuthenticationID)
 	if userAlreadyExisits.ID > 0 {
-		logger.Error("Error: ", ErrorAuthIDAlreadyInUse)
-		return false, ErrorAuthIDAlreadyInUse
+		validationErrors = append(validationErrors, ErrorAuthIDAlreadyInUse)
+	}
+
+	if err := validationErrors.Err(); err != nil {
+		logger.Debug("Rejected invalid user", "error", err)
+		return false, err
 	}
 
 	return true, nil
@@ -1,6 +1,8 @@
 package uservalidation_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -171,6 +173,19 @@ func TestValidateCreateUserNoUserWithAuthIdAlreadyExists(t *testing.T) {
 	thenTheCreateUserCommandIsRejectedWithError(uservalidation.ErrorAuthIDAlreadyInUse, t)
 }
 
+func TestValidateCreateUserReportsEveryProblem(t *testing.T) {
+
+	user := model.User{
+		EmailAddress: "email"}
+
+	givenIHaveACreateUserCommand(user)
+	whenIValidateTheCreateUserCommand()
+	thenTheCreateUserCommandIsRejectedWithError(uservalidation.ErrorInvalidName, t)
+	thenTheCreateUserCommandIsRejectedWithError(uservalidation.ErrorInvalidEmail, t)
+	thenTheCreateUserCommandIsRejectedWithError(uservalidation.ErrorInvalidAuthenticationID, t)
+	thenTheCreateUserCommandIsRejectedWithError(uservalidation.ErrorInvalidDateCreated, t)
+}
+
 func TestValidateCreateUser(t *testing.T) {
 
 	user := model.User{
@@ -186,21 +201,21 @@ func TestValidateCreateUser(t *testing.T) {
 
 func givenThereIsAUserInTheRepository(newUser model.User, t *testing.T) {
 	userRepository = userrepository.NewInMemoryUserRepository()
-	_, err := userRepository.Insert(newUser)
+	_, err := userRepository.Insert(context.Background(), newUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 func whenIValidateTheCreateUserCommand() {
-	result, err = userValidator.IsValidCreateUser(newUser, logger, userRepository)
+	result, err = userValidator.IsValidCreateUser(context.Background(), newUser, logger, userRepository)
 }
 
 func whenIValidateTheInviteUserCommand() {
-	result, err = userValidator.IsValidInviteUser(newUser, logger, userRepository)
+	result, err = userValidator.IsValidInviteUser(context.Background(), newUser, logger, userRepository)
 }
 
 func thenTheCreateUserCommandIsRejectedWithError(e error, t *testing.T) {
-	if err != e {
+	if !errors.Is(err, e) {
 		t.Fatalf("Error should be %v but was %v", e, err)
 	}
 }
@@ -0,0 +1,271 @@
+package webhookservice
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/token"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookdeliveryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookrepository"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/TomPallister/godutch-api/api/webhooks"
+)
+
+// ErrorInvalidURL ...
+var ErrorInvalidURL = domainerror.NewValidation("invalid_url", "webhook.url", "Invalid url, must be an absolute http or https url")
+
+// ErrorPrivateURL ...
+var ErrorPrivateURL = domainerror.NewValidation("private_url", "webhook.url", "Invalid url, must not be localhost or a private, loopback or link-local address")
+
+// ErrorInvalidEventTypes ...
+var ErrorInvalidEventTypes = domainerror.NewValidation("invalid_event_types", "webhook.eventTypes", "Invalid event types, must be one or more of spend.created, spend.updated, spend.deleted, tracker.updated, tracker.deleted or transfers.updated")
+
+// ErrorInvalidSecret ...
+var ErrorInvalidSecret = domainerror.NewValidation("invalid_secret", "webhook.secret", "Invalid secret, must be at least 16 characters or left out to have one generated")
+
+// ErrorPermissionsToManageWebhooks ...
+var ErrorPermissionsToManageWebhooks = domainerror.NewForbidden("not_tracker_admin", "Only the tracker admin can manage its webhooks")
+
+// minimumSecretLength ...
+const minimumSecretLength = 16
+
+// deliveryLogLength is how many of a webhook's most recent deliveries are returned
+const deliveryLogLength = 50
+
+// Sender makes a single delivery attempt straight away
+type Sender interface {
+	Send(ctx context.Context, webhook model.Webhook, event model.Event) (model.WebhookDelivery, error)
+}
+
+// WebhookService ...
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, sub string, webhook model.Webhook) (model.Webhook, error)
+
+	FindByTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.Webhook, error)
+
+	DeleteWebhook(ctx context.Context, sub string, id int64) (bool, error)
+
+	FindDeliveries(ctx context.Context, sub string, id int64) ([]model.WebhookDelivery, error)
+
+	SendTestEvent(ctx context.Context, sub string, id int64) (model.WebhookDelivery, error)
+}
+
+// GoDutchWebhookService ...
+type GoDutchWebhookService struct {
+	webhookRepository         webhookrepository.WebhookRepository
+	webhookDeliveryRepository webhookdeliveryrepository.WebhookDeliveryRepository
+	trackerRepository         trackerrepository.TrackerRepository
+	userRepository            userrepository.UserRepository
+	sender                    Sender
+	logger                    infrastructure.Logger
+	allowPrivateAddresses     bool
+}
+
+// NewGoDutchWebhookService ...
+func NewGoDutchWebhookService(webhookRepository webhookrepository.WebhookRepository,
+	webhookDeliveryRepository webhookdeliveryrepository.WebhookDeliveryRepository,
+	trackerRepository trackerrepository.TrackerRepository,
+	userRepository userrepository.UserRepository,
+	sender Sender,
+	logger infrastructure.Logger,
+	allowPrivateAddresses bool) *GoDutchWebhookService {
+
+	service := GoDutchWebhookService{}
+	service.webhookRepository = webhookRepository
+	service.webhookDeliveryRepository = webhookDeliveryRepository
+	service.trackerRepository = trackerRepository
+	service.userRepository = userRepository
+	service.sender = sender
+	service.logger = logger
+	service.allowPrivateAddresses = allowPrivateAddresses
+	return &service
+}
+
+// CreateWebhook returns the saved webhook with its secret, which is not returned again
+func (goDutchWebhookService *GoDutchWebhookService) CreateWebhook(ctx context.Context, sub string, webhook model.Webhook) (model.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+
+	user, err := goDutchWebhookService.checkTrackerAdmin(ctx, sub, webhook.TrackerID)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	var validationErrors domainerror.Errors
+
+	if !isValidURL(webhook.URL) {
+		validationErrors = append(validationErrors, ErrorInvalidURL)
+	} else if !goDutchWebhookService.allowPrivateAddresses && isPrivateURL(webhook.URL) {
+		validationErrors = append(validationErrors, ErrorPrivateURL)
+	}
+
+	if len(webhook.EventTypes) <= 0 {
+		validationErrors = append(validationErrors, ErrorInvalidEventTypes)
+	} else {
+		for _, e := range webhook.EventTypes {
+			if !infrastructure.StringsContains(events.Types, e) {
+				validationErrors = append(validationErrors, ErrorInvalidEventTypes)
+				break
+			}
+		}
+	}
+
+	if webhook.Secret != "" && len(webhook.Secret) < minimumSecretLength {
+		validationErrors = append(validationErrors, ErrorInvalidSecret)
+	}
+
+	if err := validationErrors.Err(); err != nil {
+		return model.Webhook{}, err
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = token.New()
+		if err != nil {
+			return model.Webhook{}, err
+		}
+	}
+
+	webhook.ID = 0
+	webhook.CreatedByUserID = user.ID
+	webhook.DateCreated = time.Now()
+	webhook.DateDeleted = nil
+
+	return goDutchWebhookService.webhookRepository.Insert(ctx, webhook)
+}
+
+// FindByTrackerID ...
+func (goDutchWebhookService *GoDutchWebhookService) FindByTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.FindByTrackerID")
+	defer span.End()
+
+	_, err := goDutchWebhookService.checkTrackerAdmin(ctx, sub, trackerID)
+	if err != nil {
+		return []model.Webhook{}, err
+	}
+
+	webhooksForTracker, err := goDutchWebhookService.webhookRepository.GetForTrackerID(ctx, trackerID)
+	if err != nil {
+		return []model.Webhook{}, err
+	}
+
+	for i := range webhooksForTracker {
+		webhooksForTracker[i].Secret = ""
+	}
+
+	return webhooksForTracker, nil
+}
+
+// DeleteWebhook ...
+func (goDutchWebhookService *GoDutchWebhookService) DeleteWebhook(ctx context.Context, sub string, id int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	webhook, err := goDutchWebhookService.findWebhook(ctx, sub, id)
+	if err != nil {
+		return false, err
+	}
+
+	if webhook.DateDeleted != nil {
+		return false, webhookrepository.ErrorNotFound
+	}
+
+	return goDutchWebhookService.webhookRepository.Delete(ctx, id, time.Now())
+}
+
+// FindDeliveries returns the most recent deliveries first, they are kept after the webhook is deleted
+func (goDutchWebhookService *GoDutchWebhookService) FindDeliveries(ctx context.Context, sub string, id int64) ([]model.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.FindDeliveries")
+	defer span.End()
+
+	_, err := goDutchWebhookService.findWebhook(ctx, sub, id)
+	if err != nil {
+		return []model.WebhookDelivery{}, err
+	}
+
+	return goDutchWebhookService.webhookDeliveryRepository.GetForWebhookID(ctx, id, deliveryLogLength)
+}
+
+// SendTestEvent sends a webhook.test event once and returns how the delivery went
+func (goDutchWebhookService *GoDutchWebhookService) SendTestEvent(ctx context.Context, sub string, id int64) (model.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.SendTestEvent")
+	defer span.End()
+
+	webhook, err := goDutchWebhookService.findWebhook(ctx, sub, id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	if webhook.DateDeleted != nil {
+		return model.WebhookDelivery{}, webhookrepository.ErrorNotFound
+	}
+
+	event, err := events.NewEvent(webhooks.TestEvent, webhook.TrackerID, map[string]interface{}{"webhookId": webhook.ID})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	return goDutchWebhookService.sender.Send(ctx, webhook, event)
+}
+
+func (goDutchWebhookService *GoDutchWebhookService) findWebhook(ctx context.Context, sub string, id int64) (model.Webhook, error) {
+
+	webhook, err := goDutchWebhookService.webhookRepository.GetByID(ctx, id)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	_, err = goDutchWebhookService.checkTrackerAdmin(ctx, sub, webhook.TrackerID)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (goDutchWebhookService *GoDutchWebhookService) checkTrackerAdmin(ctx context.Context, sub string, trackerID int64) (model.User, error) {
+
+	user, err := goDutchWebhookService.userRepository.GetBySub(ctx, sub)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	tracker, err := goDutchWebhookService.trackerRepository.GetByID(ctx, trackerID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if tracker.AdminUserID != user.ID {
+		return model.User{}, ErrorPermissionsToManageWebhooks
+	}
+
+	return user, nil
+}
+
+func isValidURL(rawURL string) bool {
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// isPrivateURL catches URLs that name a private host outright, names that resolve to one are
+// refused by the dispatcher when it connects
+func isPrivateURL(rawURL string) bool {
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return webhooks.IsPrivateHost(parsed.Hostname())
+}
@@ -0,0 +1,174 @@
+package webhookservice_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/webhookservice"
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookdeliveryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookrepository"
+	"github.com/TomPallister/godutch-api/api/webhooks"
+)
+
+// fakeTrackerRepository only finds the trackers it was given
+type fakeTrackerRepository struct {
+	trackerrepository.TrackerRepository
+	trackers map[int64]model.Tracker
+}
+
+func (repository fakeTrackerRepository) GetByID(ctx context.Context, id int64) (model.Tracker, error) {
+	tracker, ok := repository.trackers[id]
+	if ok == false {
+		return model.Tracker{}, trackerrepository.ErrorNotFound
+	}
+	return tracker, nil
+}
+
+// fakeSender records what it was asked to send
+type fakeSender struct {
+	sent []model.Event
+}
+
+func (sender *fakeSender) Send(ctx context.Context, webhook model.Webhook, event model.Event) (model.WebhookDelivery, error) {
+	sender.sent = append(sender.sent, event)
+	return model.WebhookDelivery{WebhookID: webhook.ID, EventType: event.Type, Status: model.WebhookDeliverySucceeded}, nil
+}
+
+var admin model.User
+var member model.User
+var savedWebhook model.Webhook
+var webhooksForTracker []model.Webhook
+var delivery model.WebhookDelivery
+var err error
+var sender *fakeSender
+var webhookRepository *webhookrepository.InMemoryWebhookRepository
+var webhookService *webhookservice.GoDutchWebhookService
+
+func TestAdminCanCreateWebhook(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "https://example.com/hook", "a-secret-that-is-long-enough", events.SpendCreated)
+	thenThereIsNoError(t)
+	thenTheSecretIs("a-secret-that-is-long-enough", t)
+	if savedWebhook.ID <= 0 || savedWebhook.CreatedByUserID != admin.ID {
+		t.Errorf("Expected a saved webhook created by the admin got %+v", savedWebhook)
+	}
+}
+
+func TestSecretIsGeneratedWhenLeftOut(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "https://example.com/hook", "", events.SpendCreated)
+	thenThereIsNoError(t)
+	if len(savedWebhook.Secret) < 16 {
+		t.Errorf("Expected a generated secret got %v", savedWebhook.Secret)
+	}
+}
+
+func TestSecretIsNotReturnedAfterCreate(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "https://example.com/hook", "", events.SpendCreated)
+	webhooksForTracker, err = webhookService.FindByTrackerID(context.Background(), "admin", 1)
+	thenThereIsNoError(t)
+	if len(webhooksForTracker) != 1 || webhooksForTracker[0].Secret != "" {
+		t.Errorf("Expected one webhook without its secret got %+v", webhooksForTracker)
+	}
+}
+
+func TestInvalidWebhookReportsEveryProblem(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "ftp://example.com", "short", "spend.exploded")
+	for _, expected := range []error{webhookservice.ErrorInvalidURL, webhookservice.ErrorInvalidEventTypes, webhookservice.ErrorInvalidSecret} {
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected %v in %v", expected, err)
+		}
+	}
+}
+
+func TestWebhooksCannotPointInsideTheNetwork(t *testing.T) {
+	givenIHaveATracker()
+	for _, url := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "https://10.0.0.8/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook"} {
+		whenICreateAWebhook("admin", url, "", events.SpendCreated)
+		thenTheErrorIs(webhookservice.ErrorPrivateURL, t)
+	}
+}
+
+func TestOnlyTheAdminCanManageWebhooks(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("member", "https://example.com/hook", "", events.SpendCreated)
+	thenTheErrorIs(webhookservice.ErrorPermissionsToManageWebhooks, t)
+	whenICreateAWebhook("admin", "https://example.com/hook", "", events.SpendCreated)
+	_, err = webhookService.DeleteWebhook(context.Background(), "member", savedWebhook.ID)
+	thenTheErrorIs(webhookservice.ErrorPermissionsToManageWebhooks, t)
+	_, err = webhookService.FindDeliveries(context.Background(), "member", savedWebhook.ID)
+	thenTheErrorIs(webhookservice.ErrorPermissionsToManageWebhooks, t)
+}
+
+func TestDeletedWebhooksAreNotListedOrTested(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "https://example.com/hook", "", events.SpendCreated)
+	_, err = webhookService.DeleteWebhook(context.Background(), "admin", savedWebhook.ID)
+	thenThereIsNoError(t)
+	webhooksForTracker, err = webhookService.FindByTrackerID(context.Background(), "admin", 1)
+	if len(webhooksForTracker) != 0 {
+		t.Errorf("Expected no webhooks got %v", len(webhooksForTracker))
+	}
+	_, err = webhookService.SendTestEvent(context.Background(), "admin", savedWebhook.ID)
+	thenTheErrorIs(webhookrepository.ErrorNotFound, t)
+}
+
+func TestCanSendTestEvent(t *testing.T) {
+	givenIHaveATracker()
+	whenICreateAWebhook("admin", "https://example.com/hook", "", events.SpendCreated)
+	delivery, err = webhookService.SendTestEvent(context.Background(), "admin", savedWebhook.ID)
+	thenThereIsNoError(t)
+	if len(sender.sent) != 1 || sender.sent[0].Type != webhooks.TestEvent || sender.sent[0].TrackerID != 1 {
+		t.Errorf("Expected one test event for tracker 1 got %+v", sender.sent)
+	}
+}
+
+func givenIHaveATracker() {
+	userRepository := userrepository.NewInMemoryUserRepository()
+	admin, _ = userRepository.Insert(context.Background(), model.User{AuthenticationID: "admin", DateCreated: time.Now()})
+	member, _ = userRepository.Insert(context.Background(), model.User{AuthenticationID: "member", DateCreated: time.Now()})
+	trackerRepository := fakeTrackerRepository{trackers: map[int64]model.Tracker{
+		1: {ID: 1, AdminUserID: admin.ID, TrackerUserIDs: []int64{admin.ID, member.ID}},
+	}}
+	sender = &fakeSender{}
+	webhookRepository = webhookrepository.NewInMemoryWebhookRepository()
+	webhookService = webhookservice.NewGoDutchWebhookService(webhookRepository,
+		webhookdeliveryrepository.NewInMemoryWebhookDeliveryRepository(), trackerRepository, userRepository,
+		sender, infrastructure.NilLogger{}, false)
+}
+
+func whenICreateAWebhook(sub string, url string, secret string, eventType string) {
+	savedWebhook, err = webhookService.CreateWebhook(context.Background(), sub, model.Webhook{
+		TrackerID:  1,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: []string{eventType},
+	})
+}
+
+func thenThereIsNoError(t *testing.T) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("expected %v but got %v", expected, err)
+	}
+}
+
+func thenTheSecretIs(expected string, t *testing.T) {
+	if savedWebhook.Secret != expected {
+		t.Errorf("Expected secret %v got %v", expected, savedWebhook.Secret)
+	}
+}
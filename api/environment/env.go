@@ -1,12 +1,23 @@
 package environment
 
 import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/apitokenservice"
+	"github.com/TomPallister/godutch-api/api/domain/localauthservice"
 	"github.com/TomPallister/godutch-api/api/domain/spendservice"
 	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
 	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
 	"github.com/TomPallister/godutch-api/api/domain/transferservice"
 	"github.com/TomPallister/godutch-api/api/domain/userservice"
+	"github.com/TomPallister/godutch-api/api/domain/webhookservice"
+	"github.com/TomPallister/godutch-api/api/events"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/repository/idempotencyrepository"
+	"github.com/rs/cors"
 )
 
 // Env ...
@@ -14,8 +25,36 @@ type Env struct {
 	Logger              infrastructure.Logger
 	UserService         userservice.UserService
 	SubjectFinder       infrastructure.SubjectFinder
+	Authenticator       infrastructure.Authenticator
 	TrackerService      trackerservice.TrackerService
 	SpendService        spendservice.SpendService
 	TransferService     transferservice.TransferService
 	SpendSummaryService spendsummaryservice.SpendSummaryService
+	APITokenService     apitokenservice.APITokenService
+	LocalAuthService    localauthservice.LocalAuthService
+	WebhookService      webhookservice.WebhookService
+
+	// RateLimiter limits requests per subject and the invite and auth routes, nil turns it off
+	RateLimiter *ratelimit.Limiter
+
+	IdempotencyRepository idempotencyrepository.IdempotencyRepository
+	IdempotencyRetention  time.Duration
+
+	// GoDutchURL is the root of the web app, links in emails point at it
+	GoDutchURL string
+
+	// RequestTimeout bounds how long a REST request or gRPC call may run
+	RequestTimeout time.Duration
+
+	EventSubscriber events.Subscriber
+
+	// CORS is the api's CORS handler, WebSocket upgrades from other origins are held to the origins it allows
+	CORS *cors.Cors
+
+	// ReadinessChecks are run by /readyz, keyed by the name reported for each
+	ReadinessChecks map[string]func(ctx context.Context) error
+
+	// ACMEChallengeHandler answers /.well-known/acme-challenge/ when certificates come from
+	// autocert, the files in ./.well-known/acme-challenge/ are served when it is nil
+	ACMEChallengeHandler http.Handler
 }
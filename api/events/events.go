@@ -0,0 +1,119 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/nu7hatch/gouuid"
+)
+
+// SpendCreated ...
+const SpendCreated = "spend.created"
+
+// SpendUpdated ...
+const SpendUpdated = "spend.updated"
+
+// SpendDeleted ...
+const SpendDeleted = "spend.deleted"
+
+// TrackerUpdated ...
+const TrackerUpdated = "tracker.updated"
+
+// TrackerDeleted is the last event a tracker's subscribers get
+const TrackerDeleted = "tracker.deleted"
+
+// TransfersUpdated carries every transfer for the tracker after they have been worked out again
+const TransfersUpdated = "transfers.updated"
+
+// DefaultBufferSize is how many events a subscriber can fall behind by before events are dropped
+const DefaultBufferSize = 64
+
+// Publisher ...
+type Publisher interface {
+	Publish(event model.Event) error
+}
+
+// Subscriber ...
+type Subscriber interface {
+	Subscribe(trackerID int64) *Subscription
+}
+
+// Broker ...
+type Broker interface {
+	Publisher
+	Subscriber
+}
+
+// Subscription receives the events for one tracker until it is closed
+type Subscription struct {
+	Events <-chan model.Event
+	close  func()
+}
+
+// Close stops the subscription and closes Events, it is safe to call more than once
+func (subscription *Subscription) Close() {
+	subscription.close()
+}
+
+// NewEvent ...
+func NewEvent(eventType string, trackerID int64, data interface{}) (model.Event, error) {
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	event := model.Event{
+		ID:          id.String(),
+		Type:        eventType,
+		TrackerID:   trackerID,
+		Data:        body,
+		DateCreated: time.Now(),
+	}
+
+	return event, nil
+}
+
+// Publish builds an event and hands it to publisher
+func Publish(publisher Publisher, eventType string, trackerID int64, data interface{}) error {
+
+	event, err := NewEvent(eventType, trackerID, data)
+	if err != nil {
+		return err
+	}
+
+	return publisher.Publish(event)
+}
+
+// NilPublisher ...
+type NilPublisher struct {
+}
+
+// Publish ...
+func (publisher NilPublisher) Publish(event model.Event) error {
+	return nil
+}
+
+// Types are the event types a tracker's subscribers can receive
+var Types = []string{SpendCreated, SpendUpdated, SpendDeleted, TrackerUpdated, TrackerDeleted, TransfersUpdated}
+
+// MultiPublisher hands each event to every publisher, one failing does not stop the rest
+type MultiPublisher []Publisher
+
+// Publish ...
+func (publishers MultiPublisher) Publish(event model.Event) error {
+	var errs []error
+	for _, publisher := range publishers {
+		if err := publisher.Publish(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
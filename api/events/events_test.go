@@ -0,0 +1,93 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+var broker *events.InProcessBroker
+var subscription *events.Subscription
+var err error
+
+func TestSubscribersGetTheirTrackersEvents(t *testing.T) {
+	givenIHaveABroker(2)
+	givenISubscribeToTracker(1)
+	whenIPublish(events.SpendCreated, 1, model.Spend{ID: 5, TrackerID: 1}, t)
+	whenIPublish(events.SpendCreated, 2, model.Spend{ID: 6, TrackerID: 2}, t)
+	thenTheNextEventIs(events.SpendCreated, 1, t)
+	thenThereAreNoMoreEvents(t)
+}
+
+func TestEventsCarryTheirData(t *testing.T) {
+	givenIHaveABroker(2)
+	givenISubscribeToTracker(1)
+	whenIPublish(events.SpendUpdated, 1, model.Spend{ID: 5, TrackerID: 1, Name: "Dinner"}, t)
+	event := thenTheNextEventIs(events.SpendUpdated, 1, t)
+	var spend model.Spend
+	if err := json.Unmarshal(event.Data, &spend); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if spend.Name != "Dinner" || event.ID == "" {
+		t.Fatalf("Unexpected event %v", event)
+	}
+}
+
+func TestSlowSubscribersMissEventsInsteadOfBlocking(t *testing.T) {
+	givenIHaveABroker(1)
+	givenISubscribeToTracker(1)
+	whenIPublish(events.SpendCreated, 1, model.Spend{ID: 5}, t)
+	whenIPublish(events.SpendDeleted, 1, model.Spend{ID: 5}, t)
+	thenTheNextEventIs(events.SpendCreated, 1, t)
+	thenThereAreNoMoreEvents(t)
+}
+
+func TestClosedSubscriptionsGetNothing(t *testing.T) {
+	givenIHaveABroker(2)
+	givenISubscribeToTracker(1)
+	subscription.Close()
+	subscription.Close()
+	whenIPublish(events.SpendCreated, 1, model.Spend{ID: 5}, t)
+	if _, open := <-subscription.Events; open {
+		t.Fatal("Expected the subscription's events to be closed")
+	}
+}
+
+func givenIHaveABroker(bufferSize int) {
+	broker = events.NewInProcessBroker(infrastructure.NilLogger{}, bufferSize)
+}
+
+func givenISubscribeToTracker(trackerID int64) {
+	subscription = broker.Subscribe(trackerID)
+}
+
+func whenIPublish(eventType string, trackerID int64, data interface{}, t *testing.T) {
+	err = events.Publish(broker, eventType, trackerID, data)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func thenTheNextEventIs(eventType string, trackerID int64, t *testing.T) model.Event {
+	select {
+	case event := <-subscription.Events:
+		if event.Type != eventType || event.TrackerID != trackerID {
+			t.Fatalf("Expected: %v for %v, Received: %v for %v", eventType, trackerID, event.Type, event.TrackerID)
+		}
+		return event
+	default:
+		t.Fatal("Expected an event")
+	}
+	return model.Event{}
+}
+
+func thenThereAreNoMoreEvents(t *testing.T) {
+	select {
+	case event := <-subscription.Events:
+		t.Fatalf("Expected no more events, Received: %v", event.Type)
+	default:
+	}
+}
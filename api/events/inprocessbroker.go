@@ -0,0 +1,71 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InProcessBroker delivers events to subscribers in this process
+type InProcessBroker struct {
+	logger        infrastructure.Logger
+	bufferSize    int
+	mutex         sync.RWMutex
+	subscriptions map[int64]map[*Subscription]chan model.Event
+}
+
+// NewInProcessBroker ...
+func NewInProcessBroker(logger infrastructure.Logger, bufferSize int) *InProcessBroker {
+	broker := InProcessBroker{}
+	broker.logger = logger
+	broker.bufferSize = bufferSize
+	broker.subscriptions = map[int64]map[*Subscription]chan model.Event{}
+	return &broker
+}
+
+// Publish never blocks, a subscriber whose buffer is full misses the event
+func (broker *InProcessBroker) Publish(event model.Event) error {
+
+	broker.mutex.RLock()
+	defer broker.mutex.RUnlock()
+
+	for _, events := range broker.subscriptions[event.TrackerID] {
+		select {
+		case events <- event:
+		default:
+			broker.logger.Warn("Dropped event for a slow subscriber", "event_type", event.Type, "event_id", event.ID, "tracker_id", event.TrackerID)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe ...
+func (broker *InProcessBroker) Subscribe(trackerID int64) *Subscription {
+
+	events := make(chan model.Event, broker.bufferSize)
+	subscription := &Subscription{Events: events}
+
+	var once sync.Once
+	subscription.close = func() {
+		once.Do(func() {
+			broker.mutex.Lock()
+			defer broker.mutex.Unlock()
+			delete(broker.subscriptions[trackerID], subscription)
+			if len(broker.subscriptions[trackerID]) == 0 {
+				delete(broker.subscriptions, trackerID)
+			}
+			close(events)
+		})
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if broker.subscriptions[trackerID] == nil {
+		broker.subscriptions[trackerID] = map[*Subscription]chan model.Event{}
+	}
+	broker.subscriptions[trackerID][subscription] = events
+
+	return subscription
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/lib/pq"
+)
+
+// NotifyChannel is the Postgres channel events are sent on
+const NotifyChannel = "godutch_events"
+
+// maxPayloadSize is the largest payload Postgres accepts for NOTIFY
+const maxPayloadSize = 7999
+
+// storedEventLifetime is how long an event too large for NOTIFY is kept for listeners to read
+const storedEventLifetime = time.Minute
+
+// notification is what is sent with NOTIFY, the event itself or, when it is too large, the id of
+// the row in "Events" that holds it
+type notification struct {
+	model.Event
+	StoredID string `json:"storedId,omitempty"`
+}
+
+// PostgresBroker sends events through Postgres LISTEN/NOTIFY so subscribers on every api instance get them
+type PostgresBroker struct {
+	logger   infrastructure.Logger
+	db       *sql.DB
+	listener *pq.Listener
+	local    *InProcessBroker
+}
+
+// NewPostgresBroker listens on NotifyChannel with its own connection and passes what it hears to local subscribers
+func NewPostgresBroker(logger infrastructure.Logger,
+	db *sql.DB,
+	connectionString string,
+	bufferSize int) (*PostgresBroker, error) {
+
+	broker := PostgresBroker{}
+	broker.logger = logger
+	broker.db = db
+	broker.local = NewInProcessBroker(logger, bufferSize)
+	broker.listener = pq.NewListener(connectionString, time.Second, time.Minute, func(listenerEvent pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error("The event listener lost its connection", err)
+		}
+	})
+
+	if err := broker.listener.Listen(NotifyChannel); err != nil {
+		broker.listener.Close()
+		return nil, err
+	}
+
+	go broker.forward()
+
+	return &broker, nil
+}
+
+// Publish sends the event to every instance, including this one. Events too large for NOTIFY
+// are stored in "Events" and only their id is sent.
+func (broker *PostgresBroker) Publish(event model.Event) error {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) <= maxPayloadSize {
+		_, err = broker.db.Exec("SELECT pg_notify($1, $2)", NotifyChannel, string(payload))
+		return err
+	}
+
+	reference, err := json.Marshal(notification{StoredID: event.ID})
+	if err != nil {
+		return err
+	}
+
+	// listeners are notified when the transaction commits, by which time the row can be read
+	tx, err := broker.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec("DELETE FROM \"Events\" WHERE \"DateCreated\" < $1", now.Add(-storedEventLifetime)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO \"Events\"(\"ID\", \"Payload\", \"DateCreated\") VALUES ($1, $2, $3)", event.ID, string(payload), now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("SELECT pg_notify($1, $2)", NotifyChannel, string(reference)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Subscribe ...
+func (broker *PostgresBroker) Subscribe(trackerID int64) *Subscription {
+	return broker.local.Subscribe(trackerID)
+}
+
+// Close stops listening
+func (broker *PostgresBroker) Close() error {
+	return broker.listener.Close()
+}
+
+func (broker *PostgresBroker) forward() {
+	for notification := range broker.listener.Notify {
+		// nil is sent after the listener reconnects, anything sent while it was down is lost
+		if notification == nil {
+			continue
+		}
+
+		event, err := broker.read(notification.Extra)
+		if err != nil {
+			broker.logger.Error("Could not read event", err, "channel", NotifyChannel)
+			continue
+		}
+
+		broker.local.Publish(event)
+	}
+}
+
+// read returns the event a NOTIFY payload carries, or the one it refers to in "Events"
+func (broker *PostgresBroker) read(payload string) (model.Event, error) {
+
+	var received notification
+	if err := json.Unmarshal([]byte(payload), &received); err != nil {
+		return model.Event{}, err
+	}
+	if received.StoredID == "" {
+		return received.Event, nil
+	}
+
+	var stored string
+	err := broker.db.QueryRow("SELECT \"Payload\" FROM \"Events\" WHERE \"ID\" = $1", received.StoredID).Scan(&stored)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	var event model.Event
+	err = json.Unmarshal([]byte(stored), &event)
+	return event, err
+}
@@ -0,0 +1,236 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/spendservice"
+	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
+	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
+	"github.com/TomPallister/godutch-api/api/domain/transferservice"
+	"github.com/TomPallister/godutch-api/api/domain/userservice"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/graph"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/shopspring/decimal"
+)
+
+var users = map[string]model.User{
+	"tom":   {ID: 1, Name: "Tom", EmailAddress: "tom@godutch.money"},
+	"laura": {ID: 2, Name: "Laura", EmailAddress: "laura@godutch.money"},
+	"other": {ID: 3, Name: "Other", EmailAddress: "other@godutch.money"},
+}
+
+var trackers = map[int64]model.Tracker{
+	1: {ID: 1, Name: "Tom and Laura", AdminUserID: 1, TrackerUserIDs: []int64{1, 2}, Currency: "GBP", Version: 3},
+	2: {ID: 2, Name: "Tom alone", AdminUserID: 1, TrackerUserIDs: []int64{1}, Currency: "GBP", Version: 1},
+}
+
+var usersLookups int32
+var spendsLookups int32
+
+type fakeUserService struct {
+	userservice.UserService
+}
+
+func (fake fakeUserService) FindBySub(ctx context.Context, sub string) (model.User, error) {
+	return users[sub], nil
+}
+
+type fakeTrackerService struct {
+	trackerservice.TrackerService
+}
+
+func (fake fakeTrackerService) FindByUser(ctx context.Context, sub string) ([]model.Tracker, error) {
+	found := []model.Tracker{}
+	for _, tracker := range trackers {
+		if infrastructure.Ints64Contains(tracker.TrackerUserIDs, users[sub].ID) {
+			found = append(found, tracker)
+		}
+	}
+	return found, nil
+}
+
+func (fake fakeTrackerService) FindByID(ctx context.Context, sub string, id int64) (model.Tracker, error) {
+	return trackers[id], nil
+}
+
+func (fake fakeTrackerService) FindUsersForTracker(ctx context.Context, sub string, id int64) ([]model.User, error) {
+	atomic.AddInt32(&usersLookups, 1)
+	return []model.User{users["tom"], users["laura"]}, nil
+}
+
+type fakeSpendService struct {
+	spendservice.SpendService
+}
+
+func (fake fakeSpendService) FindByTrackerID(ctx context.Context, sub string, id int64) ([]model.Spend, error) {
+	atomic.AddInt32(&spendsLookups, 1)
+	spends := []model.Spend{}
+	for i := int64(1); i <= 20; i++ {
+		spends = append(spends, model.Spend{ID: i, TrackerID: id, UserID: 1 + i%2, Name: "Lunch", Value: decimal.RequireFromString("10.10"), Currency: "GBP", DateCreated: time.Now()})
+	}
+	return spends, nil
+}
+
+func (fake fakeSpendService) FindByID(ctx context.Context, sub string, id int64) (model.Spend, error) {
+	if sub != "tom" && sub != "laura" {
+		return model.Spend{}, spendservice.ErrorUserDoesNotBelongToTracker
+	}
+	if id == 99 {
+		return model.Spend{}, errors.New("pq: relation \"Spends\" does not exist")
+	}
+	return model.Spend{ID: id, TrackerID: 1, UserID: 1, Value: decimal.RequireFromString("10.10"), Currency: "GBP"}, nil
+}
+
+type fakeTransferService struct {
+	transferservice.TransferService
+}
+
+func (fake fakeTransferService) FindTransfersForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.Transfer, error) {
+	return []model.Transfer{{ID: 1, TrackerID: trackerID, FromUserID: 2, ToUserID: 1, Value: decimal.RequireFromString("5.05"), Currency: "GBP"}}, nil
+}
+
+type fakeSpendSummaryService struct {
+	spendsummaryservice.SpendSummaryService
+}
+
+func (fake fakeSpendSummaryService) FindSpendSummariesForTrackerID(ctx context.Context, sub string, trackerID int64) ([]model.SpendSummary, error) {
+	return []model.SpendSummary{{ID: 1, TrackerID: trackerID, UserID: 1, Value: decimal.RequireFromString("10.10"), Currency: "GBP"}}, nil
+}
+
+var env = &environment.Env{
+	Logger:              infrastructure.NilLogger{},
+	UserService:         fakeUserService{},
+	TrackerService:      fakeTrackerService{},
+	SpendService:        fakeSpendService{},
+	TransferService:     fakeTransferService{},
+	SpendSummaryService: fakeSpendSummaryService{},
+}
+
+var schema = graph.MustNewSchema(env)
+var response *graphql.Response
+
+func TestCanQueryATrackerPageInOneRequest(t *testing.T) {
+	whenIQuery("tom", `{
+		tracker(id: "1") {
+			name version
+			admin { name }
+			members { name }
+			spends { value user { name } tracker { name } }
+			transfers { value from { name } to { name } }
+			spendSummaries { value user { emailAddress } }
+		}
+	}`)
+	thenThereAreNoErrors(t)
+	data := thenTheDataIs(t)
+	tracker := data["tracker"].(map[string]interface{})
+	if tracker["name"] != "Tom and Laura" || tracker["version"].(float64) != 3 {
+		t.Errorf("Unexpected tracker %v", tracker)
+	}
+	if tracker["admin"].(map[string]interface{})["name"] != "Tom" {
+		t.Errorf("Expected Tom to be the admin got %v", tracker["admin"])
+	}
+	spends := tracker["spends"].([]interface{})
+	if len(spends) != 20 || spends[0].(map[string]interface{})["value"] != "10.1" {
+		t.Errorf("Unexpected spends %v", spends)
+	}
+	transfer := tracker["transfers"].([]interface{})[0].(map[string]interface{})
+	if transfer["from"].(map[string]interface{})["name"] != "Laura" {
+		t.Errorf("Unexpected transfer %v", transfer)
+	}
+}
+
+func TestMembersAreLoadedOncePerTracker(t *testing.T) {
+	whenIQueryCountingLookups("tom", `{
+		trackers { members { name } spends { user { name } tracker { spends { id } } } }
+	}`)
+	thenThereAreNoErrors(t)
+	// the loaders memoise per tracker rather than batch, so each of the two trackers costs one call
+	thenTheLookupsAre(2, 2, t)
+}
+
+func TestTheSameTrackerIsOnlyLoadedOnce(t *testing.T) {
+	whenIQueryCountingLookups("tom", `{
+		a: tracker(id: "1") { members { name } spends { user { name } } }
+		b: tracker(id: "1") { members { name } spends { user { name } } }
+	}`)
+	thenThereAreNoErrors(t)
+	thenTheLookupsAre(1, 1, t)
+}
+
+func TestCannotQueryATrackerYouDoNotBelongTo(t *testing.T) {
+	whenIQuery("other", `{ tracker(id: "1") { name } }`)
+	thenTheErrorCodeIs("not_a_tracker_user", 403, t)
+}
+
+func TestServiceErrorsKeepTheirCode(t *testing.T) {
+	whenIQuery("other", `{ spend(id: "1") { name } }`)
+	thenTheErrorCodeIs("not_a_tracker_user", 403, t)
+}
+
+func TestOtherErrorsAreInternalAndDoNotRepeatTheirText(t *testing.T) {
+	whenIQuery("tom", `{ spend(id: "99") { name } }`)
+	thenTheErrorCodeIs("internal_error", 500, t)
+	if strings.Contains(response.Errors[0].Message, "Spends") {
+		t.Errorf("Expected a generic message got %v", response.Errors[0].Message)
+	}
+}
+
+func TestDeepQueriesAreRefused(t *testing.T) {
+	whenIQuery("tom", `{ tracker(id: "1") { spends { tracker { spends { tracker { spends { tracker { spends { tracker { name } } } } } } } } } }`)
+	if len(response.Errors) == 0 {
+		t.Errorf("Expected the query to be refused")
+	}
+}
+
+func whenIQuery(sub string, query string) {
+	ctx := graph.WithLoaders(context.Background(), env, sub)
+	response = schema.Exec(ctx, query, "", nil)
+}
+
+func whenIQueryCountingLookups(sub string, query string) {
+	atomic.StoreInt32(&usersLookups, 0)
+	atomic.StoreInt32(&spendsLookups, 0)
+	whenIQuery(sub, query)
+}
+
+func thenTheLookupsAre(members int32, spends int32, t *testing.T) {
+	if lookups := atomic.LoadInt32(&usersLookups); lookups != members {
+		t.Errorf("Expected the members to be looked up %v times got %v", members, lookups)
+	}
+	if lookups := atomic.LoadInt32(&spendsLookups); lookups != spends {
+		t.Errorf("Expected the spends to be looked up %v times got %v", spends, lookups)
+	}
+}
+
+func thenThereAreNoErrors(t *testing.T) {
+	if len(response.Errors) > 0 {
+		t.Fatalf("Expected no errors got %v", response.Errors)
+	}
+}
+
+func thenTheDataIs(t *testing.T) map[string]interface{} {
+	var data map[string]interface{}
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func thenTheErrorCodeIs(code string, status int, t *testing.T) {
+	if len(response.Errors) != 1 {
+		t.Fatalf("Expected one error got %v", response.Errors)
+	}
+	extensions := response.Errors[0].Extensions
+	if extensions["code"] != code || extensions["status"] != status {
+		t.Errorf("Expected %v %v got %v", code, status, extensions)
+	}
+}
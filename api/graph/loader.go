@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+type contextKey int
+
+const loadersContextKey contextKey = 0
+
+// loaders remember the service calls made while resolving one request. They do not batch: each
+// tracker still costs one call for its members, one for its spends and so on, but every field
+// that needs them shares that call, so a page of spends each asking for its user costs one
+// lookup rather than one per spend. A query over n trackers makes up to n calls of each kind.
+type loaders struct {
+	sub            string
+	me             *loader
+	trackers       *loader
+	members        *loader
+	spends         *loader
+	transfers      *loader
+	spendSummaries *loader
+}
+
+// WithLoaders returns ctx ready for a query made by sub
+func WithLoaders(ctx context.Context, env *environment.Env, sub string) context.Context {
+
+	l := &loaders{sub: sub}
+
+	l.me = newLoader(func(int64) (interface{}, error) {
+		return env.UserService.FindBySub(ctx, sub)
+	})
+
+	l.trackers = newLoader(func(id int64) (interface{}, error) {
+		return env.TrackerService.FindByID(ctx, sub, id)
+	})
+
+	l.members = newLoader(func(trackerID int64) (interface{}, error) {
+		users, err := env.TrackerService.FindUsersForTracker(ctx, sub, trackerID)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[int64]model.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+		return byID, nil
+	})
+
+	l.spends = newLoader(func(trackerID int64) (interface{}, error) {
+		return env.SpendService.FindByTrackerID(ctx, sub, trackerID)
+	})
+
+	l.transfers = newLoader(func(trackerID int64) (interface{}, error) {
+		return env.TransferService.FindTransfersForTrackerID(ctx, sub, trackerID)
+	})
+
+	l.spendSummaries = newLoader(func(trackerID int64) (interface{}, error) {
+		return env.SpendSummaryService.FindSpendSummariesForTrackerID(ctx, sub, trackerID)
+	})
+
+	return context.WithValue(ctx, loadersContextKey, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersContextKey).(*loaders)
+}
+
+// loader memoises fetch per key for the request, callers asking for a key being fetched wait for
+// it. Keys are fetched one at a time as they are asked for, never collected into one call.
+type loader struct {
+	fetch   func(key int64) (interface{}, error)
+	mutex   sync.Mutex
+	results map[int64]*loaderResult
+}
+
+type loaderResult struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLoader(fetch func(key int64) (interface{}, error)) *loader {
+	l := loader{}
+	l.fetch = fetch
+	l.results = map[int64]*loaderResult{}
+	return &l
+}
+
+func (l *loader) load(key int64) (interface{}, error) {
+
+	l.mutex.Lock()
+	result, ok := l.results[key]
+	if !ok {
+		result = &loaderResult{done: make(chan struct{})}
+		l.results[key] = result
+	}
+	l.mutex.Unlock()
+
+	if ok {
+		<-result.done
+		return result.value, result.err
+	}
+
+	result.value, result.err = l.fetch(key)
+	close(result.done)
+	return result.value, result.err
+}
+
+// prime saves a value fetched some other way, such as the trackers returned by a list
+func (l *loader) prime(key int64, value interface{}) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.results[key]; ok {
+		return
+	}
+	result := &loaderResult{done: make(chan struct{}), value: value}
+	close(result.done)
+	l.results[key] = result
+}
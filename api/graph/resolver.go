@@ -0,0 +1,348 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// ErrorUserDoesNotBelongToTracker ...
+var ErrorUserDoesNotBelongToTracker = domainerror.NewForbidden("not_a_tracker_user", "User does not belong to tracker")
+
+// ErrorInvalidID ...
+var ErrorInvalidID = domainerror.NewBadRequest("invalid_id", "The id is not a number")
+
+// queryError carries a domain error's code and status in the GraphQL error's extensions. Errors
+// that are not domain errors are reported as internal, without their text.
+type queryError struct {
+	err error
+}
+
+func (e queryError) Error() string {
+	return e.domainError().Message
+}
+
+// Unwrap lets the handler find and log the error the resolver returned
+func (e queryError) Unwrap() error {
+	return e.err
+}
+
+// Extensions ...
+func (e queryError) Extensions() map[string]interface{} {
+	domainError := e.domainError()
+	return map[string]interface{}{
+		"code":   domainError.Code,
+		"status": domainError.Status,
+	}
+}
+
+func (e queryError) domainError() *domainerror.Error {
+	if domainError := domainerror.From(e.err); domainError != nil {
+		return domainError
+	}
+	return domainerror.ErrorInternal
+}
+
+func wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return queryError{err: err}
+}
+
+func parseID(id graphql.ID) (int64, error) {
+	parsed, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, wrap(ErrorInvalidID)
+	}
+	return parsed, nil
+}
+
+func toID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+type queryResolver struct {
+	env *environment.Env
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*userResolver, error) {
+	me, err := findMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{user: me}, nil
+}
+
+func (r *queryResolver) Trackers(ctx context.Context) ([]*trackerResolver, error) {
+	l := loadersFrom(ctx)
+
+	trackers, err := r.env.TrackerService.FindByUser(ctx, l.sub)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	resolvers := make([]*trackerResolver, len(trackers))
+	for i, tracker := range trackers {
+		l.trackers.prime(tracker.ID, tracker)
+		resolvers[i] = &trackerResolver{tracker: tracker}
+	}
+	return resolvers, nil
+}
+
+func (r *queryResolver) Tracker(ctx context.Context, args struct{ ID graphql.ID }) (*trackerResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker, err := findTrackerForMember(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &trackerResolver{tracker: tracker}, nil
+}
+
+func (r *queryResolver) Spend(ctx context.Context, args struct{ ID graphql.ID }) (*spendResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	spend, err := r.env.SpendService.FindByID(ctx, loadersFrom(ctx).sub, id)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	return &spendResolver{spend: spend}, nil
+}
+
+func findMe(ctx context.Context) (model.User, error) {
+	me, err := loadersFrom(ctx).me.load(0)
+	if err != nil {
+		return model.User{}, wrap(err)
+	}
+	return me.(model.User), nil
+}
+
+// findTrackerForMember only returns trackers the caller belongs to
+func findTrackerForMember(ctx context.Context, id int64) (model.Tracker, error) {
+	me, err := findMe(ctx)
+	if err != nil {
+		return model.Tracker{}, err
+	}
+
+	value, err := loadersFrom(ctx).trackers.load(id)
+	if err != nil {
+		return model.Tracker{}, wrap(err)
+	}
+
+	tracker := value.(model.Tracker)
+	if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, me.ID) {
+		return model.Tracker{}, wrap(ErrorUserDoesNotBelongToTracker)
+	}
+	return tracker, nil
+}
+
+// findMember returns nil for users who are no longer in the tracker
+func findMember(ctx context.Context, trackerID int64, userID int64) (*userResolver, error) {
+	members, err := loadersFrom(ctx).members.load(trackerID)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	user, ok := members.(map[int64]model.User)[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &userResolver{user: user}, nil
+}
+
+type userResolver struct {
+	user model.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return toID(r.user.ID)
+}
+
+func (r *userResolver) Name() string {
+	return r.user.Name
+}
+
+func (r *userResolver) EmailAddress() string {
+	return r.user.EmailAddress
+}
+
+func (r *userResolver) DateCreated() graphql.Time {
+	return graphql.Time{Time: r.user.DateCreated}
+}
+
+type trackerResolver struct {
+	tracker model.Tracker
+}
+
+func (r *trackerResolver) ID() graphql.ID {
+	return toID(r.tracker.ID)
+}
+
+func (r *trackerResolver) Name() string {
+	return r.tracker.Name
+}
+
+func (r *trackerResolver) Currency() string {
+	return r.tracker.Currency
+}
+
+func (r *trackerResolver) DateCreated() graphql.Time {
+	return graphql.Time{Time: r.tracker.DateCreated}
+}
+
+func (r *trackerResolver) Version() int32 {
+	return int32(r.tracker.Version)
+}
+
+func (r *trackerResolver) Admin(ctx context.Context) (*userResolver, error) {
+	return findMember(ctx, r.tracker.ID, r.tracker.AdminUserID)
+}
+
+func (r *trackerResolver) Members(ctx context.Context) ([]*userResolver, error) {
+	resolvers := []*userResolver{}
+	for _, id := range r.tracker.TrackerUserIDs {
+		member, err := findMember(ctx, r.tracker.ID, id)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			resolvers = append(resolvers, member)
+		}
+	}
+	return resolvers, nil
+}
+
+func (r *trackerResolver) Spends(ctx context.Context) ([]*spendResolver, error) {
+	spends, err := loadersFrom(ctx).spends.load(r.tracker.ID)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	resolvers := []*spendResolver{}
+	for _, spend := range spends.([]model.Spend) {
+		resolvers = append(resolvers, &spendResolver{spend: spend})
+	}
+	return resolvers, nil
+}
+
+func (r *trackerResolver) Transfers(ctx context.Context) ([]*transferResolver, error) {
+	transfers, err := loadersFrom(ctx).transfers.load(r.tracker.ID)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	resolvers := []*transferResolver{}
+	for _, transfer := range transfers.([]model.Transfer) {
+		resolvers = append(resolvers, &transferResolver{transfer: transfer})
+	}
+	return resolvers, nil
+}
+
+func (r *trackerResolver) SpendSummaries(ctx context.Context) ([]*spendSummaryResolver, error) {
+	spendSummaries, err := loadersFrom(ctx).spendSummaries.load(r.tracker.ID)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	resolvers := []*spendSummaryResolver{}
+	for _, spendSummary := range spendSummaries.([]model.SpendSummary) {
+		resolvers = append(resolvers, &spendSummaryResolver{spendSummary: spendSummary})
+	}
+	return resolvers, nil
+}
+
+type spendResolver struct {
+	spend model.Spend
+}
+
+func (r *spendResolver) ID() graphql.ID {
+	return toID(r.spend.ID)
+}
+
+func (r *spendResolver) Name() string {
+	return r.spend.Name
+}
+
+func (r *spendResolver) Value() string {
+	return r.spend.Value.String()
+}
+
+func (r *spendResolver) Currency() string {
+	return r.spend.Currency
+}
+
+func (r *spendResolver) DateCreated() graphql.Time {
+	return graphql.Time{Time: r.spend.DateCreated}
+}
+
+func (r *spendResolver) Version() int32 {
+	return int32(r.spend.Version)
+}
+
+func (r *spendResolver) Tracker(ctx context.Context) (*trackerResolver, error) {
+	tracker, err := findTrackerForMember(ctx, r.spend.TrackerID)
+	if err != nil {
+		return nil, err
+	}
+	return &trackerResolver{tracker: tracker}, nil
+}
+
+func (r *spendResolver) User(ctx context.Context) (*userResolver, error) {
+	return findMember(ctx, r.spend.TrackerID, r.spend.UserID)
+}
+
+type transferResolver struct {
+	transfer model.Transfer
+}
+
+func (r *transferResolver) ID() graphql.ID {
+	return toID(r.transfer.ID)
+}
+
+func (r *transferResolver) Value() string {
+	return r.transfer.Value.String()
+}
+
+func (r *transferResolver) Currency() string {
+	return r.transfer.Currency
+}
+
+func (r *transferResolver) From(ctx context.Context) (*userResolver, error) {
+	return findMember(ctx, r.transfer.TrackerID, r.transfer.FromUserID)
+}
+
+func (r *transferResolver) To(ctx context.Context) (*userResolver, error) {
+	return findMember(ctx, r.transfer.TrackerID, r.transfer.ToUserID)
+}
+
+type spendSummaryResolver struct {
+	spendSummary model.SpendSummary
+}
+
+func (r *spendSummaryResolver) ID() graphql.ID {
+	return toID(r.spendSummary.ID)
+}
+
+func (r *spendSummaryResolver) Value() string {
+	return r.spendSummary.Value.String()
+}
+
+func (r *spendSummaryResolver) Currency() string {
+	return r.spendSummary.Currency
+}
+
+func (r *spendSummaryResolver) User(ctx context.Context) (*userResolver, error) {
+	return findMember(ctx, r.spendSummary.TrackerID, r.spendSummary.UserID)
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"github.com/TomPallister/godutch-api/api/environment"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// Schema is the GraphQL schema served at /api/v1/graphql. It is read only, changes still go through the REST routes.
+const Schema = `
+schema {
+  query: Query
+}
+
+scalar Time
+
+type Query {
+  # The caller
+  me: User!
+  # Every tracker the caller belongs to
+  trackers: [Tracker!]!
+  tracker(id: ID!): Tracker!
+  spend(id: ID!): Spend!
+}
+
+type User {
+  id: ID!
+  name: String!
+  emailAddress: String!
+  dateCreated: Time!
+}
+
+type Tracker {
+  id: ID!
+  name: String!
+  currency: String!
+  dateCreated: Time!
+  version: Int!
+  admin: User
+  members: [User!]!
+  spends: [Spend!]!
+  transfers: [Transfer!]!
+  spendSummaries: [SpendSummary!]!
+}
+
+type Spend {
+  id: ID!
+  name: String!
+  # A decimal amount, as a string to keep its precision
+  value: String!
+  currency: String!
+  dateCreated: Time!
+  version: Int!
+  tracker: Tracker!
+  # Null if the user has since left the tracker
+  user: User
+}
+
+type Transfer {
+  id: ID!
+  value: String!
+  currency: String!
+  from: User
+  to: User
+}
+
+type SpendSummary {
+  id: ID!
+  value: String!
+  currency: String!
+  user: User
+}
+`
+
+// MaxDepth stops a query nesting tracker { spends { tracker { spends ... } } } without end
+const MaxDepth = 8
+
+// NewSchema ...
+func NewSchema(env *environment.Env) (*graphql.Schema, error) {
+	return graphql.ParseSchema(Schema, &queryResolver{env: env},
+		graphql.MaxDepth(MaxDepth))
+}
+
+// MustNewSchema panics if Schema and the resolvers do not match, which is a programming error
+func MustNewSchema(env *environment.Env) *graphql.Schema {
+	schema, err := NewSchema(env)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
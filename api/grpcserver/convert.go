@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/proto/godutchpb"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrorInvalidValue ...
+var ErrorInvalidValue = domainerror.NewValidation("invalid_value", "value", "Invalid value, must be a decimal such as 10.50")
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toUser(user model.User) *godutchpb.User {
+	return &godutchpb.User{
+		Id:               user.ID,
+		Name:             user.Name,
+		AuthenticationId: user.AuthenticationID,
+		EmailAddress:     user.EmailAddress,
+		DateCreated:      timestamp(user.DateCreated),
+	}
+}
+
+func fromUser(user *godutchpb.User) model.User {
+	return model.User{
+		ID:               user.GetId(),
+		Name:             user.GetName(),
+		AuthenticationID: user.GetAuthenticationId(),
+		EmailAddress:     user.GetEmailAddress(),
+		DateCreated:      fromTimestamp(user.GetDateCreated()),
+	}
+}
+
+func toUsers(users []model.User) *godutchpb.Users {
+	converted := &godutchpb.Users{Users: make([]*godutchpb.User, len(users))}
+	for i, user := range users {
+		converted.Users[i] = toUser(user)
+	}
+	return converted
+}
+
+func toTracker(tracker model.Tracker) *godutchpb.Tracker {
+	return &godutchpb.Tracker{
+		Id:             tracker.ID,
+		AdminUserId:    tracker.AdminUserID,
+		TrackerUserIds: tracker.TrackerUserIDs,
+		Name:           tracker.Name,
+		DateCreated:    timestamp(tracker.DateCreated),
+		Currency:       tracker.Currency,
+		Version:        tracker.Version,
+	}
+}
+
+func fromTracker(tracker *godutchpb.Tracker) model.Tracker {
+	return model.Tracker{
+		ID:             tracker.GetId(),
+		AdminUserID:    tracker.GetAdminUserId(),
+		TrackerUserIDs: tracker.GetTrackerUserIds(),
+		Name:           tracker.GetName(),
+		DateCreated:    fromTimestamp(tracker.GetDateCreated()),
+		Currency:       tracker.GetCurrency(),
+		Version:        tracker.GetVersion(),
+	}
+}
+
+func toSpend(spend model.Spend) *godutchpb.Spend {
+	return &godutchpb.Spend{
+		Id:          spend.ID,
+		Value:       spend.Value.String(),
+		TrackerId:   spend.TrackerID,
+		Name:        spend.Name,
+		UserId:      spend.UserID,
+		Currency:    spend.Currency,
+		DateCreated: timestamp(spend.DateCreated),
+		Version:     spend.Version,
+	}
+}
+
+func fromSpend(spend *godutchpb.Spend) (model.Spend, error) {
+	value, err := decimal.NewFromString(spend.GetValue())
+	if err != nil {
+		return model.Spend{}, ErrorInvalidValue
+	}
+
+	return model.Spend{
+		ID:          spend.GetId(),
+		Value:       value,
+		TrackerID:   spend.GetTrackerId(),
+		Name:        spend.GetName(),
+		UserID:      spend.GetUserId(),
+		Currency:    spend.GetCurrency(),
+		DateCreated: fromTimestamp(spend.GetDateCreated()),
+		Version:     spend.GetVersion(),
+	}, nil
+}
+
+func toTransfer(transfer model.Transfer) *godutchpb.Transfer {
+	return &godutchpb.Transfer{
+		Id:         transfer.ID,
+		TrackerId:  transfer.TrackerID,
+		FromUserId: transfer.FromUserID,
+		ToUserId:   transfer.ToUserID,
+		Value:      transfer.Value.String(),
+		Currency:   transfer.Currency,
+	}
+}
+
+func toSpendSummary(spendSummary model.SpendSummary) *godutchpb.SpendSummary {
+	return &godutchpb.SpendSummary{
+		Id:        spendSummary.ID,
+		TrackerId: spendSummary.TrackerID,
+		UserId:    spendSummary.UserID,
+		Value:     spendSummary.Value.String(),
+		Currency:  spendSummary.Currency,
+	}
+}
+
+func toInvite(invite model.Invite) *godutchpb.Invite {
+	return &godutchpb.Invite{
+		Id:              invite.ID,
+		TrackerId:       invite.TrackerID,
+		UserId:          invite.UserID,
+		InvitedByUserId: invite.InvitedByUserID,
+		EmailAddress:    invite.EmailAddress,
+		DateCreated:     timestamp(invite.DateCreated),
+		DateExpires:     timestamp(invite.DateExpires),
+		DateAccepted:    optionalTimestamp(invite.DateAccepted),
+		DateRevoked:     optionalTimestamp(invite.DateRevoked),
+	}
+}
@@ -0,0 +1,335 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/proto/godutchpb"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/requestid"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail on every error, its reason is the domain error's code
+const ErrorDomain = "godutch.money"
+
+type contextKey int
+
+const subjectContextKey contextKey = 0
+
+// NewServer returns a gRPC server for the services in env, every call is authenticated like a REST request
+func NewServer(env *environment.Env, options ...grpc.ServerOption) *grpc.Server {
+
+	options = append(options, grpc.ChainUnaryInterceptor(TracingInterceptor(), TimeoutInterceptor(env), RequestIDInterceptor(env), AuthenticationInterceptor(env),
+		RateLimitInterceptor(env)))
+	server := grpc.NewServer(options...)
+
+	godutchpb.RegisterTrackerServiceServer(server, &trackerServer{env: env})
+	godutchpb.RegisterSpendServiceServer(server, &spendServer{env: env})
+	godutchpb.RegisterTransferServiceServer(server, &transferServer{env: env})
+	godutchpb.RegisterSpendSummaryServiceServer(server, &spendSummaryServer{env: env})
+	godutchpb.RegisterUserServiceServer(server, &userServer{env: env})
+
+	return server
+}
+
+// TracingInterceptor starts a server span for each call, continuing the caller's trace when it
+// sends traceparent metadata
+func TracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		}
+		ctx, span := otel.Tracer(tracing.TracerName).Start(ctx, info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("rpc.system", "grpc")))
+		defer span.End()
+
+		res, err := handler(ctx, req)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+		if status.Code(err) == codes.Internal || status.Code(err) == codes.Unknown {
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+
+		return res, err
+	}
+}
+
+// metadataCarrier lets the propagator read incoming metadata
+type metadataCarrier metadata.MD
+
+func (carrier metadataCarrier) Get(key string) string {
+	values := metadata.MD(carrier).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (carrier metadataCarrier) Set(key string, value string) {
+	metadata.MD(carrier).Set(key, value)
+}
+
+func (carrier metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TimeoutInterceptor gives each call env's RequestTimeout, or keeps the caller's deadline if it is sooner
+func TimeoutInterceptor(env *environment.Env) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if env.RequestTimeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, env.RequestTimeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+// RequestIDInterceptor is the gRPC twin of requestid.Middleware, the id is read from and returned
+// in the x-request-id metadata
+func RequestIDInterceptor(env *environment.Env) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(requestid.Header); len(ids) > 0 {
+				id = ids[0]
+			}
+		}
+		id = requestid.Parse(id)
+		grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+
+		logger := env.Logger.With("request_id", id)
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx = infrastructure.WithLogger(requestid.NewContext(ctx, id), logger)
+
+		start := time.Now()
+		res, err := handler(ctx, req)
+
+		var internal internalError
+		if errors.As(err, &internal) {
+			logger.Error("There was an error", internal.err, "method", info.FullMethod)
+		}
+
+		logger.Info("Handled call",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration_ms", time.Since(start).Milliseconds())
+
+		return res, err
+	}
+}
+
+// AuthenticationInterceptor checks the authorization metadata with env's Authenticator and
+// SubjectFinder, so JWTs and API tokens work as they do over REST. API tokens need the read
+// scope for Get and List calls and the write scope for everything else.
+func AuthenticationInterceptor(env *environment.Env) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		r, err := newAuthenticationRequest(ctx, info.FullMethod)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		r, err = env.Authenticator.Authenticate(r)
+		if err != nil {
+			infrastructure.LoggerFrom(ctx, env.Logger).Warn("The call was refused", "error", err)
+			return nil, unauthenticated(err)
+		}
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			infrastructure.LoggerFrom(ctx, env.Logger).Warn("The call was refused", "error", err)
+			return nil, unauthenticated(err)
+		}
+
+		return handler(context.WithValue(ctx, subjectContextKey, subject), req)
+	}
+}
+
+// inviteMethods send invite emails, so they are limited by the invite limit as well as the subject's
+var inviteMethods = map[string]bool{godutchpb.UserService_InviteUser_FullMethodName: true}
+
+// RateLimitInterceptor limits each subject's calls like REST requests, it must run after
+// AuthenticationInterceptor. Refused calls are ResourceExhausted with retry-after metadata.
+func RateLimitInterceptor(env *environment.Env) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		limits := env.RateLimiter.Options()
+		buckets := []string{"subject"}
+		if inviteMethods[info.FullMethod] {
+			buckets = append(buckets, "invite")
+		}
+
+		for _, bucket := range buckets {
+			limit := limits.Subject
+			if bucket == "invite" {
+				limit = limits.Invite
+			}
+			if limit.Enabled() == false {
+				continue
+			}
+			decision, err := env.RateLimiter.Take(ctx, bucket, "subject:"+subjectFrom(ctx), limit)
+			if err != nil {
+				infrastructure.LoggerFrom(ctx, env.Logger).Error("Could not check the rate limit", err, "bucket", bucket)
+				continue
+			}
+			if decision.Allowed == false {
+				grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(ratelimit.RetryAfterHeader), strconv.Itoa(int(decision.RetryAfter.Seconds()))))
+				infrastructure.LoggerFrom(ctx, env.Logger).Info("Rate limited a call", "bucket", bucket)
+				return nil, toStatus(ratelimit.ErrorRateLimited)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// restPaths are the REST paths of the calls whose scope is not the write scope, so an API token
+// gets the same scope check over gRPC as over REST
+var restPaths = map[string]string{
+	godutchpb.SpendService_CreateSpend_FullMethodName: "/api/v1/spends",
+	godutchpb.SpendService_UpdateSpend_FullMethodName: "/api/v1/spends",
+	godutchpb.SpendService_DeleteSpend_FullMethodName: "/api/v1/spends",
+}
+
+// newAuthenticationRequest is the http request the authenticators expect, with the call's
+// authorization metadata as its header and a method that says whether the call only reads
+func newAuthenticationRequest(ctx context.Context, fullMethod string) (*http.Request, error) {
+
+	method := "POST"
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List") {
+		method = "GET"
+	}
+
+	path := fullMethod
+	if restPath, ok := restPaths[fullMethod]; ok {
+		path = restPath
+	}
+
+	r, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	r = r.WithContext(ctx)
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, authorization := range md.Get("authorization") {
+			r.Header.Add("Authorization", authorization)
+		}
+	}
+
+	return r, nil
+}
+
+func subjectFrom(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectContextKey).(string)
+	return subject
+}
+
+// toStatus maps a domain error's http status to the nearest gRPC code, anything else is internal
+func toStatus(err error) error {
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+
+	domainError := domainerror.From(err)
+	if domainError == nil {
+		return internalError{err: err}
+	}
+
+	var code codes.Code
+	switch domainError.Status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	default:
+		code = codes.Internal
+	}
+
+	return withErrorInfo(status.New(code, domainError.Message), domainError)
+}
+
+// unauthenticated keeps the status of domain errors, such as an API token without the scope needed
+func unauthenticated(err error) error {
+
+	if domainerror.From(err) == nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return toStatus(err)
+}
+
+// internalError is an error the api cannot explain. Callers get a generic Internal status and
+// RequestIDInterceptor logs the error itself.
+type internalError struct {
+	err error
+}
+
+func (e internalError) Error() string {
+	return e.err.Error()
+}
+
+func (e internalError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus ...
+func (e internalError) GRPCStatus() *status.Status {
+	s, _ := status.FromError(withErrorInfo(status.New(codes.Internal, domainerror.ErrorInternal.Message), domainerror.ErrorInternal))
+	return s
+}
+
+func withErrorInfo(s *status.Status, domainError *domainerror.Error) error {
+
+	info := &errdetails.ErrorInfo{Reason: domainError.Code, Domain: ErrorDomain}
+	if domainError.Field != "" {
+		info.Metadata = map[string]string{"field": domainError.Field}
+	}
+
+	withDetails, err := s.WithDetails(info)
+	if err != nil {
+		return s.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,218 @@
+package grpcserver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/domain/spendservice"
+	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/grpcserver"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/apitokenauth"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/proto/godutchpb"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/repository/ratelimitrepository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeChecker struct {
+}
+
+// CheckAPIToken accepts gdt_read with the read scope, gdt_write with the read and write scopes and
+// gdt_spends with the read and spends:write scopes
+func (checker *fakeChecker) CheckAPIToken(ctx context.Context, apiToken string) (model.APIToken, model.User, error) {
+	user := model.User{ID: 1, AuthenticationID: "auth0|tom"}
+	switch apiToken {
+	case "gdt_read":
+		return model.APIToken{ID: 1, UserID: 1, Scopes: []string{model.APITokenScopeRead}}, user, nil
+	case "gdt_write":
+		return model.APIToken{ID: 2, UserID: 1, Scopes: []string{model.APITokenScopeRead, model.APITokenScopeWrite}}, user, nil
+	case "gdt_spends":
+		return model.APIToken{ID: 3, UserID: 1, Scopes: []string{model.APITokenScopeRead, model.APITokenScopeSpendsWrite}}, user, nil
+	}
+	return model.APIToken{}, model.User{}, errors.New("invalid")
+}
+
+type fakeJwtAuthenticator struct {
+}
+
+func (fake *fakeJwtAuthenticator) Authenticate(r *http.Request) (*http.Request, error) {
+	return r, errors.New("no jwt")
+}
+
+type fakeJwtSubjectFinder struct {
+}
+
+func (fake *fakeJwtSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	return "", infrastructure.ErrorCouldNotFindSubjectClaim
+}
+
+type fakeTrackerService struct {
+	trackerservice.TrackerService
+}
+
+func (fake fakeTrackerService) FindByUser(ctx context.Context, sub string) ([]model.Tracker, error) {
+	if sub != "auth0|tom" {
+		return []model.Tracker{}, nil
+	}
+	return []model.Tracker{{ID: 1, Name: "Tom and Laura", AdminUserID: 1, TrackerUserIDs: []int64{1, 2}, Version: 2}}, nil
+}
+
+func (fake fakeTrackerService) FindByID(ctx context.Context, sub string, id int64) (model.Tracker, error) {
+	return model.Tracker{}, trackerservice.ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker
+}
+
+func (fake fakeTrackerService) DeleteTracker(ctx context.Context, sub string, id int64, version int64) (bool, error) {
+	return false, errors.New("pq: relation \"Trackers\" does not exist")
+}
+
+type fakeSpendService struct {
+	spendservice.SpendService
+}
+
+func (fake fakeSpendService) CreateSpend(ctx context.Context, sub string, spend model.Spend) (model.Spend, error) {
+	spend.ID = 1
+	spend.Version = 1
+	return spend, nil
+}
+
+var rateLimiter *ratelimit.Limiter
+var client godutchpb.TrackerServiceClient
+var spendClient godutchpb.SpendServiceClient
+var trackers *godutchpb.Trackers
+var err error
+
+func TestCallsWithoutATokenAreRejected(t *testing.T) {
+	givenIHaveAClient()
+	whenIListTrackers(context.Background())
+	thenTheCodeIs(codes.Unauthenticated, t)
+}
+
+func TestReadScopeCanList(t *testing.T) {
+	givenIHaveAClient()
+	whenIListTrackers(withToken("gdt_read"))
+	thenTheCodeIs(codes.OK, t)
+	if len(trackers.GetTrackers()) != 1 || trackers.GetTrackers()[0].GetVersion() != 2 {
+		t.Errorf("Expected Tom's tracker got %v", trackers)
+	}
+}
+
+func TestReadScopeCannotCreate(t *testing.T) {
+	givenIHaveAClient()
+	_, err = client.CreateTracker(withToken("gdt_read"), &godutchpb.Tracker{Name: "New"})
+	thenTheCodeIs(codes.PermissionDenied, t)
+	thenTheReasonIs(apitokenauth.ErrorInsufficientScope.Code, t)
+}
+
+func TestSpendsWriteScopeCanCreateSpends(t *testing.T) {
+	givenIHaveAClient()
+	_, err = spendClient.CreateSpend(withToken("gdt_spends"), &godutchpb.Spend{TrackerId: 1, Name: "Cheese", Value: "1.99"})
+	thenTheCodeIs(codes.OK, t)
+}
+
+func TestSpendsWriteScopeCannotCreateTrackers(t *testing.T) {
+	givenIHaveAClient()
+	_, err = client.CreateTracker(withToken("gdt_spends"), &godutchpb.Tracker{Name: "New"})
+	thenTheCodeIs(codes.PermissionDenied, t)
+	thenTheReasonIs(apitokenauth.ErrorInsufficientScope.Code, t)
+}
+
+func TestDomainErrorsKeepTheirCode(t *testing.T) {
+	givenIHaveAClient()
+	_, err = client.GetTracker(withToken("gdt_write"), &godutchpb.IDRequest{Id: 1})
+	thenTheCodeIs(codes.PermissionDenied, t)
+	thenTheReasonIs(trackerservice.ErrorYouDoNotHavePermissionsToSeeTheUsersOfThisTracker.Code, t)
+}
+
+func TestOtherErrorsAreInternalAndDoNotRepeatTheirText(t *testing.T) {
+	givenIHaveAClient()
+	_, err = client.DeleteTracker(withToken("gdt_write"), &godutchpb.DeleteRequest{Id: 1, Version: 1})
+	thenTheCodeIs(codes.Internal, t)
+	thenTheReasonIs(domainerror.ErrorInternal.Code, t)
+	if strings.Contains(status.Convert(err).Message(), "Trackers") {
+		t.Errorf("Expected a generic message got %v", status.Convert(err).Message())
+	}
+}
+
+func TestCallsOverTheSubjectsLimitAreExhausted(t *testing.T) {
+	givenTheSubjectLimitIs(ratelimit.Limit{Requests: 1, Period: time.Minute})
+	givenIHaveAClient()
+	whenIListTrackers(withToken("gdt_read"))
+	thenTheCodeIs(codes.OK, t)
+	var header metadata.MD
+	trackers, err = client.ListTrackers(withToken("gdt_write"), &godutchpb.ListTrackersRequest{}, grpc.Header(&header))
+	thenTheCodeIs(codes.ResourceExhausted, t)
+	thenTheReasonIs(ratelimit.ErrorRateLimited.Code, t)
+	if retryAfter := header.Get("retry-after"); len(retryAfter) != 1 || retryAfter[0] != "60" {
+		t.Errorf("Expected retry-after 60 got %v", retryAfter)
+	}
+	rateLimiter = nil
+}
+
+func givenTheSubjectLimitIs(limit ratelimit.Limit) {
+	rateLimiter = ratelimit.NewLimiter(ratelimitrepository.NewInMemoryRateLimitRepository(), nil, infrastructure.NilLogger{},
+		ratelimit.Options{Subject: limit})
+}
+
+func givenIHaveAClient() {
+	env := &environment.Env{
+		Logger:         infrastructure.NilLogger{},
+		SubjectFinder:  apitokenauth.NewAPITokenSubjectFinder(&fakeJwtSubjectFinder{}),
+		Authenticator:  apitokenauth.NewAPITokenAuthenticator(&fakeChecker{}, &fakeJwtAuthenticator{}),
+		TrackerService: fakeTrackerService{},
+		SpendService:   fakeSpendService{},
+		RateLimiter:    rateLimiter,
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpcserver.NewServer(env)
+	go server.Serve(listener)
+
+	connection, _ := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	client = godutchpb.NewTrackerServiceClient(connection)
+	spendClient = godutchpb.NewSpendServiceClient(connection)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func whenIListTrackers(ctx context.Context) {
+	trackers, err = client.ListTrackers(ctx, &godutchpb.ListTrackersRequest{})
+}
+
+func thenTheCodeIs(expected codes.Code, t *testing.T) {
+	if code := status.Code(err); code != expected {
+		t.Fatalf("Expected %v got %v (%v)", expected, code, err)
+	}
+}
+
+func thenTheReasonIs(expected string, t *testing.T) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if info.GetReason() != expected {
+				t.Errorf("Expected reason %v got %v", expected, info.GetReason())
+			}
+			return
+		}
+	}
+	t.Errorf("Expected an ErrorInfo detail on %v", err)
+}
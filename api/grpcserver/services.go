@@ -0,0 +1,239 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/proto/godutchpb"
+)
+
+type trackerServer struct {
+	godutchpb.UnimplementedTrackerServiceServer
+	env *environment.Env
+}
+
+func (server *trackerServer) ListTrackers(ctx context.Context, req *godutchpb.ListTrackersRequest) (*godutchpb.Trackers, error) {
+	trackers, err := server.env.TrackerService.FindByUser(ctx, subjectFrom(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := &godutchpb.Trackers{Trackers: make([]*godutchpb.Tracker, len(trackers))}
+	for i, tracker := range trackers {
+		converted.Trackers[i] = toTracker(tracker)
+	}
+	return converted, nil
+}
+
+func (server *trackerServer) GetTracker(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.Tracker, error) {
+	tracker, err := server.env.TrackerService.FindByID(ctx, subjectFrom(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTracker(tracker), nil
+}
+
+func (server *trackerServer) CreateTracker(ctx context.Context, req *godutchpb.Tracker) (*godutchpb.Tracker, error) {
+	tracker, err := server.env.TrackerService.CreateTracker(ctx, subjectFrom(ctx), fromTracker(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTracker(tracker), nil
+}
+
+func (server *trackerServer) UpdateTracker(ctx context.Context, req *godutchpb.Tracker) (*godutchpb.Tracker, error) {
+	tracker, err := server.env.TrackerService.UpdateTracker(ctx, subjectFrom(ctx), fromTracker(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTracker(tracker), nil
+}
+
+func (server *trackerServer) DeleteTracker(ctx context.Context, req *godutchpb.DeleteRequest) (*godutchpb.DeleteResponse, error) {
+	deleted, err := server.env.TrackerService.DeleteTracker(ctx, subjectFrom(ctx), req.GetId(), req.GetVersion())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &godutchpb.DeleteResponse{Deleted: deleted}, nil
+}
+
+func (server *trackerServer) ListTrackerUsers(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.Users, error) {
+	users, err := server.env.TrackerService.FindUsersForTracker(ctx, subjectFrom(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUsers(users), nil
+}
+
+type spendServer struct {
+	godutchpb.UnimplementedSpendServiceServer
+	env *environment.Env
+}
+
+func (server *spendServer) ListSpends(ctx context.Context, req *godutchpb.TrackerIDRequest) (*godutchpb.Spends, error) {
+	spends, err := server.env.SpendService.FindByTrackerID(ctx, subjectFrom(ctx), req.GetTrackerId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := &godutchpb.Spends{Spends: make([]*godutchpb.Spend, len(spends))}
+	for i, spend := range spends {
+		converted.Spends[i] = toSpend(spend)
+	}
+	return converted, nil
+}
+
+func (server *spendServer) GetSpend(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.Spend, error) {
+	spend, err := server.env.SpendService.FindByID(ctx, subjectFrom(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSpend(spend), nil
+}
+
+func (server *spendServer) CreateSpend(ctx context.Context, req *godutchpb.Spend) (*godutchpb.Spend, error) {
+	spend, err := fromSpend(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	spend, err = server.env.SpendService.CreateSpend(ctx, subjectFrom(ctx), spend)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSpend(spend), nil
+}
+
+func (server *spendServer) UpdateSpend(ctx context.Context, req *godutchpb.Spend) (*godutchpb.Spend, error) {
+	spend, err := fromSpend(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	spend, err = server.env.SpendService.UpdateSpend(ctx, subjectFrom(ctx), spend)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSpend(spend), nil
+}
+
+func (server *spendServer) DeleteSpend(ctx context.Context, req *godutchpb.DeleteRequest) (*godutchpb.DeleteResponse, error) {
+	deleted, err := server.env.SpendService.DeleteSpend(ctx, subjectFrom(ctx), req.GetId(), req.GetVersion())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &godutchpb.DeleteResponse{Deleted: deleted}, nil
+}
+
+type transferServer struct {
+	godutchpb.UnimplementedTransferServiceServer
+	env *environment.Env
+}
+
+func (server *transferServer) ListTransfers(ctx context.Context, req *godutchpb.TrackerIDRequest) (*godutchpb.Transfers, error) {
+	transfers, err := server.env.TransferService.FindTransfersForTrackerID(ctx, subjectFrom(ctx), req.GetTrackerId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := &godutchpb.Transfers{Transfers: make([]*godutchpb.Transfer, len(transfers))}
+	for i, transfer := range transfers {
+		converted.Transfers[i] = toTransfer(transfer)
+	}
+	return converted, nil
+}
+
+type spendSummaryServer struct {
+	godutchpb.UnimplementedSpendSummaryServiceServer
+	env *environment.Env
+}
+
+func (server *spendSummaryServer) ListSpendSummaries(ctx context.Context, req *godutchpb.TrackerIDRequest) (*godutchpb.SpendSummaries, error) {
+	spendSummaries, err := server.env.SpendSummaryService.FindSpendSummariesForTrackerID(ctx, subjectFrom(ctx), req.GetTrackerId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := &godutchpb.SpendSummaries{SpendSummaries: make([]*godutchpb.SpendSummary, len(spendSummaries))}
+	for i, spendSummary := range spendSummaries {
+		converted.SpendSummaries[i] = toSpendSummary(spendSummary)
+	}
+	return converted, nil
+}
+
+type userServer struct {
+	godutchpb.UnimplementedUserServiceServer
+	env *environment.Env
+}
+
+func (server *userServer) GetCurrentUser(ctx context.Context, req *godutchpb.GetCurrentUserRequest) (*godutchpb.User, error) {
+	user, err := server.env.UserService.FindBySub(ctx, subjectFrom(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (server *userServer) GetUser(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.User, error) {
+	user, err := server.env.UserService.FindByID(ctx, subjectFrom(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (server *userServer) CreateUser(ctx context.Context, req *godutchpb.User) (*godutchpb.User, error) {
+	user, err := server.env.UserService.CreateUser(ctx, subjectFrom(ctx), fromUser(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (server *userServer) InviteUser(ctx context.Context, req *godutchpb.InviteUserRequest) (*godutchpb.User, error) {
+	inviteUser := model.InviteUser{EmailAddress: req.GetEmailAddress(), TrackerID: req.GetTrackerId()}
+
+	user, err := server.env.UserService.InviteUser(ctx, subjectFrom(ctx), inviteUser, server.env.GoDutchURL)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (server *userServer) AcceptInvite(ctx context.Context, req *godutchpb.AcceptInviteRequest) (*godutchpb.User, error) {
+	user, err := server.env.UserService.AcceptInvite(ctx, subjectFrom(ctx), fromUser(req.GetUser()), req.GetInviteToken())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (server *userServer) ListPendingInvites(ctx context.Context, req *godutchpb.TrackerIDRequest) (*godutchpb.Invites, error) {
+	invites, err := server.env.UserService.FindPendingInvites(ctx, subjectFrom(ctx), req.GetTrackerId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := &godutchpb.Invites{Invites: make([]*godutchpb.Invite, len(invites))}
+	for i, invite := range invites {
+		converted.Invites[i] = toInvite(invite)
+	}
+	return converted, nil
+}
+
+func (server *userServer) ResendInvite(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.Invite, error) {
+	invite, err := server.env.UserService.ResendInvite(ctx, subjectFrom(ctx), req.GetId(), server.env.GoDutchURL)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toInvite(invite), nil
+}
+
+func (server *userServer) RevokeInvite(ctx context.Context, req *godutchpb.IDRequest) (*godutchpb.Invite, error) {
+	invite, err := server.env.UserService.RevokeInvite(ctx, subjectFrom(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toInvite(invite), nil
+}
@@ -0,0 +1,118 @@
+package apitokenhandler
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/view"
+)
+
+// CreateAPITokenHandler ...
+func CreateAPITokenHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		var apiToken view.APIToken
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		if err := r.Body.Close(); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		if err := json.Unmarshal(body, &apiToken); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		newAPIToken, newToken, err := env.APITokenService.CreateAPIToken(r.Context(), subject, apiToken.APIToken)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		apiTokenView := view.NewAPIToken{
+			APIToken: newAPIToken,
+			Token:    newToken,
+		}
+
+		// the secret is only in this response, retries replayed by idempotency get the Location instead
+		w.Header().Set("Location", "/api/v1/apitokens/"+strconv.FormatInt(newAPIToken.ID, 10))
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(apiTokenView); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// FindAPITokensBySubHandler ...
+func FindAPITokensBySubHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		apiTokens, err := env.APITokenService.FindByUser(r.Context(), subject)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		apiTokensView := view.APITokens{
+			APITokens: apiTokens,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(apiTokensView); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// RevokeAPITokenHandler ...
+func RevokeAPITokenHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		result, err := env.APITokenService.RevokeAPIToken(r.Context(), subject, id)
+		if err != nil || result == false {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -3,15 +3,22 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/mergepatch"
+	"github.com/TomPallister/godutch-api/api/requestid"
 	"github.com/gorilla/mux"
 )
 
 // ErrorNotFound ...to be used when object does not exist in repository
-var ErrorNotFound = errors.New("Tracker not found")
+var ErrorNotFound = domainerror.NewNotFound("tracker_not_found", "Tracker not found")
 
 // GetIDFromVARs ...
 func GetIDFromVARs(r *http.Request) (int64, error) {
@@ -23,37 +30,170 @@ func GetIDFromVARs(r *http.Request) (int64, error) {
 	return id, nil
 }
 
-// GetCryptoEmailFromVars ...
-func GetCryptoEmailFromVars(r *http.Request) (string, error) {
+// ErrorMissingInviteToken ...
+var ErrorMissingInviteToken = domainerror.NewBadRequest("missing_invite_token", "Invite token is missing")
+
+// GetInviteTokenFromVars ...
+func GetInviteTokenFromVars(r *http.Request) (string, error) {
 	vars := mux.Vars(r)
-	cryptoEmail, ok := vars["cryptoEmail"]
-	if ok == false {
-		return "", ErrorNotFound
+	inviteToken, ok := vars["token"]
+	if ok == false || len(inviteToken) == 0 {
+		return "", ErrorMissingInviteToken
 	}
-	return cryptoEmail, nil
+	return inviteToken, nil
 }
 
-// CreateErrorResponseAndLog ...
-func CreateErrorResponseAndLog(headerValue int, w http.ResponseWriter, logger infrastructure.Logger, err error) {
-	w.WriteHeader(headerValue)
-	createErrorJSONResponse(err, w)
-	logger.Error("There was an error", err)
-	return
+// ErrorIfMatchRequired ...
+var ErrorIfMatchRequired = domainerror.NewPreconditionRequired("if_match_required", "Send the ETag you last read in an If-Match header")
+
+// ErrorInvalidIfMatch ...
+var ErrorInvalidIfMatch = domainerror.NewBadRequest("invalid_if_match", "If-Match must be an ETag returned by this api")
+
+// ETag turns a resource version into a strong entity tag
+func ETag(version int64) string {
+	return "\"" + strconv.FormatInt(version, 10) + "\""
 }
 
-type errorResponse struct {
-	Error string
+// GetVersionFromIfMatch reads the version the caller last saw from the If-Match header
+func GetVersionFromIfMatch(r *http.Request) (int64, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(ifMatch) == 0 {
+		return 0, ErrorIfMatchRequired
+	}
+	if len(ifMatch) < 3 || strings.HasPrefix(ifMatch, "\"") == false || strings.HasSuffix(ifMatch, "\"") == false {
+		return 0, ErrorInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(ifMatch[1:len(ifMatch)-1], 10, 64)
+	if err != nil {
+		return 0, ErrorInvalidIfMatch
+	}
+	return version, nil
 }
 
-func createErrorJSONResponse(err error, w http.ResponseWriter) {
-	response := errorResponse{Error: err.Error()}
+// ErrorUnsupportedMediaType ...
+var ErrorUnsupportedMediaType = domainerror.NewUnsupportedMediaType("unsupported_media_type", "Send the patch as application/merge-patch+json")
+
+// ReadMergePatch reads a JSON Merge Patch document from the request body
+func ReadMergePatch(r *http.Request) ([]byte, error) {
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) > 0 {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergepatch.ContentType && mediaType != "application/json") {
+			return nil, ErrorUnsupportedMediaType
+		}
+	}
 
-	jsonResponse, err := json.Marshal(response)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Body.Close(); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// ProblemTypeBaseURL prefixes a problem's code to give its RFC 7807 type
+const ProblemTypeBaseURL = "https://godutch.money/problems/"
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type    string                 `json:"type"`
+	Title   string                 `json:"title"`
+	Status  int                    `json:"status"`
+	Detail  string                 `json:"detail"`
+	Code    string                 `json:"code,omitempty"`
+	Field   string                 `json:"field,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ErrorMalformedRequest is what input the handler could not read becomes, e.g. a body that is not JSON
+var ErrorMalformedRequest = domainerror.NewBadRequest("malformed_request", "The request could not be read")
+
+// ErrorUnauthenticated is what a credential the authenticator refused becomes when it does not say why
+var ErrorUnauthenticated = domainerror.NewUnauthorized("unauthenticated", "The request could not be authenticated")
+
+// NewProblem maps err to a problem. Domain errors carry their own status, input the handler could
+// not read gets headerValue and refused credentials stay a 401. Anything else is the api's fault,
+// it is a 500 that does not repeat the error's text as that can describe the database or other internals.
+func NewProblem(headerValue int, err error) Problem {
+	domainError := classify(headerValue, err)
+
+	problem := Problem{
+		Type:    ProblemTypeBaseURL + domainError.Code,
+		Status:  domainError.Status,
+		Detail:  domainError.Message,
+		Code:    domainError.Code,
+		Field:   domainError.Field,
+		Details: domainError.Details,
+	}
+
+	problem.Title = http.StatusText(problem.Status)
+	if problem.Status == domainerror.StatusClientClosedRequest {
+		problem.Title = "Client Closed Request"
+	}
+	return problem
+}
+
+// classify finds the domain error err is, or the one it stands in for
+func classify(headerValue int, err error) *domainerror.Error {
+	if domainError := domainerror.From(err); domainError != nil {
+		return domainError
+	}
+	if malformed(err) {
+		if headerValue >= http.StatusBadRequest && headerValue < http.StatusInternalServerError {
+			return &domainerror.Error{Code: ErrorMalformedRequest.Code, Status: headerValue, Message: ErrorMalformedRequest.Message}
+		}
+		return ErrorMalformedRequest
+	}
+	if headerValue == http.StatusUnauthorized {
+		return ErrorUnauthenticated
+	}
+	return domainerror.ErrorInternal
+}
+
+// malformed reports whether err came from reading the caller's input rather than from the api
+func malformed(err error) bool {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var numError *strconv.NumError
+	var maxBytesError *http.MaxBytesError
+	return errors.As(err, &syntaxError) || errors.As(err, &typeError) || errors.As(err, &numError) ||
+		errors.As(err, &maxBytesError) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// WriteProblem ...
+func WriteProblem(w http.ResponseWriter, problem Problem) {
+	jsonResponse, err := json.Marshal(problem)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
 	w.Write(jsonResponse)
 }
+
+// CreateErrorResponseAndLog writes err as problem+json. headerValue is only used when err is not a domain error.
+// Problems the caller caused are logged as warnings, the rest as errors, with the request's id when it has one.
+func CreateErrorResponseAndLog(headerValue int, w http.ResponseWriter, logger infrastructure.Logger, err error) {
+	problem := NewProblem(headerValue, err)
+	WriteProblem(w, problem)
+
+	if id := w.Header().Get(requestid.Header); id != "" {
+		logger = logger.With("request_id", id)
+	}
+
+	if problem.Status >= http.StatusInternalServerError {
+		logger.Error("There was an error", err, "status", problem.Status, "code", problem.Code)
+		return
+	}
+	if domainerror.From(err) == nil {
+		logger.Warn("The request was refused", "status", problem.Status, "code", problem.Code, "error", err.Error())
+		return
+	}
+	logger.Warn("The request was refused", "status", problem.Status, "code", problem.Code, "detail", problem.Detail)
+}
@@ -0,0 +1,123 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
+)
+
+var recorder *httptest.ResponseRecorder
+var problem handler.Problem
+
+func TestDomainErrorIsWrittenWithItsOwnStatus(t *testing.T) {
+	whenTheErrorIsWritten(spendvalidation.ErrorInvalidCurrency, t)
+	thenTheProblemIs(http.StatusUnprocessableEntity, "invalid_currency", t)
+	if problem.Field != "spend.currency" {
+		t.Fatalf("expected field spend.currency but got %v", problem.Field)
+	}
+	if problem.Type != handler.ProblemTypeBaseURL+"invalid_currency" {
+		t.Fatalf("unexpected type %v", problem.Type)
+	}
+}
+
+func TestOtherErrorsAreInternalAndDoNotRepeatTheirText(t *testing.T) {
+	whenTheErrorIsWritten(errors.New("pq: relation \"Spends\" does not exist"), t)
+	thenTheProblemIs(http.StatusInternalServerError, "internal_error", t)
+	if strings.Contains(problem.Detail, "Spends") {
+		t.Fatalf("expected a generic detail but got %v", problem.Detail)
+	}
+}
+
+func TestRepositorySentinelsAreInternal(t *testing.T) {
+	whenTheErrorIsWritten(spendrepository.ErrorCouldNotInsertSpend, t)
+	thenTheProblemIs(http.StatusInternalServerError, "could_not_insert_spend", t)
+}
+
+func TestBodiesThatAreNotJSONUseTheHandlersStatus(t *testing.T) {
+	var body map[string]interface{}
+	whenTheErrorIsWritten(json.Unmarshal([]byte("{"), &body), t)
+	thenTheProblemIs(http.StatusBadRequest, "malformed_request", t)
+}
+
+func TestIfMatchIsReadAsAVersion(t *testing.T) {
+	version, err := whenIfMatchIsRead(handler.ETag(3))
+	if err != nil || version != 3 {
+		t.Fatalf("expected version 3 but got %v, %v", version, err)
+	}
+}
+
+func TestMissingIfMatchIsAPreconditionRequired(t *testing.T) {
+	_, err := whenIfMatchIsRead("")
+	whenTheErrorIsWritten(err, t)
+	thenTheProblemIs(http.StatusPreconditionRequired, "if_match_required", t)
+}
+
+func TestIfMatchMustBeAnETagWeGaveOut(t *testing.T) {
+	for _, ifMatch := range []string{"3", "W/\"3\"", "\"three\"", "\""} {
+		_, err := whenIfMatchIsRead(ifMatch)
+		if err != handler.ErrorInvalidIfMatch {
+			t.Fatalf("expected %v to be invalid but got %v", ifMatch, err)
+		}
+	}
+}
+
+func whenIfMatchIsRead(ifMatch string) (int64, error) {
+	r := httptest.NewRequest("PUT", "/api/v1/spends/1", nil)
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	return handler.GetVersionFromIfMatch(r)
+}
+
+func TestMergePatchIsRead(t *testing.T) {
+	for _, contentType := range []string{"application/merge-patch+json", "application/json; charset=UTF-8", ""} {
+		r := httptest.NewRequest("PATCH", "/api/v1/spends/1", strings.NewReader(`{"spend":{"name":"Lunch"}}`))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		patch, err := handler.ReadMergePatch(r)
+		if err != nil || string(patch) != `{"spend":{"name":"Lunch"}}` {
+			t.Fatalf("expected the patch to be read for %v but got %v, %v", contentType, string(patch), err)
+		}
+	}
+}
+
+func TestOtherPatchFormatsAreUnsupported(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/api/v1/spends/1", strings.NewReader(`[]`))
+	r.Header.Set("Content-Type", "application/json-patch+json")
+	_, err := handler.ReadMergePatch(r)
+	whenTheErrorIsWritten(err, t)
+	thenTheProblemIs(http.StatusUnsupportedMediaType, "unsupported_media_type", t)
+}
+
+func whenTheErrorIsWritten(err error, t *testing.T) {
+	recorder = httptest.NewRecorder()
+	handler.CreateErrorResponseAndLog(http.StatusBadRequest, recorder, infrastructure.NilLogger{}, err)
+	if recorder.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected problem+json but got %v", recorder.Header().Get("Content-Type"))
+	}
+	problem = handler.Problem{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func thenTheProblemIs(status int, code string, t *testing.T) {
+	if recorder.Code != status || problem.Status != status {
+		t.Fatalf("expected status %v but got %v and %v", status, recorder.Code, problem.Status)
+	}
+	if problem.Code != code {
+		t.Fatalf("expected code %v but got %v", code, problem.Code)
+	}
+	if problem.Title != http.StatusText(status) {
+		t.Fatalf("unexpected title %v", problem.Title)
+	}
+}
@@ -0,0 +1,268 @@
+package eventhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/gorilla/websocket"
+)
+
+// HeartbeatInterval is how often an idle stream is written to so proxies do not close it
+const HeartbeatInterval = 30 * time.Second
+
+// writeTimeout is how long a WebSocket write may take before the client is given up on
+const writeTimeout = 10 * time.Second
+
+// ErrorUserDoesNotBelongToTracker ...
+var ErrorUserDoesNotBelongToTracker = domainerror.NewForbidden("not_a_tracker_user", "User does not belong to tracker")
+
+// ErrorStreamingNotSupported ...
+var ErrorStreamingNotSupported = domainerror.NewInternal("streaming_not_supported", "The server cannot stream responses")
+
+// StreamTrackerEventsHandler streams a tracker's events as Server-Sent Events
+func StreamTrackerEventsHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		trackerID, err := findTrackerForMember(env, r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if ok == false {
+			handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, env.Logger, ErrorStreamingNotSupported)
+			return
+		}
+
+		subscription := env.EventSubscriber.Subscribe(trackerID)
+		defer subscription.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(HeartbeatInterval)
+		defer heartbeat.Stop()
+		expired := untilExpiry(r)
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-expired:
+				return
+			case <-heartbeat.C:
+				if !stillAuthenticated(env, r) {
+					return
+				}
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case event, open := <-subscription.Events:
+				if open == false {
+					return
+				}
+				if !stillAMember(env, r, event) {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					infrastructure.LoggerFrom(r.Context(), env.Logger).Error("Could not write event", err, "event_id", event.ID)
+					continue
+				}
+				fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", event.ID, event.Type, data)
+				flusher.Flush()
+				if event.Type == events.TrackerDeleted {
+					return
+				}
+			}
+		}
+	})
+}
+
+// TrackerEventsWebSocketHandler sends a tracker's events as JSON text messages over a WebSocket
+func TrackerEventsWebSocketHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		trackerID, err := findTrackerForMember(env, r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		upgrader := websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return originAllowed(env, r)
+			},
+		}
+
+		// the upgrader writes its own error response
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			infrastructure.LoggerFrom(r.Context(), env.Logger).Error("Could not upgrade to a WebSocket", err)
+			return
+		}
+		defer conn.Close()
+
+		subscription := env.EventSubscriber.Subscribe(trackerID)
+		defer subscription.Close()
+
+		// clients only send pongs and close frames, reading is how they are noticed
+		closed := make(chan struct{})
+		conn.SetReadDeadline(time.Now().Add(2 * HeartbeatInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * HeartbeatInterval))
+		})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(HeartbeatInterval)
+		defer heartbeat.Stop()
+		expired := untilExpiry(r)
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-expired:
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "The token has expired"),
+					time.Now().Add(writeTimeout))
+				return
+			case <-r.Context().Done():
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "The server is shutting down"),
+					time.Now().Add(writeTimeout))
+				return
+			case <-heartbeat.C:
+				if !stillAuthenticated(env, r) {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "The token is no longer valid"),
+						time.Now().Add(writeTimeout))
+					return
+				}
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+			case event, open := <-subscription.Events:
+				if open == false {
+					return
+				}
+				if !stillAMember(env, r, event) {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "You no longer belong to the tracker"),
+						time.Now().Add(writeTimeout))
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := conn.WriteJSON(event); err != nil {
+					infrastructure.LoggerFrom(r.Context(), env.Logger).Error("Could not write event", err, "event_id", event.ID)
+					return
+				}
+				if event.Type == events.TrackerDeleted {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseNormalClosure, "The tracker was deleted"),
+						time.Now().Add(writeTimeout))
+					return
+				}
+			}
+		}
+	})
+}
+
+// untilExpiry fires when the caller's token expires, streams are only checked when they open so
+// they must not outlive the credentials that opened them. Tokens without an expiry never fire,
+// stillAuthenticated notices when those are revoked.
+func untilExpiry(r *http.Request) <-chan time.Time {
+	expires, ok := infrastructure.TokenExpiryFrom(r.Context())
+	if ok == false {
+		return nil
+	}
+	return time.After(time.Until(expires))
+}
+
+// stillAuthenticated checks the caller's token again, API tokens do not expire so this is how a
+// stream notices one has been revoked
+func stillAuthenticated(env *environment.Env, r *http.Request) bool {
+	if _, err := env.Authenticator.Authenticate(r); err != nil {
+		infrastructure.LoggerFrom(r.Context(), env.Logger).Info("Closed the event stream of a caller whose token is no longer valid",
+			"error", err.Error())
+		return false
+	}
+	return true
+}
+
+// originAllowed lets a WebSocket be opened by clients that send no Origin, by pages served from
+// the api itself and by the origins CORS lets call the api
+func originAllowed(env *environment.Env, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return env.CORS != nil && env.CORS.OriginAllowed(r)
+}
+
+// stillAMember checks the caller again when the tracker's members may have changed, e.g. after
+// their invite was revoked, so they are not sent the tracker or anything that happens to it later
+func stillAMember(env *environment.Env, r *http.Request, event model.Event) bool {
+	if event.Type != events.TrackerUpdated {
+		return true
+	}
+	if _, err := findTrackerForMember(env, r); err != nil {
+		infrastructure.LoggerFrom(r.Context(), env.Logger).Info("Closed the event stream of a caller who can no longer see the tracker",
+			"tracker_id", event.TrackerID, "error", err.Error())
+		return false
+	}
+	return true
+}
+
+// findTrackerForMember returns the id of the tracker in the url if the caller belongs to it
+func findTrackerForMember(env *environment.Env, r *http.Request) (int64, error) {
+
+	subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := handler.GetIDFromVARs(r)
+	if err != nil {
+		return 0, err
+	}
+
+	user, err := env.UserService.FindBySub(r.Context(), subject)
+	if err != nil {
+		return 0, err
+	}
+
+	tracker, err := env.TrackerService.FindByID(r.Context(), subject, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if !infrastructure.Ints64Contains(tracker.TrackerUserIDs, user.ID) {
+		return 0, ErrorUserDoesNotBelongToTracker
+	}
+
+	return tracker.ID, nil
+}
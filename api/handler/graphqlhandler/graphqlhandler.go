@@ -0,0 +1,79 @@
+package graphqlhandler
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/graph"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+)
+
+// Request ...
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLHandler answers POSTed queries, and GET ones with the query in the url. Errors from the
+// services come back in the response's errors with their code and status in extensions.
+func GraphQLHandler(env *environment.Env) http.Handler {
+	schema := graph.MustNewSchema(env)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		var request Request
+		if r.Method == "GET" {
+			request.Query = r.URL.Query().Get("query")
+			request.OperationName = r.URL.Query().Get("operationName")
+			if variables := r.URL.Query().Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+					handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+					return
+				}
+			}
+		} else {
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+			if err != nil {
+				handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+				return
+			}
+
+			if err := r.Body.Close(); err != nil {
+				handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+				return
+			}
+
+			if err := json.Unmarshal(body, &request); err != nil {
+				handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+				return
+			}
+		}
+
+		ctx := graph.WithLoaders(r.Context(), env, subject)
+		response := schema.Exec(ctx, request.Query, request.OperationName, request.Variables)
+		for _, queryError := range response.Errors {
+			if queryError.ResolverError != nil && domainerror.From(queryError.ResolverError) == nil {
+				infrastructure.LoggerFrom(r.Context(), env.Logger).Error("There was an error", queryError.ResolverError, "path", queryError.Path)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package healthhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+)
+
+// CheckTimeout bounds each readiness check so a hung dependency fails the probe instead of stalling it
+const CheckTimeout = 2 * time.Second
+
+// Health is the body of /healthz and /readyz, Checks has "ok" or "failing" for each readiness check
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// HealthzHandler says the process is up, it checks nothing else so a struggling database does
+// not get the api restarted
+func HealthzHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, Health{Status: "ok"})
+	})
+}
+
+// ReadyzHandler runs env's readiness checks and answers 503 if any fail, so the api is taken
+// out of the load balancer until its dependencies are back. Why a check failed is only logged.
+func ReadyzHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		names := make([]string, 0, len(env.ReadinessChecks))
+		for name := range env.ReadinessChecks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		health := Health{Status: "ok", Checks: map[string]string{}}
+		status := http.StatusOK
+		for _, name := range names {
+			ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+			err := env.ReadinessChecks[name](ctx)
+			cancel()
+			if err != nil {
+				infrastructure.LoggerFrom(r.Context(), env.Logger).Error("Readiness check failed", err, "check", name)
+				health.Checks[name] = "failing"
+				health.Status = "unavailable"
+				status = http.StatusServiceUnavailable
+				continue
+			}
+			health.Checks[name] = "ok"
+		}
+
+		writeHealth(w, status, health)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, status int, health Health) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(health)
+}
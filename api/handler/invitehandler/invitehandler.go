@@ -0,0 +1,108 @@
+package invitehandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/view"
+)
+
+// FindPendingInvitesHandler ...
+func FindPendingInvitesHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("trackerId"), 10, 64)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		invites, err := env.UserService.FindPendingInvites(r.Context(), subject, id)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		invitesView := view.Invites{
+			Invites: invites,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(invitesView); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// ResendInviteHandler ...
+func ResendInviteHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		invite, err := env.UserService.ResendInvite(r.Context(), subject, id, env.GoDutchURL)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		inviteView := view.Invite{
+			Invite: invite,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(inviteView); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// RevokeInviteHandler ...
+func RevokeInviteHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		_, err = env.UserService.RevokeInvite(r.Context(), subject, id)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,116 @@
+package localauthhandler
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+func readJSON(w http.ResponseWriter, r *http.Request, env *environment.Env, v interface{}) bool {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+		return false
+	}
+
+	if err := r.Body.Close(); err != nil {
+		handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+		return false
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+		return false
+	}
+
+	return true
+}
+
+// RegisterHandler ...
+func RegisterHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var register model.Register
+		if readJSON(w, r, env, &register) == false {
+			return
+		}
+
+		newUser, err := env.LocalAuthService.Register(r.Context(), register)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(newUser); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// LoginHandler ...
+func LoginHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var login model.Login
+		if readJSON(w, r, env, &login) == false {
+			return
+		}
+
+		loginResult, err := env.LocalAuthService.Login(r.Context(), login)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(loginResult); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// ForgotPasswordHandler always answers 202 so it cannot be used to find out which emails are registered
+func ForgotPasswordHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var forgotPassword model.ForgotPassword
+		if readJSON(w, r, env, &forgotPassword) == false {
+			return
+		}
+
+		if err := env.LocalAuthService.ForgotPassword(r.Context(), forgotPassword, env.GoDutchURL); err != nil {
+			infrastructure.LoggerFrom(r.Context(), env.Logger).Error("There was an error", err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// ResetPasswordHandler ...
+func ResetPasswordHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		var resetPassword model.ResetPassword
+		if readJSON(w, r, env, &resetPassword) == false {
+			return
+		}
+
+		if err := env.LocalAuthService.ResetPassword(r.Context(), resetPassword); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/TomPallister/godutch-api/api/environment"
 	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure/mergepatch"
 	"github.com/TomPallister/godutch-api/api/view"
 )
 
@@ -39,7 +40,7 @@ func CreateSpendHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		newSpend, err := env.SpendService.CreateSpend(subject, spend.Spend)
+		newSpend, err := env.SpendService.CreateSpend(r.Context(), subject, spend.Spend)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -50,6 +51,7 @@ func CreateSpendHandler(env *environment.Env) http.Handler {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(newSpend.Version))
 		w.WriteHeader(http.StatusCreated)
 		if err := json.NewEncoder(w).Encode(viewSpend); err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
@@ -74,6 +76,12 @@ func UpdateSpendHandler(env *environment.Env) http.Handler {
 			return
 		}
 
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
 		var spend view.Spend
 		body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 		if err != nil {
@@ -97,8 +105,84 @@ func UpdateSpendHandler(env *environment.Env) http.Handler {
 		}
 
 		spend.Spend.ID = id
+		spend.Spend.Version = version
+
+		updatedSpend, err := env.SpendService.UpdateSpend(r.Context(), subject, spend.Spend)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		viewSpend := view.Spend{
+			Spend: updatedSpend,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(updatedSpend.Version))
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(viewSpend); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// PatchSpendHandler ...applies a JSON Merge Patch to the stored spend, then updates it like a PUT
+func PatchSpendHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		patch, err := handler.ReadMergePatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		existingSpend, err := env.SpendService.FindByID(r.Context(), subject, id)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		original, err := json.Marshal(view.Spend{Spend: existingSpend})
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, env.Logger, err)
+			return
+		}
+
+		patched, err := mergepatch.Apply(original, patch)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		var spend view.Spend
+		if err := json.Unmarshal(patched, &spend); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		spend.Spend.ID = id
+		spend.Spend.Version = version
 
-		updatedSpend, err := env.SpendService.UpdateSpend(subject, spend.Spend)
+		updatedSpend, err := env.SpendService.UpdateSpend(r.Context(), subject, spend.Spend)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -109,6 +193,7 @@ func UpdateSpendHandler(env *environment.Env) http.Handler {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(updatedSpend.Version))
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(viewSpend); err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
@@ -133,8 +218,14 @@ func DeleteSpendHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		result, err := env.SpendService.DeleteSpend(subject, id)
-		if err != nil && result == true {
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		result, err := env.SpendService.DeleteSpend(r.Context(), subject, id, version)
+		if err != nil || result == false {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
 		}
@@ -144,6 +235,42 @@ func DeleteSpendHandler(env *environment.Env) http.Handler {
 	})
 }
 
+// FindSpendByIDHandler ...
+func FindSpendByIDHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		spend, err := env.SpendService.FindByID(r.Context(), subject, id)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		viewSpend := view.Spend{
+			Spend: spend,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(spend.Version))
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(viewSpend); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
 // FindFindByTrackerIDHandler ...
 func FindFindByTrackerIDHandler(env *environment.Env) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -160,7 +287,7 @@ func FindFindByTrackerIDHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		spends, err := env.SpendService.FindByTrackerID(subject, id)
+		spends, err := env.SpendService.FindByTrackerID(r.Context(), subject, id)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
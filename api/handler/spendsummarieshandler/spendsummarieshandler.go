@@ -27,7 +27,7 @@ func FindFindByTrackerIDHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		spendSummaries, err := env.SpendSummaryService.FindSpendSummariesForTrackerID(subject, id)
+		spendSummaries, err := env.SpendSummaryService.FindSpendSummariesForTrackerID(r.Context(), subject, id)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/TomPallister/godutch-api/api/environment"
 	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure/mergepatch"
 	"github.com/TomPallister/godutch-api/api/view"
 )
 
@@ -38,7 +39,7 @@ func CreateTrackerHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		newTracker, err := env.TrackerService.CreateTracker(subject, tracker.Tracker)
+		newTracker, err := env.TrackerService.CreateTracker(r.Context(), subject, tracker.Tracker)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -49,6 +50,7 @@ func CreateTrackerHandler(env *environment.Env) http.Handler {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(newTracker.Version))
 		w.WriteHeader(http.StatusCreated)
 		if err := json.NewEncoder(w).Encode(trackerView); err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
@@ -73,6 +75,12 @@ func UpdateTrackerHandler(env *environment.Env) http.Handler {
 			return
 		}
 
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
 		var tracker view.Tracker
 		body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 		if err != nil {
@@ -96,8 +104,9 @@ func UpdateTrackerHandler(env *environment.Env) http.Handler {
 		}
 
 		tracker.Tracker.ID = id
+		tracker.Tracker.Version = version
 
-		updatedTracker, err := env.TrackerService.UpdateTracker(subject, tracker.Tracker)
+		updatedTracker, err := env.TrackerService.UpdateTracker(r.Context(), subject, tracker.Tracker)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -108,6 +117,82 @@ func UpdateTrackerHandler(env *environment.Env) http.Handler {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(updatedTracker.Version))
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(trackerView); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+	})
+}
+
+// PatchTrackerHandler ...applies a JSON Merge Patch to the stored tracker, then updates it like a PUT
+func PatchTrackerHandler(env *environment.Env) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		subject, err := env.SubjectFinder.FindSubject(r, env.Logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+
+		id, err := handler.GetIDFromVARs(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		patch, err := handler.ReadMergePatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		existingTracker, err := env.TrackerService.FindByID(r.Context(), subject, id)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		original, err := json.Marshal(view.Tracker{Tracker: existingTracker})
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, env.Logger, err)
+			return
+		}
+
+		patched, err := mergepatch.Apply(original, patch)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		var tracker view.Tracker
+		if err := json.Unmarshal(patched, &tracker); err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		tracker.Tracker.ID = id
+		tracker.Tracker.Version = version
+
+		updatedTracker, err := env.TrackerService.UpdateTracker(r.Context(), subject, tracker.Tracker)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		trackerView := view.Tracker{
+			Tracker: updatedTracker,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(updatedTracker.Version))
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(trackerView); err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
@@ -132,7 +217,13 @@ func DeleteTrackerHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		result, err := env.TrackerService.DeleteTracker(subject, id)
+		version, err := handler.GetVersionFromIfMatch(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		result, err := env.TrackerService.DeleteTracker(r.Context(), subject, id, version)
 		if err != nil || result == false {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -154,7 +245,7 @@ func FindTrackersBySubHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		trackers, err := env.TrackerService.FindByUser(subject)
+		trackers, err := env.TrackerService.FindByUser(r.Context(), subject)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -189,7 +280,7 @@ func FindTrackersByIDHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		tracker, err := env.TrackerService.FindByID(subject, id)
+		tracker, err := env.TrackerService.FindByID(r.Context(), subject, id)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -200,6 +291,7 @@ func FindTrackersByIDHandler(env *environment.Env) http.Handler {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("ETag", handler.ETag(tracker.Version))
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(trackerView); err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
@@ -27,7 +27,7 @@ func FindFindByTrackerIDHandler(env *environment.Env) http.Handler {
 			return
 		}
  
-		transfers, err := env.TransferService.FindTransfersForTrackerID(subject, id)
+		transfers, err := env.TransferService.FindTransfersForTrackerID(r.Context(), subject, id)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -2,11 +2,9 @@ package userhandler
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/TomPallister/godutch-api/api/environment"
@@ -42,7 +40,7 @@ func CreateUserHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		newUser, err := env.UserService.CreateUser(subject, user.User)
+		newUser, err := env.UserService.CreateUser(r.Context(), subject, user.User)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -88,12 +86,7 @@ func InviteUserHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		goDutchRootURL := os.Getenv("GODUTCH_URL")
-		if goDutchRootURL == "" {
-			fmt.Println("Environment variable GODUTCH_URL is undefined.")
-		}
-
-		newUser, err := env.UserService.InviteUser(subject, inviteUser, goDutchRootURL)
+		newUser, err := env.UserService.InviteUser(r.Context(), subject, inviteUser, env.GoDutchURL)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -139,8 +132,13 @@ func AcceptInviteUserHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		cryptoEmail, err := handler.GetCryptoEmailFromVars(r)
-		newUser, err := env.UserService.AcceptInvite(subject, inviteUser.User, cryptoEmail)
+		inviteToken, err := handler.GetInviteTokenFromVars(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
+			return
+		}
+
+		newUser, err := env.UserService.AcceptInvite(r.Context(), subject, inviteUser.User, inviteToken)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -175,7 +173,7 @@ func FindUserByIDHandler(env *environment.Env) http.Handler {
 			return
 		}
 
-		user, err := env.UserService.FindByID(subject, id)
+		user, err := env.UserService.FindByID(r.Context(), subject, id)
 		if err != nil {
 			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 			return
@@ -208,7 +206,7 @@ func FindUserBySubHandler(env *environment.Env) http.Handler {
 
 		if len(trackerID) <= 0 {
 
-			user, err := env.UserService.FindBySub(subject)
+			user, err := env.UserService.FindBySub(r.Context(), subject)
 			if err != nil {
 				handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 				return
@@ -230,7 +228,7 @@ func FindUserBySubHandler(env *environment.Env) http.Handler {
 		id, err := strconv.ParseInt(trackerID[0], 10, 64)
 		if id > 0 {
 
-			users, err := env.TrackerService.FindUsersForTracker(subject, id)
+			users, err := env.TrackerService.FindUsersForTracker(r.Context(), subject, id)
 			if err != nil {
 				handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, env.Logger, err)
 				return
@@ -0,0 +1,268 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/idempotencyrepository"
+	"github.com/codegangsta/negroni"
+)
+
+// Header is the request header clients put a unique key in to make retries safe
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on responses replayed from an earlier request
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength ...
+const MaxKeyLength = 255
+
+// DefaultRetention is how long keys are remembered when IDEMPOTENCY_RETENTION is not set
+const DefaultRetention = 24 * time.Hour
+
+// maxBodySize matches the limit the handlers read
+const maxBodySize = 1048576
+
+// storeTimeout bounds releasing or completing a key, which outlives the request's own deadline
+const storeTimeout = 5 * time.Second
+
+// ErrorInvalidKey ...
+var ErrorInvalidKey = domainerror.NewBadRequest("invalid_idempotency_key", "The Idempotency-Key header must be between 1 and 255 characters")
+
+// ErrorKeyReused is returned when a key is sent again with a different request
+var ErrorKeyReused = domainerror.NewConflict("idempotency_key_reused", "", "The Idempotency-Key has already been used for a different request")
+
+// ErrorRequestTooLarge is returned for bodies over maxBodySize, they cannot be fingerprinted whole
+var ErrorRequestTooLarge = domainerror.NewRequestEntityTooLarge("request_too_large", "The request body is larger than 1MB")
+
+// ErrorRequestInProgress is returned when a retry arrives before the first request has finished
+var ErrorRequestInProgress = domainerror.NewConflict("idempotency_request_in_progress", "", "A request with this Idempotency-Key is still being processed")
+
+// Middleware makes POST, PUT, PATCH and DELETE requests that carry an Idempotency-Key safe to retry.
+// The first response for a key is stored for the retention window and replayed to retries of the
+// same request, while reusing the key for a different request is a conflict. It must run after
+// authentication because keys belong to the subject that sent them.
+func Middleware(repository idempotencyrepository.IdempotencyRepository, subjectFinder infrastructure.SubjectFinder,
+	logger infrastructure.Logger, retention time.Duration) negroni.HandlerFunc {
+	return middleware(repository, subjectFinder, logger, retention, true)
+}
+
+// SecretMiddleware is Middleware for routes whose response shows a secret once, such as a new API
+// token or webhook. Only the status and headers are stored, so retries get the Location of what
+// was created and an empty body rather than a copy of the secret sitting in the database.
+func SecretMiddleware(repository idempotencyrepository.IdempotencyRepository, subjectFinder infrastructure.SubjectFinder,
+	logger infrastructure.Logger, retention time.Duration) negroni.HandlerFunc {
+	return middleware(repository, subjectFinder, logger, retention, false)
+}
+
+func middleware(repository idempotencyrepository.IdempotencyRepository, subjectFinder infrastructure.SubjectFinder,
+	logger infrastructure.Logger, retention time.Duration, storeBody bool) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		key := r.Header.Get(Header)
+		if key == "" || isMutating(r.Method) == false {
+			next(w, r)
+			return
+		}
+
+		if len(key) > MaxKeyLength {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, logger, ErrorInvalidKey)
+			return
+		}
+
+		subject, err := subjectFinder.FindSubject(r, logger)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, logger, err)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			handler.CreateErrorResponseAndLog(http.StatusRequestEntityTooLarge, w, logger, ErrorRequestTooLarge)
+			return
+		}
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, logger, err)
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		idempotencyKey := model.IdempotencyKey{
+			Key:         key,
+			Subject:     subject,
+			RequestHash: Fingerprint(r.Method, r.URL.RequestURI(), body),
+			DateCreated: now,
+		}
+
+		existing, err := repository.Get(r.Context(), subject, key)
+		switch {
+		case err == idempotencyrepository.ErrorNotFound:
+		case err != nil:
+			handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, logger, err)
+			return
+		case existing.DateCreated.Add(retention).Before(now):
+			if err := repository.Delete(r.Context(), subject, key); err != nil {
+				handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, logger, err)
+				return
+			}
+		default:
+			replay(w, logger, existing, idempotencyKey.RequestHash)
+			return
+		}
+
+		err = repository.Insert(r.Context(), idempotencyKey)
+		if err == idempotencyrepository.ErrorAlreadyExists {
+			handler.CreateErrorResponseAndLog(http.StatusConflict, w, logger, ErrorRequestInProgress)
+			return
+		}
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusInternalServerError, w, logger, err)
+			return
+		}
+
+		// the request's context is done once it times out or the client goes, which is exactly
+		// when the key must still be released or completed
+		storeContext := func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.WithoutCancel(r.Context()), storeTimeout)
+		}
+
+		// server errors and panics are not the client's fault, so let them retry with the same key.
+		// A panic is passed on to Recovery once the key is released.
+		succeeded := false
+		defer func() {
+			panicked := recover()
+			if succeeded == false {
+				ctx, cancel := storeContext()
+				if err := repository.Delete(ctx, subject, key); err != nil {
+					infrastructure.LoggerFrom(r.Context(), logger).Error("Could not release idempotency key", err)
+				}
+				cancel()
+			}
+			if panicked != nil {
+				panic(panicked)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			return
+		}
+		succeeded = true
+
+		completed := time.Now()
+		idempotencyKey.ResponseStatus = recorder.status
+		idempotencyKey.ResponseContentType = recorder.Header().Get("Content-Type")
+		idempotencyKey.ResponseLocation = recorder.Header().Get("Location")
+		idempotencyKey.ResponseETag = recorder.Header().Get("ETag")
+		if storeBody {
+			idempotencyKey.ResponseBody = recorder.body.Bytes()
+		} else {
+			idempotencyKey.ResponseContentType = ""
+		}
+		idempotencyKey.DateCompleted = &completed
+		ctx, cancel := storeContext()
+		defer cancel()
+		if err := repository.Complete(ctx, idempotencyKey); err != nil {
+			infrastructure.LoggerFrom(r.Context(), logger).Error("Could not store idempotent response", err)
+		}
+	}
+}
+
+// Fingerprint identifies a request so a reused key can be told apart from a retry
+func Fingerprint(method string, requestURI string, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, method)
+	io.WriteString(hash, " ")
+	io.WriteString(hash, requestURI)
+	io.WriteString(hash, "\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// DeleteExpired removes keys older than the retention window every interval until stop is closed
+func DeleteExpired(repository idempotencyrepository.IdempotencyRepository, logger infrastructure.Logger,
+	retention time.Duration, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := repository.DeleteCreatedBefore(context.Background(), time.Now().Add(-retention)); err != nil {
+				logger.Error("Could not delete expired idempotency keys", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, logger infrastructure.Logger, existing model.IdempotencyKey, requestHash string) {
+	if existing.RequestHash != requestHash {
+		handler.CreateErrorResponseAndLog(http.StatusConflict, w, logger, ErrorKeyReused)
+		return
+	}
+
+	if existing.IsCompleted() == false {
+		handler.CreateErrorResponseAndLog(http.StatusConflict, w, logger, ErrorRequestInProgress)
+		return
+	}
+
+	if existing.ResponseContentType != "" {
+		w.Header().Set("Content-Type", existing.ResponseContentType)
+	}
+	if existing.ResponseLocation != "" {
+		w.Header().Set("Location", existing.ResponseLocation)
+	}
+	if existing.ResponseETag != "" {
+		w.Header().Set("ETag", existing.ResponseETag)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(existing.ResponseStatus)
+	w.Write(existing.ResponseBody)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder passes the response through while keeping a copy to store
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.wroteHeader == false {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.wroteHeader = true
+	recorder.body.Write(b)
+	return recorder.ResponseWriter.Write(b)
+}
@@ -0,0 +1,237 @@
+package idempotency_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/idempotency"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/idempotencyrepository"
+	"github.com/codegangsta/negroni"
+)
+
+type headerSubjectFinder struct{}
+
+func (finder headerSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	return r.Header.Get("X-Subject"), nil
+}
+
+var repository *idempotencyrepository.InMemoryIdempotencyRepository
+var handled int
+var status int
+var secret bool
+var recorder *httptest.ResponseRecorder
+var cancelRequest bool
+var panics bool
+
+// cancelableRepository fails once the context is done, as the postgres repository does
+type cancelableRepository struct {
+	*idempotencyrepository.InMemoryIdempotencyRepository
+}
+
+func (repository cancelableRepository) Complete(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return repository.InMemoryIdempotencyRepository.Complete(ctx, idempotencyKey)
+}
+
+func (repository cancelableRepository) Delete(ctx context.Context, subject string, key string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return repository.InMemoryIdempotencyRepository.Delete(ctx, subject, key)
+}
+
+func TestRetryReplaysTheOriginalResponse(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	thenTheHandlerRan(1, t)
+	if recorder.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Fatal("expected the replayed header")
+	}
+}
+
+func TestReusingAKeyForADifferentRequestIsAConflict(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Lunch"}}`)
+	thenTheResponseIs(http.StatusConflict, "idempotency_key_reused", t)
+	thenTheHandlerRan(1, t)
+}
+
+func TestKeysBelongToTheSubject(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	whenISend("POST", "/api/v1/spends", "key-1", "laura", `{"spend":{"name":"Dinner"}}`)
+	thenTheHandlerRan(2, t)
+}
+
+func TestRequestsWithoutAKeyAreNotRemembered(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "", "tom", `{"spend":{"name":"Dinner"}}`)
+	whenISend("POST", "/api/v1/spends", "", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheHandlerRan(2, t)
+}
+
+func TestServerErrorsCanBeRetried(t *testing.T) {
+	givenIHaveCleanDependencies()
+	status = http.StatusInternalServerError
+	whenISend("DELETE", "/api/v1/spends/1", "key-1", "tom", ``)
+	status = http.StatusNoContent
+	whenISend("DELETE", "/api/v1/spends/1", "key-1", "tom", ``)
+	thenTheResponseIs(http.StatusNoContent, "", t)
+	thenTheHandlerRan(2, t)
+}
+
+func TestExpiredKeysAreForgotten(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	deleted, _ := repository.DeleteCreatedBefore(context.Background(), time.Now().Add(time.Minute))
+	if deleted != 1 {
+		t.Fatalf("expected 1 key to be deleted but %v were", deleted)
+	}
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Lunch"}}`)
+	thenTheHandlerRan(2, t)
+}
+
+func TestRetryReplaysTheLocationAndETag(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheHandlerRan(1, t)
+	if recorder.Header().Get("Location") != "/api/v1/spends/1" || recorder.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected the original Location and ETag but got %v", recorder.Header())
+	}
+}
+
+func TestSecretsAreNotStoredOrReplayed(t *testing.T) {
+	givenIHaveCleanDependencies()
+	secret = true
+	whenISend("POST", "/api/v1/apitokens", "key-1", "tom", `{"name":"CI"}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	stored, _ := repository.Get(context.Background(), "tom", "key-1")
+	if len(stored.ResponseBody) > 0 {
+		t.Fatalf("expected no body to be stored but got %s", stored.ResponseBody)
+	}
+	whenISend("POST", "/api/v1/apitokens", "key-1", "tom", `{"name":"CI"}`)
+	thenTheHandlerRan(1, t)
+	if recorder.Code != http.StatusCreated || recorder.Body.Len() > 0 || recorder.Header().Get("Location") != "/api/v1/spends/1" {
+		t.Fatalf("expected a 201 with the Location and no body but got %v %v %v", recorder.Code, recorder.Header(), recorder.Body.String())
+	}
+}
+
+func TestBodiesOverTheLimitAreTooLarge(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"name":"`+strings.Repeat("a", 1048576)+`"}`)
+	thenTheResponseIs(http.StatusRequestEntityTooLarge, "request_too_large", t)
+	thenTheHandlerRan(0, t)
+}
+
+func TestTimedOutRequestsCanBeRetried(t *testing.T) {
+	givenIHaveCleanDependencies()
+	cancelRequest = true
+	status = http.StatusServiceUnavailable
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	cancelRequest = false
+	status = http.StatusCreated
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	thenTheHandlerRan(2, t)
+}
+
+func TestResponsesAreStoredWhenTheClientHasGone(t *testing.T) {
+	givenIHaveCleanDependencies()
+	cancelRequest = true
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	cancelRequest = false
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	thenTheHandlerRan(1, t)
+}
+
+func TestPanicsCanBeRetried(t *testing.T) {
+	givenIHaveCleanDependencies()
+	panics = true
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusInternalServerError, "", t)
+	panics = false
+	whenISend("POST", "/api/v1/spends", "key-1", "tom", `{"spend":{"name":"Dinner"}}`)
+	thenTheResponseIs(http.StatusCreated, `{"id":1}`, t)
+	thenTheHandlerRan(2, t)
+}
+
+func givenIHaveCleanDependencies() {
+	repository = idempotencyrepository.NewInMemoryIdempotencyRepository()
+	handled = 0
+	status = http.StatusCreated
+	secret = false
+	cancelRequest = false
+	panics = false
+}
+
+func whenISend(method string, url string, key string, subject string, body string) {
+	idempotent := idempotency.Middleware(cancelableRepository{repository}, headerSubjectFinder{}, infrastructure.NilLogger{}, idempotency.DefaultRetention)
+	if secret {
+		idempotent = idempotency.SecretMiddleware(cancelableRepository{repository}, headerSubjectFinder{}, infrastructure.NilLogger{}, idempotency.DefaultRetention)
+	}
+	recovery := negroni.NewRecovery()
+	recovery.Logger = log.New(ioutil.Discard, "", 0)
+	recovery.PrintStack = false
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	middleware := negroni.New(
+		recovery,
+		idempotent,
+		negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled++
+			if cancelRequest {
+				cancel()
+			}
+			if panics {
+				panic("the handler failed")
+			}
+			if status == http.StatusCreated {
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.Header().Set("Location", "/api/v1/spends/1")
+				w.Header().Set("ETag", `"1"`)
+				w.WriteHeader(status)
+				w.Write([]byte(`{"id":1}`))
+				return
+			}
+			w.WriteHeader(status)
+		})),
+	)
+	r := httptest.NewRequest(method, url, strings.NewReader(body)).WithContext(ctx)
+	if key != "" {
+		r.Header.Set(idempotency.Header, key)
+	}
+	r.Header.Set("X-Subject", subject)
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+}
+
+func thenTheResponseIs(expectedStatus int, expectedBody string, t *testing.T) {
+	if recorder.Code != expectedStatus {
+		t.Fatalf("expected status %v but got %v", expectedStatus, recorder.Code)
+	}
+	if strings.Contains(recorder.Body.String(), expectedBody) == false {
+		t.Fatalf("expected %v in %v", expectedBody, recorder.Body.String())
+	}
+}
+
+func thenTheHandlerRan(times int, t *testing.T) {
+	if handled != times {
+		t.Fatalf("expected the handler to run %v times but it ran %v", times, handled)
+	}
+}
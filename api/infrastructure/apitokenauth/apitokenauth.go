@@ -0,0 +1,116 @@
+package apitokenauth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// TokenPrefix marks a bearer token as an API token rather than a JWT
+const TokenPrefix = "gdt_"
+
+// ErrorInsufficientScope ...
+var ErrorInsufficientScope = domainerror.NewForbidden("insufficient_scope", "The API token does not have the scope needed for this request")
+
+// ErrorCannotManageAPITokens ...
+var ErrorCannotManageAPITokens = domainerror.NewForbidden("api_token_cannot_manage_api_tokens", "API tokens cannot be used to manage API tokens")
+
+// apiTokensPath is the route API tokens are managed from, which needs a JWT
+const apiTokensPath = "/api/v1/apitokens"
+
+type contextKey int
+
+const subjectContextKey contextKey = 0
+
+// TokenChecker looks up the API token and the user it belongs to
+type TokenChecker interface {
+	CheckAPIToken(ctx context.Context, apiToken string) (model.APIToken, model.User, error)
+}
+
+// APITokenAuthenticator accepts API tokens and passes any other bearer token to the fallback
+type APITokenAuthenticator struct {
+	checker  TokenChecker
+	fallback infrastructure.Authenticator
+}
+
+// NewAPITokenAuthenticator ...
+func NewAPITokenAuthenticator(checker TokenChecker,
+	fallback infrastructure.Authenticator) *APITokenAuthenticator {
+	authenticator := APITokenAuthenticator{}
+	authenticator.checker = checker
+	authenticator.fallback = fallback
+	return &authenticator
+}
+
+// Authenticate ...
+func (authenticator *APITokenAuthenticator) Authenticate(r *http.Request) (*http.Request, error) {
+
+	apiToken, ok := bearerAPIToken(r)
+	if ok == false {
+		return authenticator.fallback.Authenticate(r)
+	}
+
+	if strings.HasPrefix(r.URL.Path, apiTokensPath) {
+		return r, ErrorCannotManageAPITokens
+	}
+
+	storedAPIToken, user, err := authenticator.checker.CheckAPIToken(r.Context(), apiToken)
+	if err != nil {
+		return r, err
+	}
+
+	if !storedAPIToken.HasScope(RequiredScope(r)) {
+		return r, ErrorInsufficientScope
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), subjectContextKey, user.AuthenticationID)), nil
+}
+
+// RequiredScope ...
+func RequiredScope(r *http.Request) string {
+	switch {
+	case r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS":
+		return model.APITokenScopeRead
+	case r.URL.Path == "/api/v1/graphql":
+		// queries are POSTed but the schema has no mutations
+		return model.APITokenScopeRead
+	case strings.HasPrefix(r.URL.Path, "/api/v1/spends"):
+		return model.APITokenScopeSpendsWrite
+	}
+	return model.APITokenScopeWrite
+}
+
+func bearerAPIToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" || !strings.HasPrefix(parts[1], TokenPrefix) {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// APITokenSubjectFinder finds the subject of an API token and asks the fallback for anything else
+type APITokenSubjectFinder struct {
+	fallback infrastructure.SubjectFinder
+}
+
+// NewAPITokenSubjectFinder ...
+func NewAPITokenSubjectFinder(fallback infrastructure.SubjectFinder) *APITokenSubjectFinder {
+
+	service := APITokenSubjectFinder{}
+	service.fallback = fallback
+
+	return &service
+}
+
+// FindSubject ...
+func (finder *APITokenSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	if sub, ok := r.Context().Value(subjectContextKey).(string); ok {
+		return sub, nil
+	}
+	return finder.fallback.FindSubject(r, logger)
+}
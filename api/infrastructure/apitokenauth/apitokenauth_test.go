@@ -0,0 +1,126 @@
+package apitokenauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/apitokenauth"
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+var errorFallback = errors.New("fallback")
+var authenticator *apitokenauth.APITokenAuthenticator
+var subjectFinder = apitokenauth.NewAPITokenSubjectFinder(&fakeSubjectFinder{})
+var subject string
+var err error
+
+type fakeChecker struct {
+	scopes []string
+}
+
+func (checker *fakeChecker) CheckAPIToken(ctx context.Context, apiToken string) (model.APIToken, model.User, error) {
+	if apiToken != "gdt_valid" {
+		return model.APIToken{}, model.User{}, errors.New("invalid")
+	}
+	return model.APIToken{ID: 1, UserID: 1, Scopes: checker.scopes}, model.User{ID: 1, AuthenticationID: "auth0|tom"}, nil
+}
+
+type fakeAuthenticator struct {
+}
+
+func (fake *fakeAuthenticator) Authenticate(r *http.Request) (*http.Request, error) {
+	return r, errorFallback
+}
+
+type fakeSubjectFinder struct {
+}
+
+func (fake *fakeSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	return "jwt-subject", nil
+}
+
+func TestReadScopeCanGet(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeRead)
+	whenIAuthenticate("GET", "/api/v1/spends?trackerId=1", "gdt_valid")
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestReadScopeCannotPost(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeRead)
+	whenIAuthenticate("POST", "/api/v1/spends", "gdt_valid")
+	thenTheErrorIs(apitokenauth.ErrorInsufficientScope, t)
+}
+
+func TestReadScopeCanPostGraphQLQueries(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeRead)
+	whenIAuthenticate("POST", "/api/v1/graphql", "gdt_valid")
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestSpendsWriteScopeCanPostSpends(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeSpendsWrite)
+	whenIAuthenticate("POST", "/api/v1/spends", "gdt_valid")
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestSpendsWriteScopeCannotPostTrackers(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeSpendsWrite)
+	whenIAuthenticate("POST", "/api/v1/trackers", "gdt_valid")
+	thenTheErrorIs(apitokenauth.ErrorInsufficientScope, t)
+}
+
+func TestWriteScopeCanPutSpends(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeWrite)
+	whenIAuthenticate("PUT", "/api/v1/spends/1", "gdt_valid")
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestAPITokensCannotManageAPITokens(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeRead, model.APITokenScopeWrite)
+	whenIAuthenticate("GET", "/api/v1/apitokens", "gdt_valid")
+	thenTheErrorIs(apitokenauth.ErrorCannotManageAPITokens, t)
+}
+
+func TestOtherTokensUseTheFallback(t *testing.T) {
+	givenIHaveATokenWithScopes(model.APITokenScopeRead)
+	whenIAuthenticate("GET", "/api/v1/trackers", "eyJhbGciOiJIUzI1NiJ9.e30.sig")
+	thenTheErrorIs(errorFallback, t)
+}
+
+func TestSubjectFinderUsesTheFallbackForOtherTokens(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/trackers", nil)
+	subject, err = subjectFinder.FindSubject(r, infrastructure.NilLogger{})
+	thenTheSubjectIs("jwt-subject", t)
+}
+
+func givenIHaveATokenWithScopes(scopes ...string) {
+	authenticator = apitokenauth.NewAPITokenAuthenticator(&fakeChecker{scopes: scopes}, &fakeAuthenticator{})
+}
+
+func whenIAuthenticate(method string, path string, bearer string) {
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("Authorization", "Bearer "+bearer)
+	r, err = authenticator.Authenticate(r)
+	if err == nil {
+		subject, err = subjectFinder.FindSubject(r, infrastructure.NilLogger{})
+	}
+}
+
+func thenTheSubjectIs(expected string, t *testing.T) {
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	if subject != expected {
+		t.Fatalf("Expected %v, got %v", expected, subject)
+	}
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("Expected %v, got %v", expected, err)
+	}
+}
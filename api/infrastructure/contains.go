@@ -18,4 +18,14 @@ func Ints64Contains(s []int64, e int64) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// StringsContains ...
+func StringsContains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,18 @@
 package infrastructure
 
 import (
+	"context"
 	"errors"
-	"os"
+	"net"
 
 	"github.com/sendgrid/sendgrid-go"
 )
 
+// SendGridAddress is dialled by CheckReachable
+const SendGridAddress = "api.sendgrid.com:443"
+
 // ErrorAPIKey ...
-var ErrorAPIKey = errors.New("Environment variable SENDGRID_API_KEY is undefined.")
+var ErrorAPIKey = errors.New("The SendGrid API key, SENDGRID_API_KEY, is not configured")
 
 // EmailService ...
 type EmailService interface {
@@ -18,23 +22,28 @@ type EmailService interface {
 
 // FakeEmailService ...
 type FakeEmailService struct {
+	LastToAddress string
+	LastText      string
 }
 
 // SendEmail ...
 func (fakeEmailService *FakeEmailService) SendEmail(toAddress string,
 	subject string, text string, fromAddress string, fromName string) (bool, error) {
 
+	fakeEmailService.LastToAddress = toAddress
+	fakeEmailService.LastText = text
 	return true, nil
 }
 
 // SendGridEmailService ...
 type SendGridEmailService struct {
+	APIKey string
 }
 
 // SendEmail ...
 func (sendGridEmailService *SendGridEmailService) SendEmail(toAddress string,
 	subject string, text string, fromAddress string, fromName string) (bool, error) {
-	sendgridKey := os.Getenv("SENDGRID_API_KEY")
+	sendgridKey := sendGridEmailService.APIKey
 	if sendgridKey == "" {
 		return false, ErrorAPIKey
 	}
@@ -50,3 +59,13 @@ func (sendGridEmailService *SendGridEmailService) SendEmail(toAddress string,
 	}
 	return true, nil
 }
+
+// CheckReachable dials SendGrid, it is an optional readiness check
+func (sendGridEmailService *SendGridEmailService) CheckReachable(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", SendGridAddress)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -5,59 +5,225 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"io"
+	"io/ioutil"
+	"strings"
 )
 
-var key = []byte("±as-per !up;rxv@ryqex£z$lyls^e")
+// ErrorNoKeys ...
+var ErrorNoKeys = errors.New("No encryption keys are configured")
 
-// Encrypt string to base64 crypto using AES
-func Encrypt(text string) string {
+// ErrorNoPrimaryKey ...
+var ErrorNoPrimaryKey = errors.New("The primary encryption key is not configured")
 
-	plaintext := []byte(text)
+// ErrorInvalidKey ...
+var ErrorInvalidKey = errors.New("Encryption keys must be 16, 24 or 32 bytes")
 
-	block, err := aes.NewCipher(key)
+// ErrorInvalidKeyID ...
+var ErrorInvalidKeyID = errors.New("Encryption key IDs cannot be empty or contain '.'")
+
+// ErrorUnknownKeyID ...
+var ErrorUnknownKeyID = errors.New("The ciphertext was encrypted with an unknown key")
+
+// ErrorMalformedCiphertext ...
+var ErrorMalformedCiphertext = errors.New("The ciphertext is malformed")
+
+// ErrorDecryptionFailed ...
+var ErrorDecryptionFailed = errors.New("The ciphertext could not be decrypted")
+
+// keyIDSeparator separates the key ID from the sealed data in ciphertext
+const keyIDSeparator = "."
+
+// KeyFile is the on disk format for encryption keys. Keys are base64 encoded.
+type KeyFile struct {
+	PrimaryKeyID string            `json:"primaryKeyId"`
+	Keys         map[string]string `json:"keys"`
+}
+
+// Keyring encrypts with its primary key and decrypts with any key it holds,
+// so old keys can be kept around to read existing ciphertext while rotating.
+type Keyring struct {
+	primaryKeyID string
+	aeads        map[string]cipher.AEAD
+}
+
+// NewKeyring ...
+func NewKeyring(primaryKeyID string, keys map[string][]byte) (*Keyring, error) {
+
+	if len(keys) == 0 {
+		return nil, ErrorNoKeys
+	}
+
+	keyring := Keyring{}
+	keyring.primaryKeyID = primaryKeyID
+	keyring.aeads = map[string]cipher.AEAD{}
+
+	for id, key := range keys {
+		if len(id) == 0 || strings.Contains(id, keyIDSeparator) {
+			return nil, ErrorInvalidKeyID
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, ErrorInvalidKey
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.aeads[id] = aead
+	}
+
+	if _, ok := keyring.aeads[primaryKeyID]; ok == false {
+		return nil, ErrorNoPrimaryKey
+	}
+
+	return &keyring, nil
+}
+
+// NewKeyringFromKeyFile ...
+func NewKeyringFromKeyFile(path string) (*Keyring, error) {
+
+	contents, err := ioutil.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	// The IV needs to be unique, but not secure. Therefore it's common to
-	// include it at the beginning of the ciphertext.
-	ciphertext := make([]byte, aes.BlockSize+len(plaintext))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		panic(err)
+	var keyFile KeyFile
+	if err := json.Unmarshal(contents, &keyFile); err != nil {
+		return nil, err
 	}
 
-	stream := cipher.NewCFBEncrypter(block, iv)
-	stream.XORKeyStream(ciphertext[aes.BlockSize:], plaintext)
+	return newKeyringFromEncodedKeys(keyFile.PrimaryKeyID, keyFile.Keys)
+}
+
+// KeySource names the key file or, if that is not set, keys in the form
+// "id:base64key,id:base64key" with PrimaryKeyID naming the key to encrypt with
+type KeySource struct {
+	KeyFile      string `yaml:"key_file" env:"ENCRYPTION_KEY_FILE"`
+	Keys         string `yaml:"keys" env:"ENCRYPTION_KEYS" secret:"true"`
+	PrimaryKeyID string `yaml:"primary_key_id" env:"ENCRYPTION_PRIMARY_KEY_ID"`
+}
 
-	// convert to base64
-	return base64.URLEncoding.EncodeToString(ciphertext)
+// Configured is true when a key file or keys are set
+func (source KeySource) Configured() bool {
+	return source.KeyFile != "" || source.Keys != ""
 }
 
-// Decrypt from base64 to decrypted string
-func Decrypt(cryptoText string) string {
+// NewKeyringFromKeySource loads the keys source names
+func NewKeyringFromKeySource(source KeySource) (*Keyring, error) {
+
+	if source.KeyFile != "" {
+		return NewKeyringFromKeyFile(source.KeyFile)
+	}
+
+	encodedKeys := map[string]string{}
+	for _, pair := range strings.Split(source.Keys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, ErrorInvalidKey
+		}
+		encodedKeys[parts[0]] = parts[1]
+	}
 
-	ciphertext, _ := base64.URLEncoding.DecodeString(cryptoText)
+	return newKeyringFromEncodedKeys(source.PrimaryKeyID, encodedKeys)
+}
 
-	block, err := aes.NewCipher(key)
+func newKeyringFromEncodedKeys(primaryKeyID string, encodedKeys map[string]string) (*Keyring, error) {
+
+	keys := map[string][]byte{}
+	for id, encodedKey := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, ErrorInvalidKey
+		}
+		keys[id] = key
+	}
+
+	return NewKeyring(primaryKeyID, keys)
+}
+
+// Encrypt string to url safe base64 using AES-GCM and the primary key.
+// The key ID is prepended so the right key can be found when decrypting.
+func (keyring *Keyring) Encrypt(text string) (string, error) {
+
+	aead := keyring.aeads[keyring.primaryKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// the key ID is passed as additional data so it cannot be swapped
+	sealed := aead.Seal(nonce, nonce, []byte(text), []byte(keyring.primaryKeyID))
+
+	return keyring.primaryKeyID + keyIDSeparator + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt from url safe base64 to decrypted string using whichever key
+// the ciphertext was encrypted with.
+func (keyring *Keyring) Decrypt(cryptoText string) (string, error) {
+
+	keyID, aead, sealed, err := keyring.open(cryptoText)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
-	// The IV needs to be unique, but not secure. Therefore it's common to
-	// include it at the beginning of the ciphertext.
-	if len(ciphertext) < aes.BlockSize {
-		panic("ciphertext too short")
+	nonce := sealed[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, sealed[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return "", ErrorDecryptionFailed
 	}
-	iv := ciphertext[:aes.BlockSize]
-	ciphertext = ciphertext[aes.BlockSize:]
 
-	stream := cipher.NewCFBDecrypter(block, iv)
+	return string(plaintext), nil
+}
 
-	// XORKeyStream can work in-place if the two arguments are the same.
-	stream.XORKeyStream(ciphertext, ciphertext)
+// NeedsRotation reports whether the ciphertext was encrypted with a key other than the primary key
+func (keyring *Keyring) NeedsRotation(cryptoText string) bool {
+	keyID, _, _, err := keyring.open(cryptoText)
+	return err != nil || keyID != keyring.primaryKeyID
+}
+
+// Rotate re-encrypts ciphertext with the primary key if it was encrypted with an older key
+func (keyring *Keyring) Rotate(cryptoText string) (string, error) {
+
+	plaintext, err := keyring.Decrypt(cryptoText)
+	if err != nil {
+		return "", err
+	}
+
+	if !keyring.NeedsRotation(cryptoText) {
+		return cryptoText, nil
+	}
+
+	return keyring.Encrypt(plaintext)
+}
+
+func (keyring *Keyring) open(cryptoText string) (string, cipher.AEAD, []byte, error) {
+
+	parts := strings.SplitN(cryptoText, keyIDSeparator, 2)
+	if len(parts) != 2 {
+		return "", nil, nil, ErrorMalformedCiphertext
+	}
+
+	aead, ok := keyring.aeads[parts[0]]
+	if ok == false {
+		return "", nil, nil, ErrorUnknownKeyID
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return "", nil, nil, ErrorMalformedCiphertext
+	}
 
-	return fmt.Sprintf("%s", ciphertext)
+	return parts[0], aead, sealed, nil
 }
@@ -0,0 +1,154 @@
+package encryption_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure/encryption"
+)
+
+var oldKey = []byte("0123456789abcdef0123456789abcdef")
+var newKey = []byte("fedcba9876543210fedcba9876543210")
+var keyring *encryption.Keyring
+var cryptoText string
+var plainText string
+var err error
+
+func TestCanEncryptAndDecrypt(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+	whenIDecrypt(t)
+	thenThePlainTextIs("tom@godutch.money", t)
+}
+
+func TestCanDecryptWithOldKeyAfterRotation(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+	givenIHaveAKeyring("2", map[string][]byte{"1": oldKey, "2": newKey}, t)
+	whenIDecrypt(t)
+	thenThePlainTextIs("tom@godutch.money", t)
+}
+
+func TestRotateReEncryptsWithPrimaryKey(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+	givenIHaveAKeyring("2", map[string][]byte{"1": oldKey, "2": newKey}, t)
+
+	if !keyring.NeedsRotation(cryptoText) {
+		t.Fatalf("Expected %v to need rotation", cryptoText)
+	}
+
+	cryptoText, err = keyring.Rotate(cryptoText)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+
+	givenIHaveAKeyring("2", map[string][]byte{"2": newKey}, t)
+	whenIDecrypt(t)
+	thenThePlainTextIs("tom@godutch.money", t)
+}
+
+func TestUnknownKeyIsRejected(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+	givenIHaveAKeyring("2", map[string][]byte{"2": newKey}, t)
+	whenITryToDecrypt(cryptoText)
+	thenTheErrorIs(encryption.ErrorUnknownKeyID, t)
+}
+
+func TestTamperedCiphertextIsRejected(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+	tampered := []byte(cryptoText)
+	last := len(tampered) - 2
+	if tampered[last] == 'A' {
+		tampered[last] = 'B'
+	} else {
+		tampered[last] = 'A'
+	}
+	whenITryToDecrypt(string(tampered))
+	thenTheErrorIs(encryption.ErrorDecryptionFailed, t)
+}
+
+func TestMalformedCiphertextIsRejected(t *testing.T) {
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	for _, malformed := range []string{"", "nokeyid", "1.", "1.%%%", "1.c2hvcnQ"} {
+		whenITryToDecrypt(malformed)
+		if err != encryption.ErrorMalformedCiphertext {
+			t.Fatalf("Expected %v for %q, got %v", encryption.ErrorMalformedCiphertext, malformed, err)
+		}
+	}
+}
+
+func TestInvalidKeyIsRejected(t *testing.T) {
+	_, err = encryption.NewKeyring("1", map[string][]byte{"1": []byte("short")})
+	thenTheErrorIs(encryption.ErrorInvalidKey, t)
+}
+
+func TestMissingPrimaryKeyIsRejected(t *testing.T) {
+	_, err = encryption.NewKeyring("2", map[string][]byte{"1": oldKey})
+	thenTheErrorIs(encryption.ErrorNoPrimaryKey, t)
+}
+
+func TestCanLoadKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keys.json")
+	contents := `{"primaryKeyId":"2","keys":{"1":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=","2":"ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="}}`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+
+	givenIHaveAKeyring("1", map[string][]byte{"1": oldKey}, t)
+	whenIEncrypt("tom@godutch.money", t)
+
+	keyring, err = encryption.NewKeyringFromKeyFile(path)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	whenIDecrypt(t)
+	thenThePlainTextIs("tom@godutch.money", t)
+}
+
+func givenIHaveAKeyring(primaryKeyID string, keys map[string][]byte, t *testing.T) {
+	keyring, err = encryption.NewKeyring(primaryKeyID, keys)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenIEncrypt(text string, t *testing.T) {
+	cryptoText, err = keyring.Encrypt(text)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenIDecrypt(t *testing.T) {
+	plainText, err = keyring.Decrypt(cryptoText)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func whenITryToDecrypt(text string) {
+	plainText, err = keyring.Decrypt(text)
+}
+
+func thenThePlainTextIs(expected string, t *testing.T) {
+	if plainText != expected {
+		t.Fatalf("Expected %v, got %v", expected, plainText)
+	}
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("Expected %v, got %v", expected, err)
+	}
+}
@@ -1,45 +1,40 @@
 package infrastructure
 
 import (
-	"errors"
+	"context"
 	"net/http"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
-	"github.com/gorilla/context"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 )
 
 // ErrorCouldNotFindSubjectClaim ...
-var ErrorCouldNotFindSubjectClaim = errors.New("Could not find subject claim")
+var ErrorCouldNotFindSubjectClaim = domainerror.NewUnauthorized("missing_subject", "Could not find subject claim")
 
 // ErrorSubjectWasNotAString ...
-var ErrorSubjectWasNotAString = errors.New("Subject was not a string")
+var ErrorSubjectWasNotAString = domainerror.NewUnauthorized("invalid_subject", "Subject was not a string")
 
 // SubjectFinder ...
 type SubjectFinder interface {
 	FindSubject(r *http.Request, logger Logger) (string, error)
 }
 
-// GorrilaAndJwtSubjectFinder ...
-type GorrilaAndJwtSubjectFinder struct {
+// Authenticator checks the credentials on a request and returns the request
+// with whatever the SubjectFinder needs stored on its context.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*http.Request, error)
 }
 
-// NewGorrilaAndJwtSubjectFinder ...
-func NewGorrilaAndJwtSubjectFinder() *GorrilaAndJwtSubjectFinder {
+type tokenExpiryContextKey struct{}
 
-	service := GorrilaAndJwtSubjectFinder{}
-
-	return &service
+// WithTokenExpiry returns ctx carrying when the request's credentials expire, so handlers that
+// outlive the request, such as event streams, can stop when they do
+func WithTokenExpiry(ctx context.Context, expires time.Time) context.Context {
+	return context.WithValue(ctx, tokenExpiryContextKey{}, expires)
 }
 
-// FindSubject ...
-func (g *GorrilaAndJwtSubjectFinder) FindSubject(r *http.Request, logger Logger) (string, error) {
-	u := context.Get(r, "user")
-	sub, ok := u.(*jwt.Token).Claims["sub"]
-	if ok == false {
-		return "", ErrorCouldNotFindSubjectClaim
-	}
-	if str, ok := sub.(string); ok {
-		return str, nil
-	}
-	return "", ErrorSubjectWasNotAString
+// TokenExpiryFrom returns when the request's credentials expire, ok is false if they do not
+func TokenExpiryFrom(ctx context.Context) (time.Time, bool) {
+	expires, ok := ctx.Value(tokenExpiryContextKey{}).(time.Time)
+	return expires, ok
 }
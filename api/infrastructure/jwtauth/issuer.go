@@ -0,0 +1,45 @@
+package jwtauth
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// HMACTokenIssuer signs HS256 tokens that a JwtAuthenticator using the same secret will accept
+type HMACTokenIssuer struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+// NewHMACTokenIssuer ...
+func NewHMACTokenIssuer(secret []byte, issuer string, ttl time.Duration) *HMACTokenIssuer {
+	tokenIssuer := HMACTokenIssuer{}
+	tokenIssuer.secret = secret
+	tokenIssuer.issuer = issuer
+	tokenIssuer.ttl = ttl
+	return &tokenIssuer
+}
+
+// Issue returns a signed token for the subject and when it expires
+func (tokenIssuer *HMACTokenIssuer) Issue(sub string) (string, time.Time, error) {
+
+	now := time.Now()
+	expires := now.Add(tokenIssuer.ttl)
+
+	token := jwt.New(jwt.SigningMethodHS256)
+	token.Claims["sub"] = sub
+	token.Claims["iat"] = now.Unix()
+	token.Claims["exp"] = expires.Unix()
+	if tokenIssuer.issuer != "" {
+		token.Claims["iss"] = tokenIssuer.issuer
+	}
+
+	signed, err := token.SignedString(tokenIssuer.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expires, nil
+}
@@ -0,0 +1,215 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrorMissingToken ...
+var ErrorMissingToken = errors.New("Authorization header with a bearer token is required")
+
+// ErrorInvalidToken ...
+var ErrorInvalidToken = errors.New("The token is invalid")
+
+// ErrorMissingExpiry ...
+var ErrorMissingExpiry = errors.New("The token does not have an expiry")
+
+// ErrorInvalidIssuer ...
+var ErrorInvalidIssuer = errors.New("The token issuer is invalid")
+
+// ErrorInvalidAudience ...
+var ErrorInvalidAudience = errors.New("The token audience is invalid")
+
+// ErrorNoKeySource ...
+var ErrorNoKeySource = errors.New("One of AUTH_JWKS_URL, AUTH_JWKS_FILE or AUTH0_CLIENT_SECRET must be set")
+
+type contextKey int
+
+const tokenContextKey contextKey = 0
+
+// Options ...
+type Options struct {
+	// Algorithms the tokens can be signed with e.g. RS256, ES256 or HS256
+	Algorithms []string
+	// KeyGetter finds the key used to verify a token
+	KeyGetter KeyGetter
+	// Issuer is checked against the iss claim when set
+	Issuer string
+	// Audience is checked against the aud claim when set
+	Audience string
+}
+
+// JwtAuthenticator verifies bearer tokens and stores them on the request context
+type JwtAuthenticator struct {
+	options Options
+	parser  jwt.Parser
+}
+
+// NewJwtAuthenticator ...
+func NewJwtAuthenticator(options Options) *JwtAuthenticator {
+	authenticator := JwtAuthenticator{}
+	authenticator.options = options
+	authenticator.parser = jwt.Parser{ValidMethods: options.Algorithms}
+	return &authenticator
+}
+
+// KeySource names where the keys that verify tokens come from, one of JWKSURL, JWKSFile or the
+// base64url ClientSecret
+type KeySource struct {
+	JWKSURL      string `yaml:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSFile     string `yaml:"jwks_file" env:"AUTH_JWKS_FILE"`
+	ClientSecret string `yaml:"client_secret" env:"AUTH0_CLIENT_SECRET" secret:"true"`
+	// Algorithms default to RS256 and ES256 for JWKS and HS256 for the client secret
+	Algorithms []string `yaml:"algorithms" env:"AUTH_ALGORITHMS"`
+	Issuer     string   `yaml:"issuer" env:"AUTH_ISSUER"`
+	Audience   string   `yaml:"audience" env:"AUTH_AUDIENCE"`
+}
+
+// NewJwtAuthenticatorFromKeySource builds an authenticator from the JWKS url, the JWKS file or,
+// if neither is set, the HS256 client secret
+func NewJwtAuthenticatorFromKeySource(source KeySource) (*JwtAuthenticator, error) {
+
+	options := Options{
+		Issuer:   source.Issuer,
+		Audience: source.Audience,
+	}
+
+	switch {
+	case source.JWKSURL != "":
+		options.KeyGetter = NewJWKSURLKeyGetter(source.JWKSURL, time.Hour)
+		options.Algorithms = []string{"RS256", "ES256"}
+	case source.JWKSFile != "":
+		keyGetter, err := NewJWKSFileKeyGetter(source.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		options.KeyGetter = keyGetter
+		options.Algorithms = []string{"RS256", "ES256"}
+	case source.ClientSecret != "":
+		secret, err := base64.URLEncoding.DecodeString(source.ClientSecret)
+		if err != nil {
+			return nil, err
+		}
+		options.KeyGetter = NewHMACKeyGetter(secret)
+		options.Algorithms = []string{"HS256"}
+	default:
+		return nil, ErrorNoKeySource
+	}
+
+	if len(source.Algorithms) > 0 {
+		options.Algorithms = source.Algorithms
+	}
+
+	return NewJwtAuthenticator(options), nil
+}
+
+// Authenticate ...
+func (authenticator *JwtAuthenticator) Authenticate(r *http.Request) (*http.Request, error) {
+
+	tokenString, err := bearerToken(r)
+	if err != nil {
+		return r, err
+	}
+
+	token, err := authenticator.parser.Parse(tokenString, authenticator.options.KeyGetter.GetKey)
+	if err != nil || !token.Valid {
+		return r, ErrorInvalidToken
+	}
+
+	expires, ok := expiry(token)
+	if ok == false {
+		return r, ErrorMissingExpiry
+	}
+
+	if authenticator.options.Issuer != "" && token.Claims["iss"] != authenticator.options.Issuer {
+		return r, ErrorInvalidIssuer
+	}
+
+	if authenticator.options.Audience != "" && !hasAudience(token, authenticator.options.Audience) {
+		return r, ErrorInvalidAudience
+	}
+
+	ctx := infrastructure.WithTokenExpiry(context.WithValue(r.Context(), tokenContextKey, token), expires)
+	return r.WithContext(ctx), nil
+}
+
+// expiry reads the exp claim, which the parser decodes as a float64 or, with UseNumber, a json.Number
+func expiry(token *jwt.Token) (time.Time, bool) {
+	switch exp := token.Claims["exp"].(type) {
+	case float64:
+		return time.Unix(int64(exp), 0), true
+	case json.Number:
+		seconds, err := exp.Int64()
+		return time.Unix(seconds, 0), err == nil
+	}
+	return time.Time{}, false
+}
+
+// TokenFromContext returns the token stored by Authenticate
+func TokenFromContext(ctx context.Context) (*jwt.Token, bool) {
+	token, ok := ctx.Value(tokenContextKey).(*jwt.Token)
+	return token, ok
+}
+
+func bearerToken(r *http.Request) (string, error) {
+
+	header := r.Header.Get("Authorization")
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" || len(parts[1]) == 0 {
+		return "", ErrorMissingToken
+	}
+
+	return parts[1], nil
+}
+
+func hasAudience(token *jwt.Token, audience string) bool {
+
+	switch aud := token.Claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// JwtSubjectFinder ...
+type JwtSubjectFinder struct {
+}
+
+// NewJwtSubjectFinder ...
+func NewJwtSubjectFinder() *JwtSubjectFinder {
+
+	service := JwtSubjectFinder{}
+
+	return &service
+}
+
+// FindSubject ...
+func (finder *JwtSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	token, ok := TokenFromContext(r.Context())
+	if ok == false {
+		return "", infrastructure.ErrorCouldNotFindSubjectClaim
+	}
+	sub, ok := token.Claims["sub"]
+	if ok == false {
+		return "", infrastructure.ErrorCouldNotFindSubjectClaim
+	}
+	if str, ok := sub.(string); ok {
+		return str, nil
+	}
+	return "", infrastructure.ErrorSubjectWasNotAString
+}
@@ -0,0 +1,281 @@
+package jwtauth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/jwtauth"
+	"github.com/dgrijalva/jwt-go"
+)
+
+var rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+var rotatedRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+var ecKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+var authenticator *jwtauth.JwtAuthenticator
+var authenticated *http.Request
+var subject string
+var err error
+
+func TestCanAuthenticateRS256FromJWKSFile(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestCanAuthenticateES256FromJWKSFile(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	whenIAuthenticate(signed(jwt.SigningMethodES256, "ec", ecKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestExpiredTokenIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, claims, t))
+	thenTheErrorIs(jwtauth.ErrorInvalidToken, t)
+}
+
+func TestTheTokensExpiryIsKeptOnTheRequest(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	claims := validClaims()
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	claims["exp"] = expires.Unix()
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, claims, t))
+	thenTheSubjectIs("auth0|tom", t)
+	if actual, ok := infrastructure.TokenExpiryFrom(authenticated.Context()); !ok || !actual.Equal(expires) {
+		t.Fatalf("Expected %v, got %v", expires, actual)
+	}
+}
+
+func TestTokenWithoutExpiryIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	claims := validClaims()
+	delete(claims, "exp")
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, claims, t))
+	thenTheErrorIs(jwtauth.ErrorMissingExpiry, t)
+}
+
+func TestWrongIssuerIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	claims := validClaims()
+	claims["iss"] = "https://someone-else/"
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, claims, t))
+	thenTheErrorIs(jwtauth.ErrorInvalidIssuer, t)
+}
+
+func TestWrongAudienceIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	claims := validClaims()
+	claims["aud"] = []string{"someone-else"}
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, claims, t))
+	thenTheErrorIs(jwtauth.ErrorInvalidAudience, t)
+}
+
+func TestUnknownKeyIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rotated", rotatedRSAKey, validClaims(), t))
+	thenTheErrorIs(jwtauth.ErrorInvalidToken, t)
+}
+
+func TestHMACTokenIsRejectedWhenExpectingRS256(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	whenIAuthenticate(signed(jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims(), t))
+	thenTheErrorIs(jwtauth.ErrorInvalidToken, t)
+}
+
+func TestMissingTokenIsRejected(t *testing.T) {
+	givenIHaveAnAuthenticatorForAJWKSFile(t)
+	whenIAuthenticate("")
+	thenTheErrorIs(jwtauth.ErrorMissingToken, t)
+}
+
+func TestCanAuthenticateHS256(t *testing.T) {
+	authenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"HS256"},
+		KeyGetter:  jwtauth.NewHMACKeyGetter([]byte("secret")),
+	})
+	whenIAuthenticate(signed(jwt.SigningMethodHS256, "", []byte("secret"), validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestJWKSURLIsCachedAndPicksUpRotatedKeys(t *testing.T) {
+	keySet := jwks(map[string]interface{}{"rsa": &rsaKey.PublicKey})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(keySet)
+	}))
+	defer server.Close()
+
+	authenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"RS256"},
+		KeyGetter:  jwtauth.NewJWKSURLKeyGetter(server.URL, time.Hour),
+	})
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+	if requests != 1 {
+		t.Fatalf("Expected the JWKS to be fetched once, it was fetched %v times", requests)
+	}
+
+	keySet = jwks(map[string]interface{}{"rotated": &rotatedRSAKey.PublicKey})
+	authenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"RS256"},
+		KeyGetter:  jwtauth.NewJWKSURLKeyGetter(server.URL, 0),
+	})
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rotated", rotatedRSAKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+}
+
+func TestStaleJWKSKeysAreUsedWhileTheIdentityProviderIsDown(t *testing.T) {
+	keySet := jwks(map[string]interface{}{"rsa": &rsaKey.PublicKey})
+	var requests int32
+	down := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Write(keySet)
+			return
+		}
+		<-down
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	authenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"RS256"},
+		KeyGetter:  jwtauth.NewJWKSURLKeyGetter(server.URL, 0),
+	})
+	whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+	thenTheSubjectIs("auth0|tom", t)
+
+	// the refresh hangs until down is closed, requests carry on with the keys already fetched
+	for i := 0; i < 5; i++ {
+		whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+		thenTheSubjectIs("auth0|tom", t)
+	}
+	close(down)
+	time.Sleep(100 * time.Millisecond)
+
+	// the failed refresh backs off rather than being retried on every request
+	for i := 0; i < 5; i++ {
+		whenIAuthenticate(signed(jwt.SigningMethodRS256, "rsa", rsaKey, validClaims(), t))
+		thenTheSubjectIs("auth0|tom", t)
+	}
+	if fetched := atomic.LoadInt32(&requests); fetched != 2 {
+		t.Fatalf("Expected the JWKS to be fetched twice, it was fetched %v times", fetched)
+	}
+}
+
+func givenIHaveAnAuthenticatorForAJWKSFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtauth")
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jwks.json")
+	keySet := jwks(map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+	if err := ioutil.WriteFile(path, keySet, 0600); err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+
+	keyGetter, err := jwtauth.NewJWKSFileKeyGetter(path)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+
+	authenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+		Algorithms: []string{"RS256", "ES256"},
+		KeyGetter:  keyGetter,
+		Issuer:     "https://godutch.eu.auth0.com/",
+		Audience:   "godutch-api",
+	})
+}
+
+func whenIAuthenticate(tokenString string) {
+	r := httptest.NewRequest("GET", "/api/v1/trackers", nil)
+	if tokenString != "" {
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+	}
+	authenticated, err = authenticator.Authenticate(r)
+	if err == nil {
+		subject, err = jwtauth.NewJwtSubjectFinder().FindSubject(authenticated, infrastructure.NilLogger{})
+	}
+}
+
+func thenTheSubjectIs(expected string, t *testing.T) {
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	if subject != expected {
+		t.Fatalf("Expected %v, got %v", expected, subject)
+	}
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("Expected %v, got %v", expected, err)
+	}
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "auth0|tom",
+		"iss": "https://godutch.eu.auth0.com/",
+		"aud": "godutch-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func signed(method jwt.SigningMethod, kid string, key interface{}, claims map[string]interface{}, t *testing.T) string {
+	token := jwt.New(method)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	token.Claims = claims
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+	return tokenString
+}
+
+func jwks(keys map[string]interface{}) []byte {
+	keySet := jwtauth.JSONWebKeySet{}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			keySet.Keys = append(keySet.Keys, jwtauth.JSONWebKey{
+				KeyType: "RSA", KeyID: kid, Use: "sig",
+				N: encode(k.N), E: encode(big.NewInt(int64(k.E))),
+			})
+		case *ecdsa.PublicKey:
+			keySet.Keys = append(keySet.Keys, jwtauth.JSONWebKey{
+				KeyType: "EC", KeyID: kid, Use: "sig", Curve: "P-256",
+				X: encode(k.X), Y: encode(k.Y),
+			})
+		}
+	}
+	b, _ := json.Marshal(keySet)
+	return b
+}
+
+func encode(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
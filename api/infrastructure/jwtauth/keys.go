@@ -0,0 +1,341 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrorUnknownKeyID ...
+var ErrorUnknownKeyID = errors.New("The token was signed with an unknown key")
+
+// ErrorUnsupportedKey ...
+var ErrorUnsupportedKey = errors.New("The JSON web key type is not supported")
+
+// minimumRefreshInterval stops tokens with made up key IDs forcing a JWKS download on every request
+const minimumRefreshInterval = time.Minute
+
+// KeyGetter ...
+type KeyGetter interface {
+	GetKey(token *jwt.Token) (interface{}, error)
+}
+
+// HMACKeyGetter ...
+type HMACKeyGetter struct {
+	secret []byte
+}
+
+// NewHMACKeyGetter ...
+func NewHMACKeyGetter(secret []byte) *HMACKeyGetter {
+	keyGetter := HMACKeyGetter{}
+	keyGetter.secret = secret
+	return &keyGetter
+}
+
+// GetKey ...
+func (keyGetter *HMACKeyGetter) GetKey(token *jwt.Token) (interface{}, error) {
+	return keyGetter.secret, nil
+}
+
+// JSONWebKey ...
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// JSONWebKeySet ...
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKSURLKeyGetter downloads keys from a JWKS endpoint, caching them for the ttl.
+// Tokens with a key ID that is not in the cache cause a refresh so rotated keys are picked up.
+// Refreshes run outside the lock, one at a time, and when one fails the cached keys are kept
+// and the next attempt backs off, so an identity provider outage does not reject every request.
+type JWKSURLKeyGetter struct {
+	url        string
+	ttl        time.Duration
+	client     *http.Client
+	mutex      sync.Mutex
+	keys       map[string]interface{}
+	fetchedAt  time.Time
+	failures   int
+	retryAt    time.Time
+	lastError  error
+	refreshing chan struct{}
+}
+
+// NewJWKSURLKeyGetter ...
+func NewJWKSURLKeyGetter(url string, ttl time.Duration) *JWKSURLKeyGetter {
+	keyGetter := JWKSURLKeyGetter{}
+	keyGetter.url = url
+	keyGetter.ttl = ttl
+	keyGetter.client = &http.Client{Timeout: 10 * time.Second}
+	return &keyGetter
+}
+
+// GetKey ...
+func (keyGetter *JWKSURLKeyGetter) GetKey(token *jwt.Token) (interface{}, error) {
+
+	keys, err := keyGetter.currentKeys(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := findKey(keys, token)
+	if err != ErrorUnknownKeyID {
+		return key, err
+	}
+
+	keys, refreshErr := keyGetter.currentKeys(true)
+	if refreshErr != nil {
+		return nil, err
+	}
+	return findKey(keys, token)
+}
+
+// currentKeys returns the cached keys, starting a refresh when they are older than the ttl.
+// Callers only wait for the refresh when there are no keys yet or the token's key is unknown,
+// otherwise the stale keys are used while it runs.
+func (keyGetter *JWKSURLKeyGetter) currentKeys(unknownKey bool) (map[string]interface{}, error) {
+	keyGetter.mutex.Lock()
+
+	keys := keyGetter.keys
+	age := time.Since(keyGetter.fetchedAt)
+	due := keys == nil || age > keyGetter.ttl
+	if unknownKey {
+		due = age > minimumRefreshInterval
+	}
+
+	if due == false || time.Now().Before(keyGetter.retryAt) {
+		err := keyGetter.lastError
+		keyGetter.mutex.Unlock()
+		if keys == nil {
+			return nil, err
+		}
+		return keys, nil
+	}
+
+	done := keyGetter.refreshing
+	if done == nil {
+		done = make(chan struct{})
+		keyGetter.refreshing = done
+		go keyGetter.refresh(done)
+	}
+	keyGetter.mutex.Unlock()
+
+	if keys != nil && unknownKey == false {
+		return keys, nil
+	}
+
+	<-done
+
+	keyGetter.mutex.Lock()
+	defer keyGetter.mutex.Unlock()
+	if keyGetter.keys == nil {
+		return nil, keyGetter.lastError
+	}
+	return keyGetter.keys, nil
+}
+
+// refresh downloads the keys and closes done when it has finished, whether or not it worked
+func (keyGetter *JWKSURLKeyGetter) refresh(done chan struct{}) {
+
+	keys, err := keyGetter.fetch()
+
+	keyGetter.mutex.Lock()
+	defer keyGetter.mutex.Unlock()
+
+	if err != nil {
+		keyGetter.failures++
+		keyGetter.retryAt = time.Now().Add(refreshBackoff(keyGetter.failures))
+		keyGetter.lastError = err
+	} else {
+		keyGetter.keys = keys
+		keyGetter.fetchedAt = time.Now()
+		keyGetter.failures = 0
+		keyGetter.retryAt = time.Time{}
+		keyGetter.lastError = nil
+	}
+
+	keyGetter.refreshing = nil
+	close(done)
+}
+
+// refreshBackoff doubles from a second after each failed refresh, up to the minimum refresh interval
+func refreshBackoff(failures int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < failures && backoff < minimumRefreshInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > minimumRefreshInterval {
+		return minimumRefreshInterval
+	}
+	return backoff
+}
+
+func (keyGetter *JWKSURLKeyGetter) fetch() (map[string]interface{}, error) {
+
+	response, err := keyGetter.client.Get(keyGetter.url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Could not get JWKS from %v, status %v", keyGetter.url, response.StatusCode)
+	}
+
+	return readKeySet(io.LimitReader(response.Body, 1048576))
+}
+
+// JWKSFileKeyGetter reads keys from a local JWKS file, useful for testing without an identity provider
+type JWKSFileKeyGetter struct {
+	keys map[string]interface{}
+}
+
+// NewJWKSFileKeyGetter ...
+func NewJWKSFileKeyGetter(path string) (*JWKSFileKeyGetter, error) {
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseKeySet(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	keyGetter := JWKSFileKeyGetter{}
+	keyGetter.keys = keys
+	return &keyGetter, nil
+}
+
+// GetKey ...
+func (keyGetter *JWKSFileKeyGetter) GetKey(token *jwt.Token) (interface{}, error) {
+	return findKey(keyGetter.keys, token)
+}
+
+func findKey(keys map[string]interface{}, token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	// tokens without a key ID are fine as long as there is only one key to choose from
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+
+	return nil, ErrorUnknownKeyID
+}
+
+func readKeySet(r io.Reader) (map[string]interface{}, error) {
+
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKeySet(contents)
+}
+
+func parseKeySet(contents []byte) (map[string]interface{}, error) {
+
+	var keySet JSONWebKeySet
+	if err := json.Unmarshal(contents, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range keySet.Keys {
+
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err == ErrorUnsupportedKey {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+// PublicKey converts the JSON web key to an *rsa.PublicKey or *ecdsa.PublicKey
+func (jwk JSONWebKey) PublicKey() (interface{}, error) {
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrorUnsupportedKey
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, ErrorUnsupportedKey
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -1,33 +1,174 @@
 package infrastructure
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
 
-// Logger ...
+// ErrorInvalidLogLevel ...
+var ErrorInvalidLogLevel = errors.New("LOG_LEVEL must be one of debug, info, warn or error")
+
+// ErrorInvalidLogFormat ...
+var ErrorInvalidLogFormat = errors.New("LOG_FORMAT must be json or text")
+
+// Logger writes levelled log lines. fields are alternating keys and values, such as
+// logger.Info("Created spend", "spend_id", spend.ID, "tracker_id", spend.TrackerID).
 type Logger interface {
-	Info(message string)
-	Error(message string, err error)
+	Debug(message string, fields ...interface{})
+	Info(message string, fields ...interface{})
+	Warn(message string, fields ...interface{})
+	Error(message string, err error, fields ...interface{})
+	// With returns a logger that adds fields to every line
+	With(fields ...interface{}) Logger
+}
+
+// StructuredLogger ...
+type StructuredLogger struct {
+	logger *slog.Logger
+}
+
+// NewStructuredLogger writes lines at level and above to w, as JSON objects or key=value text
+func NewStructuredLogger(w io.Writer, level string, format string) (*StructuredLogger, error) {
+
+	var slogLevel slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "", "info":
+		slogLevel = slog.LevelInfo
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		return nil, ErrorInvalidLogLevel
+	}
+
+	options := &slog.HandlerOptions{Level: slogLevel}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, ErrorInvalidLogFormat
+	}
+
+	logger := StructuredLogger{}
+	logger.logger = slog.New(handler)
+	return &logger, nil
+}
+
+// Debug ...
+func (logger *StructuredLogger) Debug(message string, fields ...interface{}) {
+	logger.logger.Debug(message, fields...)
+}
+
+// Info ...
+func (logger *StructuredLogger) Info(message string, fields ...interface{}) {
+	logger.logger.Info(message, fields...)
+}
+
+// Warn ...
+func (logger *StructuredLogger) Warn(message string, fields ...interface{}) {
+	logger.logger.Warn(message, fields...)
+}
+
+// Error adds err to the line as the error field
+func (logger *StructuredLogger) Error(message string, err error, fields ...interface{}) {
+	if err != nil {
+		fields = append(fields, "error", err.Error())
+	}
+	logger.logger.Error(message, fields...)
+}
+
+// With ...
+func (logger *StructuredLogger) With(fields ...interface{}) Logger {
+	return &StructuredLogger{logger: logger.logger.With(fields...)}
 }
 
-// ConsoleLogger ...
+// ConsoleLogger prints every level, it is handy in tests
 type ConsoleLogger struct {
+	fields []interface{}
+}
+
+// Debug ...
+func (logger ConsoleLogger) Debug(message string, fields ...interface{}) {
+	logger.print("DEBUG", message, fields)
 }
 
 // Info ...
-func (logger ConsoleLogger) Info(message string) {
-	fmt.Println(message)
+func (logger ConsoleLogger) Info(message string, fields ...interface{}) {
+	logger.print("INFO", message, fields)
+}
+
+// Warn ...
+func (logger ConsoleLogger) Warn(message string, fields ...interface{}) {
+	logger.print("WARN", message, fields)
 }
 
-func (logger ConsoleLogger) Error(message string, err error) {
-	fmt.Println(message, err)
+func (logger ConsoleLogger) Error(message string, err error, fields ...interface{}) {
+	logger.print("ERROR", message, append(fields, "error", err))
+}
+
+// With ...
+func (logger ConsoleLogger) With(fields ...interface{}) Logger {
+	return ConsoleLogger{fields: append(append([]interface{}{}, logger.fields...), fields...)}
+}
+
+func (logger ConsoleLogger) print(level string, message string, fields []interface{}) {
+	line := level + " " + message
+	all := append(append([]interface{}{}, logger.fields...), fields...)
+	for i := 0; i+1 < len(all); i += 2 {
+		line += fmt.Sprintf(" %v=%v", all[i], all[i+1])
+	}
+	fmt.Println(line)
 }
 
 // NilLogger ...
 type NilLogger struct {
 }
 
+// Debug ...
+func (logger NilLogger) Debug(message string, fields ...interface{}) {
+}
+
 // Info ...
-func (logger NilLogger) Info(message string) {
+func (logger NilLogger) Info(message string, fields ...interface{}) {
+}
+
+// Warn ...
+func (logger NilLogger) Warn(message string, fields ...interface{}) {
+}
+
+func (logger NilLogger) Error(message string, err error, fields ...interface{}) {
+}
+
+// With ...
+func (logger NilLogger) With(fields ...interface{}) Logger {
+	return logger
+}
+
+type loggerContextKey struct{}
+
+// WithLogger returns ctx carrying logger, the request id middleware uses it to hand every layer a
+// logger that adds the request's id to each line
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
 }
 
-func (logger NilLogger) Error(message string, err error) {
+// LoggerFrom returns the logger on ctx, or fallback outside a request
+func LoggerFrom(ctx context.Context, fallback Logger) Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey{}).(Logger); ok {
+			return logger
+		}
+	}
+	return fallback
 }
@@ -0,0 +1,60 @@
+package infrastructure_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+)
+
+func TestLinesBelowTheLevelAreDropped(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger, err := infrastructure.NewStructuredLogger(output, "warn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn", "tracker_id", 1)
+	logger.Error("error", errors.New("boom"))
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %v", output.String())
+	}
+	if !strings.Contains(lines[0], `"tracker_id":1`) || !strings.Contains(lines[1], `"error":"boom"`) {
+		t.Fatalf("expected the fields on each line but got %v", output.String())
+	}
+}
+
+func TestWithAddsFieldsToEveryLine(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger, _ := infrastructure.NewStructuredLogger(output, "debug", "text")
+	logger.With("request_id", "abc").Debug("one")
+	if !strings.Contains(output.String(), "request_id=abc") {
+		t.Fatalf("expected request_id=abc but got %v", output.String())
+	}
+}
+
+func TestUnknownLevelsAndFormatsAreRefused(t *testing.T) {
+	if _, err := infrastructure.NewStructuredLogger(&bytes.Buffer{}, "loud", "json"); err != infrastructure.ErrorInvalidLogLevel {
+		t.Fatalf("expected %v but got %v", infrastructure.ErrorInvalidLogLevel, err)
+	}
+	if _, err := infrastructure.NewStructuredLogger(&bytes.Buffer{}, "info", "xml"); err != infrastructure.ErrorInvalidLogFormat {
+		t.Fatalf("expected %v but got %v", infrastructure.ErrorInvalidLogFormat, err)
+	}
+}
+
+func TestLoggerFromFallsBackOutsideARequest(t *testing.T) {
+	fallback := infrastructure.NilLogger{}
+	if infrastructure.LoggerFrom(context.Background(), fallback) != fallback {
+		t.Fatal("expected the fallback logger")
+	}
+	ctx := infrastructure.WithLogger(context.Background(), infrastructure.ConsoleLogger{})
+	if _, ok := infrastructure.LoggerFrom(ctx, fallback).(infrastructure.ConsoleLogger); !ok {
+		t.Fatal("expected the logger on the context")
+	}
+}
@@ -0,0 +1,70 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+)
+
+// ContentType is the media type of a JSON Merge Patch document
+const ContentType = "application/merge-patch+json"
+
+// ErrorInvalidPatch ...
+var ErrorInvalidPatch = domainerror.NewBadRequest("invalid_merge_patch", "The merge patch is not valid JSON")
+
+// ErrorInvalidTarget ...
+var ErrorInvalidTarget = domainerror.NewInternal("invalid_merge_patch_target", "The document being patched is not valid JSON")
+
+// Apply applies patch to target as described by RFC 7396 and returns the patched document.
+// Members set to null in the patch are removed, objects are merged and anything else replaces the target's value.
+func Apply(target []byte, patch []byte) ([]byte, error) {
+
+	var patchValue interface{}
+	if err := decode(patch, &patchValue); err != nil {
+		return nil, ErrorInvalidPatch
+	}
+
+	var targetValue interface{}
+	if err := decode(target, &targetValue); err != nil {
+		return nil, ErrorInvalidTarget
+	}
+
+	return json.Marshal(merge(targetValue, patchValue))
+}
+
+func merge(target interface{}, patch interface{}) interface{} {
+
+	patchObject, ok := patch.(map[string]interface{})
+	if ok == false {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if ok == false {
+		targetObject = map[string]interface{}{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// decode keeps numbers as they were written so large ids and money values survive the round trip
+func decode(document []byte, value *interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	if err := decoder.Decode(value); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return ErrorInvalidPatch
+	}
+	return nil
+}
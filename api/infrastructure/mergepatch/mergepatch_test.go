@@ -0,0 +1,65 @@
+package mergepatch_test
+
+import (
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure/mergepatch"
+)
+
+var patched []byte
+var err error
+
+// the examples from appendix A of RFC 7396
+func TestAppliesTheRFCExamples(t *testing.T) {
+	examples := []struct {
+		target   string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, example := range examples {
+		whenIApplyThePatch(example.target, example.patch)
+		thenTheDocumentIs(example.expected, t)
+	}
+}
+
+func TestNumbersKeepTheirPrecision(t *testing.T) {
+	whenIApplyThePatch(`{"id":9007199254740993,"value":"10.99"}`, `{"value":"12.50"}`)
+	thenTheDocumentIs(`{"id":9007199254740993,"value":"12.50"}`, t)
+}
+
+func TestInvalidPatchesAreRejected(t *testing.T) {
+	whenIApplyThePatch(`{"a":"b"}`, `{"a":`)
+	if err != mergepatch.ErrorInvalidPatch {
+		t.Fatalf("Expected: %v, Received: %v", mergepatch.ErrorInvalidPatch, err)
+	}
+}
+
+func whenIApplyThePatch(target string, patch string) {
+	patched, err = mergepatch.Apply([]byte(target), []byte(patch))
+}
+
+func thenTheDocumentIs(expected string, t *testing.T) {
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if string(patched) != expected {
+		t.Fatalf("Expected: %v, Received: %v", expected, string(patched))
+	}
+}
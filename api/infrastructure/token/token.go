@@ -0,0 +1,44 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// tokenLength is the number of random bytes in a token before encoding
+const tokenLength = 32
+
+// ErrorMalformedToken ...
+var ErrorMalformedToken = errors.New("The token is malformed")
+
+// New returns a random url safe token suitable for sending to a user
+func New() (string, error) {
+
+	b := make([]byte, tokenLength)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the value that should be stored in place of the token
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate checks the token looks like one created by New
+func Validate(token string) error {
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != tokenLength {
+		return ErrorMalformedToken
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package token_test
+
+import (
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure/token"
+)
+
+var newToken string
+var err error
+
+func TestCanCreateToken(t *testing.T) {
+	whenICreateAToken(t)
+	thenTheTokenIsValid(t)
+}
+
+func TestTokensAreUnique(t *testing.T) {
+	whenICreateAToken(t)
+	first := newToken
+	whenICreateAToken(t)
+	if first == newToken {
+		t.Fatalf("Expected different tokens, got %v twice", newToken)
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	whenICreateAToken(t)
+	if token.Hash(newToken) != token.Hash(newToken) {
+		t.Fatalf("Expected the same hash for %v", newToken)
+	}
+	if token.Hash(newToken) == newToken {
+		t.Fatalf("The hash should not be the token")
+	}
+}
+
+func TestMalformedTokenIsRejected(t *testing.T) {
+	for _, malformed := range []string{"", "not a token", "c2hvcnQ", "%%%"} {
+		if err = token.Validate(malformed); err != token.ErrorMalformedToken {
+			t.Fatalf("Expected %v for %q, got %v", token.ErrorMalformedToken, malformed, err)
+		}
+	}
+}
+
+func whenICreateAToken(t *testing.T) {
+	newToken, err = token.New()
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
+
+func thenTheTokenIsValid(t *testing.T) {
+	if err = token.Validate(newToken); err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+}
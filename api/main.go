@@ -1,11 +1,21 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/TomPallister/godutch-api/api/config"
+	"github.com/TomPallister/godutch-api/api/domain/apitokenservice"
+	"github.com/TomPallister/godutch-api/api/domain/localauthservice"
 	"github.com/TomPallister/godutch-api/api/domain/spendservice"
 	"github.com/TomPallister/godutch-api/api/domain/spendsummaryservice"
 	"github.com/TomPallister/godutch-api/api/domain/trackerservice"
@@ -14,92 +24,297 @@ import (
 	"github.com/TomPallister/godutch-api/api/domain/validation/spendvalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/trackervalidation"
 	"github.com/TomPallister/godutch-api/api/domain/validation/uservalidation"
+	"github.com/TomPallister/godutch-api/api/domain/webhookservice"
 	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/grpcserver"
+	"github.com/TomPallister/godutch-api/api/idempotency"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/infrastructure/apitokenauth"
+	"github.com/TomPallister/godutch-api/api/infrastructure/encryption"
+	"github.com/TomPallister/godutch-api/api/infrastructure/jwtauth"
+	"github.com/TomPallister/godutch-api/api/metrics"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
 	"github.com/TomPallister/godutch-api/api/repository"
+	"github.com/TomPallister/godutch-api/api/repository/apitokenrepository"
+	"github.com/TomPallister/godutch-api/api/repository/idempotencyrepository"
+	"github.com/TomPallister/godutch-api/api/repository/inviterepository"
+	"github.com/TomPallister/godutch-api/api/repository/localcredentialrepository"
+	"github.com/TomPallister/godutch-api/api/repository/ratelimitrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendrepository"
 	"github.com/TomPallister/godutch-api/api/repository/spendsummaryrepository"
 	"github.com/TomPallister/godutch-api/api/repository/trackerrepository"
 	"github.com/TomPallister/godutch-api/api/repository/transferrepository"
 	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookdeliveryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookrepository"
+	"github.com/TomPallister/godutch-api/api/requestid"
 	"github.com/TomPallister/godutch-api/api/route"
+	"github.com/TomPallister/godutch-api/api/security"
+	"github.com/TomPallister/godutch-api/api/server"
+	"github.com/TomPallister/godutch-api/api/timeout"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/TomPallister/godutch-api/api/webhooks"
 	"github.com/codegangsta/negroni"
-	"github.com/joho/godotenv"
-	"github.com/rs/cors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// ErrorDatabaseConnectionString ...
-var ErrorDatabaseConnectionString = errors.New("Environment variable PGSQLCONNECTIONSTRING is undefined.")
+// LocalAuthIssuer is the iss claim on tokens the api signs itself
+const LocalAuthIssuer = "godutch-local"
 
-// ErrorAPIPort ...
-var ErrorAPIPort = errors.New("Environment variable API_PORT is undefined.")
+// usage is printed for -h and unknown commands
+const usage = `Usage: api [-config file.yaml] [config print]
+
+Serves the api, or with "config print" writes the effective config with secrets redacted.
+Config comes from the defaults, the YAML file, then the environment, with .env read if present.
+`
 
 func main() {
 
-	err := godotenv.Load()
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	configFile := flags.String("config", "", "YAML config file, CONFIG_FILE by default")
+	flags.Parse(os.Args[1:])
 
+	cfg, err := config.Read(*configFile)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatalf("The config could not be read: %v", err)
 	}
 
-	startServer()
-}
+	switch strings.Join(flags.Args(), " ") {
+	case "":
+	case "config print":
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Fatalf("The config is invalid: %v", err)
+		}
+		return
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("The config is invalid: %v", err)
+	}
 
-func startServer() {
+	logger, err := infrastructure.NewStructuredLogger(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	connectionString := os.Getenv("PGSQL_CONNECTIONSTRING")
-	if connectionString == "" {
-		panic(ErrorDatabaseConnectionString)
+	if err := startServer(cfg, logger); err != nil {
+		logger.Error("The api stopped", err)
+		os.Exit(1)
 	}
+	logger.Info("The api stopped")
+}
+
+// startServer serves until SIGINT or SIGTERM and then shuts down gracefully, it returns the error
+// that stopped it otherwise
+func startServer(cfg config.Config, logger infrastructure.Logger) error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	db, err := repository.NewDB(connectionString)
+	apiServer := server.New(logger, cfg.Server)
+
+	db, err := repository.Open(ctx, logger, cfg.Database)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	var emailService = &infrastructure.SendGridEmailService{}
-	var logger = infrastructure.ConsoleLogger{}
+	defer db.Close()
+	metrics.RegisterDB(db, "godutch")
+	apiServer.Go(func(stop <-chan struct{}) {
+		repository.LogStats(db, logger, cfg.Database.StatsInterval, stop)
+	})
+	shutdownTracing, err := tracing.NewProvider(context.Background(), cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	var emailService = &infrastructure.SendGridEmailService{APIKey: cfg.Email.SendGridAPIKey}
 	var trackerRepository = trackerrepository.NewPostgresTrackerRepository(logger, db)
 	var userRepository = userrepository.NewPostgresUserRepository(logger, db)
 	var spendRepository = spendrepository.NewPostgresSpendRepository(logger, db)
 	var transferRepository = transferrepository.NewPostgresTransferRepository(logger, db)
 	var spendSummaryRepository = spendsummaryrepository.NewPostgresSpendSummaryRepository(logger, db)
+	var inviteRepository = inviterepository.NewPostgresInviteRepository(logger, db)
+	var apiTokenRepository = apitokenrepository.NewPostgresAPITokenRepository(logger, db)
+	var idempotencyRepository = idempotencyrepository.NewPostgresIdempotencyRepository(logger, db)
+	var eventBroker events.Broker = events.NewInProcessBroker(logger, events.DefaultBufferSize)
+	if cfg.Events.Broker == "postgres" {
+		broker, err := events.NewPostgresBroker(logger, db, cfg.Database.ConnectionString, events.DefaultBufferSize)
+		if err != nil {
+			return err
+		}
+		defer broker.Close()
+		eventBroker = broker
+	}
+	var publisher events.Publisher = eventBroker
+	var webhookService webhookservice.WebhookService
+	if cfg.Encryption.Configured() {
+		keyring, err := encryption.NewKeyringFromKeySource(cfg.Encryption)
+		if err != nil {
+			return err
+		}
+		var webhookRepository = webhookrepository.NewPostgresWebhookRepository(logger, db, keyring)
+		var webhookDeliveryRepository = webhookdeliveryrepository.NewPostgresWebhookDeliveryRepository(logger, db)
+		var dispatcher = webhooks.NewDispatcher(webhookRepository, webhookDeliveryRepository, logger, cfg.Webhooks)
+		apiServer.Go(dispatcher.Run)
+		publisher = events.MultiPublisher{eventBroker, dispatcher}
+		webhookService = webhookservice.
+			NewGoDutchWebhookService(webhookRepository, webhookDeliveryRepository, trackerRepository, userRepository, dispatcher, logger, cfg.Webhooks.AllowPrivateAddresses)
+	}
 	var spendSummaryService = spendsummaryservice.
 		NewGoDutchSpendSummaryService(spendRepository, spendSummaryRepository, trackerRepository, userRepository)
 	var transferService = transferservice.
 		NewGoDutchTransferService(spendRepository, transferRepository, trackerRepository, userRepository)
 	var userService = userservice.
-		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository)
+		NewGoDutchUserService(userRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService, trackerRepository, inviteRepository, publisher)
 	var trackerService = trackerservice.
-		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, spendRepository)
+		NewGoDutchTrackerService(trackerRepository, userService, logger, trackervalidation.NewGoDutchTrackerValidator(), transferService, spendSummaryService, publisher)
 	var spendService = spendservice.
-		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService)
+		NewGoDutchSpendService(spendRepository, userService, trackerService, spendvalidation.NewGoDutchSpendValidator(), logger, transferService, spendSummaryService, publisher)
+
+	var apiTokenService = apitokenservice.
+		NewGoDutchAPITokenService(apiTokenRepository, userRepository, logger)
+
+	var localAuthService localauthservice.LocalAuthService
+	var jwtAuthenticator *jwtauth.JwtAuthenticator
+	if cfg.Auth.Mode == "local" {
+		signingKey := cfg.Auth.LocalSigningKey
+		jwtAuthenticator = jwtauth.NewJwtAuthenticator(jwtauth.Options{
+			Algorithms: []string{"HS256"},
+			KeyGetter:  jwtauth.NewHMACKeyGetter([]byte(signingKey)),
+			Issuer:     LocalAuthIssuer,
+		})
+		var credentialRepository = localcredentialrepository.NewPostgresLocalCredentialRepository(logger, db)
+		localAuthService = localauthservice.
+			NewGoDutchLocalAuthService(userRepository, credentialRepository, uservalidation.NewGoDutchUserValidator(), logger, emailService,
+				jwtauth.NewHMACTokenIssuer([]byte(signingKey), LocalAuthIssuer, cfg.Auth.LocalTokenTimeout))
+	} else {
+		jwtAuthenticator, err = jwtauth.NewJwtAuthenticatorFromKeySource(cfg.Auth.Source)
+		if err != nil {
+			return err
+		}
+	}
+
+	apiServer.Go(func(stop <-chan struct{}) {
+		idempotency.DeleteExpired(idempotencyRepository, logger, cfg.IdempotencyRetention, time.Hour, stop)
+	})
+
+	var subjectFinder = apitokenauth.NewAPITokenSubjectFinder(jwtauth.NewJwtSubjectFinder())
+	var rateLimitRepository ratelimitrepository.RateLimitRepository = ratelimitrepository.NewInMemoryRateLimitRepository()
+	if cfg.RateLimits.Store == "postgres" {
+		rateLimitRepository = ratelimitrepository.NewPostgresRateLimitRepository(logger, db)
+	}
+	var rateLimiter = ratelimit.NewLimiter(rateLimitRepository, subjectFinder, logger, cfg.RateLimits)
+	apiServer.Go(func(stop <-chan struct{}) {
+		ratelimit.DeleteFull(rateLimitRepository, logger, cfg.RateLimits, time.Minute, stop)
+	})
 
 	env := &environment.Env{
 		Logger:              logger,
 		UserService:         userService,
-		SubjectFinder:       infrastructure.NewGorrilaAndJwtSubjectFinder(),
+		SubjectFinder:       subjectFinder,
+		Authenticator:       apitokenauth.NewAPITokenAuthenticator(apiTokenService, jwtAuthenticator),
 		TrackerService:      trackerService,
 		SpendService:        spendService,
 		TransferService:     transferService,
 		SpendSummaryService: spendSummaryService,
+		APITokenService:     apiTokenService,
+		LocalAuthService:    localAuthService,
+		WebhookService:      webhookService,
+
+		RateLimiter: rateLimiter,
+
+		IdempotencyRepository: idempotencyRepository,
+		IdempotencyRetention:  cfg.IdempotencyRetention,
+
+		GoDutchURL:     cfg.GoDutchURL,
+		RequestTimeout: cfg.RequestTimeout,
+
+		EventSubscriber: eventBroker,
+		CORS:            security.NewCORS(cfg.CORS),
+
+		ReadinessChecks: map[string]func(ctx context.Context) error{
+			"database": db.PingContext,
+		},
+
+		ACMEChallengeHandler: apiServer.ACMEChallengeHandler(),
+	}
+	if cfg.Email.CheckReachable {
+		env.ReadinessChecks["email"] = emailService.CheckReachable
 	}
 
-	router := route.GetRouter(env)
+	if cfg.GRPCAddr != "" {
+		listener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			return err
+		}
+		// gRPC is served with the same TLS as the api, or none when the api serves plain HTTP
+		tlsConfig, err := apiServer.TLSConfig()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		grpcOptions := []grpc.ServerOption{}
+		if tlsConfig != nil {
+			grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer := grpcserver.NewServer(env, grpcOptions...)
+		apiServer.Serve(func() error {
+			return grpcServer.Serve(listener)
+		})
+		apiServer.Go(func(stop <-chan struct{}) {
+			<-stop
+			grpcServer.GracefulStop()
+		})
+	}
 
-	c := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "OPTIONS", "PUT", "DELETE"},
-		AllowedHeaders: []string{"*"},
-	})
+	if cfg.MetricsAddr != "" {
+		listener, err := net.Listen("tcp", cfg.MetricsAddr)
+		if err != nil {
+			return err
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsServer := &http.Server{Handler: mux, ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout}
+		apiServer.Serve(func() error {
+			return metricsServer.Serve(listener)
+		})
+		apiServer.Go(func(stop <-chan struct{}) {
+			<-stop
+			metricsServer.Close()
+		})
+	}
+
+	router := route.GetRouter(env)
 
-	n := negroni.Classic()
-	n.Use(c)
+	n := negroni.New(
+		negroni.NewRecovery(),
+		tracing.Middleware(router),
+		requestid.Middleware(logger),
+		metrics.Middleware(router),
+		timeout.Middleware(router, env.RequestTimeout, route.StreamingRoutes...),
+		security.Headers(cfg.Headers),
+		negroni.NewStatic(http.Dir("public")),
+		env.CORS,
+		rateLimiter.PerIP("ip", cfg.RateLimits.IP, route.ProbeRoutes...),
+	)
 	n.UseHandler(router)
 
-	apiPort := os.Getenv("API_PORT")
-	if apiPort == "" {
-		panic(ErrorAPIPort)
+	streaming := map[string]bool{}
+	for _, template := range route.StreamingRoutes {
+		streaming[template] = true
 	}
 
-	http.ListenAndServe(apiPort, n)
+	return apiServer.ListenAndServe(ctx, n, func(r *http.Request) bool {
+		return streaming[metrics.Route(router, r)]
+	})
 }
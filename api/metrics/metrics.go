@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// UnmatchedRoute is the route label of requests that did not match a route, so unknown paths
+// do not make a series each
+const UnmatchedRoute = "unmatched"
+
+// Registry holds every metric the api exports at /metrics
+var Registry = prometheus.NewRegistry()
+
+var requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "godutch_http_requests_total",
+	Help: "HTTP requests by route template, method and status.",
+}, []string{"route", "method", "status"})
+
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "godutch_http_request_duration_seconds",
+	Help:    "HTTP request latency by route template and method.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"route", "method"})
+
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "godutch_db_query_duration_seconds",
+	Help:    "Database time by repository and method.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"repository", "method"})
+
+// SpendsCreated ...
+var SpendsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "godutch_spends_created_total",
+	Help: "Spends created.",
+})
+
+// InvitesSent counts invite emails sent, resent invites included
+var InvitesSent = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "godutch_invites_sent_total",
+	Help: "Invite emails sent.",
+})
+
+// RateLimited counts requests refused by the rate limiter by bucket
+var RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "godutch_rate_limited_requests_total",
+	Help: "Requests refused by the rate limiter by bucket.",
+}, []string{"bucket"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		queryDuration,
+		SpendsCreated,
+		InvitesSent,
+		RateLimited,
+	)
+}
+
+// RegisterDB exports db's pool stats, such as connections in use and waits for one, as go_sql_*
+// metrics labelled with name
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times each request under the template of the route in router it matches,
+// such as /api/v1/trackers/{id}, rather than its path
+func Middleware(router *mux.Router) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		route := Route(router, r)
+
+		start := time.Now()
+		next(w, r)
+
+		status := http.StatusOK
+		if res, ok := w.(negroni.ResponseWriter); ok && res.Status() != 0 {
+			status = res.Status()
+		}
+
+		requests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Route is the template of the route in router that r matches, or UnmatchedRoute
+func Route(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(r, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return UnmatchedRoute
+}
+
+// ObserveQuery records the time since start against a repository method, see tracing.StartQuery
+func ObserveQuery(repository string, method string, start time.Time) {
+	queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/metrics"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+var scraped string
+
+func TestRequestsAreCountedByRouteTemplate(t *testing.T) {
+	whenISend("GET", "/api/v1/trackers/42")
+	whenIScrape()
+	thenTheMetricsContain(`godutch_http_requests_total{method="GET",route="/api/v1/trackers/{id}",status="200"}`, t)
+	thenTheMetricsContain(`godutch_http_request_duration_seconds_count{method="GET",route="/api/v1/trackers/{id}"}`, t)
+	thenTheMetricsDoNotContain(`/api/v1/trackers/42`, t)
+}
+
+func TestUnknownPathsShareOneSeries(t *testing.T) {
+	whenISend("GET", "/wp-login.php")
+	whenIScrape()
+	thenTheMetricsContain(`godutch_http_requests_total{method="GET",route="unmatched",status="404"}`, t)
+	thenTheMetricsDoNotContain(`wp-login`, t)
+}
+
+func TestQueriesAreTimedByRepositoryMethod(t *testing.T) {
+	metrics.ObserveQuery("spend", "GetByID", time.Now())
+	whenIScrape()
+	thenTheMetricsContain(`godutch_db_query_duration_seconds_count{method="GetByID",repository="spend"} 1`, t)
+}
+
+func whenISend(method string, path string) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/trackers/{id}", func(w http.ResponseWriter, r *http.Request) {}).
+		Methods("GET")
+
+	n := negroni.New(metrics.Middleware(router))
+	n.UseHandler(router)
+	n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func whenIScrape() {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	scraped = string(body)
+}
+
+func thenTheMetricsContain(expected string, t *testing.T) {
+	if !strings.Contains(scraped, expected) {
+		t.Fatalf("expected the metrics to contain %v", expected)
+	}
+}
+
+func thenTheMetricsDoNotContain(unexpected string, t *testing.T) {
+	if strings.Contains(scraped, unexpected) {
+		t.Fatalf("expected the metrics not to contain %v", unexpected)
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// APIToken ...
+type APIToken struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"userId"`
+	Name         string     `json:"name"`
+	TokenHash    string     `json:"-"`
+	Scopes       []string   `json:"scopes"`
+	DateCreated  time.Time  `json:"dateCreated"`
+	DateLastUsed *time.Time `json:"dateLastUsed"`
+	DateRevoked  *time.Time `json:"dateRevoked"`
+}
+
+// APITokenScopeRead allows GET requests
+const APITokenScopeRead = "read"
+
+// APITokenScopeSpendsWrite allows creating, updating and deleting spends
+const APITokenScopeSpendsWrite = "spends:write"
+
+// APITokenScopeWrite allows every request an API token can make
+const APITokenScopeWrite = "write"
+
+// APITokenScopes ...
+var APITokenScopes = []string{APITokenScopeRead, APITokenScopeSpendsWrite, APITokenScopeWrite}
+
+// HasScope ...
+func (apiToken APIToken) HasScope(scope string) bool {
+	for _, s := range apiToken.Scopes {
+		if s == scope || (s == APITokenScopeWrite && scope == APITokenScopeSpendsWrite) {
+			return true
+		}
+	}
+	return false
+}
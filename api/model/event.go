@@ -0,0 +1,15 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is a change to a tracker, its spends or its transfers that members can be told about as it happens
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	TrackerID   int64           `json:"trackerId"`
+	Data        json.RawMessage `json:"data"`
+	DateCreated time.Time       `json:"dateCreated"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a mutating request a client may retry, and the response it got the first time
+type IdempotencyKey struct {
+	Key                 string
+	Subject             string
+	RequestHash         string
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseLocation    string
+	ResponseETag        string
+	ResponseBody        []byte
+	DateCreated         time.Time
+	DateCompleted       *time.Time
+}
+
+// IsCompleted is false while the first request is still being handled
+func (idempotencyKey IdempotencyKey) IsCompleted() bool {
+	return idempotencyKey.DateCompleted != nil
+}
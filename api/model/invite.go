@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Invite ...
+type Invite struct {
+	ID              int64      `json:"id"`
+	TrackerID       int64      `json:"trackerId"`
+	UserID          int64      `json:"userId"`
+	InvitedByUserID int64      `json:"invitedByUserId"`
+	EmailAddress    string     `json:"emailAddress"`
+	TokenHash       string     `json:"-"`
+	DateCreated     time.Time  `json:"dateCreated"`
+	DateExpires     time.Time  `json:"dateExpires"`
+	DateAccepted    *time.Time `json:"dateAccepted"`
+	DateRevoked     *time.Time `json:"dateRevoked"`
+}
+
+// IsPending ...
+func (invite Invite) IsPending(now time.Time) bool {
+	return invite.DateAccepted == nil && invite.DateRevoked == nil && now.Before(invite.DateExpires)
+}
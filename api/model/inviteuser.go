@@ -2,6 +2,6 @@ package model
 
 // InviteUser ...
 type InviteUser struct {
-	EmailAddress string
-	TrackerID    int64
+	EmailAddress string `json:"emailAddress"`
+	TrackerID    int64  `json:"trackerId"`
 }
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// LocalCredential is the password for a user signing in without Auth0
+type LocalCredential struct {
+	UserID                int64
+	PasswordHash          string
+	FailedLoginAttempts   int
+	DateLockedUntil       *time.Time
+	ResetTokenHash        *string
+	DateResetTokenExpires *time.Time
+}
+
+// Register ...
+type Register struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	Password     string `json:"password"`
+}
+
+// Login ...
+type Login struct {
+	EmailAddress string `json:"emailAddress"`
+	Password     string `json:"password"`
+}
+
+// LoginResult ...
+type LoginResult struct {
+	Token       string    `json:"token"`
+	DateExpires time.Time `json:"dateExpires"`
+}
+
+// ForgotPassword ...
+type ForgotPassword struct {
+	EmailAddress string `json:"emailAddress"`
+}
+
+// ResetPassword ...
+type ResetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
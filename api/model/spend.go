@@ -15,4 +15,5 @@ type Spend struct {
 	UserID      int64           `json:"userId"`
 	Currency    string          `json:"currency"`
 	DateCreated time.Time       `json:"dateCreated"`
+	Version     int64           `json:"version"`
 }
@@ -10,4 +10,5 @@ type Tracker struct {
 	Name           string    `json:"name"`
 	DateCreated    time.Time `json:"dateCreated"`
 	Currency       string    `json:"currency"`
+	Version        int64     `json:"version"`
 }
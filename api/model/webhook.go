@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Webhook sends a tracker's events to a URL outside godutch
+type Webhook struct {
+	ID              int64      `json:"id"`
+	TrackerID       int64      `json:"trackerId"`
+	CreatedByUserID int64      `json:"createdByUserId"`
+	URL             string     `json:"url"`
+	Secret          string     `json:"secret,omitempty"`
+	EventTypes      []string   `json:"eventTypes"`
+	DateCreated     time.Time  `json:"dateCreated"`
+	DateDeleted     *time.Time `json:"-"`
+}
+
+// Subscribes ...
+func (webhook Webhook) Subscribes(eventType string) bool {
+	for _, e := range webhook.EventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// WebhookDeliveryPending is a delivery that has not succeeded yet but will be tried again
+const WebhookDeliveryPending = "pending"
+
+// WebhookDeliverySucceeded ...
+const WebhookDeliverySucceeded = "succeeded"
+
+// WebhookDeliveryFailed is a delivery that has run out of attempts
+const WebhookDeliveryFailed = "failed"
+
+// WebhookDelivery is one event sent, or being sent, to a webhook
+type WebhookDelivery struct {
+	ID              int64      `json:"id"`
+	WebhookID       int64      `json:"webhookId"`
+	EventID         string     `json:"eventId"`
+	EventType       string     `json:"eventType"`
+	Payload         string     `json:"payload"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	MaxAttempts     int        `json:"maxAttempts"`
+	ResponseStatus  int        `json:"responseStatus"`
+	Error           string     `json:"error,omitempty"`
+	DateCreated     time.Time  `json:"dateCreated"`
+	DateNextAttempt *time.Time `json:"dateNextAttempt"`
+	DateCompleted   *time.Time `json:"dateCompleted"`
+}
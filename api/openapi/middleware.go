@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+// ErrorRequestDoesNotMatchSpecification ...
+var ErrorRequestDoesNotMatchSpecification = domainerror.NewBadRequest("request_does_not_match_specification", "The request does not match the API specification")
+
+// maxBodySize matches the limit the handlers read
+const maxBodySize = 1048576
+
+// ValidationMiddleware rejects requests whose parameters or body do not match the route's operation
+func ValidationMiddleware(document *Document, logger infrastructure.Logger) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next(w, r)
+			return
+		}
+
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			next(w, r)
+			return
+		}
+
+		operation := document.Operation(pathTemplate, r.Method)
+		if operation == nil {
+			next(w, r)
+			return
+		}
+
+		validationErrors, err := ValidateRequest(operation, r)
+		if err != nil {
+			writeValidationErrors(w, logger, []ValidationError{{Field: "body", Message: err.Error()}})
+			return
+		}
+		if len(validationErrors) > 0 {
+			writeValidationErrors(w, logger, validationErrors)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// ValidateRequest checks the request against the operation, leaving the body readable for the handler
+func ValidateRequest(operation *Operation, r *http.Request) ([]ValidationError, error) {
+
+	var validationErrors []ValidationError
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	for _, parameter := range operation.Parameters {
+		var value string
+		var present bool
+		switch parameter.In {
+		case "path":
+			value, present = vars[parameter.Name]
+		case "query":
+			values, ok := query[parameter.Name]
+			present = ok && len(values) > 0
+			if present {
+				value = values[0]
+			}
+		case "header":
+			value = r.Header.Get(parameter.Name)
+			present = value != ""
+		default:
+			continue
+		}
+		if present == false {
+			if parameter.Required {
+				validationErrors = append(validationErrors, ValidationError{Field: parameter.Name, Message: "is required"})
+			}
+			continue
+		}
+		if parameter.Schema != nil {
+			validationErrors = append(validationErrors, parameter.Schema.ValidateParameter(parameter.Name, value)...)
+		}
+	}
+
+	if operation.RequestBody == nil {
+		return validationErrors, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			validationErrors = append(validationErrors, ValidationError{Field: "body", Message: "is required"})
+		}
+		return validationErrors, nil
+	}
+
+	mediaType, ok := operation.RequestBody.Content[requestMediaType(r)]
+	if ok == false {
+		mediaType, ok = operation.RequestBody.Content["application/json"]
+	}
+	if ok == false || mediaType.Schema == nil {
+		return validationErrors, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		validationErrors = append(validationErrors, ValidationError{Field: "body", Message: "must be valid JSON"})
+		return validationErrors, nil
+	}
+
+	for _, validationError := range mediaType.Schema.Validate("", value) {
+		if validationError.Field == "" {
+			validationError.Field = "body"
+		}
+		validationErrors = append(validationErrors, validationError)
+	}
+	return validationErrors, nil
+}
+
+// requestMediaType is the request's content type without parameters such as charset
+func requestMediaType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+func writeValidationErrors(w http.ResponseWriter, logger infrastructure.Logger, validationErrors []ValidationError) {
+	sort.SliceStable(validationErrors, func(i, j int) bool {
+		return validationErrors[i].Field < validationErrors[j].Field
+	})
+
+	handler.CreateErrorResponseAndLog(http.StatusBadRequest, w, logger,
+		ErrorRequestDoesNotMatchSpecification.WithDetails(map[string]interface{}{"errors": validationErrors}))
+}
+
+// SpecificationHandler serves the OpenAPI document
+func SpecificationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, Specification)
+}
@@ -0,0 +1,224 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrorUnresolvedReference ...
+var ErrorUnresolvedReference = errors.New("The specification has a $ref that does not resolve")
+
+// Document is the part of an OpenAPI 3 document the api needs to validate requests
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// PathItem maps lower case http methods to operations
+type PathItem map[string]*Operation
+
+// Operation ...
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter ...
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody ...
+type RequestBody struct {
+	Ref      string               `json:"$ref"`
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType ...
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components ...
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+}
+
+// Types is a JSON schema type, which may be a single type or a list of them
+type Types []string
+
+// UnmarshalJSON ...
+func (types *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*types = Types{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*types = Types(many)
+	return nil
+}
+
+// Schema is the subset of JSON schema used by the specification
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       Types              `json:"type"`
+	Format     string             `json:"format"`
+	Pattern    string             `json:"pattern"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+	OneOf      []*Schema          `json:"oneOf"`
+	Enum       []interface{}      `json:"enum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	MinItems   *int               `json:"minItems"`
+	Minimum    *float64           `json:"minimum"`
+}
+
+// Load parses a specification and resolves its $refs
+func Load(specification string) (*Document, error) {
+
+	var document Document
+	if err := json.Unmarshal([]byte(specification), &document); err != nil {
+		return nil, err
+	}
+
+	for _, pathItem := range document.Paths {
+		for _, operation := range pathItem {
+			for index, parameter := range operation.Parameters {
+				resolved, err := document.resolveParameter(parameter)
+				if err != nil {
+					return nil, err
+				}
+				operation.Parameters[index] = resolved
+			}
+			if operation.RequestBody != nil {
+				resolved, err := document.resolveRequestBody(operation.RequestBody)
+				if err != nil {
+					return nil, err
+				}
+				operation.RequestBody = resolved
+			}
+		}
+	}
+
+	for _, schema := range document.Components.Schemas {
+		if err := document.resolveSchema(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return &document, nil
+}
+
+// MustLoad is Load for the built in Specification, which is known to be valid
+func MustLoad() *Document {
+	document, err := Load(Specification)
+	if err != nil {
+		panic(err)
+	}
+	return document
+}
+
+// Operation returns the operation for a route's path template and method, or nil
+func (document *Document) Operation(pathTemplate string, method string) *Operation {
+	pathItem, ok := document.Paths[pathTemplate]
+	if ok == false {
+		return nil
+	}
+	return pathItem[strings.ToLower(method)]
+}
+
+func refName(ref string, prefix string) (string, error) {
+	if strings.HasPrefix(ref, prefix) == false {
+		return "", ErrorUnresolvedReference
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (document *Document) resolveParameter(parameter *Parameter) (*Parameter, error) {
+	if parameter.Ref != "" {
+		name, err := refName(parameter.Ref, "#/components/parameters/")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := document.Components.Parameters[name]
+		if ok == false {
+			return nil, ErrorUnresolvedReference
+		}
+		parameter = resolved
+	}
+	if parameter.Schema != nil {
+		if err := document.resolveSchema(parameter.Schema); err != nil {
+			return nil, err
+		}
+	}
+	return parameter, nil
+}
+
+func (document *Document) resolveRequestBody(requestBody *RequestBody) (*RequestBody, error) {
+	if requestBody.Ref != "" {
+		name, err := refName(requestBody.Ref, "#/components/requestBodies/")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := document.Components.RequestBodies[name]
+		if ok == false {
+			return nil, ErrorUnresolvedReference
+		}
+		requestBody = resolved
+	}
+	for _, mediaType := range requestBody.Content {
+		if err := document.resolveSchema(mediaType.Schema); err != nil {
+			return nil, err
+		}
+	}
+	return requestBody, nil
+}
+
+// resolveSchema replaces $refs with pointers to the component schema, so recursive schemas stay finite
+func (document *Document) resolveSchema(schema *Schema) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		name, err := refName(schema.Ref, "#/components/schemas/")
+		if err != nil {
+			return err
+		}
+		resolved, ok := document.Components.Schemas[name]
+		if ok == false {
+			return ErrorUnresolvedReference
+		}
+		*schema = *resolved
+		if schema.Ref != "" {
+			return document.resolveSchema(schema)
+		}
+		return nil
+	}
+	for _, property := range schema.Properties {
+		if err := document.resolveSchema(property); err != nil {
+			return err
+		}
+	}
+	for _, oneOf := range schema.OneOf {
+		if err := document.resolveSchema(oneOf); err != nil {
+			return err
+		}
+	}
+	return document.resolveSchema(schema.Items)
+}
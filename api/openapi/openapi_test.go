@@ -0,0 +1,145 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/openapi"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+var document = openapi.MustLoad()
+var recorder *httptest.ResponseRecorder
+var handlerCalled bool
+
+func TestValidSpendIsAccepted(t *testing.T) {
+	whenIPost("/api/v1/spends", `{"spend":{"name":"Dinner","value":"12.50","trackerId":1,"currency":"GBP","dateCreated":"2016-06-01T19:00:00Z"}}`)
+	thenTheRequestIsAccepted(t)
+}
+
+func TestSpendWithMissingFieldsIsRejected(t *testing.T) {
+	whenIPost("/api/v1/spends", `{"spend":{"value":"12.50","trackerId":1}}`)
+	thenTheRequestIsRejectedWith(t, "spend.currency is required", "spend.name is required")
+}
+
+func TestSpendWithWrongTypesIsRejected(t *testing.T) {
+	whenIPost("/api/v1/spends", `{"spend":{"name":"Dinner","value":"twelve","trackerId":"one","currency":"GBP"}}`)
+	thenTheRequestIsRejectedWith(t, "spend.trackerId must be of type [integer]", "spend.value must match")
+}
+
+func TestMissingBodyIsRejected(t *testing.T) {
+	whenIPost("/api/v1/spends", ``)
+	thenTheRequestIsRejectedWith(t, "body is required")
+}
+
+func TestInvalidJSONIsRejected(t *testing.T) {
+	whenIPost("/api/v1/spends", `{"spend":`)
+	thenTheRequestIsRejectedWith(t, "body must be valid JSON")
+}
+
+func TestMissingQueryParameterIsRejected(t *testing.T) {
+	whenIGet("/api/v1/spends", "/api/v1/spends")
+	thenTheRequestIsRejectedWith(t, "trackerId is required")
+}
+
+func TestNonNumericPathParameterIsRejected(t *testing.T) {
+	whenIGet("/api/v1/trackers/{id}", "/api/v1/trackers/abc")
+	thenTheRequestIsRejectedWith(t, "id must be of type [integer]")
+}
+
+func TestUnknownScopeIsRejected(t *testing.T) {
+	whenIPost("/api/v1/apitokens", `{"apiToken":{"name":"ci","scopes":["admin"]}}`)
+	thenTheRequestIsRejectedWith(t, "apiToken.scopes[0] must be one of")
+}
+
+func TestPartialMergePatchIsAccepted(t *testing.T) {
+	whenIPatch("/api/v1/spends/{id}", "/api/v1/spends/1", `{"spend":{"name":"Lunch"}}`)
+	thenTheRequestIsAccepted(t)
+}
+
+func TestMergePatchIsValidatedAgainstItsOwnSchema(t *testing.T) {
+	whenIPatch("/api/v1/spends/{id}", "/api/v1/spends/1", `{"spend":"Lunch"}`)
+	thenTheRequestIsRejectedWith(t, "spend must be of type [object]")
+}
+
+func TestTheBodyCanStillBeReadByTheHandler(t *testing.T) {
+	body := `{"tracker":{"name":"Holiday","currency":"GBP"}}`
+	router := mux.NewRouter()
+	var read string
+	router.Handle("/api/v1/trackers", negroni.New(
+		openapi.ValidationMiddleware(document, infrastructure.NilLogger{}),
+		negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			read = string(body)
+		})),
+	)).Methods("POST")
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/trackers", strings.NewReader(body)))
+	if read != body {
+		t.Fatalf("expected the handler to read %v but read %v", body, read)
+	}
+}
+
+func whenIPost(pathTemplate string, body string) {
+	serve(pathTemplate, "POST", httptest.NewRequest("POST", pathTemplate, strings.NewReader(body)))
+}
+
+func whenIPatch(pathTemplate string, url string, body string) {
+	r := httptest.NewRequest("PATCH", url, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/merge-patch+json")
+	serve(pathTemplate, "PATCH", r)
+}
+
+func whenIGet(pathTemplate string, url string) {
+	serve(pathTemplate, "GET", httptest.NewRequest("GET", url, nil))
+}
+
+func serve(pathTemplate string, method string, r *http.Request) {
+	handlerCalled = false
+	router := mux.NewRouter()
+	router.Handle(pathTemplate, negroni.New(
+		openapi.ValidationMiddleware(document, infrastructure.NilLogger{}),
+		negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})),
+	)).Methods(method)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+}
+
+func thenTheRequestIsAccepted(t *testing.T) {
+	if handlerCalled == false {
+		t.Fatalf("expected the request to be accepted but got %v", recorder.Body.String())
+	}
+}
+
+func thenTheRequestIsRejectedWith(t *testing.T, messages ...string) {
+	if handlerCalled {
+		t.Fatal("expected the request to be rejected")
+	}
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 but got %v", recorder.Code)
+	}
+	var response struct {
+		Details struct {
+			Errors []openapi.ValidationError `json:"errors"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		found := false
+		for _, validationError := range response.Details.Errors {
+			found = found || strings.HasPrefix(validationError.Error(), message)
+		}
+		if found == false {
+			t.Errorf("expected %v in %v", message, response.Details.Errors)
+		}
+	}
+}
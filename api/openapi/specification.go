@@ -0,0 +1,980 @@
+package openapi
+
+// Specification is the OpenAPI document for every route in route.GetRouter. Request bodies and
+// query parameters are validated against it, so add or change the entry here with the route.
+const Specification = `{
+  "openapi": "3.1.0",
+  "info": {
+    "title": "GoDutch API",
+    "version": "1.0.0"
+  },
+  "security": [{"bearerAuth": []}],
+  "paths": {
+    "/": {
+      "get": {
+        "operationId": "ping",
+        "security": [],
+        "responses": {"200": {"description": "The api is running"}}
+      }
+    },
+    "/healthz": {
+      "get": {
+        "operationId": "getHealth",
+        "summary": "Liveness probe, answers 200 while the process is up",
+        "security": [],
+        "responses": {
+          "200": {"description": "The api is running", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Health"}}}}
+        }
+      }
+    },
+    "/readyz": {
+      "get": {
+        "operationId": "getReadiness",
+        "summary": "Readiness probe, checks the database and any other configured dependency",
+        "security": [],
+        "responses": {
+          "200": {"description": "Every check passed", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Health"}}}},
+          "503": {"description": "At least one check failed", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Health"}}}}
+        }
+      }
+    },
+    "/secured/ping": {
+      "get": {
+        "operationId": "securedPing",
+        "responses": {
+          "200": {"description": "The caller is authenticated"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      }
+    },
+    "/api/v1/openapi.json": {
+      "get": {
+        "operationId": "getOpenAPISpecification",
+        "security": [],
+        "responses": {"200": {"description": "This document"}}
+      }
+    },
+    "/api/v1/users": {
+      "get": {
+        "operationId": "findUsers",
+        "summary": "Returns the current user, or the users of a tracker when trackerId is given",
+        "parameters": [{"$ref": "#/components/parameters/OptionalTrackerID"}],
+        "responses": {
+          "200": {"description": "A user or users", "content": {"application/json": {"schema": {"oneOf": [
+            {"$ref": "#/components/schemas/UserView"},
+            {"$ref": "#/components/schemas/UsersView"}
+          ]}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      },
+      "post": {
+        "operationId": "createUser",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"$ref": "#/components/requestBodies/UserView"},
+        "responses": {
+          "201": {"$ref": "#/components/responses/User"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      }
+    },
+    "/api/v1/users/invite": {
+      "post": {
+        "operationId": "inviteUser",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/InviteUser"}}}},
+        "responses": {
+          "201": {"$ref": "#/components/responses/User"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/api/v1/users/accept/{token}": {
+      "post": {
+        "operationId": "acceptInvite",
+        "parameters": [{"name": "token", "in": "path", "required": true, "schema": {"type": "string", "minLength": 1}}, {"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"$ref": "#/components/requestBodies/UserView"},
+        "responses": {
+          "201": {"$ref": "#/components/responses/User"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      }
+    },
+    "/api/v1/users/{id}": {
+      "get": {
+        "operationId": "findUserByID",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/User"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/invites": {
+      "get": {
+        "operationId": "findPendingInvites",
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"description": "Pending invites for the tracker", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/InvitesView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/invites/{id}/resend": {
+      "post": {
+        "operationId": "resendInvite",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}],
+        "responses": {
+          "200": {"description": "The invite with a new token and expiry", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/InviteView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/api/v1/invites/{id}": {
+      "delete": {
+        "operationId": "revokeInvite",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}],
+        "responses": {
+          "204": {"description": "The invite was revoked"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"}
+        }
+      }
+    },
+    "/api/v1/trackers": {
+      "get": {
+        "operationId": "findTrackers",
+        "responses": {
+          "200": {"description": "The trackers the current user belongs to", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackersView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      },
+      "post": {
+        "operationId": "createTracker",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"$ref": "#/components/requestBodies/TrackerView"},
+        "responses": {
+          "201": {"$ref": "#/components/responses/Tracker"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      }
+    },
+    "/api/v1/trackers/{id}": {
+      "get": {
+        "operationId": "findTrackerByID",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/Tracker"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      },
+      "put": {
+        "operationId": "updateTracker",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "requestBody": {"$ref": "#/components/requestBodies/TrackerView"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Tracker"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      },
+      "patch": {
+        "operationId": "patchTracker",
+        "description": "Applies a JSON Merge Patch (RFC 7396) to the stored tracker, then validates and saves it like a PUT",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "requestBody": {"$ref": "#/components/requestBodies/TrackerPatch"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Tracker"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "415": {"$ref": "#/components/responses/UnsupportedMediaType"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"}
+        }
+      },
+      "delete": {
+        "operationId": "deleteTracker",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "responses": {
+          "204": {"description": "The tracker was deleted"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"}
+        }
+      }
+    },
+    "/api/v1/trackers/{id}/events": {
+      "get": {
+        "operationId": "streamTrackerEvents",
+        "description": "Server-Sent Events for changes to the tracker, its spends and its transfers. Each event's name is its type and its data is an Event",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"description": "A stream that stays open until the client goes away or the tracker is deleted", "content": {"text/event-stream": {"schema": {"$ref": "#/components/schemas/Event"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/trackers/{id}/events/ws": {
+      "get": {
+        "operationId": "trackerEventsWebSocket",
+        "description": "The same events as /events sent as JSON text messages over a WebSocket",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "101": {"description": "Switched to a WebSocket, each message is an Event"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/spends": {
+      "get": {
+        "operationId": "findSpends",
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"description": "The spends for the tracker", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SpendsView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      },
+      "post": {
+        "operationId": "createSpend",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"$ref": "#/components/requestBodies/SpendView"},
+        "responses": {
+          "201": {"$ref": "#/components/responses/Spend"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      }
+    },
+    "/api/v1/spends/{id}": {
+      "get": {
+        "operationId": "findSpendByID",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/Spend"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      },
+      "put": {
+        "operationId": "updateSpend",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "requestBody": {"$ref": "#/components/requestBodies/SpendView"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Spend"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      },
+      "patch": {
+        "operationId": "patchSpend",
+        "description": "Applies a JSON Merge Patch (RFC 7396) to the stored spend, then validates and saves it like a PUT",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "requestBody": {"$ref": "#/components/requestBodies/SpendPatch"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Spend"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "415": {"$ref": "#/components/responses/UnsupportedMediaType"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"}
+        }
+      },
+      "delete": {
+        "operationId": "deleteSpend",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}, {"$ref": "#/components/parameters/IfMatch"}],
+        "responses": {
+          "204": {"description": "The spend was deleted"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "412": {"$ref": "#/components/responses/PreconditionFailed"},
+          "428": {"$ref": "#/components/responses/PreconditionRequired"}
+        }
+      }
+    },
+    "/api/v1/transfers": {
+      "get": {
+        "operationId": "findTransfers",
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"description": "Who owes who for the tracker", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TransfersView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/spendsummaries": {
+      "get": {
+        "operationId": "findSpendSummaries",
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/SpendSummaries"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/spendSummaries": {
+      "get": {
+        "operationId": "findSpendSummariesCamelCase",
+        "deprecated": true,
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"$ref": "#/components/responses/SpendSummaries"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/apitokens": {
+      "get": {
+        "operationId": "findAPITokens",
+        "responses": {
+          "200": {"description": "The current user's API tokens", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/APITokensView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      },
+      "post": {
+        "operationId": "createAPIToken",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/APITokenView"}}}},
+        "responses": {
+          "201": {"description": "The new API token, the token itself is only shown once. Retries with the same Idempotency-Key get the Location without a body", "headers": {"Location": {"$ref": "#/components/headers/Location"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewAPITokenView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "409": {"$ref": "#/components/responses/Conflict"}
+        }
+      }
+    },
+    "/api/v1/apitokens/{id}": {
+      "delete": {
+        "operationId": "revokeAPIToken",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}],
+        "responses": {
+          "204": {"description": "The API token was revoked"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"}
+        }
+      }
+    },
+    "/api/v1/graphql": {
+      "get": {
+        "operationId": "graphQLQuery",
+        "description": "Runs a query against the read only GraphQL schema in graph/schema.go",
+        "parameters": [
+          {"name": "query", "in": "query", "required": true, "schema": {"type": "string", "minLength": 1}},
+          {"name": "operationName", "in": "query", "required": false, "schema": {"type": "string"}},
+          {"name": "variables", "in": "query", "required": false, "description": "A JSON object", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/GraphQLResponse"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      },
+      "post": {
+        "operationId": "postGraphQLQuery",
+        "description": "Runs a query against the read only GraphQL schema in graph/schema.go",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GraphQLRequest"}}}},
+        "responses": {
+          "200": {"$ref": "#/components/responses/GraphQLResponse"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      }
+    },
+    "/api/v1/webhooks": {
+      "get": {
+        "operationId": "findWebhooks",
+        "summary": "Returns the tracker's webhooks, only the tracker admin can see them",
+        "parameters": [{"$ref": "#/components/parameters/TrackerID"}],
+        "responses": {
+          "200": {"description": "The tracker's webhooks without their secrets", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WebhooksView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      },
+      "post": {
+        "operationId": "createWebhook",
+        "description": "Events for the tracker are POSTed to url as an Event, signed in the GoDutch-Signature header as t=<unix time>,v1=<hex HMAC-SHA256 of \"<t>.<body>\" keyed with the secret>",
+        "parameters": [{"$ref": "#/components/parameters/IdempotencyKey"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WebhookView"}}}},
+        "responses": {
+          "201": {"description": "The new webhook, its secret is only shown once. Retries with the same Idempotency-Key get the Location without a body", "headers": {"Location": {"$ref": "#/components/headers/Location"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WebhookView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"}
+        }
+      }
+    },
+    "/api/v1/webhooks/{id}": {
+      "delete": {
+        "operationId": "deleteWebhook",
+        "parameters": [{"$ref": "#/components/parameters/ID"}, {"$ref": "#/components/parameters/IdempotencyKey"}],
+        "responses": {
+          "204": {"description": "The webhook was deleted, deliveries still pending will not be retried"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "409": {"$ref": "#/components/responses/Conflict"}
+        }
+      }
+    },
+    "/api/v1/webhooks/{id}/deliveries": {
+      "get": {
+        "operationId": "findWebhookDeliveries",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"description": "The webhook's 50 most recent deliveries, newest first", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WebhookDeliveriesView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/webhooks/{id}/test": {
+      "post": {
+        "operationId": "sendWebhookTestEvent",
+        "description": "Sends a webhook.test event once, straight away, and returns the delivery",
+        "parameters": [{"$ref": "#/components/parameters/ID"}],
+        "responses": {
+          "200": {"description": "The delivery, its status says whether the webhook accepted the event", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WebhookDeliveryView"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotFound"}
+        }
+      }
+    },
+    "/api/v1/auth/register": {
+      "post": {
+        "operationId": "register",
+        "security": [],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Register"}}}},
+        "responses": {
+          "201": {"description": "The registered user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "409": {"$ref": "#/components/responses/Conflict"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/api/v1/auth/login": {
+      "post": {
+        "operationId": "login",
+        "security": [],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Login"}}}},
+        "responses": {
+          "200": {"description": "A signed token", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/LoginResult"}}}},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/api/v1/auth/password/forgot": {
+      "post": {
+        "operationId": "forgotPassword",
+        "security": [],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ForgotPassword"}}}},
+        "responses": {
+          "202": {"description": "A reset email is sent if the email address is registered"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    },
+    "/api/v1/auth/password/reset": {
+      "post": {
+        "operationId": "resetPassword",
+        "security": [],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ResetPassword"}}}},
+        "responses": {
+          "204": {"description": "The password was changed"},
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "422": {"$ref": "#/components/responses/UnprocessableEntity"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearerAuth": {"type": "http", "scheme": "bearer", "description": "A JWT or a gdt_ API token"}
+    },
+    "parameters": {
+      "ID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 1}},
+      "TrackerID": {"name": "trackerId", "in": "query", "required": true, "schema": {"type": "integer", "minimum": 1}},
+      "IdempotencyKey": {"name": "Idempotency-Key", "in": "header", "required": false, "description": "A unique key that makes retrying this request safe, the first response is replayed for 24 hours", "schema": {"type": "string", "minLength": 1, "maxLength": 255}},
+      "IfMatch": {"name": "If-Match", "in": "header", "required": false, "description": "The ETag you last read, the change is refused with 412 if someone else has changed the resource since and with 428 if this is missing", "schema": {"type": "string", "pattern": "^\"[0-9]+\"$"}},
+      "OptionalTrackerID": {"name": "trackerId", "in": "query", "required": false, "schema": {"type": "integer", "minimum": 1}}
+    },
+    "requestBodies": {
+      "UserView": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/UserView"}}}},
+      "TrackerView": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackerView"}}}},
+      "SpendView": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SpendView"}}}},
+      "TrackerPatch": {"required": true, "content": {"application/merge-patch+json": {"schema": {"$ref": "#/components/schemas/TrackerPatch"}}, "application/json": {"schema": {"$ref": "#/components/schemas/TrackerPatch"}}}},
+      "SpendPatch": {"required": true, "content": {"application/merge-patch+json": {"schema": {"$ref": "#/components/schemas/SpendPatch"}}, "application/json": {"schema": {"$ref": "#/components/schemas/SpendPatch"}}}}
+    },
+    "headers": {
+      "ETag": {"description": "The resource's version, send it back in If-Match to change or delete it", "schema": {"type": "string"}},
+      "Location": {"description": "The url of the resource that was created", "schema": {"type": "string"}},
+      "RetryAfter": {"description": "Seconds to wait before the request will be allowed", "schema": {"type": "integer"}}
+    },
+    "responses": {
+      "BadRequest": {"description": "The request was invalid", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "Unauthorized": {"description": "The caller is not authenticated", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "Forbidden": {"description": "The caller is not allowed to do this", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "NotFound": {"description": "The resource does not exist", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "Conflict": {"description": "The request clashes with the resource's current state", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "PreconditionFailed": {"description": "The resource has changed since the ETag in If-Match was read", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "PreconditionRequired": {"description": "The request needs an If-Match header", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "TooManyRequests": {"description": "The caller has made too many of these requests, try again after Retry-After seconds", "headers": {"Retry-After": {"$ref": "#/components/headers/RetryAfter"}}, "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "UnsupportedMediaType": {"description": "The request body is not in a format this operation accepts", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "UnprocessableEntity": {"description": "The request broke a validation rule", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}},
+      "User": {"description": "A user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/UserView"}}}},
+      "Tracker": {"description": "A tracker", "headers": {"ETag": {"$ref": "#/components/headers/ETag"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackerView"}}}},
+      "Spend": {"description": "A spend", "headers": {"ETag": {"$ref": "#/components/headers/ETag"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SpendView"}}}},
+      "GraphQLResponse": {"description": "The data asked for and any errors, a service error has its code and status in extensions", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GraphQLResponse"}}}},
+      "SpendSummaries": {"description": "How much each user has spent on the tracker", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SpendSummariesView"}}}}
+    },
+    "schemas": {
+      "Health": {
+        "type": "object",
+        "required": ["status"],
+        "properties": {
+          "status": {"type": "string", "enum": ["ok", "unavailable"]},
+          "checks": {"type": "object", "additionalProperties": {"type": "string", "enum": ["ok", "failing"]}}
+        }
+      },
+      "Problem": {
+        "type": "object",
+        "description": "An RFC 7807 problem. code is stable and safe to switch on, field names the input at fault",
+        "required": ["type", "title", "status", "detail"],
+        "properties": {
+          "type": {"type": "string"},
+          "title": {"type": "string"},
+          "status": {"type": "integer"},
+          "detail": {"type": "string"},
+          "code": {"type": "string"},
+          "field": {"type": "string"},
+          "details": {
+            "type": "object",
+            "properties": {"errors": {"type": "array", "items": {"$ref": "#/components/schemas/ValidationError"}}}
+          }
+        }
+      },
+      "ValidationError": {
+        "type": "object",
+        "required": ["field", "message"],
+        "properties": {
+          "field": {"type": "string"},
+          "code": {"type": "string"},
+          "message": {"type": "string"}
+        }
+      },
+      "Money": {"type": ["string", "number"], "pattern": "^-?[0-9]+(\\.[0-9]+)?$", "description": "A decimal amount, sent as a string to keep its precision"},
+      "User": {
+        "type": "object",
+        "required": ["name", "emailAddress"],
+        "properties": {
+          "id": {"type": "integer"},
+          "name": {"type": "string", "minLength": 1},
+          "authenticationID": {"type": "string"},
+          "emailAddress": {"type": "string", "format": "email"},
+          "dateCreated": {"type": "string", "format": "date-time"}
+        }
+      },
+      "UserView": {
+        "type": "object",
+        "required": ["user"],
+        "properties": {"user": {"$ref": "#/components/schemas/User"}}
+      },
+      "UsersView": {
+        "type": "object",
+        "required": ["users"],
+        "properties": {"users": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}
+      },
+      "InviteUser": {
+        "type": "object",
+        "required": ["emailAddress", "trackerId"],
+        "properties": {
+          "emailAddress": {"type": "string", "format": "email"},
+          "trackerId": {"type": "integer", "minimum": 1}
+        }
+      },
+      "Invite": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "trackerId": {"type": "integer"},
+          "userId": {"type": "integer"},
+          "invitedByUserId": {"type": "integer"},
+          "emailAddress": {"type": "string", "format": "email"},
+          "dateCreated": {"type": "string", "format": "date-time"},
+          "dateExpires": {"type": "string", "format": "date-time"},
+          "dateAccepted": {"type": ["string", "null"], "format": "date-time"},
+          "dateRevoked": {"type": ["string", "null"], "format": "date-time"}
+        }
+      },
+      "InviteView": {
+        "type": "object",
+        "required": ["invite"],
+        "properties": {"invite": {"$ref": "#/components/schemas/Invite"}}
+      },
+      "InvitesView": {
+        "type": "object",
+        "required": ["invites"],
+        "properties": {"invites": {"type": "array", "items": {"$ref": "#/components/schemas/Invite"}}}
+      },
+      "Tracker": {
+        "type": "object",
+        "required": ["name", "currency"],
+        "properties": {
+          "id": {"type": "integer"},
+          "adminUserId": {"type": "integer"},
+          "trackerUserIds": {"type": ["array", "null"], "items": {"type": "integer"}},
+          "name": {"type": "string", "minLength": 1},
+          "dateCreated": {"type": "string", "format": "date-time"},
+          "currency": {"type": "string", "minLength": 1},
+          "version": {"type": "integer", "readOnly": true}
+        }
+      },
+      "TrackerView": {
+        "type": "object",
+        "required": ["tracker"],
+        "properties": {"tracker": {"$ref": "#/components/schemas/Tracker"}}
+      },
+      "TrackerPatch": {
+        "type": "object",
+        "description": "Only the members to change, null removes a member and arrays such as trackerUserIds are replaced whole. The result is validated like a full tracker",
+        "required": ["tracker"],
+        "properties": {"tracker": {"type": "object"}}
+      },
+      "TrackersView": {
+        "type": "object",
+        "required": ["trackers"],
+        "properties": {"trackers": {"type": "array", "items": {"$ref": "#/components/schemas/Tracker"}}}
+      },
+      "Spend": {
+        "type": "object",
+        "required": ["name", "value", "trackerId", "currency"],
+        "properties": {
+          "id": {"type": "integer"},
+          "value": {"$ref": "#/components/schemas/Money"},
+          "trackerId": {"type": "integer", "minimum": 1},
+          "name": {"type": "string", "minLength": 1},
+          "userId": {"type": "integer"},
+          "currency": {"type": "string", "minLength": 1},
+          "dateCreated": {"type": "string", "format": "date-time"},
+          "version": {"type": "integer", "readOnly": true}
+        }
+      },
+      "SpendView": {
+        "type": "object",
+        "required": ["spend"],
+        "properties": {"spend": {"$ref": "#/components/schemas/Spend"}}
+      },
+      "SpendPatch": {
+        "type": "object",
+        "description": "Only the members to change, null removes a member. The result is validated like a full spend",
+        "required": ["spend"],
+        "properties": {"spend": {"type": "object"}}
+      },
+      "SpendsView": {
+        "type": "object",
+        "required": ["spends"],
+        "properties": {"spends": {"type": "array", "items": {"$ref": "#/components/schemas/Spend"}}}
+      },
+      "Event": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "type": {"type": "string", "enum": ["spend.created", "spend.updated", "spend.deleted", "tracker.updated", "tracker.deleted", "transfers.updated"]},
+          "trackerId": {"type": "integer"},
+          "data": {"description": "The spend or tracker that changed, or every transfer for transfers.updated"},
+          "dateCreated": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Transfer": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "trackerId": {"type": "integer"},
+          "fromUserId": {"type": "integer"},
+          "toUserId": {"type": "integer"},
+          "value": {"$ref": "#/components/schemas/Money"},
+          "currency": {"type": "string"}
+        }
+      },
+      "TransfersView": {
+        "type": "object",
+        "required": ["transfers"],
+        "properties": {"transfers": {"type": "array", "items": {"$ref": "#/components/schemas/Transfer"}}}
+      },
+      "SpendSummary": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "trackerId": {"type": "integer"},
+          "userId": {"type": "integer"},
+          "value": {"$ref": "#/components/schemas/Money"},
+          "currency": {"type": "string"}
+        }
+      },
+      "SpendSummariesView": {
+        "type": "object",
+        "required": ["spendSummaries"],
+        "properties": {"spendSummaries": {"type": "array", "items": {"$ref": "#/components/schemas/SpendSummary"}}}
+      },
+      "APIToken": {
+        "type": "object",
+        "required": ["name", "scopes"],
+        "properties": {
+          "id": {"type": "integer"},
+          "userId": {"type": "integer"},
+          "name": {"type": "string", "minLength": 1},
+          "scopes": {"type": "array", "minItems": 1, "items": {"type": "string", "enum": ["read", "spends:write", "write"]}},
+          "dateCreated": {"type": "string", "format": "date-time"},
+          "dateLastUsed": {"type": ["string", "null"], "format": "date-time"},
+          "dateRevoked": {"type": ["string", "null"], "format": "date-time"}
+        }
+      },
+      "APITokenView": {
+        "type": "object",
+        "required": ["apiToken"],
+        "properties": {"apiToken": {"$ref": "#/components/schemas/APIToken"}}
+      },
+      "NewAPITokenView": {
+        "type": "object",
+        "required": ["apiToken", "token"],
+        "properties": {
+          "apiToken": {"$ref": "#/components/schemas/APIToken"},
+          "token": {"type": "string"}
+        }
+      },
+      "APITokensView": {
+        "type": "object",
+        "required": ["apiTokens"],
+        "properties": {"apiTokens": {"type": "array", "items": {"$ref": "#/components/schemas/APIToken"}}}
+      },
+      "GraphQLRequest": {
+        "type": "object",
+        "required": ["query"],
+        "properties": {
+          "query": {"type": "string", "minLength": 1},
+          "operationName": {"type": ["string", "null"]},
+          "variables": {"type": ["object", "null"]}
+        }
+      },
+      "GraphQLResponse": {
+        "type": "object",
+        "properties": {
+          "data": {"type": ["object", "null"]},
+          "errors": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "message": {"type": "string"},
+                "path": {"type": "array"},
+                "extensions": {"type": "object", "properties": {"code": {"type": "string"}, "status": {"type": "integer"}}}
+              }
+            }
+          }
+        }
+      },
+      "Webhook": {
+        "type": "object",
+        "required": ["trackerId", "url", "eventTypes"],
+        "properties": {
+          "id": {"type": "integer"},
+          "trackerId": {"type": "integer", "minimum": 1},
+          "createdByUserId": {"type": "integer"},
+          "url": {"type": "string", "minLength": 1},
+          "secret": {"type": "string", "description": "Used to sign deliveries, at least 16 characters. One is generated if left out, it is only returned when the webhook is created"},
+          "eventTypes": {"type": "array", "minItems": 1, "items": {"type": "string", "enum": ["spend.created", "spend.updated", "spend.deleted", "tracker.updated", "tracker.deleted", "transfers.updated"]}},
+          "dateCreated": {"type": "string", "format": "date-time"}
+        }
+      },
+      "WebhookView": {
+        "type": "object",
+        "required": ["webhook"],
+        "properties": {"webhook": {"$ref": "#/components/schemas/Webhook"}}
+      },
+      "WebhooksView": {
+        "type": "object",
+        "required": ["webhooks"],
+        "properties": {"webhooks": {"type": "array", "items": {"$ref": "#/components/schemas/Webhook"}}}
+      },
+      "WebhookDelivery": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "webhookId": {"type": "integer"},
+          "eventId": {"type": "string"},
+          "eventType": {"type": "string"},
+          "payload": {"type": "string", "description": "The body that was sent"},
+          "status": {"type": "string", "enum": ["pending", "succeeded", "failed"]},
+          "attempts": {"type": "integer"},
+          "maxAttempts": {"type": "integer"},
+          "responseStatus": {"type": "integer", "description": "The status of the last response, 0 if there was none"},
+          "error": {"type": "string"},
+          "dateCreated": {"type": "string", "format": "date-time"},
+          "dateNextAttempt": {"type": ["string", "null"], "format": "date-time"},
+          "dateCompleted": {"type": ["string", "null"], "format": "date-time"}
+        }
+      },
+      "WebhookDeliveryView": {
+        "type": "object",
+        "required": ["webhookDelivery"],
+        "properties": {"webhookDelivery": {"$ref": "#/components/schemas/WebhookDelivery"}}
+      },
+      "WebhookDeliveriesView": {
+        "type": "object",
+        "required": ["webhookDeliveries"],
+        "properties": {"webhookDeliveries": {"type": "array", "items": {"$ref": "#/components/schemas/WebhookDelivery"}}}
+      },
+      "Register": {
+        "type": "object",
+        "required": ["name", "emailAddress", "password"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "emailAddress": {"type": "string", "format": "email"},
+          "password": {"type": "string", "minLength": 8, "maxLength": 72}
+        }
+      },
+      "Login": {
+        "type": "object",
+        "required": ["emailAddress", "password"],
+        "properties": {
+          "emailAddress": {"type": "string"},
+          "password": {"type": "string"}
+        }
+      },
+      "LoginResult": {
+        "type": "object",
+        "properties": {
+          "token": {"type": "string"},
+          "dateExpires": {"type": "string", "format": "date-time"}
+        }
+      },
+      "ForgotPassword": {
+        "type": "object",
+        "required": ["emailAddress"],
+        "properties": {"emailAddress": {"type": "string"}}
+      },
+      "ResetPassword": {
+        "type": "object",
+        "required": ["token", "password"],
+        "properties": {
+          "token": {"type": "string", "minLength": 1},
+          "password": {"type": "string", "minLength": 8, "maxLength": 72}
+        }
+      }
+    }
+  }
+}`
@@ -0,0 +1,180 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ValidationError describes one part of a request that does not match the specification
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (validationError ValidationError) Error() string {
+	return fmt.Sprintf("%v %v", validationError.Field, validationError.Message)
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func (schema *Schema) allows(actual string) bool {
+	if len(schema.Type) == 0 {
+		return true
+	}
+	for _, t := range schema.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a value decoded with json.Decoder.UseNumber against the schema
+func (schema *Schema) Validate(field string, value interface{}) []ValidationError {
+
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, oneOf := range schema.OneOf {
+			if len(oneOf.Validate(field, value)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return []ValidationError{{Field: field, Message: "must match exactly one schema"}}
+		}
+		return nil
+	}
+
+	actual := jsonType(value)
+	if schema.allows(actual) == false {
+		return []ValidationError{{Field: field, Message: fmt.Sprintf("must be of type %v", schema.Type)}}
+	}
+
+	if len(schema.Enum) > 0 && inEnum(schema.Enum, value) == false {
+		return []ValidationError{{Field: field, Message: fmt.Sprintf("must be one of %v", schema.Enum)}}
+	}
+
+	var validationErrors []ValidationError
+	switch v := value.(type) {
+	case string:
+		validationErrors = append(validationErrors, schema.validateString(field, v)...)
+	case json.Number:
+		if schema.Minimum != nil {
+			number, _ := v.Float64()
+			if number < *schema.Minimum {
+				validationErrors = append(validationErrors, ValidationError{Field: field, Message: fmt.Sprintf("must be at least %v", *schema.Minimum)})
+			}
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			validationErrors = append(validationErrors, ValidationError{Field: field, Message: fmt.Sprintf("must have at least %v items", *schema.MinItems)})
+		}
+		if schema.Items != nil {
+			for index, item := range v {
+				validationErrors = append(validationErrors, schema.Items.Validate(fmt.Sprintf("%v[%v]", field, index), item)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, required := range schema.Required {
+			if _, ok := v[required]; ok == false {
+				validationErrors = append(validationErrors, ValidationError{Field: join(field, required), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := v[name]; ok {
+				validationErrors = append(validationErrors, schema.Properties[name].Validate(join(field, name), property)...)
+			}
+		}
+	}
+	return validationErrors
+}
+
+func (schema *Schema) validateString(field string, value string) []ValidationError {
+	if schema.MinLength != nil && len(value) < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			return []ValidationError{{Field: field, Message: "must not be empty"}}
+		}
+		return []ValidationError{{Field: field, Message: fmt.Sprintf("must be at least %v characters", *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && len(value) > *schema.MaxLength {
+		return []ValidationError{{Field: field, Message: fmt.Sprintf("must be at most %v characters", *schema.MaxLength)}}
+	}
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return []ValidationError{{Field: field, Message: "must be an RFC 3339 date-time"}}
+		}
+	case "email":
+		if _, err := mail.ParseAddress(value); err != nil {
+			return []ValidationError{{Field: field, Message: "must be an email address"}}
+		}
+	}
+	if schema.Pattern != "" {
+		if matched, err := regexp.MatchString(schema.Pattern, value); err != nil || matched == false {
+			return []ValidationError{{Field: field, Message: fmt.Sprintf("must match %v", schema.Pattern)}}
+		}
+	}
+	return nil
+}
+
+// ValidateParameter checks a path or query string value, which is always a string on the wire
+func (schema *Schema) ValidateParameter(field string, value string) []ValidationError {
+	if schema.allows("string") {
+		return schema.Validate(field, value)
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return schema.Validate(field, json.Number(value))
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && schema.allows("number") {
+		return schema.Validate(field, json.Number(value))
+	}
+	if value == "true" || value == "false" {
+		return schema.Validate(field, value == "true")
+	}
+	return []ValidationError{{Field: field, Message: fmt.Sprintf("must be of type %v", schema.Type)}}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func join(field string, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
@@ -0,0 +1,1438 @@
+// The gRPC API, served by grpcserver on GRPC_PORT. It exposes the same services as the REST api
+// and every call needs an "authorization: Bearer <token>" header, a JWT or a gdt_ API token.
+//
+// Regenerate godutchpb after changing this file, from the api directory:
+//   buf generate proto
+// or
+//   protoc --go_out=. --go_opt=module=github.com/TomPallister/godutch-api/api \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/TomPallister/godutch-api/api proto/godutch.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: godutch.proto
+
+package godutchpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	AuthenticationId string                 `protobuf:"bytes,3,opt,name=authentication_id,json=authenticationId,proto3" json:"authentication_id,omitempty"`
+	EmailAddress     string                 `protobuf:"bytes,4,opt,name=email_address,json=emailAddress,proto3" json:"email_address,omitempty"`
+	DateCreated      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_godutch_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *User) GetAuthenticationId() string {
+	if x != nil {
+		return x.AuthenticationId
+	}
+	return ""
+}
+
+func (x *User) GetEmailAddress() string {
+	if x != nil {
+		return x.EmailAddress
+	}
+	return ""
+}
+
+func (x *User) GetDateCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateCreated
+	}
+	return nil
+}
+
+type Tracker struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	AdminUserId    int64                  `protobuf:"varint,2,opt,name=admin_user_id,json=adminUserId,proto3" json:"admin_user_id,omitempty"`
+	TrackerUserIds []int64                `protobuf:"varint,3,rep,packed,name=tracker_user_ids,json=trackerUserIds,proto3" json:"tracker_user_ids,omitempty"`
+	Name           string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	DateCreated    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	Currency       string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	Version        int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Tracker) Reset() {
+	*x = Tracker{}
+	mi := &file_godutch_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tracker) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tracker) ProtoMessage() {}
+
+func (x *Tracker) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tracker.ProtoReflect.Descriptor instead.
+func (*Tracker) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Tracker) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Tracker) GetAdminUserId() int64 {
+	if x != nil {
+		return x.AdminUserId
+	}
+	return 0
+}
+
+func (x *Tracker) GetTrackerUserIds() []int64 {
+	if x != nil {
+		return x.TrackerUserIds
+	}
+	return nil
+}
+
+func (x *Tracker) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tracker) GetDateCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateCreated
+	}
+	return nil
+}
+
+func (x *Tracker) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Tracker) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Spend values are decimal strings, such as "10.50", to keep their precision
+type Spend struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TrackerId     int64                  `protobuf:"varint,3,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	UserId        int64                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	DateCreated   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Spend) Reset() {
+	*x = Spend{}
+	mi := &file_godutch_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Spend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Spend) ProtoMessage() {}
+
+func (x *Spend) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Spend.ProtoReflect.Descriptor instead.
+func (*Spend) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Spend) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Spend) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Spend) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+func (x *Spend) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Spend) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Spend) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Spend) GetDateCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateCreated
+	}
+	return nil
+}
+
+func (x *Spend) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Transfer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TrackerId     int64                  `protobuf:"varint,2,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	FromUserId    int64                  `protobuf:"varint,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`
+	ToUserId      int64                  `protobuf:"varint,4,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`
+	Value         string                 `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	Currency      string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transfer) Reset() {
+	*x = Transfer{}
+	mi := &file_godutch_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transfer) ProtoMessage() {}
+
+func (x *Transfer) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transfer.ProtoReflect.Descriptor instead.
+func (*Transfer) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Transfer) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transfer) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+func (x *Transfer) GetFromUserId() int64 {
+	if x != nil {
+		return x.FromUserId
+	}
+	return 0
+}
+
+func (x *Transfer) GetToUserId() int64 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *Transfer) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Transfer) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type SpendSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TrackerId     int64                  `protobuf:"varint,2,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Value         string                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Currency      string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpendSummary) Reset() {
+	*x = SpendSummary{}
+	mi := &file_godutch_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendSummary) ProtoMessage() {}
+
+func (x *SpendSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendSummary.ProtoReflect.Descriptor instead.
+func (*SpendSummary) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SpendSummary) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SpendSummary) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+func (x *SpendSummary) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SpendSummary) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *SpendSummary) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type Invite struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	TrackerId       int64                  `protobuf:"varint,2,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	UserId          int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	InvitedByUserId int64                  `protobuf:"varint,4,opt,name=invited_by_user_id,json=invitedByUserId,proto3" json:"invited_by_user_id,omitempty"`
+	EmailAddress    string                 `protobuf:"bytes,5,opt,name=email_address,json=emailAddress,proto3" json:"email_address,omitempty"`
+	DateCreated     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	DateExpires     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=date_expires,json=dateExpires,proto3" json:"date_expires,omitempty"`
+	DateAccepted    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=date_accepted,json=dateAccepted,proto3" json:"date_accepted,omitempty"`
+	DateRevoked     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=date_revoked,json=dateRevoked,proto3" json:"date_revoked,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Invite) Reset() {
+	*x = Invite{}
+	mi := &file_godutch_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invite) ProtoMessage() {}
+
+func (x *Invite) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invite.ProtoReflect.Descriptor instead.
+func (*Invite) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Invite) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Invite) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+func (x *Invite) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Invite) GetInvitedByUserId() int64 {
+	if x != nil {
+		return x.InvitedByUserId
+	}
+	return 0
+}
+
+func (x *Invite) GetEmailAddress() string {
+	if x != nil {
+		return x.EmailAddress
+	}
+	return ""
+}
+
+func (x *Invite) GetDateCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateCreated
+	}
+	return nil
+}
+
+func (x *Invite) GetDateExpires() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateExpires
+	}
+	return nil
+}
+
+func (x *Invite) GetDateAccepted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateAccepted
+	}
+	return nil
+}
+
+func (x *Invite) GetDateRevoked() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateRevoked
+	}
+	return nil
+}
+
+type IDRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IDRequest) Reset() {
+	*x = IDRequest{}
+	mi := &file_godutch_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IDRequest) ProtoMessage() {}
+
+func (x *IDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IDRequest.ProtoReflect.Descriptor instead.
+func (*IDRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IDRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type TrackerIDRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackerId     int64                  `protobuf:"varint,1,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackerIDRequest) Reset() {
+	*x = TrackerIDRequest{}
+	mi := &file_godutch_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackerIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackerIDRequest) ProtoMessage() {}
+
+func (x *TrackerIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackerIDRequest.ProtoReflect.Descriptor instead.
+func (*TrackerIDRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TrackerIDRequest) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+// DeleteRequest carries the version last read, the delete fails with FAILED_PRECONDITION if it has changed
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_godutch_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       bool                   `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_godutch_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteResponse) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+type ListTrackersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrackersRequest) Reset() {
+	*x = ListTrackersRequest{}
+	mi := &file_godutch_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrackersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrackersRequest) ProtoMessage() {}
+
+func (x *ListTrackersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrackersRequest.ProtoReflect.Descriptor instead.
+func (*ListTrackersRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{10}
+}
+
+type Trackers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trackers      []*Tracker             `protobuf:"bytes,1,rep,name=trackers,proto3" json:"trackers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trackers) Reset() {
+	*x = Trackers{}
+	mi := &file_godutch_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trackers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trackers) ProtoMessage() {}
+
+func (x *Trackers) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trackers.ProtoReflect.Descriptor instead.
+func (*Trackers) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Trackers) GetTrackers() []*Tracker {
+	if x != nil {
+		return x.Trackers
+	}
+	return nil
+}
+
+type Users struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Users) Reset() {
+	*x = Users{}
+	mi := &file_godutch_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Users) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Users) ProtoMessage() {}
+
+func (x *Users) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Users.ProtoReflect.Descriptor instead.
+func (*Users) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Users) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type Spends struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Spends        []*Spend               `protobuf:"bytes,1,rep,name=spends,proto3" json:"spends,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Spends) Reset() {
+	*x = Spends{}
+	mi := &file_godutch_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Spends) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Spends) ProtoMessage() {}
+
+func (x *Spends) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Spends.ProtoReflect.Descriptor instead.
+func (*Spends) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Spends) GetSpends() []*Spend {
+	if x != nil {
+		return x.Spends
+	}
+	return nil
+}
+
+type Transfers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transfers     []*Transfer            `protobuf:"bytes,1,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transfers) Reset() {
+	*x = Transfers{}
+	mi := &file_godutch_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transfers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transfers) ProtoMessage() {}
+
+func (x *Transfers) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transfers.ProtoReflect.Descriptor instead.
+func (*Transfers) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Transfers) GetTransfers() []*Transfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+type SpendSummaries struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SpendSummaries []*SpendSummary        `protobuf:"bytes,1,rep,name=spend_summaries,json=spendSummaries,proto3" json:"spend_summaries,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SpendSummaries) Reset() {
+	*x = SpendSummaries{}
+	mi := &file_godutch_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendSummaries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendSummaries) ProtoMessage() {}
+
+func (x *SpendSummaries) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendSummaries.ProtoReflect.Descriptor instead.
+func (*SpendSummaries) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SpendSummaries) GetSpendSummaries() []*SpendSummary {
+	if x != nil {
+		return x.SpendSummaries
+	}
+	return nil
+}
+
+type Invites struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invites       []*Invite              `protobuf:"bytes,1,rep,name=invites,proto3" json:"invites,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invites) Reset() {
+	*x = Invites{}
+	mi := &file_godutch_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invites) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invites) ProtoMessage() {}
+
+func (x *Invites) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invites.ProtoReflect.Descriptor instead.
+func (*Invites) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Invites) GetInvites() []*Invite {
+	if x != nil {
+		return x.Invites
+	}
+	return nil
+}
+
+type GetCurrentUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentUserRequest) Reset() {
+	*x = GetCurrentUserRequest{}
+	mi := &file_godutch_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentUserRequest) ProtoMessage() {}
+
+func (x *GetCurrentUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentUserRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentUserRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{17}
+}
+
+type InviteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EmailAddress  string                 `protobuf:"bytes,1,opt,name=email_address,json=emailAddress,proto3" json:"email_address,omitempty"`
+	TrackerId     int64                  `protobuf:"varint,2,opt,name=tracker_id,json=trackerId,proto3" json:"tracker_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InviteUserRequest) Reset() {
+	*x = InviteUserRequest{}
+	mi := &file_godutch_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InviteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InviteUserRequest) ProtoMessage() {}
+
+func (x *InviteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InviteUserRequest.ProtoReflect.Descriptor instead.
+func (*InviteUserRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *InviteUserRequest) GetEmailAddress() string {
+	if x != nil {
+		return x.EmailAddress
+	}
+	return ""
+}
+
+func (x *InviteUserRequest) GetTrackerId() int64 {
+	if x != nil {
+		return x.TrackerId
+	}
+	return 0
+}
+
+type AcceptInviteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InviteToken   string                 `protobuf:"bytes,1,opt,name=invite_token,json=inviteToken,proto3" json:"invite_token,omitempty"`
+	User          *User                  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptInviteRequest) Reset() {
+	*x = AcceptInviteRequest{}
+	mi := &file_godutch_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptInviteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptInviteRequest) ProtoMessage() {}
+
+func (x *AcceptInviteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_godutch_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptInviteRequest.ProtoReflect.Descriptor instead.
+func (*AcceptInviteRequest) Descriptor() ([]byte, []int) {
+	return file_godutch_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AcceptInviteRequest) GetInviteToken() string {
+	if x != nil {
+		return x.InviteToken
+	}
+	return ""
+}
+
+func (x *AcceptInviteRequest) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+var File_godutch_proto protoreflect.FileDescriptor
+
+const file_godutch_proto_rawDesc = "" +
+	"\n" +
+	"\rgodutch.proto\x12\n" +
+	"godutch.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbb\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12+\n" +
+	"\x11authentication_id\x18\x03 \x01(\tR\x10authenticationId\x12#\n" +
+	"\remail_address\x18\x04 \x01(\tR\femailAddress\x12=\n" +
+	"\fdate_created\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vdateCreated\"\xf0\x01\n" +
+	"\aTracker\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\"\n" +
+	"\radmin_user_id\x18\x02 \x01(\x03R\vadminUserId\x12(\n" +
+	"\x10tracker_user_ids\x18\x03 \x03(\x03R\x0etrackerUserIds\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12=\n" +
+	"\fdate_created\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vdateCreated\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\"\xee\x01\n" +
+	"\x05Spend\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x03 \x01(\x03R\ttrackerId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\x03R\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12=\n" +
+	"\fdate_created\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vdateCreated\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\"\xab\x01\n" +
+	"\bTransfer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x02 \x01(\x03R\ttrackerId\x12 \n" +
+	"\ffrom_user_id\x18\x03 \x01(\x03R\n" +
+	"fromUserId\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x04 \x01(\x03R\btoUserId\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\tR\x05value\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\"\x88\x01\n" +
+	"\fSpendSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x02 \x01(\x03R\ttrackerId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\"\xa0\x03\n" +
+	"\x06Invite\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x02 \x01(\x03R\ttrackerId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12+\n" +
+	"\x12invited_by_user_id\x18\x04 \x01(\x03R\x0finvitedByUserId\x12#\n" +
+	"\remail_address\x18\x05 \x01(\tR\femailAddress\x12=\n" +
+	"\fdate_created\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vdateCreated\x12=\n" +
+	"\fdate_expires\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vdateExpires\x12?\n" +
+	"\rdate_accepted\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\fdateAccepted\x12=\n" +
+	"\fdate_revoked\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vdateRevoked\"\x1b\n" +
+	"\tIDRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"1\n" +
+	"\x10TrackerIDRequest\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x01 \x01(\x03R\ttrackerId\"9\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"*\n" +
+	"\x0eDeleteResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\bR\adeleted\"\x15\n" +
+	"\x13ListTrackersRequest\";\n" +
+	"\bTrackers\x12/\n" +
+	"\btrackers\x18\x01 \x03(\v2\x13.godutch.v1.TrackerR\btrackers\"/\n" +
+	"\x05Users\x12&\n" +
+	"\x05users\x18\x01 \x03(\v2\x10.godutch.v1.UserR\x05users\"3\n" +
+	"\x06Spends\x12)\n" +
+	"\x06spends\x18\x01 \x03(\v2\x11.godutch.v1.SpendR\x06spends\"?\n" +
+	"\tTransfers\x122\n" +
+	"\ttransfers\x18\x01 \x03(\v2\x14.godutch.v1.TransferR\ttransfers\"S\n" +
+	"\x0eSpendSummaries\x12A\n" +
+	"\x0fspend_summaries\x18\x01 \x03(\v2\x18.godutch.v1.SpendSummaryR\x0espendSummaries\"7\n" +
+	"\aInvites\x12,\n" +
+	"\ainvites\x18\x01 \x03(\v2\x12.godutch.v1.InviteR\ainvites\"\x17\n" +
+	"\x15GetCurrentUserRequest\"W\n" +
+	"\x11InviteUserRequest\x12#\n" +
+	"\remail_address\x18\x01 \x01(\tR\femailAddress\x12\x1d\n" +
+	"\n" +
+	"tracker_id\x18\x02 \x01(\x03R\ttrackerId\"^\n" +
+	"\x13AcceptInviteRequest\x12!\n" +
+	"\finvite_token\x18\x01 \x01(\tR\vinviteToken\x12$\n" +
+	"\x04user\x18\x02 \x01(\v2\x10.godutch.v1.UserR\x04user2\x8d\x03\n" +
+	"\x0eTrackerService\x12E\n" +
+	"\fListTrackers\x12\x1f.godutch.v1.ListTrackersRequest\x1a\x14.godutch.v1.Trackers\x128\n" +
+	"\n" +
+	"GetTracker\x12\x15.godutch.v1.IDRequest\x1a\x13.godutch.v1.Tracker\x129\n" +
+	"\rCreateTracker\x12\x13.godutch.v1.Tracker\x1a\x13.godutch.v1.Tracker\x129\n" +
+	"\rUpdateTracker\x12\x13.godutch.v1.Tracker\x1a\x13.godutch.v1.Tracker\x12F\n" +
+	"\rDeleteTracker\x12\x19.godutch.v1.DeleteRequest\x1a\x1a.godutch.v1.DeleteResponse\x12<\n" +
+	"\x10ListTrackerUsers\x12\x15.godutch.v1.IDRequest\x1a\x11.godutch.v1.Users2\xb4\x02\n" +
+	"\fSpendService\x12>\n" +
+	"\n" +
+	"ListSpends\x12\x1c.godutch.v1.TrackerIDRequest\x1a\x12.godutch.v1.Spends\x124\n" +
+	"\bGetSpend\x12\x15.godutch.v1.IDRequest\x1a\x11.godutch.v1.Spend\x123\n" +
+	"\vCreateSpend\x12\x11.godutch.v1.Spend\x1a\x11.godutch.v1.Spend\x123\n" +
+	"\vUpdateSpend\x12\x11.godutch.v1.Spend\x1a\x11.godutch.v1.Spend\x12D\n" +
+	"\vDeleteSpend\x12\x19.godutch.v1.DeleteRequest\x1a\x1a.godutch.v1.DeleteResponse2W\n" +
+	"\x0fTransferService\x12D\n" +
+	"\rListTransfers\x12\x1c.godutch.v1.TrackerIDRequest\x1a\x15.godutch.v1.Transfers2e\n" +
+	"\x13SpendSummaryService\x12N\n" +
+	"\x12ListSpendSummaries\x12\x1c.godutch.v1.TrackerIDRequest\x1a\x1a.godutch.v1.SpendSummaries2\xfb\x03\n" +
+	"\vUserService\x12E\n" +
+	"\x0eGetCurrentUser\x12!.godutch.v1.GetCurrentUserRequest\x1a\x10.godutch.v1.User\x122\n" +
+	"\aGetUser\x12\x15.godutch.v1.IDRequest\x1a\x10.godutch.v1.User\x120\n" +
+	"\n" +
+	"CreateUser\x12\x10.godutch.v1.User\x1a\x10.godutch.v1.User\x12=\n" +
+	"\n" +
+	"InviteUser\x12\x1d.godutch.v1.InviteUserRequest\x1a\x10.godutch.v1.User\x12A\n" +
+	"\fAcceptInvite\x12\x1f.godutch.v1.AcceptInviteRequest\x1a\x10.godutch.v1.User\x12G\n" +
+	"\x12ListPendingInvites\x12\x1c.godutch.v1.TrackerIDRequest\x1a\x13.godutch.v1.Invites\x129\n" +
+	"\fResendInvite\x12\x15.godutch.v1.IDRequest\x1a\x12.godutch.v1.Invite\x129\n" +
+	"\fRevokeInvite\x12\x15.godutch.v1.IDRequest\x1a\x12.godutch.v1.InviteBCZAgithub.com/TomPallister/godutch-api/api/proto/godutchpb;godutchpbb\x06proto3"
+
+var (
+	file_godutch_proto_rawDescOnce sync.Once
+	file_godutch_proto_rawDescData []byte
+)
+
+func file_godutch_proto_rawDescGZIP() []byte {
+	file_godutch_proto_rawDescOnce.Do(func() {
+		file_godutch_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_godutch_proto_rawDesc), len(file_godutch_proto_rawDesc)))
+	})
+	return file_godutch_proto_rawDescData
+}
+
+var file_godutch_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_godutch_proto_goTypes = []any{
+	(*User)(nil),                  // 0: godutch.v1.User
+	(*Tracker)(nil),               // 1: godutch.v1.Tracker
+	(*Spend)(nil),                 // 2: godutch.v1.Spend
+	(*Transfer)(nil),              // 3: godutch.v1.Transfer
+	(*SpendSummary)(nil),          // 4: godutch.v1.SpendSummary
+	(*Invite)(nil),                // 5: godutch.v1.Invite
+	(*IDRequest)(nil),             // 6: godutch.v1.IDRequest
+	(*TrackerIDRequest)(nil),      // 7: godutch.v1.TrackerIDRequest
+	(*DeleteRequest)(nil),         // 8: godutch.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 9: godutch.v1.DeleteResponse
+	(*ListTrackersRequest)(nil),   // 10: godutch.v1.ListTrackersRequest
+	(*Trackers)(nil),              // 11: godutch.v1.Trackers
+	(*Users)(nil),                 // 12: godutch.v1.Users
+	(*Spends)(nil),                // 13: godutch.v1.Spends
+	(*Transfers)(nil),             // 14: godutch.v1.Transfers
+	(*SpendSummaries)(nil),        // 15: godutch.v1.SpendSummaries
+	(*Invites)(nil),               // 16: godutch.v1.Invites
+	(*GetCurrentUserRequest)(nil), // 17: godutch.v1.GetCurrentUserRequest
+	(*InviteUserRequest)(nil),     // 18: godutch.v1.InviteUserRequest
+	(*AcceptInviteRequest)(nil),   // 19: godutch.v1.AcceptInviteRequest
+	(*timestamppb.Timestamp)(nil), // 20: google.protobuf.Timestamp
+}
+var file_godutch_proto_depIdxs = []int32{
+	20, // 0: godutch.v1.User.date_created:type_name -> google.protobuf.Timestamp
+	20, // 1: godutch.v1.Tracker.date_created:type_name -> google.protobuf.Timestamp
+	20, // 2: godutch.v1.Spend.date_created:type_name -> google.protobuf.Timestamp
+	20, // 3: godutch.v1.Invite.date_created:type_name -> google.protobuf.Timestamp
+	20, // 4: godutch.v1.Invite.date_expires:type_name -> google.protobuf.Timestamp
+	20, // 5: godutch.v1.Invite.date_accepted:type_name -> google.protobuf.Timestamp
+	20, // 6: godutch.v1.Invite.date_revoked:type_name -> google.protobuf.Timestamp
+	1,  // 7: godutch.v1.Trackers.trackers:type_name -> godutch.v1.Tracker
+	0,  // 8: godutch.v1.Users.users:type_name -> godutch.v1.User
+	2,  // 9: godutch.v1.Spends.spends:type_name -> godutch.v1.Spend
+	3,  // 10: godutch.v1.Transfers.transfers:type_name -> godutch.v1.Transfer
+	4,  // 11: godutch.v1.SpendSummaries.spend_summaries:type_name -> godutch.v1.SpendSummary
+	5,  // 12: godutch.v1.Invites.invites:type_name -> godutch.v1.Invite
+	0,  // 13: godutch.v1.AcceptInviteRequest.user:type_name -> godutch.v1.User
+	10, // 14: godutch.v1.TrackerService.ListTrackers:input_type -> godutch.v1.ListTrackersRequest
+	6,  // 15: godutch.v1.TrackerService.GetTracker:input_type -> godutch.v1.IDRequest
+	1,  // 16: godutch.v1.TrackerService.CreateTracker:input_type -> godutch.v1.Tracker
+	1,  // 17: godutch.v1.TrackerService.UpdateTracker:input_type -> godutch.v1.Tracker
+	8,  // 18: godutch.v1.TrackerService.DeleteTracker:input_type -> godutch.v1.DeleteRequest
+	6,  // 19: godutch.v1.TrackerService.ListTrackerUsers:input_type -> godutch.v1.IDRequest
+	7,  // 20: godutch.v1.SpendService.ListSpends:input_type -> godutch.v1.TrackerIDRequest
+	6,  // 21: godutch.v1.SpendService.GetSpend:input_type -> godutch.v1.IDRequest
+	2,  // 22: godutch.v1.SpendService.CreateSpend:input_type -> godutch.v1.Spend
+	2,  // 23: godutch.v1.SpendService.UpdateSpend:input_type -> godutch.v1.Spend
+	8,  // 24: godutch.v1.SpendService.DeleteSpend:input_type -> godutch.v1.DeleteRequest
+	7,  // 25: godutch.v1.TransferService.ListTransfers:input_type -> godutch.v1.TrackerIDRequest
+	7,  // 26: godutch.v1.SpendSummaryService.ListSpendSummaries:input_type -> godutch.v1.TrackerIDRequest
+	17, // 27: godutch.v1.UserService.GetCurrentUser:input_type -> godutch.v1.GetCurrentUserRequest
+	6,  // 28: godutch.v1.UserService.GetUser:input_type -> godutch.v1.IDRequest
+	0,  // 29: godutch.v1.UserService.CreateUser:input_type -> godutch.v1.User
+	18, // 30: godutch.v1.UserService.InviteUser:input_type -> godutch.v1.InviteUserRequest
+	19, // 31: godutch.v1.UserService.AcceptInvite:input_type -> godutch.v1.AcceptInviteRequest
+	7,  // 32: godutch.v1.UserService.ListPendingInvites:input_type -> godutch.v1.TrackerIDRequest
+	6,  // 33: godutch.v1.UserService.ResendInvite:input_type -> godutch.v1.IDRequest
+	6,  // 34: godutch.v1.UserService.RevokeInvite:input_type -> godutch.v1.IDRequest
+	11, // 35: godutch.v1.TrackerService.ListTrackers:output_type -> godutch.v1.Trackers
+	1,  // 36: godutch.v1.TrackerService.GetTracker:output_type -> godutch.v1.Tracker
+	1,  // 37: godutch.v1.TrackerService.CreateTracker:output_type -> godutch.v1.Tracker
+	1,  // 38: godutch.v1.TrackerService.UpdateTracker:output_type -> godutch.v1.Tracker
+	9,  // 39: godutch.v1.TrackerService.DeleteTracker:output_type -> godutch.v1.DeleteResponse
+	12, // 40: godutch.v1.TrackerService.ListTrackerUsers:output_type -> godutch.v1.Users
+	13, // 41: godutch.v1.SpendService.ListSpends:output_type -> godutch.v1.Spends
+	2,  // 42: godutch.v1.SpendService.GetSpend:output_type -> godutch.v1.Spend
+	2,  // 43: godutch.v1.SpendService.CreateSpend:output_type -> godutch.v1.Spend
+	2,  // 44: godutch.v1.SpendService.UpdateSpend:output_type -> godutch.v1.Spend
+	9,  // 45: godutch.v1.SpendService.DeleteSpend:output_type -> godutch.v1.DeleteResponse
+	14, // 46: godutch.v1.TransferService.ListTransfers:output_type -> godutch.v1.Transfers
+	15, // 47: godutch.v1.SpendSummaryService.ListSpendSummaries:output_type -> godutch.v1.SpendSummaries
+	0,  // 48: godutch.v1.UserService.GetCurrentUser:output_type -> godutch.v1.User
+	0,  // 49: godutch.v1.UserService.GetUser:output_type -> godutch.v1.User
+	0,  // 50: godutch.v1.UserService.CreateUser:output_type -> godutch.v1.User
+	0,  // 51: godutch.v1.UserService.InviteUser:output_type -> godutch.v1.User
+	0,  // 52: godutch.v1.UserService.AcceptInvite:output_type -> godutch.v1.User
+	16, // 53: godutch.v1.UserService.ListPendingInvites:output_type -> godutch.v1.Invites
+	5,  // 54: godutch.v1.UserService.ResendInvite:output_type -> godutch.v1.Invite
+	5,  // 55: godutch.v1.UserService.RevokeInvite:output_type -> godutch.v1.Invite
+	35, // [35:56] is the sub-list for method output_type
+	14, // [14:35] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_godutch_proto_init() }
+func file_godutch_proto_init() {
+	if File_godutch_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_godutch_proto_rawDesc), len(file_godutch_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   5,
+		},
+		GoTypes:           file_godutch_proto_goTypes,
+		DependencyIndexes: file_godutch_proto_depIdxs,
+		MessageInfos:      file_godutch_proto_msgTypes,
+	}.Build()
+	File_godutch_proto = out.File
+	file_godutch_proto_goTypes = nil
+	file_godutch_proto_depIdxs = nil
+}
@@ -0,0 +1,1152 @@
+// The gRPC API, served by grpcserver on GRPC_PORT. It exposes the same services as the REST api
+// and every call needs an "authorization: Bearer <token>" header, a JWT or a gdt_ API token.
+//
+// Regenerate godutchpb after changing this file, from the api directory:
+//   buf generate proto
+// or
+//   protoc --go_out=. --go_opt=module=github.com/TomPallister/godutch-api/api \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/TomPallister/godutch-api/api proto/godutch.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: godutch.proto
+
+package godutchpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TrackerService_ListTrackers_FullMethodName     = "/godutch.v1.TrackerService/ListTrackers"
+	TrackerService_GetTracker_FullMethodName       = "/godutch.v1.TrackerService/GetTracker"
+	TrackerService_CreateTracker_FullMethodName    = "/godutch.v1.TrackerService/CreateTracker"
+	TrackerService_UpdateTracker_FullMethodName    = "/godutch.v1.TrackerService/UpdateTracker"
+	TrackerService_DeleteTracker_FullMethodName    = "/godutch.v1.TrackerService/DeleteTracker"
+	TrackerService_ListTrackerUsers_FullMethodName = "/godutch.v1.TrackerService/ListTrackerUsers"
+)
+
+// TrackerServiceClient is the client API for TrackerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TrackerServiceClient interface {
+	// ListTrackers returns the trackers the caller belongs to
+	ListTrackers(ctx context.Context, in *ListTrackersRequest, opts ...grpc.CallOption) (*Trackers, error)
+	GetTracker(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Tracker, error)
+	CreateTracker(ctx context.Context, in *Tracker, opts ...grpc.CallOption) (*Tracker, error)
+	// UpdateTracker fails with FAILED_PRECONDITION if version is not the current one
+	UpdateTracker(ctx context.Context, in *Tracker, opts ...grpc.CallOption) (*Tracker, error)
+	DeleteTracker(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	ListTrackerUsers(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Users, error)
+}
+
+type trackerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrackerServiceClient(cc grpc.ClientConnInterface) TrackerServiceClient {
+	return &trackerServiceClient{cc}
+}
+
+func (c *trackerServiceClient) ListTrackers(ctx context.Context, in *ListTrackersRequest, opts ...grpc.CallOption) (*Trackers, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Trackers)
+	err := c.cc.Invoke(ctx, TrackerService_ListTrackers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) GetTracker(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Tracker, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tracker)
+	err := c.cc.Invoke(ctx, TrackerService_GetTracker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) CreateTracker(ctx context.Context, in *Tracker, opts ...grpc.CallOption) (*Tracker, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tracker)
+	err := c.cc.Invoke(ctx, TrackerService_CreateTracker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) UpdateTracker(ctx context.Context, in *Tracker, opts ...grpc.CallOption) (*Tracker, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tracker)
+	err := c.cc.Invoke(ctx, TrackerService_UpdateTracker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) DeleteTracker(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, TrackerService_DeleteTracker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) ListTrackerUsers(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Users, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Users)
+	err := c.cc.Invoke(ctx, TrackerService_ListTrackerUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TrackerServiceServer is the server API for TrackerService service.
+// All implementations must embed UnimplementedTrackerServiceServer
+// for forward compatibility.
+type TrackerServiceServer interface {
+	// ListTrackers returns the trackers the caller belongs to
+	ListTrackers(context.Context, *ListTrackersRequest) (*Trackers, error)
+	GetTracker(context.Context, *IDRequest) (*Tracker, error)
+	CreateTracker(context.Context, *Tracker) (*Tracker, error)
+	// UpdateTracker fails with FAILED_PRECONDITION if version is not the current one
+	UpdateTracker(context.Context, *Tracker) (*Tracker, error)
+	DeleteTracker(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	ListTrackerUsers(context.Context, *IDRequest) (*Users, error)
+	mustEmbedUnimplementedTrackerServiceServer()
+}
+
+// UnimplementedTrackerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTrackerServiceServer struct{}
+
+func (UnimplementedTrackerServiceServer) ListTrackers(context.Context, *ListTrackersRequest) (*Trackers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrackers not implemented")
+}
+func (UnimplementedTrackerServiceServer) GetTracker(context.Context, *IDRequest) (*Tracker, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTracker not implemented")
+}
+func (UnimplementedTrackerServiceServer) CreateTracker(context.Context, *Tracker) (*Tracker, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTracker not implemented")
+}
+func (UnimplementedTrackerServiceServer) UpdateTracker(context.Context, *Tracker) (*Tracker, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTracker not implemented")
+}
+func (UnimplementedTrackerServiceServer) DeleteTracker(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTracker not implemented")
+}
+func (UnimplementedTrackerServiceServer) ListTrackerUsers(context.Context, *IDRequest) (*Users, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrackerUsers not implemented")
+}
+func (UnimplementedTrackerServiceServer) mustEmbedUnimplementedTrackerServiceServer() {}
+func (UnimplementedTrackerServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeTrackerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TrackerServiceServer will
+// result in compilation errors.
+type UnsafeTrackerServiceServer interface {
+	mustEmbedUnimplementedTrackerServiceServer()
+}
+
+func RegisterTrackerServiceServer(s grpc.ServiceRegistrar, srv TrackerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTrackerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TrackerService_ServiceDesc, srv)
+}
+
+func _TrackerService_ListTrackers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrackersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).ListTrackers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_ListTrackers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).ListTrackers(ctx, req.(*ListTrackersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_GetTracker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).GetTracker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_GetTracker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).GetTracker(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_CreateTracker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Tracker)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).CreateTracker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_CreateTracker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).CreateTracker(ctx, req.(*Tracker))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_UpdateTracker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Tracker)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).UpdateTracker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_UpdateTracker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).UpdateTracker(ctx, req.(*Tracker))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_DeleteTracker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).DeleteTracker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_DeleteTracker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).DeleteTracker(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_ListTrackerUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).ListTrackerUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrackerService_ListTrackerUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).ListTrackerUsers(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TrackerService_ServiceDesc is the grpc.ServiceDesc for TrackerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TrackerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godutch.v1.TrackerService",
+	HandlerType: (*TrackerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTrackers",
+			Handler:    _TrackerService_ListTrackers_Handler,
+		},
+		{
+			MethodName: "GetTracker",
+			Handler:    _TrackerService_GetTracker_Handler,
+		},
+		{
+			MethodName: "CreateTracker",
+			Handler:    _TrackerService_CreateTracker_Handler,
+		},
+		{
+			MethodName: "UpdateTracker",
+			Handler:    _TrackerService_UpdateTracker_Handler,
+		},
+		{
+			MethodName: "DeleteTracker",
+			Handler:    _TrackerService_DeleteTracker_Handler,
+		},
+		{
+			MethodName: "ListTrackerUsers",
+			Handler:    _TrackerService_ListTrackerUsers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "godutch.proto",
+}
+
+const (
+	SpendService_ListSpends_FullMethodName  = "/godutch.v1.SpendService/ListSpends"
+	SpendService_GetSpend_FullMethodName    = "/godutch.v1.SpendService/GetSpend"
+	SpendService_CreateSpend_FullMethodName = "/godutch.v1.SpendService/CreateSpend"
+	SpendService_UpdateSpend_FullMethodName = "/godutch.v1.SpendService/UpdateSpend"
+	SpendService_DeleteSpend_FullMethodName = "/godutch.v1.SpendService/DeleteSpend"
+)
+
+// SpendServiceClient is the client API for SpendService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SpendServiceClient interface {
+	ListSpends(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Spends, error)
+	GetSpend(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Spend, error)
+	CreateSpend(ctx context.Context, in *Spend, opts ...grpc.CallOption) (*Spend, error)
+	// UpdateSpend fails with FAILED_PRECONDITION if version is not the current one
+	UpdateSpend(ctx context.Context, in *Spend, opts ...grpc.CallOption) (*Spend, error)
+	DeleteSpend(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+}
+
+type spendServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpendServiceClient(cc grpc.ClientConnInterface) SpendServiceClient {
+	return &spendServiceClient{cc}
+}
+
+func (c *spendServiceClient) ListSpends(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Spends, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Spends)
+	err := c.cc.Invoke(ctx, SpendService_ListSpends_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spendServiceClient) GetSpend(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Spend, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Spend)
+	err := c.cc.Invoke(ctx, SpendService_GetSpend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spendServiceClient) CreateSpend(ctx context.Context, in *Spend, opts ...grpc.CallOption) (*Spend, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Spend)
+	err := c.cc.Invoke(ctx, SpendService_CreateSpend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spendServiceClient) UpdateSpend(ctx context.Context, in *Spend, opts ...grpc.CallOption) (*Spend, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Spend)
+	err := c.cc.Invoke(ctx, SpendService_UpdateSpend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *spendServiceClient) DeleteSpend(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, SpendService_DeleteSpend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SpendServiceServer is the server API for SpendService service.
+// All implementations must embed UnimplementedSpendServiceServer
+// for forward compatibility.
+type SpendServiceServer interface {
+	ListSpends(context.Context, *TrackerIDRequest) (*Spends, error)
+	GetSpend(context.Context, *IDRequest) (*Spend, error)
+	CreateSpend(context.Context, *Spend) (*Spend, error)
+	// UpdateSpend fails with FAILED_PRECONDITION if version is not the current one
+	UpdateSpend(context.Context, *Spend) (*Spend, error)
+	DeleteSpend(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	mustEmbedUnimplementedSpendServiceServer()
+}
+
+// UnimplementedSpendServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpendServiceServer struct{}
+
+func (UnimplementedSpendServiceServer) ListSpends(context.Context, *TrackerIDRequest) (*Spends, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSpends not implemented")
+}
+func (UnimplementedSpendServiceServer) GetSpend(context.Context, *IDRequest) (*Spend, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpend not implemented")
+}
+func (UnimplementedSpendServiceServer) CreateSpend(context.Context, *Spend) (*Spend, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSpend not implemented")
+}
+func (UnimplementedSpendServiceServer) UpdateSpend(context.Context, *Spend) (*Spend, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSpend not implemented")
+}
+func (UnimplementedSpendServiceServer) DeleteSpend(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSpend not implemented")
+}
+func (UnimplementedSpendServiceServer) mustEmbedUnimplementedSpendServiceServer() {}
+func (UnimplementedSpendServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeSpendServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpendServiceServer will
+// result in compilation errors.
+type UnsafeSpendServiceServer interface {
+	mustEmbedUnimplementedSpendServiceServer()
+}
+
+func RegisterSpendServiceServer(s grpc.ServiceRegistrar, srv SpendServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSpendServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SpendService_ServiceDesc, srv)
+}
+
+func _SpendService_ListSpends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendServiceServer).ListSpends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendService_ListSpends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendServiceServer).ListSpends(ctx, req.(*TrackerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpendService_GetSpend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendServiceServer).GetSpend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendService_GetSpend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendServiceServer).GetSpend(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpendService_CreateSpend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Spend)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendServiceServer).CreateSpend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendService_CreateSpend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendServiceServer).CreateSpend(ctx, req.(*Spend))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpendService_UpdateSpend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Spend)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendServiceServer).UpdateSpend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendService_UpdateSpend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendServiceServer).UpdateSpend(ctx, req.(*Spend))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpendService_DeleteSpend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendServiceServer).DeleteSpend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendService_DeleteSpend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendServiceServer).DeleteSpend(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SpendService_ServiceDesc is the grpc.ServiceDesc for SpendService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SpendService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godutch.v1.SpendService",
+	HandlerType: (*SpendServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSpends",
+			Handler:    _SpendService_ListSpends_Handler,
+		},
+		{
+			MethodName: "GetSpend",
+			Handler:    _SpendService_GetSpend_Handler,
+		},
+		{
+			MethodName: "CreateSpend",
+			Handler:    _SpendService_CreateSpend_Handler,
+		},
+		{
+			MethodName: "UpdateSpend",
+			Handler:    _SpendService_UpdateSpend_Handler,
+		},
+		{
+			MethodName: "DeleteSpend",
+			Handler:    _SpendService_DeleteSpend_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "godutch.proto",
+}
+
+const (
+	TransferService_ListTransfers_FullMethodName = "/godutch.v1.TransferService/ListTransfers"
+)
+
+// TransferServiceClient is the client API for TransferService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TransferServiceClient interface {
+	ListTransfers(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Transfers, error)
+}
+
+type transferServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransferServiceClient(cc grpc.ClientConnInterface) TransferServiceClient {
+	return &transferServiceClient{cc}
+}
+
+func (c *transferServiceClient) ListTransfers(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Transfers, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transfers)
+	err := c.cc.Invoke(ctx, TransferService_ListTransfers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransferServiceServer is the server API for TransferService service.
+// All implementations must embed UnimplementedTransferServiceServer
+// for forward compatibility.
+type TransferServiceServer interface {
+	ListTransfers(context.Context, *TrackerIDRequest) (*Transfers, error)
+	mustEmbedUnimplementedTransferServiceServer()
+}
+
+// UnimplementedTransferServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTransferServiceServer struct{}
+
+func (UnimplementedTransferServiceServer) ListTransfers(context.Context, *TrackerIDRequest) (*Transfers, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransfers not implemented")
+}
+func (UnimplementedTransferServiceServer) mustEmbedUnimplementedTransferServiceServer() {}
+func (UnimplementedTransferServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeTransferServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TransferServiceServer will
+// result in compilation errors.
+type UnsafeTransferServiceServer interface {
+	mustEmbedUnimplementedTransferServiceServer()
+}
+
+func RegisterTransferServiceServer(s grpc.ServiceRegistrar, srv TransferServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTransferServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TransferService_ServiceDesc, srv)
+}
+
+func _TransferService_ListTransfers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransferServiceServer).ListTransfers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransferService_ListTransfers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransferServiceServer).ListTransfers(ctx, req.(*TrackerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TransferService_ServiceDesc is the grpc.ServiceDesc for TransferService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TransferService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godutch.v1.TransferService",
+	HandlerType: (*TransferServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTransfers",
+			Handler:    _TransferService_ListTransfers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "godutch.proto",
+}
+
+const (
+	SpendSummaryService_ListSpendSummaries_FullMethodName = "/godutch.v1.SpendSummaryService/ListSpendSummaries"
+)
+
+// SpendSummaryServiceClient is the client API for SpendSummaryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SpendSummaryServiceClient interface {
+	ListSpendSummaries(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*SpendSummaries, error)
+}
+
+type spendSummaryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpendSummaryServiceClient(cc grpc.ClientConnInterface) SpendSummaryServiceClient {
+	return &spendSummaryServiceClient{cc}
+}
+
+func (c *spendSummaryServiceClient) ListSpendSummaries(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*SpendSummaries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SpendSummaries)
+	err := c.cc.Invoke(ctx, SpendSummaryService_ListSpendSummaries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SpendSummaryServiceServer is the server API for SpendSummaryService service.
+// All implementations must embed UnimplementedSpendSummaryServiceServer
+// for forward compatibility.
+type SpendSummaryServiceServer interface {
+	ListSpendSummaries(context.Context, *TrackerIDRequest) (*SpendSummaries, error)
+	mustEmbedUnimplementedSpendSummaryServiceServer()
+}
+
+// UnimplementedSpendSummaryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpendSummaryServiceServer struct{}
+
+func (UnimplementedSpendSummaryServiceServer) ListSpendSummaries(context.Context, *TrackerIDRequest) (*SpendSummaries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSpendSummaries not implemented")
+}
+func (UnimplementedSpendSummaryServiceServer) mustEmbedUnimplementedSpendSummaryServiceServer() {}
+func (UnimplementedSpendSummaryServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafeSpendSummaryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpendSummaryServiceServer will
+// result in compilation errors.
+type UnsafeSpendSummaryServiceServer interface {
+	mustEmbedUnimplementedSpendSummaryServiceServer()
+}
+
+func RegisterSpendSummaryServiceServer(s grpc.ServiceRegistrar, srv SpendSummaryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSpendSummaryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SpendSummaryService_ServiceDesc, srv)
+}
+
+func _SpendSummaryService_ListSpendSummaries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpendSummaryServiceServer).ListSpendSummaries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpendSummaryService_ListSpendSummaries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpendSummaryServiceServer).ListSpendSummaries(ctx, req.(*TrackerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SpendSummaryService_ServiceDesc is the grpc.ServiceDesc for SpendSummaryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SpendSummaryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godutch.v1.SpendSummaryService",
+	HandlerType: (*SpendSummaryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSpendSummaries",
+			Handler:    _SpendSummaryService_ListSpendSummaries_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "godutch.proto",
+}
+
+const (
+	UserService_GetCurrentUser_FullMethodName     = "/godutch.v1.UserService/GetCurrentUser"
+	UserService_GetUser_FullMethodName            = "/godutch.v1.UserService/GetUser"
+	UserService_CreateUser_FullMethodName         = "/godutch.v1.UserService/CreateUser"
+	UserService_InviteUser_FullMethodName         = "/godutch.v1.UserService/InviteUser"
+	UserService_AcceptInvite_FullMethodName       = "/godutch.v1.UserService/AcceptInvite"
+	UserService_ListPendingInvites_FullMethodName = "/godutch.v1.UserService/ListPendingInvites"
+	UserService_ResendInvite_FullMethodName       = "/godutch.v1.UserService/ResendInvite"
+	UserService_RevokeInvite_FullMethodName       = "/godutch.v1.UserService/RevokeInvite"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	GetCurrentUser(ctx context.Context, in *GetCurrentUserRequest, opts ...grpc.CallOption) (*User, error)
+	GetUser(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*User, error)
+	CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	InviteUser(ctx context.Context, in *InviteUserRequest, opts ...grpc.CallOption) (*User, error)
+	AcceptInvite(ctx context.Context, in *AcceptInviteRequest, opts ...grpc.CallOption) (*User, error)
+	ListPendingInvites(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Invites, error)
+	ResendInvite(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Invite, error)
+	RevokeInvite(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Invite, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) GetCurrentUser(ctx context.Context, in *GetCurrentUserRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_GetCurrentUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetUser(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_GetUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_CreateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) InviteUser(ctx context.Context, in *InviteUserRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_InviteUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) AcceptInvite(ctx context.Context, in *AcceptInviteRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_AcceptInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListPendingInvites(ctx context.Context, in *TrackerIDRequest, opts ...grpc.CallOption) (*Invites, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invites)
+	err := c.cc.Invoke(ctx, UserService_ListPendingInvites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ResendInvite(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Invite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invite)
+	err := c.cc.Invoke(ctx, UserService_ResendInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RevokeInvite(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*Invite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invite)
+	err := c.cc.Invoke(ctx, UserService_RevokeInvite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility.
+type UserServiceServer interface {
+	GetCurrentUser(context.Context, *GetCurrentUserRequest) (*User, error)
+	GetUser(context.Context, *IDRequest) (*User, error)
+	CreateUser(context.Context, *User) (*User, error)
+	InviteUser(context.Context, *InviteUserRequest) (*User, error)
+	AcceptInvite(context.Context, *AcceptInviteRequest) (*User, error)
+	ListPendingInvites(context.Context, *TrackerIDRequest) (*Invites, error)
+	ResendInvite(context.Context, *IDRequest) (*Invite, error)
+	RevokeInvite(context.Context, *IDRequest) (*Invite, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUserServiceServer struct{}
+
+func (UnimplementedUserServiceServer) GetCurrentUser(context.Context, *GetCurrentUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetUser(context.Context, *IDRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserServiceServer) CreateUser(context.Context, *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedUserServiceServer) InviteUser(context.Context, *InviteUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteUser not implemented")
+}
+func (UnimplementedUserServiceServer) AcceptInvite(context.Context, *AcceptInviteRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptInvite not implemented")
+}
+func (UnimplementedUserServiceServer) ListPendingInvites(context.Context, *TrackerIDRequest) (*Invites, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingInvites not implemented")
+}
+func (UnimplementedUserServiceServer) ResendInvite(context.Context, *IDRequest) (*Invite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendInvite not implemented")
+}
+func (UnimplementedUserServiceServer) RevokeInvite(context.Context, *IDRequest) (*Invite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeInvite not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUserServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_GetCurrentUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetCurrentUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetCurrentUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetCurrentUser(ctx, req.(*GetCurrentUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUser(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).CreateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_InviteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).InviteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_InviteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).InviteUser(ctx, req.(*InviteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_AcceptInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInviteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).AcceptInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_AcceptInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).AcceptInvite(ctx, req.(*AcceptInviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListPendingInvites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrackerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListPendingInvites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListPendingInvites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListPendingInvites(ctx, req.(*TrackerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ResendInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ResendInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ResendInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ResendInvite(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RevokeInvite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RevokeInvite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RevokeInvite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RevokeInvite(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godutch.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrentUser",
+			Handler:    _UserService_GetCurrentUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _UserService_GetUser_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _UserService_CreateUser_Handler,
+		},
+		{
+			MethodName: "InviteUser",
+			Handler:    _UserService_InviteUser_Handler,
+		},
+		{
+			MethodName: "AcceptInvite",
+			Handler:    _UserService_AcceptInvite_Handler,
+		},
+		{
+			MethodName: "ListPendingInvites",
+			Handler:    _UserService_ListPendingInvites_Handler,
+		},
+		{
+			MethodName: "ResendInvite",
+			Handler:    _UserService_ResendInvite_Handler,
+		},
+		{
+			MethodName: "RevokeInvite",
+			Handler:    _UserService_RevokeInvite_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "godutch.proto",
+}
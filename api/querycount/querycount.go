@@ -0,0 +1,199 @@
+package querycount
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+// Counter counts the statements sent to the database through a pool opened with Open
+type Counter struct {
+	count int64
+}
+
+// Count ...
+func (counter *Counter) Count() int64 {
+	return atomic.LoadInt64(&counter.count)
+}
+
+// Reset ...
+func (counter *Counter) Reset() {
+	atomic.StoreInt64(&counter.count, 0)
+}
+
+func (counter *Counter) add() {
+	atomic.AddInt64(&counter.count, 1)
+}
+
+// Open returns a pool on connector that counts every query and exec, prepared or not, so tests
+// and benchmarks can see how many round trips a request makes
+func Open(connector driver.Connector) (*sql.DB, *Counter) {
+	counter := &Counter{}
+	return sql.OpenDB(&countingConnector{connector: connector, counter: counter}), counter
+}
+
+type countingConnector struct {
+	connector driver.Connector
+	counter   *Counter
+}
+
+func (connector *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := connector.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{conn: conn, counter: connector.counter}, nil
+}
+
+func (connector *countingConnector) Driver() driver.Driver {
+	return connector.connector.Driver()
+}
+
+// countingConn passes everything to conn, returning driver.ErrSkip for what conn cannot do so
+// database/sql falls back as it would without the counter
+type countingConn struct {
+	conn    driver.Conn
+	counter *Counter
+}
+
+func (conn *countingConn) Prepare(query string) (driver.Stmt, error) {
+	return conn.PrepareContext(context.Background(), query)
+}
+
+func (conn *countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &countingStmt{stmt: stmt, counter: conn.counter}, nil
+}
+
+func (conn *countingConn) Close() error {
+	return conn.conn.Close()
+}
+
+func (conn *countingConn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *countingConn) BeginTx(ctx context.Context, options driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := conn.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, options)
+	}
+	return conn.conn.Begin()
+}
+
+func (conn *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := conn.conn.(driver.QueryerContext)
+	if ok == false {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		conn.counter.add()
+	}
+	return rows, err
+}
+
+func (conn *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := conn.conn.(driver.ExecerContext)
+	if ok == false {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		conn.counter.add()
+	}
+	return result, err
+}
+
+func (conn *countingConn) Ping(ctx context.Context) error {
+	if pinger, ok := conn.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (conn *countingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := conn.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (conn *countingConn) IsValid() bool {
+	if validator, ok := conn.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (conn *countingConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := conn.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type countingStmt struct {
+	stmt    driver.Stmt
+	counter *Counter
+}
+
+func (stmt *countingStmt) Close() error {
+	return stmt.stmt.Close()
+}
+
+func (stmt *countingStmt) NumInput() int {
+	return stmt.stmt.NumInput()
+}
+
+func (stmt *countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.counter.add()
+	return stmt.stmt.Exec(args)
+}
+
+func (stmt *countingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stmt.counter.add()
+	return stmt.stmt.Query(args)
+}
+
+func (stmt *countingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := stmt.stmt.(driver.StmtExecContext)
+	if ok == false {
+		return stmt.Exec(values(args))
+	}
+	stmt.counter.add()
+	return execer.ExecContext(ctx, args)
+}
+
+func (stmt *countingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := stmt.stmt.(driver.StmtQueryContext)
+	if ok == false {
+		return stmt.Query(values(args))
+	}
+	stmt.counter.add()
+	return queryer.QueryContext(ctx, args)
+}
+
+func (stmt *countingStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := stmt.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,127 @@
+package querycount_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/querycount"
+)
+
+// fakeConnector makes connections that execute directly but can only query through prepared statements
+type fakeConnector struct{}
+
+func (connector fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+func (connector fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct{}
+
+func (conn fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{}, nil
+}
+
+func (conn fakeConn) Close() error {
+	return nil
+}
+
+func (conn fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (conn fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{}
+
+func (stmt fakeStmt) Close() error {
+	return nil
+}
+
+func (stmt fakeStmt) NumInput() int {
+	return -1
+}
+
+func (stmt fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (stmt fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (rows fakeRows) Columns() []string {
+	return []string{"ID"}
+}
+
+func (rows fakeRows) Close() error {
+	return nil
+}
+
+func (rows fakeRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+type fakeTx struct{}
+
+func (tx fakeTx) Commit() error {
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	return nil
+}
+
+var db *sql.DB
+var counter *querycount.Counter
+
+func TestDirectAndPreparedStatementsAreCounted(t *testing.T) {
+	givenIHaveACountingPool()
+
+	db.Exec("DELETE FROM \"Trackers\" WHERE \"ID\" = $1", 1)
+	rows, _ := db.Query("SELECT \"ID\" FROM \"Trackers\" WHERE \"ID\" = $1", 1)
+	rows.Close()
+	stmt, _ := db.Prepare("INSERT INTO \"TrackerUsers\"(\"TrackerID\", \"UserID\") VALUES ($1, $2)")
+	for userID := 1; userID <= 3; userID++ {
+		stmt.Exec(1, userID)
+	}
+	stmt.Close()
+
+	thenTheCountIs(5, t)
+}
+
+func TestTransactionsAreCounted(t *testing.T) {
+	givenIHaveACountingPool()
+
+	tx, _ := db.Begin()
+	tx.Exec("UPDATE \"Trackers\" SET \"Name\" = $1", "Holiday")
+	tx.Commit()
+
+	thenTheCountIs(1, t)
+}
+
+func TestTheCountCanBeReset(t *testing.T) {
+	givenIHaveACountingPool()
+	db.Exec("DELETE FROM \"Trackers\"")
+	counter.Reset()
+	thenTheCountIs(0, t)
+}
+
+func givenIHaveACountingPool() {
+	db, counter = querycount.Open(fakeConnector{})
+}
+
+func thenTheCountIs(expected int64, t *testing.T) {
+	if actual := counter.Count(); actual != expected {
+		t.Fatalf("expected %v statements but counted %v", expected, actual)
+	}
+}
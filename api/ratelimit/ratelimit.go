@@ -0,0 +1,328 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/metrics"
+	"github.com/TomPallister/godutch-api/api/repository/ratelimitrepository"
+	"github.com/codegangsta/negroni"
+)
+
+// LimitHeader is the number of requests the bucket holds
+const LimitHeader = "RateLimit-Limit"
+
+// RemainingHeader is the number of requests that can be made now
+const RemainingHeader = "RateLimit-Remaining"
+
+// ResetHeader is the number of seconds until the bucket is full again
+const ResetHeader = "RateLimit-Reset"
+
+// RetryAfterHeader is the number of seconds a refused caller should wait
+const RetryAfterHeader = "Retry-After"
+
+// ErrorRateLimited ...
+var ErrorRateLimited = domainerror.NewTooManyRequests("rate_limited", "Too many requests, try again after the number of seconds in Retry-After")
+
+// ErrorInvalidLimit ...
+var ErrorInvalidLimit = errors.New("Rate limits must be a number of requests per period, such as 60/1m, or off")
+
+// ErrorInvalidStore ...
+var ErrorInvalidStore = errors.New("RATE_LIMIT_STORE must be memory or postgres")
+
+// Limit lets Requests through each Period, up to Requests of them at once. The zero Limit is off.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads limits written as 60/1m, or off
+func ParseLimit(value string) (Limit, error) {
+
+	if value == "off" || value == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, ErrorInvalidLimit
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests < 1 {
+		return Limit{}, ErrorInvalidLimit
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return Limit{}, ErrorInvalidLimit
+	}
+
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// Enabled ...
+func (limit Limit) Enabled() bool {
+	return limit.Requests > 0 && limit.Period > 0
+}
+
+// String writes the limit the way ParseLimit reads it
+func (limit Limit) String() string {
+	if limit.Enabled() == false {
+		return "off"
+	}
+	period := limit.Period.String()
+	if strings.HasSuffix(period, "m0s") {
+		period = strings.TrimSuffix(period, "0s")
+	}
+	if strings.HasSuffix(period, "h0m") {
+		period = strings.TrimSuffix(period, "0m")
+	}
+	return strconv.Itoa(limit.Requests) + "/" + period
+}
+
+// MarshalText ...
+func (limit Limit) MarshalText() ([]byte, error) {
+	return []byte(limit.String()), nil
+}
+
+// UnmarshalText ...
+func (limit *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*limit = parsed
+	return nil
+}
+
+// Options ...
+type Options struct {
+	// Store is memory, where each instance limits on its own, or postgres, where they share buckets
+	Store string `yaml:"store" env:"RATE_LIMIT_STORE"`
+	// IP limits every request from an address
+	IP Limit `yaml:"ip" env:"RATE_LIMIT_IP"`
+	// Subject limits every authenticated request from a user or API token
+	Subject Limit `yaml:"subject" env:"RATE_LIMIT_SUBJECT"`
+	// Invite limits the requests that send invite emails, per subject
+	Invite Limit `yaml:"invite" env:"RATE_LIMIT_INVITE"`
+	// Auth limits registering, logging in and password resets, per address
+	Auth Limit `yaml:"auth" env:"RATE_LIMIT_AUTH"`
+	// TrustForwardedFor takes the address from the last X-Forwarded-For entry, set it behind a proxy
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" env:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+}
+
+// DefaultOptions ...
+var DefaultOptions = Options{
+	Store:   "memory",
+	IP:      Limit{Requests: 600, Period: time.Minute},
+	Subject: Limit{Requests: 300, Period: time.Minute},
+	Invite:  Limit{Requests: 20, Period: time.Hour},
+	Auth:    Limit{Requests: 10, Period: time.Minute},
+}
+
+// Validate ...
+func (options Options) Validate() error {
+	switch options.Store {
+	case "", "memory", "postgres":
+		return nil
+	}
+	return ErrorInvalidStore
+}
+
+// Decision is the state of a bucket after a request was counted against it
+type Decision struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a refused request would be allowed
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests against token buckets in a repository
+type Limiter struct {
+	repository    ratelimitrepository.RateLimitRepository
+	subjectFinder infrastructure.SubjectFinder
+	logger        infrastructure.Logger
+	options       Options
+}
+
+// NewLimiter ...
+func NewLimiter(repository ratelimitrepository.RateLimitRepository, subjectFinder infrastructure.SubjectFinder,
+	logger infrastructure.Logger, options Options) *Limiter {
+	limiter := Limiter{}
+	limiter.repository = repository
+	limiter.subjectFinder = subjectFinder
+	limiter.logger = logger
+	limiter.options = options
+	return &limiter
+}
+
+// Options are the limits the limiter was built with, a nil limiter has every limit off
+func (limiter *Limiter) Options() Options {
+	if limiter == nil {
+		return Options{}
+	}
+	return limiter.options
+}
+
+// Take counts a request from key against the named bucket's limit
+func (limiter *Limiter) Take(ctx context.Context, bucket string, key string, limit Limit) (Decision, error) {
+
+	capacity := float64(limit.Requests)
+	perSecond := capacity / limit.Period.Seconds()
+
+	tokens, allowed, err := limiter.repository.Take(ctx, bucket+":"+key, capacity, perSecond, time.Now())
+	if err != nil {
+		return Decision{Allowed: true, Limit: limit, Remaining: limit.Requests}, err
+	}
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((capacity - tokens) / perSecond),
+	}
+	if allowed == false {
+		decision.RetryAfter = seconds((1 - tokens) / perSecond)
+		metrics.RateLimited.WithLabelValues(bucket).Inc()
+	}
+	return decision, nil
+}
+
+// PerIP limits requests from each client address with the named bucket. Requests for the exempt
+// paths, such as probes polled from one address, are never limited.
+func (limiter *Limiter) PerIP(bucket string, limit Limit, exempt ...string) negroni.HandlerFunc {
+
+	exemptPaths := map[string]bool{}
+	for _, path := range exempt {
+		exemptPaths[path] = true
+	}
+
+	limited := limiter.middleware(bucket, limit, func(r *http.Request) string {
+		return "ip:" + limiter.ClientIP(r)
+	})
+
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if exemptPaths[r.URL.Path] {
+			next(w, r)
+			return
+		}
+		limited(w, r, next)
+	}
+}
+
+// PerSubject limits each authenticated caller with the named bucket, it must run after authentication
+func (limiter *Limiter) PerSubject(bucket string, limit Limit) negroni.HandlerFunc {
+	return limiter.middleware(bucket, limit, func(r *http.Request) string {
+		subject, err := limiter.subjectFinder.FindSubject(r, limiter.logger)
+		if err != nil {
+			return "ip:" + limiter.ClientIP(r)
+		}
+		return "subject:" + subject
+	})
+}
+
+// ClientIP is the address the request came from
+func (limiter *Limiter) ClientIP(r *http.Request) string {
+
+	if limiter.options.TrustForwardedFor {
+		forwardedFor := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if last := strings.TrimSpace(forwardedFor[len(forwardedFor)-1]); last != "" {
+			return last
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// middleware refuses requests once their bucket is empty. When the store fails requests are let
+// through rather than refusing everyone.
+func (limiter *Limiter) middleware(bucket string, limit Limit, key func(r *http.Request) string) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		if limiter == nil || limit.Enabled() == false {
+			next(w, r)
+			return
+		}
+
+		logger := infrastructure.LoggerFrom(r.Context(), limiter.logger)
+
+		decision, err := limiter.Take(r.Context(), bucket, key(r), limit)
+		if err != nil {
+			logger.Error("Could not check the rate limit", err, "bucket", bucket)
+			next(w, r)
+			return
+		}
+
+		WriteHeaders(w.Header(), decision)
+
+		if decision.Allowed == false {
+			logger.Info("Rate limited a request", "bucket", bucket, "retry_after_seconds", int(decision.RetryAfter.Seconds()))
+			handler.CreateErrorResponseAndLog(http.StatusTooManyRequests, w, limiter.logger, ErrorRateLimited)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// WriteHeaders sets the RateLimit headers for decision unless an earlier bucket left fewer requests,
+// and Retry-After when the request was refused
+func WriteHeaders(header http.Header, decision Decision) {
+
+	if existing, err := strconv.Atoi(header.Get(RemainingHeader)); err == nil && existing < decision.Remaining && decision.Allowed {
+		return
+	}
+
+	header.Set(LimitHeader, strconv.Itoa(decision.Limit.Requests))
+	header.Set(RemainingHeader, strconv.Itoa(decision.Remaining))
+	header.Set(ResetHeader, strconv.Itoa(int(decision.Reset.Seconds())))
+	if decision.Allowed == false {
+		header.Set(RetryAfterHeader, strconv.Itoa(int(decision.RetryAfter.Seconds())))
+	}
+}
+
+// DeleteFull removes buckets every interval that have not been used for the longest period in
+// options, by then they have refilled, until stop is closed
+func DeleteFull(repository ratelimitrepository.RateLimitRepository, logger infrastructure.Logger,
+	options Options, interval time.Duration, stop <-chan struct{}) {
+
+	var longest time.Duration
+	for _, limit := range []Limit{options.IP, options.Subject, options.Invite, options.Auth} {
+		if limit.Period > longest {
+			longest = limit.Period
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := repository.DeleteUpdatedBefore(context.Background(), time.Now().Add(-longest)); err != nil {
+				logger.Error("Could not delete full rate limit buckets", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// seconds rounds up to a whole second so callers that wait that long are let through
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Ceil(math.Max(0, value))) * time.Second
+}
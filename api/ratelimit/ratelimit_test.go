@@ -0,0 +1,211 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/repository/ratelimitrepository"
+	"github.com/codegangsta/negroni"
+)
+
+type headerSubjectFinder struct{}
+
+func (finder headerSubjectFinder) FindSubject(r *http.Request, logger infrastructure.Logger) (string, error) {
+	if r.Header.Get("X-Subject") == "" {
+		return "", infrastructure.ErrorCouldNotFindSubjectClaim
+	}
+	return r.Header.Get("X-Subject"), nil
+}
+
+type failingRepository struct {
+	ratelimitrepository.RateLimitRepository
+}
+
+func (repository failingRepository) Take(ctx context.Context, key string, capacity float64, perSecond float64, now time.Time) (float64, bool, error) {
+	return 0, false, errors.New("the database is down")
+}
+
+var limiter *ratelimit.Limiter
+var chain *negroni.Negroni
+var handled int
+var recorder *httptest.ResponseRecorder
+
+func TestRequestsOverTheLimitAreRefused(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("2/1m")))
+	whenISend("10.0.0.1", "", 3)
+	thenTheStatusIs(http.StatusTooManyRequests, t)
+	thenTheHandlerRan(2, t)
+	thenTheHeaderIs(ratelimit.LimitHeader, "2", t)
+	thenTheHeaderIs(ratelimit.RemainingHeader, "0", t)
+	thenTheHeaderIs(ratelimit.RetryAfterHeader, "30", t)
+	thenTheHeaderIs(ratelimit.ResetHeader, "60", t)
+}
+
+func TestExemptPathsAreNotLimited(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("1/1m"), "/healthz", "/readyz"))
+	whenISendTo("/healthz", "10.0.0.1", 3)
+	whenISendTo("/readyz", "10.0.0.1", 3)
+	thenTheStatusIs(http.StatusOK, t)
+	thenTheHandlerRan(6, t)
+	whenISend("10.0.0.1", "", 2)
+	thenTheStatusIs(http.StatusTooManyRequests, t)
+	thenTheHandlerRan(7, t)
+}
+
+func TestAllowedRequestsSayHowManyAreLeft(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("10/1m")))
+	whenISend("10.0.0.1", "", 1)
+	thenTheStatusIs(http.StatusOK, t)
+	thenTheHeaderIs(ratelimit.RemainingHeader, "9", t)
+	thenTheHeaderIs(ratelimit.ResetHeader, "6", t)
+	thenTheHeaderIs(ratelimit.RetryAfterHeader, "", t)
+}
+
+func TestBucketsRefill(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("1/50ms")))
+	whenISend("10.0.0.1", "", 2)
+	thenTheStatusIs(http.StatusTooManyRequests, t)
+	time.Sleep(60 * time.Millisecond)
+	whenISend("10.0.0.1", "", 1)
+	thenTheStatusIs(http.StatusOK, t)
+}
+
+func TestEachAddressAndSubjectHasItsOwnBucket(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerSubject("subject", limit("1/1m")))
+	whenISend("10.0.0.1", "tom", 1)
+	whenISend("10.0.0.1", "laura", 1)
+	thenTheStatusIs(http.StatusOK, t)
+	whenISend("10.0.0.2", "tom", 1)
+	thenTheStatusIs(http.StatusTooManyRequests, t)
+	whenISend("10.0.0.2", "", 1)
+	thenTheStatusIs(http.StatusOK, t)
+}
+
+func TestTheMostRestrictiveLimitsHeadersAreSent(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("2/1m")), limiter.PerSubject("subject", limit("10/1m")))
+	whenISend("10.0.0.1", "tom", 1)
+	thenTheHeaderIs(ratelimit.LimitHeader, "2", t)
+	thenTheHeaderIs(ratelimit.RemainingHeader, "1", t)
+}
+
+func TestForwardedForIsOnlyTrustedWhenConfigured(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/trackers", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	givenIHaveALimiter(ratelimit.Options{})
+	if ip := limiter.ClientIP(r); ip != "10.0.0.1" {
+		t.Fatalf("expected the remote address but got %v", ip)
+	}
+
+	givenIHaveALimiter(ratelimit.Options{TrustForwardedFor: true})
+	if ip := limiter.ClientIP(r); ip != "198.51.100.7" {
+		t.Fatalf("expected the address the proxy saw but got %v", ip)
+	}
+}
+
+func TestDisabledLimitsAndNilLimitersLetEverythingThrough(t *testing.T) {
+	givenIHaveALimiter(ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("off")))
+	whenISend("10.0.0.1", "", 5)
+	thenTheHandlerRan(5, t)
+
+	var none *ratelimit.Limiter
+	givenTheChainIs(none.PerSubject("subject", limit("1/1m")))
+	whenISend("10.0.0.1", "tom", 5)
+	thenTheHandlerRan(5, t)
+}
+
+func TestRequestsAreAllowedWhenTheStoreFails(t *testing.T) {
+	limiter = ratelimit.NewLimiter(failingRepository{}, headerSubjectFinder{}, infrastructure.NilLogger{}, ratelimit.Options{})
+	givenTheChainIs(limiter.PerIP("ip", limit("1/1m")))
+	whenISend("10.0.0.1", "", 2)
+	thenTheHandlerRan(2, t)
+}
+
+func TestLimitsAreParsed(t *testing.T) {
+	for value, expected := range map[string]string{"60/1m": "60/1m", "20/1h": "20/1h", "5/30s": "5/30s", "3/1h30m": "3/1h30m", "off": "off", "": "off"} {
+		parsed, err := ratelimit.ParseLimit(value)
+		if err != nil || parsed.String() != expected {
+			t.Fatalf("expected %q to be %v but got %v, %v", value, expected, parsed, err)
+		}
+	}
+	for _, value := range []string{"60", "0/1m", "60/soon", "60/-1m", "many/1m"} {
+		if _, err := ratelimit.ParseLimit(value); err != ratelimit.ErrorInvalidLimit {
+			t.Fatalf("expected %q to be invalid but got %v", value, err)
+		}
+	}
+}
+
+func givenIHaveALimiter(options ratelimit.Options) {
+	limiter = ratelimit.NewLimiter(ratelimitrepository.NewInMemoryRateLimitRepository(), headerSubjectFinder{}, infrastructure.NilLogger{}, options)
+}
+
+func givenTheChainIs(middlewares ...negroni.HandlerFunc) {
+	chain = negroni.New()
+	for _, middleware := range middlewares {
+		chain.Use(middleware)
+	}
+	chain.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled++
+	})
+	handled = 0
+}
+
+func whenISend(ip string, subject string, times int) {
+	whenISendAs("/api/v1/trackers", ip, subject, times)
+}
+
+func whenISendTo(path string, ip string, times int) {
+	whenISendAs(path, ip, "", times)
+}
+
+func whenISendAs(path string, ip string, subject string, times int) {
+	for i := 0; i < times; i++ {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = ip + ":1234"
+		if subject != "" {
+			r.Header.Set("X-Subject", subject)
+		}
+		recorder = httptest.NewRecorder()
+		chain.ServeHTTP(recorder, r)
+	}
+}
+
+func limit(value string) ratelimit.Limit {
+	parsed, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func thenTheStatusIs(expected int, t *testing.T) {
+	if recorder.Code != expected {
+		t.Fatalf("expected %v but got %v: %v", expected, recorder.Code, recorder.Body.String())
+	}
+}
+
+func thenTheHandlerRan(expected int, t *testing.T) {
+	if handled != expected {
+		t.Fatalf("expected the handler to run %v times but it ran %v", expected, handled)
+	}
+}
+
+func thenTheHeaderIs(name string, expected string, t *testing.T) {
+	if actual := recorder.Header().Get(name); actual != expected {
+		t.Fatalf("expected %v to be %q but got %q", name, expected, actual)
+	}
+}
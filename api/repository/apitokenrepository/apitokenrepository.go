@@ -0,0 +1,182 @@
+package apitokenrepository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = domainerror.NewNotFound("api_token_not_found", "API token not found")
+
+// APITokenRepository ...
+type APITokenRepository interface {
+	GetByID(ctx context.Context, id int64) (model.APIToken, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (model.APIToken, error)
+	GetForUserID(ctx context.Context, id int64) ([]model.APIToken, error)
+	Insert(ctx context.Context, apiToken model.APIToken) (model.APIToken, error)
+	Revoke(ctx context.Context, id int64, revoked time.Time) (bool, error)
+	UpdateLastUsed(ctx context.Context, id int64, lastUsed time.Time) (bool, error)
+}
+
+// PostgresAPITokenRepository ...
+type PostgresAPITokenRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresAPITokenRepository ...
+func NewPostgresAPITokenRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresAPITokenRepository {
+	repository := PostgresAPITokenRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// GetByID ...
+func (repository *PostgresAPITokenRepository) GetByID(ctx context.Context, id int64) (model.APIToken, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "GetByID")
+	defer done()
+
+	repoAPIToken := model.APIToken{}
+	var scopes string
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"UserID\", \"Name\", \"TokenHash\", \"Scopes\", \"DateCreated\", \"DateLastUsed\", \"DateRevoked\" FROM \"APITokens\" WHERE \"ID\" = $1", id).
+		Scan(&repoAPIToken.ID, &repoAPIToken.UserID, &repoAPIToken.Name, &repoAPIToken.TokenHash, &scopes, &repoAPIToken.DateCreated, &repoAPIToken.DateLastUsed, &repoAPIToken.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.APIToken{}, ErrorNotFound
+	case err != nil:
+		return model.APIToken{}, err
+	}
+
+	repoAPIToken.Scopes = splitScopes(scopes)
+
+	return repoAPIToken, nil
+}
+
+// GetByTokenHash ...
+func (repository *PostgresAPITokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (model.APIToken, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "GetByTokenHash")
+	defer done()
+
+	repoAPIToken := model.APIToken{}
+	var scopes string
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"UserID\", \"Name\", \"TokenHash\", \"Scopes\", \"DateCreated\", \"DateLastUsed\", \"DateRevoked\" FROM \"APITokens\" WHERE \"TokenHash\" = $1", tokenHash).
+		Scan(&repoAPIToken.ID, &repoAPIToken.UserID, &repoAPIToken.Name, &repoAPIToken.TokenHash, &scopes, &repoAPIToken.DateCreated, &repoAPIToken.DateLastUsed, &repoAPIToken.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.APIToken{}, ErrorNotFound
+	case err != nil:
+		return model.APIToken{}, err
+	}
+
+	repoAPIToken.Scopes = splitScopes(scopes)
+
+	return repoAPIToken, nil
+}
+
+// GetForUserID ...
+func (repository *PostgresAPITokenRepository) GetForUserID(ctx context.Context, id int64) ([]model.APIToken, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "GetForUserID")
+	defer done()
+
+	apiTokensForUser := []model.APIToken{}
+
+	rows, err := repository.db.QueryContext(ctx, "SELECT \"ID\", \"UserID\", \"Name\", \"TokenHash\", \"Scopes\", \"DateCreated\", \"DateLastUsed\", \"DateRevoked\" FROM \"APITokens\" WHERE \"UserID\" = $1 ORDER BY \"ID\"", id)
+	if err != nil {
+		return []model.APIToken{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var apiToken model.APIToken
+		var scopes string
+
+		err = rows.Scan(&apiToken.ID, &apiToken.UserID, &apiToken.Name, &apiToken.TokenHash, &scopes, &apiToken.DateCreated, &apiToken.DateLastUsed, &apiToken.DateRevoked)
+		if err != nil {
+			return []model.APIToken{}, err
+		}
+
+		apiToken.Scopes = splitScopes(scopes)
+		apiTokensForUser = append(apiTokensForUser, apiToken)
+	}
+
+	return apiTokensForUser, nil
+}
+
+// Insert ...
+func (repository *PostgresAPITokenRepository) Insert(ctx context.Context, apiToken model.APIToken) (model.APIToken, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "Insert")
+	defer done()
+
+	var lastInsertID int64
+
+	err := repository.
+		db.
+		QueryRowContext(ctx, "INSERT INTO \"APITokens\"(\"UserID\", \"Name\", \"TokenHash\", \"Scopes\", \"DateCreated\") VALUES ($1, $2, $3, $4, $5) RETURNING \"ID\"",
+			apiToken.UserID, apiToken.Name, apiToken.TokenHash, strings.Join(apiToken.Scopes, ","), apiToken.DateCreated).Scan(&lastInsertID)
+	if err != nil {
+		return model.APIToken{}, err
+	}
+
+	apiToken.ID = lastInsertID
+
+	return apiToken, nil
+}
+
+// Revoke ...
+func (repository *PostgresAPITokenRepository) Revoke(ctx context.Context, id int64, revoked time.Time) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "Revoke")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"APITokens\" SET \"DateRevoked\"= $1 WHERE \"ID\" = $2 AND \"DateRevoked\" IS NULL")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, revoked, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// UpdateLastUsed ...
+func (repository *PostgresAPITokenRepository) UpdateLastUsed(ctx context.Context, id int64, lastUsed time.Time) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "apitoken", "UpdateLastUsed")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"APITokens\" SET \"DateLastUsed\"= $1 WHERE \"ID\" = $2")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, lastUsed, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+	return strings.Split(scopes, ",")
+}
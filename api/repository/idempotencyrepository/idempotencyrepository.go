@@ -0,0 +1,118 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = errors.New("Idempotency key not found")
+
+// ErrorAlreadyExists is returned by Insert when another request has claimed the key
+var ErrorAlreadyExists = errors.New("Idempotency key already exists")
+
+// IdempotencyRepository ...
+type IdempotencyRepository interface {
+	Get(ctx context.Context, subject string, key string) (model.IdempotencyKey, error)
+	Insert(ctx context.Context, idempotencyKey model.IdempotencyKey) error
+	Complete(ctx context.Context, idempotencyKey model.IdempotencyKey) error
+	Delete(ctx context.Context, subject string, key string) error
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresIdempotencyRepository ...
+type PostgresIdempotencyRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresIdempotencyRepository ...
+func NewPostgresIdempotencyRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresIdempotencyRepository {
+	repository := PostgresIdempotencyRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// Get ...
+func (repository *PostgresIdempotencyRepository) Get(ctx context.Context, subject string, key string) (model.IdempotencyKey, error) {
+	ctx, done := tracing.StartQuery(ctx, "idempotency", "Get")
+	defer done()
+
+	repoKey := model.IdempotencyKey{}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"Subject\", \"Key\", \"RequestHash\", \"ResponseStatus\", \"ResponseContentType\", \"ResponseLocation\", \"ResponseETag\", \"ResponseBody\", \"DateCreated\", \"DateCompleted\" FROM \"IdempotencyKeys\" WHERE \"Subject\" = $1 AND \"Key\" = $2", subject, key).
+		Scan(&repoKey.Subject, &repoKey.Key, &repoKey.RequestHash, &repoKey.ResponseStatus, &repoKey.ResponseContentType, &repoKey.ResponseLocation, &repoKey.ResponseETag, &repoKey.ResponseBody, &repoKey.DateCreated, &repoKey.DateCompleted)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.IdempotencyKey{}, ErrorNotFound
+	case err != nil:
+		return model.IdempotencyKey{}, err
+	}
+
+	return repoKey, nil
+}
+
+// Insert claims the key, the primary key makes sure only one request can
+func (repository *PostgresIdempotencyRepository) Insert(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	ctx, done := tracing.StartQuery(ctx, "idempotency", "Insert")
+	defer done()
+
+	result, err := repository.db.ExecContext(ctx, "INSERT INTO \"IdempotencyKeys\" (\"Subject\", \"Key\", \"RequestHash\", \"DateCreated\") VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		idempotencyKey.Subject, idempotencyKey.Key, idempotencyKey.RequestHash, idempotencyKey.DateCreated)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ErrorAlreadyExists
+	}
+
+	return nil
+}
+
+// Complete stores the response so retries can replay it
+func (repository *PostgresIdempotencyRepository) Complete(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	ctx, done := tracing.StartQuery(ctx, "idempotency", "Complete")
+	defer done()
+
+	_, err := repository.db.ExecContext(ctx, "UPDATE \"IdempotencyKeys\" SET \"ResponseStatus\" = $1, \"ResponseContentType\" = $2, \"ResponseLocation\" = $3, \"ResponseETag\" = $4, \"ResponseBody\" = $5, \"DateCompleted\" = $6 WHERE \"Subject\" = $7 AND \"Key\" = $8",
+		idempotencyKey.ResponseStatus, idempotencyKey.ResponseContentType, idempotencyKey.ResponseLocation, idempotencyKey.ResponseETag, idempotencyKey.ResponseBody, idempotencyKey.DateCompleted, idempotencyKey.Subject, idempotencyKey.Key)
+
+	return err
+}
+
+// Delete ...
+func (repository *PostgresIdempotencyRepository) Delete(ctx context.Context, subject string, key string) error {
+	ctx, done := tracing.StartQuery(ctx, "idempotency", "Delete")
+	defer done()
+
+	_, err := repository.db.ExecContext(ctx, "DELETE FROM \"IdempotencyKeys\" WHERE \"Subject\" = $1 AND \"Key\" = $2", subject, key)
+
+	return err
+}
+
+// DeleteCreatedBefore removes keys older than the retention window
+func (repository *PostgresIdempotencyRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := tracing.StartQuery(ctx, "idempotency", "DeleteCreatedBefore")
+	defer done()
+
+	result, err := repository.db.ExecContext(ctx, "DELETE FROM \"IdempotencyKeys\" WHERE \"DateCreated\" < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
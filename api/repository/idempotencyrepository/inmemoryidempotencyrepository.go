@@ -0,0 +1,84 @@
+package idempotencyrepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryIdempotencyRepository ...
+type InMemoryIdempotencyRepository struct {
+	mutex sync.Mutex
+	keys  map[string]model.IdempotencyKey
+}
+
+// NewInMemoryIdempotencyRepository ...
+func NewInMemoryIdempotencyRepository() *InMemoryIdempotencyRepository {
+	repository := InMemoryIdempotencyRepository{}
+	repository.keys = make(map[string]model.IdempotencyKey)
+	return &repository
+}
+
+func mapKey(subject string, key string) string {
+	return subject + "\x00" + key
+}
+
+// Get ...
+func (repository *InMemoryIdempotencyRepository) Get(ctx context.Context, subject string, key string) (model.IdempotencyKey, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	idempotencyKey, ok := repository.keys[mapKey(subject, key)]
+	if ok == false {
+		return model.IdempotencyKey{}, ErrorNotFound
+	}
+	return idempotencyKey, nil
+}
+
+// Insert ...
+func (repository *InMemoryIdempotencyRepository) Insert(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	k := mapKey(idempotencyKey.Subject, idempotencyKey.Key)
+	if _, ok := repository.keys[k]; ok {
+		return ErrorAlreadyExists
+	}
+	repository.keys[k] = idempotencyKey
+	return nil
+}
+
+// Complete ...
+func (repository *InMemoryIdempotencyRepository) Complete(ctx context.Context, idempotencyKey model.IdempotencyKey) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.keys[mapKey(idempotencyKey.Subject, idempotencyKey.Key)] = idempotencyKey
+	return nil
+}
+
+// Delete ...
+func (repository *InMemoryIdempotencyRepository) Delete(ctx context.Context, subject string, key string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	delete(repository.keys, mapKey(subject, key))
+	return nil
+}
+
+// DeleteCreatedBefore ...
+func (repository *InMemoryIdempotencyRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	var deleted int64
+	for k, idempotencyKey := range repository.keys {
+		if idempotencyKey.DateCreated.Before(before) {
+			delete(repository.keys, k)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
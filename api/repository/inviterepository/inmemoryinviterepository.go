@@ -0,0 +1,145 @@
+package inviterepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/userrepository"
+)
+
+// InMemoryInviteRepository ...
+type InMemoryInviteRepository struct {
+	mutex          sync.Mutex
+	invites        []model.Invite
+	userRepository userrepository.UserRepository
+}
+
+// NewInMemoryInviteRepository ...userRepository is where Accept saves the user
+func NewInMemoryInviteRepository(userRepository userrepository.UserRepository) *InMemoryInviteRepository {
+	repository := InMemoryInviteRepository{}
+	repository.userRepository = userRepository
+	return &repository
+}
+
+// GetByID ...
+func (repository *InMemoryInviteRepository) GetByID(ctx context.Context, id int64) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, i := range repository.invites {
+		if i.ID == id {
+			return i, nil
+		}
+	}
+	return model.Invite{}, ErrorNotFound
+}
+
+// GetByTokenHash ...
+func (repository *InMemoryInviteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, i := range repository.invites {
+		if i.TokenHash == tokenHash {
+			return i, nil
+		}
+	}
+	return model.Invite{}, ErrorNotFound
+}
+
+// GetPendingForTrackerID ...
+func (repository *InMemoryInviteRepository) GetPendingForTrackerID(ctx context.Context, id int64, now time.Time) ([]model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	invites := []model.Invite{}
+	for _, i := range repository.invites {
+		if i.TrackerID == id && i.IsPending(now) {
+			invites = append(invites, i)
+		}
+	}
+	return invites, nil
+}
+
+// Insert ...
+func (repository *InMemoryInviteRepository) Insert(ctx context.Context, invite model.Invite) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	invite.ID = int64(len(repository.invites) + 1)
+	repository.invites = append(repository.invites, invite)
+	return invite, nil
+}
+
+// Update ...
+func (repository *InMemoryInviteRepository) Update(ctx context.Context, id int64, invite model.Invite) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, i := range repository.invites {
+		if i.ID == id {
+			invite.ID = id
+			repository.invites[index] = invite
+			return invite, nil
+		}
+	}
+	return model.Invite{}, ErrorNotFound
+}
+
+// Renew ...
+func (repository *InMemoryInviteRepository) Renew(ctx context.Context, id int64, tokenHash string, dateExpires time.Time) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, i := range repository.invites {
+		if i.ID == id {
+			if i.DateAccepted != nil || i.DateRevoked != nil {
+				return model.Invite{}, ErrorNotPending
+			}
+			repository.invites[index].TokenHash = tokenHash
+			repository.invites[index].DateExpires = dateExpires
+			return repository.invites[index], nil
+		}
+	}
+	return model.Invite{}, ErrorNotPending
+}
+
+// Accept ...
+func (repository *InMemoryInviteRepository) Accept(ctx context.Context, id int64, user model.User, now time.Time) (model.User, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, i := range repository.invites {
+		if i.ID == id {
+			if i.IsPending(now) == false {
+				return model.User{}, ErrorNotPending
+			}
+			user, err := repository.userRepository.Update(ctx, user.ID, user)
+			if err != nil {
+				return model.User{}, err
+			}
+			repository.invites[index].DateAccepted = &now
+			return user, nil
+		}
+	}
+	return model.User{}, ErrorNotPending
+}
+
+// Revoke ...
+func (repository *InMemoryInviteRepository) Revoke(ctx context.Context, id int64, now time.Time) (model.Invite, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, i := range repository.invites {
+		if i.ID == id {
+			if i.DateAccepted != nil || i.DateRevoked != nil {
+				return model.Invite{}, ErrorNotPending
+			}
+			repository.invites[index].DateRevoked = &now
+			return repository.invites[index], nil
+		}
+	}
+	return model.Invite{}, ErrorNotPending
+}
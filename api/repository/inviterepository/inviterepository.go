@@ -0,0 +1,232 @@
+package inviterepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = domainerror.NewNotFound("invite_not_found", "Invite not found")
+
+// ErrorNotPending ...to be used when an invite could not be claimed because it was already accepted, revoked or had expired
+var ErrorNotPending = domainerror.NewConflict("invite_not_pending", "", "Invite is no longer pending")
+
+// InviteRepository ...
+type InviteRepository interface {
+	GetByID(ctx context.Context, id int64) (model.Invite, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (model.Invite, error)
+	GetPendingForTrackerID(ctx context.Context, id int64, now time.Time) ([]model.Invite, error)
+	Insert(ctx context.Context, invite model.Invite) (model.Invite, error)
+	Update(ctx context.Context, id int64, invite model.Invite) (model.Invite, error)
+	Renew(ctx context.Context, id int64, tokenHash string, dateExpires time.Time) (model.Invite, error)
+	Accept(ctx context.Context, id int64, user model.User, now time.Time) (model.User, error)
+	Revoke(ctx context.Context, id int64, now time.Time) (model.Invite, error)
+}
+
+// PostgresInviteRepository ...
+type PostgresInviteRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresInviteRepository ...
+func NewPostgresInviteRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresInviteRepository {
+	repository := PostgresInviteRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// GetByID ...
+func (repository *PostgresInviteRepository) GetByID(ctx context.Context, id int64) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "GetByID")
+	defer done()
+
+	repoInvite := model.Invite{}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\" FROM \"Invites\" WHERE \"ID\" = $1", id).
+		Scan(&repoInvite.ID, &repoInvite.TrackerID, &repoInvite.UserID, &repoInvite.InvitedByUserID, &repoInvite.EmailAddress, &repoInvite.TokenHash, &repoInvite.DateCreated, &repoInvite.DateExpires, &repoInvite.DateAccepted, &repoInvite.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Invite{}, ErrorNotFound
+	case err != nil:
+		return model.Invite{}, err
+	}
+
+	return repoInvite, nil
+}
+
+// GetByTokenHash ...
+func (repository *PostgresInviteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "GetByTokenHash")
+	defer done()
+
+	repoInvite := model.Invite{}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\" FROM \"Invites\" WHERE \"TokenHash\" = $1", tokenHash).
+		Scan(&repoInvite.ID, &repoInvite.TrackerID, &repoInvite.UserID, &repoInvite.InvitedByUserID, &repoInvite.EmailAddress, &repoInvite.TokenHash, &repoInvite.DateCreated, &repoInvite.DateExpires, &repoInvite.DateAccepted, &repoInvite.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Invite{}, ErrorNotFound
+	case err != nil:
+		return model.Invite{}, err
+	}
+
+	return repoInvite, nil
+}
+
+// GetPendingForTrackerID ...
+func (repository *PostgresInviteRepository) GetPendingForTrackerID(ctx context.Context, id int64, now time.Time) ([]model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "GetPendingForTrackerID")
+	defer done()
+
+	invitesForTracker := []model.Invite{}
+
+	rows, err := repository.db.QueryContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\" FROM \"Invites\" WHERE \"TrackerID\" = $1 AND \"DateAccepted\" IS NULL AND \"DateRevoked\" IS NULL AND \"DateExpires\" > $2", id, now)
+	if err != nil {
+		return []model.Invite{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var invite model.Invite
+
+		err = rows.Scan(&invite.ID, &invite.TrackerID, &invite.UserID, &invite.InvitedByUserID, &invite.EmailAddress, &invite.TokenHash, &invite.DateCreated, &invite.DateExpires, &invite.DateAccepted, &invite.DateRevoked)
+		if err != nil {
+			return []model.Invite{}, err
+		}
+
+		invitesForTracker = append(invitesForTracker, invite)
+	}
+
+	return invitesForTracker, nil
+}
+
+// Insert ...
+func (repository *PostgresInviteRepository) Insert(ctx context.Context, invite model.Invite) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "Insert")
+	defer done()
+
+	var lastInsertID int64
+
+	err := repository.
+		db.
+		QueryRowContext(ctx, "INSERT INTO \"Invites\"(\"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING \"ID\"",
+			invite.TrackerID, invite.UserID, invite.InvitedByUserID, invite.EmailAddress, invite.TokenHash, invite.DateCreated, invite.DateExpires, invite.DateAccepted, invite.DateRevoked).Scan(&lastInsertID)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	invite.ID = lastInsertID
+
+	return invite, nil
+}
+
+// Update ...
+func (repository *PostgresInviteRepository) Update(ctx context.Context, id int64, invite model.Invite) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "Update")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"Invites\" SET \"TrackerID\"= $1, \"UserID\"= $2, \"InvitedByUserID\"= $3, \"EmailAddress\"= $4, \"TokenHash\"= $5, \"DateCreated\"= $6, \"DateExpires\"= $7, \"DateAccepted\"= $8, \"DateRevoked\"= $9 WHERE \"ID\" = $10")
+	if err != nil {
+		return model.Invite{}, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, invite.TrackerID, invite.UserID, invite.InvitedByUserID, invite.EmailAddress, invite.TokenHash, invite.DateCreated, invite.DateExpires, invite.DateAccepted, invite.DateRevoked, id)
+	if err != nil {
+		return model.Invite{}, err
+	}
+
+	return invite, nil
+}
+
+// Renew ...replaces the token and expiry only while the invite has not been accepted or revoked, so a
+// resend racing either cannot reopen it
+func (repository *PostgresInviteRepository) Renew(ctx context.Context, id int64, tokenHash string, dateExpires time.Time) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "Renew")
+	defer done()
+
+	repoInvite := model.Invite{}
+
+	err := repository.db.QueryRowContext(ctx, "UPDATE \"Invites\" SET \"TokenHash\"=$2, \"DateExpires\"=$3 WHERE \"ID\"=$1 AND \"DateAccepted\" IS NULL AND \"DateRevoked\" IS NULL RETURNING \"ID\", \"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\"", id, tokenHash, dateExpires).
+		Scan(&repoInvite.ID, &repoInvite.TrackerID, &repoInvite.UserID, &repoInvite.InvitedByUserID, &repoInvite.EmailAddress, &repoInvite.TokenHash, &repoInvite.DateCreated, &repoInvite.DateExpires, &repoInvite.DateAccepted, &repoInvite.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Invite{}, ErrorNotPending
+	case err != nil:
+		return model.Invite{}, err
+	}
+
+	return repoInvite, nil
+}
+
+// Accept ...claims the invite if it is still pending at now and saves the user who accepted it in
+// the same transaction, so an invite can only ever be accepted once
+func (repository *PostgresInviteRepository) Accept(ctx context.Context, id int64, user model.User, now time.Time) (model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "Accept")
+	defer done()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.User{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE \"Invites\" SET \"DateAccepted\"=$2 WHERE \"ID\"=$1 AND \"DateAccepted\" IS NULL AND \"DateRevoked\" IS NULL AND \"DateExpires\" > $2", id, now)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if claimed == 0 {
+		return model.User{}, ErrorNotPending
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE \"Users\" SET \"Name\"= $1, \"DateCreated\"= $2, \"EmailAddress\"= $3, \"AuthenticationID\"= $4 WHERE \"ID\"= $5",
+		user.Name, user.DateCreated, user.EmailAddress, user.AuthenticationID, user.ID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// Revoke ...sets DateRevoked if the invite has not been accepted or revoked already
+func (repository *PostgresInviteRepository) Revoke(ctx context.Context, id int64, now time.Time) (model.Invite, error) {
+	ctx, done := tracing.StartQuery(ctx, "invite", "Revoke")
+	defer done()
+
+	repoInvite := model.Invite{}
+
+	err := repository.db.QueryRowContext(ctx, "UPDATE \"Invites\" SET \"DateRevoked\"=$2 WHERE \"ID\"=$1 AND \"DateAccepted\" IS NULL AND \"DateRevoked\" IS NULL RETURNING \"ID\", \"TrackerID\", \"UserID\", \"InvitedByUserID\", \"EmailAddress\", \"TokenHash\", \"DateCreated\", \"DateExpires\", \"DateAccepted\", \"DateRevoked\"", id, now).
+		Scan(&repoInvite.ID, &repoInvite.TrackerID, &repoInvite.UserID, &repoInvite.InvitedByUserID, &repoInvite.EmailAddress, &repoInvite.TokenHash, &repoInvite.DateCreated, &repoInvite.DateExpires, &repoInvite.DateAccepted, &repoInvite.DateRevoked)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Invite{}, ErrorNotPending
+	case err != nil:
+		return model.Invite{}, err
+	}
+
+	return repoInvite, nil
+}
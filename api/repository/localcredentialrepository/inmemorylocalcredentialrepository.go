@@ -0,0 +1,67 @@
+package localcredentialrepository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryLocalCredentialRepository ...
+type InMemoryLocalCredentialRepository struct {
+	mutex       sync.Mutex
+	credentials map[int64]model.LocalCredential
+}
+
+// NewInMemoryLocalCredentialRepository ...
+func NewInMemoryLocalCredentialRepository() *InMemoryLocalCredentialRepository {
+	repository := InMemoryLocalCredentialRepository{}
+	repository.credentials = map[int64]model.LocalCredential{}
+	return &repository
+}
+
+// GetByUserID ...
+func (repository *InMemoryLocalCredentialRepository) GetByUserID(ctx context.Context, id int64) (model.LocalCredential, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	credential, ok := repository.credentials[id]
+	if ok == false {
+		return model.LocalCredential{}, ErrorNotFound
+	}
+	return credential, nil
+}
+
+// GetByResetTokenHash ...
+func (repository *InMemoryLocalCredentialRepository) GetByResetTokenHash(ctx context.Context, tokenHash string) (model.LocalCredential, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, c := range repository.credentials {
+		if c.ResetTokenHash != nil && *c.ResetTokenHash == tokenHash {
+			return c, nil
+		}
+	}
+	return model.LocalCredential{}, ErrorNotFound
+}
+
+// Insert ...
+func (repository *InMemoryLocalCredentialRepository) Insert(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.credentials[credential.UserID] = credential
+	return credential, nil
+}
+
+// Update ...
+func (repository *InMemoryLocalCredentialRepository) Update(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.credentials[credential.UserID]; ok == false {
+		return model.LocalCredential{}, ErrorNotFound
+	}
+	repository.credentials[credential.UserID] = credential
+	return credential, nil
+}
@@ -0,0 +1,115 @@
+package localcredentialrepository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = domainerror.NewNotFound("local_credential_not_found", "Local credential not found")
+
+// LocalCredentialRepository ...
+type LocalCredentialRepository interface {
+	GetByUserID(ctx context.Context, id int64) (model.LocalCredential, error)
+	GetByResetTokenHash(ctx context.Context, tokenHash string) (model.LocalCredential, error)
+	Insert(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error)
+	Update(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error)
+}
+
+// PostgresLocalCredentialRepository ...
+type PostgresLocalCredentialRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresLocalCredentialRepository ...
+func NewPostgresLocalCredentialRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresLocalCredentialRepository {
+	repository := PostgresLocalCredentialRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// GetByUserID ...
+func (repository *PostgresLocalCredentialRepository) GetByUserID(ctx context.Context, id int64) (model.LocalCredential, error) {
+	ctx, done := tracing.StartQuery(ctx, "localcredential", "GetByUserID")
+	defer done()
+
+	credential := model.LocalCredential{}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"UserID\", \"PasswordHash\", \"FailedLoginAttempts\", \"DateLockedUntil\", \"ResetTokenHash\", \"DateResetTokenExpires\" FROM \"LocalCredentials\" WHERE \"UserID\" = $1", id).
+		Scan(&credential.UserID, &credential.PasswordHash, &credential.FailedLoginAttempts, &credential.DateLockedUntil, &credential.ResetTokenHash, &credential.DateResetTokenExpires)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.LocalCredential{}, ErrorNotFound
+	case err != nil:
+		return model.LocalCredential{}, err
+	}
+
+	return credential, nil
+}
+
+// GetByResetTokenHash ...
+func (repository *PostgresLocalCredentialRepository) GetByResetTokenHash(ctx context.Context, tokenHash string) (model.LocalCredential, error) {
+	ctx, done := tracing.StartQuery(ctx, "localcredential", "GetByResetTokenHash")
+	defer done()
+
+	credential := model.LocalCredential{}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"UserID\", \"PasswordHash\", \"FailedLoginAttempts\", \"DateLockedUntil\", \"ResetTokenHash\", \"DateResetTokenExpires\" FROM \"LocalCredentials\" WHERE \"ResetTokenHash\" = $1", tokenHash).
+		Scan(&credential.UserID, &credential.PasswordHash, &credential.FailedLoginAttempts, &credential.DateLockedUntil, &credential.ResetTokenHash, &credential.DateResetTokenExpires)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.LocalCredential{}, ErrorNotFound
+	case err != nil:
+		return model.LocalCredential{}, err
+	}
+
+	return credential, nil
+}
+
+// Insert ...
+func (repository *PostgresLocalCredentialRepository) Insert(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error) {
+	ctx, done := tracing.StartQuery(ctx, "localcredential", "Insert")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "INSERT INTO \"LocalCredentials\"(\"UserID\", \"PasswordHash\", \"FailedLoginAttempts\", \"DateLockedUntil\", \"ResetTokenHash\", \"DateResetTokenExpires\") VALUES ($1, $2, $3, $4, $5, $6)")
+	if err != nil {
+		return model.LocalCredential{}, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, credential.UserID, credential.PasswordHash, credential.FailedLoginAttempts, credential.DateLockedUntil, credential.ResetTokenHash, credential.DateResetTokenExpires)
+	if err != nil {
+		return model.LocalCredential{}, err
+	}
+
+	return credential, nil
+}
+
+// Update ...
+func (repository *PostgresLocalCredentialRepository) Update(ctx context.Context, credential model.LocalCredential) (model.LocalCredential, error) {
+	ctx, done := tracing.StartQuery(ctx, "localcredential", "Update")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"LocalCredentials\" SET \"PasswordHash\"= $1, \"FailedLoginAttempts\"= $2, \"DateLockedUntil\"= $3, \"ResetTokenHash\"= $4, \"DateResetTokenExpires\"= $5 WHERE \"UserID\" = $6")
+	if err != nil {
+		return model.LocalCredential{}, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, credential.PasswordHash, credential.FailedLoginAttempts, credential.DateLockedUntil, credential.ResetTokenHash, credential.DateResetTokenExpires, credential.UserID)
+	if err != nil {
+		return model.LocalCredential{}, err
+	}
+
+	return credential, nil
+}
@@ -0,0 +1,61 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens      float64
+	dateUpdated time.Time
+}
+
+// InMemoryRateLimitRepository keeps buckets in this instance, each instance of the api limits on its own
+type InMemoryRateLimitRepository struct {
+	mutex   sync.Mutex
+	buckets map[string]bucket
+}
+
+// NewInMemoryRateLimitRepository ...
+func NewInMemoryRateLimitRepository() *InMemoryRateLimitRepository {
+	repository := InMemoryRateLimitRepository{}
+	repository.buckets = make(map[string]bucket)
+	return &repository
+}
+
+// Take ...
+func (repository *InMemoryRateLimitRepository) Take(ctx context.Context, key string, capacity float64, perSecond float64, now time.Time) (float64, bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	existing, ok := repository.buckets[key]
+	if ok == false {
+		repository.buckets[key] = bucket{tokens: capacity - 1, dateUpdated: now}
+		return capacity - 1, true, nil
+	}
+
+	tokens := math.Min(capacity, existing.tokens+math.Max(0, now.Sub(existing.dateUpdated).Seconds())*perSecond)
+	if tokens < 1 {
+		return tokens, false, nil
+	}
+
+	repository.buckets[key] = bucket{tokens: tokens - 1, dateUpdated: now}
+	return tokens - 1, true, nil
+}
+
+// DeleteUpdatedBefore ...
+func (repository *InMemoryRateLimitRepository) DeleteUpdatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	var deleted int64
+	for key, existing := range repository.buckets {
+		if existing.dateUpdated.Before(before) {
+			delete(repository.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
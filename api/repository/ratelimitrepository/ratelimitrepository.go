@@ -0,0 +1,83 @@
+package ratelimitrepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// RateLimitRepository holds token buckets. A bucket holds up to capacity tokens, starts full and
+// gains perSecond tokens a second.
+type RateLimitRepository interface {
+	// Take refills the bucket at key for the time since it was last used and takes a token if it
+	// has one. It returns the tokens left and whether one was taken.
+	Take(ctx context.Context, key string, capacity float64, perSecond float64, now time.Time) (float64, bool, error)
+	// DeleteUpdatedBefore removes buckets that have not been used since before, they would be full
+	DeleteUpdatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresRateLimitRepository shares buckets between every instance of the api
+type PostgresRateLimitRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresRateLimitRepository ...
+func NewPostgresRateLimitRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresRateLimitRepository {
+	repository := PostgresRateLimitRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// refilled is the bucket's tokens after refilling it up to $2 at $3 a second until $4
+const refilled = "LEAST($2::float8, \"RateLimitBuckets\".\"Tokens\" + EXTRACT(EPOCH FROM GREATEST($4::timestamp - \"RateLimitBuckets\".\"DateUpdated\", interval '0')) * $3::float8)"
+
+// Take updates the bucket in one statement so concurrent requests cannot both take its last token
+func (repository *PostgresRateLimitRepository) Take(ctx context.Context, key string, capacity float64, perSecond float64, now time.Time) (float64, bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "ratelimit", "Take")
+	defer done()
+
+	now = now.UTC()
+	var tokens float64
+
+	err := repository.db.QueryRowContext(ctx, "INSERT INTO \"RateLimitBuckets\" (\"Key\", \"Tokens\", \"DateUpdated\") VALUES ($1, $2::float8 - 1, $4::timestamp) "+
+		"ON CONFLICT (\"Key\") DO UPDATE SET \"Tokens\" = "+refilled+" - 1, \"DateUpdated\" = $4 WHERE "+refilled+" >= 1 RETURNING \"Tokens\"",
+		key, capacity, perSecond, now).
+		Scan(&tokens)
+
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return 0, false, err
+	default:
+		return tokens, true, nil
+	}
+
+	// the bucket is empty, it is not updated so it keeps filling from when a token was last taken
+	err = repository.db.QueryRowContext(ctx, "SELECT "+refilled+" FROM \"RateLimitBuckets\" WHERE \"Key\" = $1",
+		key, capacity, perSecond, now).
+		Scan(&tokens)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return tokens, false, nil
+}
+
+// DeleteUpdatedBefore ...
+func (repository *PostgresRateLimitRepository) DeleteUpdatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := tracing.StartQuery(ctx, "ratelimit", "DeleteUpdatedBefore")
+	defer done()
+
+	result, err := repository.db.ExecContext(ctx, "DELETE FROM \"RateLimitBuckets\" WHERE \"DateUpdated\" < $1", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
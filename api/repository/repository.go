@@ -1,21 +1,164 @@
 package repository
 
 import (
-    "database/sql"
-    "fmt"
-    // llalalal
-    _ "github.com/lib/pq"
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	// llalalal
+	_ "github.com/lib/pq"
 )
 
-// NewDB ...
+// ErrorInvalidPool ...
+var ErrorInvalidPool = errors.New("DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and the database timeouts cannot be negative")
+
+// Options say how the api connects to Postgres and how many connections it keeps
+type Options struct {
+	ConnectionString string `yaml:"connection_string" env:"PGSQL_CONNECTIONSTRING" secret:"true"`
+	// MaxOpenConns caps the connections in use and idle, 0 is no cap
+	MaxOpenConns int `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns int `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	// ConnMaxLifetime closes connections this old so they are spread across replicas and restarts, 0 keeps them
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// StatementTimeout has Postgres cancel any statement that runs longer, 0 leaves it to the server
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// ConnectTimeout is how long Open keeps retrying while Postgres comes up, 0 tries once
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// StatsInterval is how often the pool's stats are logged, 0 never logs them
+	StatsInterval time.Duration `yaml:"stats_interval" env:"DB_STATS_LOG_INTERVAL"`
+}
+
+// DefaultOptions ...
+var DefaultOptions = Options{
+	MaxOpenConns:     25,
+	MaxIdleConns:     10,
+	ConnMaxLifetime:  30 * time.Minute,
+	ConnMaxIdleTime:  5 * time.Minute,
+	StatementTimeout: 30 * time.Second,
+	ConnectTimeout:   time.Minute,
+	StatsInterval:    5 * time.Minute,
+}
+
+// Validate ...
+func (options Options) Validate() error {
+	if options.MaxOpenConns < 0 || options.MaxIdleConns < 0 || options.ConnMaxLifetime < 0 || options.ConnMaxIdleTime < 0 ||
+		options.StatementTimeout < 0 || options.ConnectTimeout < 0 || options.StatsInterval < 0 {
+		return ErrorInvalidPool
+	}
+	return nil
+}
+
+// firstRetry and lastRetry bound the wait between attempts to reach Postgres, it doubles each time
+const firstRetry = 500 * time.Millisecond
+const lastRetry = 10 * time.Second
+
+// NewDB opens a pool with the default options that has reached Postgres once
 func NewDB(dataSourceName string) (*sql.DB, error) {
-    db, err := sql.Open("postgres", dataSourceName)
-    if err != nil {
-        return nil, err
-    }
-    if err = db.Ping(); err != nil {
-        fmt.Println(err)
-        return nil, err
-    }
-    return db, nil
-}
\ No newline at end of file
+	options := DefaultOptions
+	options.ConnectionString = dataSourceName
+	options.ConnectTimeout = 0
+	return Open(context.Background(), infrastructure.NilLogger{}, options)
+}
+
+// Open opens a pool with options and pings Postgres, retrying with backoff for up to the
+// ConnectTimeout so the api can start alongside the database
+func Open(ctx context.Context, logger infrastructure.Logger, options Options) (*sql.DB, error) {
+
+	db, err := sql.Open("postgres", WithStatementTimeout(options.ConnectionString, options.StatementTimeout))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxLifetime(options.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+
+	deadline := time.Now().Add(options.ConnectTimeout)
+	wait := firstRetry
+	for attempt := 1; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if time.Now().Add(wait).After(deadline) {
+			break
+		}
+		logger.Warn("Could not reach the database, retrying", "attempt", attempt, "retry_in_ms", wait.Milliseconds(), "error", err.Error())
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		}
+		if wait *= 2; wait > lastRetry {
+			wait = lastRetry
+		}
+	}
+
+	db.Close()
+	return nil, err
+}
+
+// WithStatementTimeout adds Postgres' statement_timeout to a URL or key=value connection string,
+// lib/pq sends parameters it does not know to the server
+func WithStatementTimeout(dataSourceName string, timeout time.Duration) string {
+
+	if timeout <= 0 {
+		return dataSourceName
+	}
+	milliseconds := strconv.FormatInt(timeout.Milliseconds(), 10)
+
+	if strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://") {
+		parsed, err := url.Parse(dataSourceName)
+		if err != nil {
+			return dataSourceName
+		}
+		query := parsed.Query()
+		if query.Get("statement_timeout") == "" {
+			query.Set("statement_timeout", milliseconds)
+		}
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+
+	if strings.Contains(dataSourceName, "statement_timeout=") {
+		return dataSourceName
+	}
+	return strings.TrimSpace(dataSourceName + " statement_timeout=" + milliseconds)
+}
+
+// LogStats logs the pool's stats every interval until stop is closed, waits for a connection are
+// what show the pool is too small
+func LogStats(db *sql.DB, logger infrastructure.Logger, interval time.Duration, stop <-chan struct{}) {
+
+	if interval <= 0 {
+		<-stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			stats := db.Stats()
+			logger.Info("Database pool",
+				"open", stats.OpenConnections,
+				"in_use", stats.InUse,
+				"idle", stats.Idle,
+				"max_open", stats.MaxOpenConnections,
+				"wait_count", stats.WaitCount,
+				"wait_duration_ms", stats.WaitDuration.Milliseconds(),
+				"max_idle_closed", stats.MaxIdleClosed,
+				"max_lifetime_closed", stats.MaxLifetimeClosed)
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package spendrepository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemorySpendRepository ...
+type InMemorySpendRepository struct {
+	mutex  sync.Mutex
+	lastID int64
+	spends []model.Spend
+}
+
+// NewInMemorySpendRepository ...
+func NewInMemorySpendRepository() *InMemorySpendRepository {
+	repository := InMemorySpendRepository{}
+	return &repository
+}
+
+// GetByID ...
+func (repository *InMemorySpendRepository) GetByID(ctx context.Context, id int64) (model.Spend, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, s := range repository.spends {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return model.Spend{}, ErrorNotFound
+}
+
+// GetForTrackerID ...
+func (repository *InMemorySpendRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Spend, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	spends := []model.Spend{}
+	for _, s := range repository.spends {
+		if s.TrackerID == id {
+			spends = append(spends, s)
+		}
+	}
+	return spends, nil
+}
+
+// Insert ...
+func (repository *InMemorySpendRepository) Insert(ctx context.Context, spend model.Spend) (model.Spend, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.lastID++
+	spend.ID = repository.lastID
+	spend.Version = 1
+	repository.spends = append(repository.spends, spend)
+	return spend, nil
+}
+
+// Update ...only changes the spend if spend.Version is still its current version
+func (repository *InMemorySpendRepository) Update(ctx context.Context, id int64, spend model.Spend) (model.Spend, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, s := range repository.spends {
+		if s.ID == id {
+			if s.Version != spend.Version {
+				return model.Spend{}, ErrorVersionMismatch
+			}
+			spend.ID = id
+			spend.Version++
+			repository.spends[index] = spend
+			return spend, nil
+		}
+	}
+	return model.Spend{}, ErrorNotFound
+}
+
+// Delete ...only deletes the spend if version is still its current version
+func (repository *InMemorySpendRepository) Delete(ctx context.Context, id int64, version int64) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, s := range repository.spends {
+		if s.ID == id {
+			if s.Version != version {
+				return false, ErrorVersionMismatch
+			}
+			repository.spends = append(repository.spends[:index], repository.spends[index+1:]...)
+			return true, nil
+		}
+	}
+	return false, ErrorNotFound
+}
+
+// DeleteForTrackerID ...
+func (repository *InMemorySpendRepository) DeleteForTrackerID(ctx context.Context, id int64) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	spends := []model.Spend{}
+	for _, s := range repository.spends {
+		if s.TrackerID != id {
+			spends = append(spends, s)
+		}
+	}
+	repository.spends = spends
+	return true, nil
+}
@@ -1,30 +1,35 @@
 package spendrepository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
 )
 
 // ErrorNotFound ...to be used when object does not exist in repository
-var ErrorNotFound = errors.New("Spend not found")
+var ErrorNotFound = domainerror.NewNotFound("spend_not_found", "Spend not found")
 
 // ErrorCouldNotInsertSpend ...
-var ErrorCouldNotInsertSpend = errors.New("Could not insert spend")
+var ErrorCouldNotInsertSpend = domainerror.NewInternal("could_not_insert_spend", "Could not insert spend")
 
 // ErrorCouldNotUpdateSpend ...
-var ErrorCouldNotUpdateSpend = errors.New("Could not update spend")
+var ErrorCouldNotUpdateSpend = domainerror.NewInternal("could_not_update_spend", "Could not update spend")
+
+// ErrorVersionMismatch ...to be used when the spend has changed since the caller read it
+var ErrorVersionMismatch = domainerror.NewPreconditionFailed("spend_version_mismatch", "Spend has been changed since you last read it")
 
 // SpendRepository ...
 type SpendRepository interface {
-	GetByID(id int64) (model.Spend, error)
-	GetForTrackerID(id int64) ([]model.Spend, error)
-	Insert(spend model.Spend) (model.Spend, error)
-	Update(id int64, spend model.Spend) (model.Spend, error)
-	Delete(id int64) (bool, error)
-	DeleteForTrackerID(id int64) (bool, error)
+	GetByID(ctx context.Context, id int64) (model.Spend, error)
+	GetForTrackerID(ctx context.Context, id int64) ([]model.Spend, error)
+	Insert(ctx context.Context, spend model.Spend) (model.Spend, error)
+	Update(ctx context.Context, id int64, spend model.Spend) (model.Spend, error)
+	Delete(ctx context.Context, id int64, version int64) (bool, error)
+	DeleteForTrackerID(ctx context.Context, id int64) (bool, error)
 }
 
 // PostgresSpendRepository ...
@@ -43,12 +48,14 @@ func NewPostgresSpendRepository(logger infrastructure.Logger,
 }
 
 // GetByID ...
-func (repository *PostgresSpendRepository) GetByID(id int64) (model.Spend, error) {
+func (repository *PostgresSpendRepository) GetByID(ctx context.Context, id int64) (model.Spend, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "GetByID")
+	defer done()
 
 	repoSpend := model.Spend{}
 
-	err := repository.db.QueryRow("SELECT \"ID\", \"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\" FROM \"Spends\" WHERE \"ID\" = $1", id).
-		Scan(&repoSpend.ID, &repoSpend.TrackerID, &repoSpend.UserID, &repoSpend.Name, &repoSpend.DateCreated, &repoSpend.Value, &repoSpend.Currency)
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\", \"Version\" FROM \"Spends\" WHERE \"ID\" = $1", id).
+		Scan(&repoSpend.ID, &repoSpend.TrackerID, &repoSpend.UserID, &repoSpend.Name, &repoSpend.DateCreated, &repoSpend.Value, &repoSpend.Currency, &repoSpend.Version)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -61,11 +68,13 @@ func (repository *PostgresSpendRepository) GetByID(id int64) (model.Spend, error
 }
 
 // GetForTrackerID ...
-func (repository *PostgresSpendRepository) GetForTrackerID(id int64) ([]model.Spend, error) {
+func (repository *PostgresSpendRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Spend, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "GetForTrackerID")
+	defer done()
 
 	spendsForTracker := []model.Spend{}
 
-	rows, err := repository.db.Query("SELECT \"ID\", \"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\" FROM \"Spends\" WHERE \"TrackerID\" = $1", id)
+	rows, err := repository.db.QueryContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\", \"Version\" FROM \"Spends\" WHERE \"TrackerID\" = $1", id)
 	if err != nil {
 		return []model.Spend{}, err
 	}
@@ -74,7 +83,7 @@ func (repository *PostgresSpendRepository) GetForTrackerID(id int64) ([]model.Sp
 
 		var spend model.Spend
 
-		err = rows.Scan(&spend.ID, &spend.TrackerID, &spend.UserID, &spend.Name, &spend.DateCreated, &spend.Value, &spend.Currency)
+		err = rows.Scan(&spend.ID, &spend.TrackerID, &spend.UserID, &spend.Name, &spend.DateCreated, &spend.Value, &spend.Currency, &spend.Version)
 		if err != nil {
 			return []model.Spend{}, err
 		}
@@ -86,14 +95,16 @@ func (repository *PostgresSpendRepository) GetForTrackerID(id int64) ([]model.Sp
 }
 
 // Insert ...
-func (repository *PostgresSpendRepository) Insert(spend model.Spend) (model.Spend, error) {
+func (repository *PostgresSpendRepository) Insert(ctx context.Context, spend model.Spend) (model.Spend, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "Insert")
+	defer done()
 
 	var lastInsertID int64
 
 	err := repository.
 		db.
-		QueryRow("INSERT INTO \"Spends\"(\"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\") VALUES ($1, $2, $3, $4, $5, $6) RETURNING \"ID\"",
-			spend.TrackerID, spend.UserID, spend.Name, spend.DateCreated, spend.Value, spend.Currency).Scan(&lastInsertID)
+		QueryRowContext(ctx, "INSERT INTO \"Spends\"(\"TrackerID\", \"UserID\", \"Name\", \"DateCreated\", \"Value\", \"Currency\") VALUES ($1, $2, $3, $4, $5, $6) RETURNING \"ID\", \"Version\"",
+			spend.TrackerID, spend.UserID, spend.Name, spend.DateCreated, spend.Value, spend.Currency).Scan(&lastInsertID, &spend.Version)
 	if err != nil {
 		return model.Spend{}, err
 	}
@@ -104,50 +115,82 @@ func (repository *PostgresSpendRepository) Insert(spend model.Spend) (model.Spen
 
 }
 
-// Update ...
-func (repository *PostgresSpendRepository) Update(id int64, spend model.Spend) (model.Spend, error) {
+// Update ...only changes the spend if spend.Version is still its current version
+func (repository *PostgresSpendRepository) Update(ctx context.Context, id int64, spend model.Spend) (model.Spend, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "Update")
+	defer done()
 
-	stmt, err := repository.db.Prepare("UPDATE \"Spends\" SET \"TrackerID\"= $1, \"UserID\"= $2, \"Name\"= $3, \"DateCreated\"= $4, \"Value\"= $5, \"Currency\"= $6 WHERE \"ID\" = $7")
-	if err != nil {
-		return model.Spend{}, err
-	}
+	err := repository.db.QueryRowContext(ctx, "UPDATE \"Spends\" SET \"TrackerID\"= $1, \"UserID\"= $2, \"Name\"= $3, \"DateCreated\"= $4, \"Value\"= $5, \"Currency\"= $6, \"Version\"= \"Version\" + 1 WHERE \"ID\" = $7 AND \"Version\" = $8 RETURNING \"Version\"",
+		spend.TrackerID, spend.UserID, spend.Name, spend.DateCreated, spend.Value, spend.Currency, id, spend.Version).Scan(&spend.Version)
 
-	_, err = stmt.Exec(spend.TrackerID, spend.UserID, spend.Name, spend.DateCreated, spend.Value, spend.Currency, id)
-	if err != nil {
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Spend{}, repository.missingOrChanged(ctx, id)
+	case err != nil:
 		return model.Spend{}, err
 	}
 
 	return spend, nil
 }
 
-// Delete ...
-func (repository *PostgresSpendRepository) Delete(id int64) (bool, error) {
+// Delete ...only deletes the spend if version is still its current version
+func (repository *PostgresSpendRepository) Delete(ctx context.Context, id int64, version int64) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "Delete")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "DELETE FROM \"Spends\" where \"ID\"=$1 AND \"Version\"=$2")
+	if err != nil {
+		return false, err
+	}
 
-	stmt, err := repository.db.Prepare("DELETE FROM \"Spends\" where \"ID\"=$1")
+	result, err := stmt.ExecContext(ctx, id, version)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = stmt.Exec(id)
+	deleted, err := result.RowsAffected()
 	if err != nil {
 		return false, err
 	}
 
+	if deleted == 0 {
+		return false, repository.missingOrChanged(ctx, id)
+	}
+
 	return true, nil
 }
 
 // DeleteForTrackerID ...
-func (repository *PostgresSpendRepository) DeleteForTrackerID(id int64) (bool, error) {
+func (repository *PostgresSpendRepository) DeleteForTrackerID(ctx context.Context, id int64) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "spend", "DeleteForTrackerID")
+	defer done()
 
-	stmt, err := repository.db.Prepare("DELETE FROM \"Spends\" where \"TrackerID\"=$1")
+	stmt, err := repository.db.PrepareContext(ctx, "DELETE FROM \"Spends\" where \"TrackerID\"=$1")
 	if err != nil {
 		return false, err
 	}
 
-	_, err = stmt.Exec(id)
+	_, err = stmt.ExecContext(ctx, id)
 	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
+
+// missingOrChanged works out why a versioned write touched no rows
+func (repository *PostgresSpendRepository) missingOrChanged(ctx context.Context, id int64) error {
+
+	var exists bool
+
+	err := repository.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM \"Spends\" WHERE \"ID\" = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists == false {
+		return ErrorNotFound
+	}
+
+	return ErrorVersionMismatch
+}
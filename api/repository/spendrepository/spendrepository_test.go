@@ -1,6 +1,7 @@
 package spendrepository_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -52,6 +53,7 @@ func TestCanUpdateSpend(t *testing.T) {
 		ID:          domainSpend.ID,
 		Currency:    "$",
 		DateCreated: domainSpend.DateCreated,
+		Version:     domainSpend.Version,
 		Name:        "Ikea MALM",
 		TrackerID:   domainTracker.ID,
 		UserID:      userTwo.ID,
@@ -124,6 +126,30 @@ func TestCanGetForTrackerID(t *testing.T) {
 	thenTheFollowingSpendsAreReturned(expected, t)
 }
 
+func TestCannotUpdateSpendThatHasChanged(t *testing.T) {
+	givenIHaveCleanDependencies(t)
+	givenThereIsATrackerAndTwoUsers(t)
+
+	spend := model.Spend{
+		Currency:    domainTracker.Currency,
+		DateCreated: time.Now(),
+		Name:        "Cheese",
+		TrackerID:   domainTracker.ID,
+		UserID:      userOne.ID,
+		Value:       decimal.NewFromFloat(1.99),
+	}
+
+	givenIHaveASpend(spend)
+	givenISaveTheSpend(t)
+
+	staleSpend := domainSpend
+	staleSpend.Name = "Wine"
+
+	whenIUpdateTheSpend(domainSpend, t)
+	whenITryToUpdateTheSpend(staleSpend)
+	thenTheErrorIs(spendrepository.ErrorVersionMismatch, t)
+}
+
 func TestCanInsertSpend(t *testing.T) {
 	givenIHaveCleanDependencies(t)
 	givenThereIsATrackerAndTwoUsers(t)
@@ -143,14 +169,24 @@ func TestCanInsertSpend(t *testing.T) {
 }
 
 func whenIUpdateTheSpend(spend model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.Update(spend.ID, spend)
+	domainSpend, err = spendRepository.Update(context.Background(), spend.ID, spend)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
+func whenITryToUpdateTheSpend(spend model.Spend) {
+	_, err = spendRepository.Update(context.Background(), spend.ID, spend)
+}
+
+func thenTheErrorIs(expected error, t *testing.T) {
+	if err != expected {
+		t.Fatalf("Expected: %v, Received: %v", expected, err)
+	}
+}
+
 func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -173,7 +209,7 @@ func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
 }
 
 func whenIDeleteTheSpend(t *testing.T) {
-	result, err = spendRepository.Delete(domainSpend.ID)
+	result, err = spendRepository.Delete(context.Background(), domainSpend.ID, domainSpend.Version)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -224,28 +260,28 @@ func thenTheFollowingSpendIsReturned(expected model.Spend, t *testing.T) {
 }
 
 func whenIGetTheSpendsForATracker(t *testing.T) {
-	domainSpends, err = spendRepository.GetForTrackerID(domainTracker.ID)
+	domainSpends, err = spendRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenIGetTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func givenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
@@ -286,6 +322,7 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 		AdminUserID:    userOne.ID,
 		Currency:       "$",
 		DateCreated:    domainTracker.DateCreated,
+		Version:        domainTracker.Version,
 		Name:           "NewName",
 		TrackerUserIDs: []int64{userOne.ID, userTwo.ID},
 	}
@@ -295,14 +332,14 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 }
 
 func whenIUpdateTheTracker(updated model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.Update(updated.ID, updated)
+	domainTracker, err = trackerRepository.Update(context.Background(), updated.ID, updated)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func thenTheTrackerIsUpdated(expected model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -345,14 +382,14 @@ func thenTheFollowingIsReturned(expected model.Tracker, t *testing.T) {
 }
 
 func whenIGetTheTrackersForAUser(t *testing.T) {
-	domainTrackers, err = trackerRepository.GetForUserID(domainUser.ID)
+	domainTrackers, err = trackerRepository.GetForUserID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func whenIGetTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -365,14 +402,14 @@ func thenTheTrackerIsSaved(t *testing.T) {
 }
 
 func whenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func givenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -410,7 +447,7 @@ func givenIHaveADomainUser(user model.User) {
 }
 
 func whenISaveTheUser(t *testing.T) {
-	domainUser, err = userRepository.Insert(domainUser)
+	domainUser, err = userRepository.Insert(context.Background(), domainUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
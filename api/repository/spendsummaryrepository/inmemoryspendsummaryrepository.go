@@ -0,0 +1,86 @@
+package spendsummaryrepository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemorySpendSummaryRepository ...
+type InMemorySpendSummaryRepository struct {
+	mutex          sync.Mutex
+	lastID         int64
+	spendSummaries []model.SpendSummary
+}
+
+// NewInMemorySpendSummaryRepository ...
+func NewInMemorySpendSummaryRepository() *InMemorySpendSummaryRepository {
+	repository := InMemorySpendSummaryRepository{}
+	return &repository
+}
+
+// GetForTrackerID ...
+func (repository *InMemorySpendSummaryRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.SpendSummary, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	spendSummaries := []model.SpendSummary{}
+	for _, ss := range repository.spendSummaries {
+		if ss.TrackerID == id {
+			spendSummaries = append(spendSummaries, ss)
+		}
+	}
+	return spendSummaries, nil
+}
+
+// Insert ...
+func (repository *InMemorySpendSummaryRepository) Insert(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for i := range spendSummaries {
+		repository.insert(&spendSummaries[i])
+	}
+	return spendSummaries, nil
+}
+
+// Update ...inserts the summaries without an ID, as the postgres repository does
+func (repository *InMemorySpendSummaryRepository) Update(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for i := range spendSummaries {
+		if spendSummaries[i].ID == 0 {
+			repository.insert(&spendSummaries[i])
+			continue
+		}
+		for index, ss := range repository.spendSummaries {
+			if ss.ID == spendSummaries[i].ID {
+				repository.spendSummaries[index] = spendSummaries[i]
+			}
+		}
+	}
+	return spendSummaries, nil
+}
+
+// Delete ...removes every summary for the tracker
+func (repository *InMemorySpendSummaryRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	spendSummaries := []model.SpendSummary{}
+	for _, ss := range repository.spendSummaries {
+		if ss.TrackerID != id {
+			spendSummaries = append(spendSummaries, ss)
+		}
+	}
+	repository.spendSummaries = spendSummaries
+	return true, nil
+}
+
+func (repository *InMemorySpendSummaryRepository) insert(spendSummary *model.SpendSummary) {
+	repository.lastID++
+	spendSummary.ID = repository.lastID
+	repository.spendSummaries = append(repository.spendSummaries, *spendSummary)
+}
@@ -1,25 +1,27 @@
 package spendsummaryrepository
 
 import (
-	"errors"
+	"context"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"database/sql"
 	"github.com/TomPallister/godutch-api/api/model"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/tracing"
 
 )
 
 // ErrorCouldNotFindSpendSummaries ...
-var ErrorCouldNotFindSpendSummaries = errors.New("Could not find spend summaries")
+var ErrorCouldNotFindSpendSummaries = domainerror.NewInternal("could_not_find_spend_summaries", "Could not find spend summaries")
 
 // ErrorCouldNotInsertSummaries ...
-var ErrorCouldNotInsertSummaries = errors.New("Could not insert summaries")
+var ErrorCouldNotInsertSummaries = domainerror.NewInternal("could_not_insert_spend_summaries", "Could not insert summaries")
 
 // SpendSummaryRepository ...
 type SpendSummaryRepository interface {
-	GetForTrackerID(id int64) ([]model.SpendSummary, error)
-	Insert(spendSummaries []model.SpendSummary) ([]model.SpendSummary, error)
-	Update(spendSummaries []model.SpendSummary) ([]model.SpendSummary, error)
-	Delete(id int64) (bool, error)
+	GetForTrackerID(ctx context.Context, id int64) ([]model.SpendSummary, error)
+	Insert(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error)
+	Update(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error)
+	Delete(ctx context.Context, id int64) (bool, error)
 }
 
 // PostgresSpendSummaryRepository ...
@@ -38,11 +40,13 @@ func NewPostgresSpendSummaryRepository(logger infrastructure.Logger,
 }
 
 // GetForTrackerID ...
-func (repo *PostgresSpendSummaryRepository) GetForTrackerID(id int64) ([]model.SpendSummary, error) {
+func (repo *PostgresSpendSummaryRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.SpendSummary, error) {
+	ctx, done := tracing.StartQuery(ctx, "spendsummary", "GetForTrackerID")
+	defer done()
 
 	ssForTracker := []model.SpendSummary{}
 	
-	rows, err := repo.db.Query("SELECT \"ID\", \"TrackerID\", \"UserID\", \"Currency\", \"Value\" FROM \"SpendSummaries\" WHERE \"TrackerID\" = $1", id)
+	rows, err := repo.db.QueryContext(ctx, "SELECT \"ID\", \"TrackerID\", \"UserID\", \"Currency\", \"Value\" FROM \"SpendSummaries\" WHERE \"TrackerID\" = $1", id)
 	if err != nil {
 		return []model.SpendSummary{}, err
 	}
@@ -63,14 +67,16 @@ func (repo *PostgresSpendSummaryRepository) GetForTrackerID(id int64) ([]model.S
 }
 
 // Insert ..
-func (repo *PostgresSpendSummaryRepository) Insert(spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+func (repo *PostgresSpendSummaryRepository) Insert(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+	ctx, done := tracing.StartQuery(ctx, "spendsummary", "Insert")
+	defer done()
 
 	for i := 0; i < len(spendSummaries); i++ {
 		var lastInsertID int64
 		
 			err := repo. 
 				db.
-				QueryRow("INSERT INTO \"SpendSummaries\"( \"TrackerID\", \"UserID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4) RETURNING \"ID\"",
+				QueryRowContext(ctx, "INSERT INTO \"SpendSummaries\"( \"TrackerID\", \"UserID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4) RETURNING \"ID\"",
 				spendSummaries[i].TrackerID, spendSummaries[i].UserID, spendSummaries[i].Currency, spendSummaries[i].Value).Scan(&lastInsertID)
 			
 			if err != nil {
@@ -84,7 +90,9 @@ func (repo *PostgresSpendSummaryRepository) Insert(spendSummaries []model.SpendS
 }
 
 // Update ...
-func (repo *PostgresSpendSummaryRepository) Update(spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+func (repo *PostgresSpendSummaryRepository) Update(ctx context.Context, spendSummaries []model.SpendSummary) ([]model.SpendSummary, error) {
+	ctx, done := tracing.StartQuery(ctx, "spendsummary", "Update")
+	defer done()
 
 	for i := 0; i < len(spendSummaries); i++ {
 		
@@ -94,7 +102,7 @@ func (repo *PostgresSpendSummaryRepository) Update(spendSummaries []model.SpendS
 		
 			err := repo. 
 				db.
-				QueryRow("INSERT INTO \"SpendSummaries\"( \"TrackerID\", \"UserID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4) RETURNING \"ID\"",
+				QueryRowContext(ctx, "INSERT INTO \"SpendSummaries\"( \"TrackerID\", \"UserID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4) RETURNING \"ID\"",
 				spendSummaries[i].TrackerID, spendSummaries[i].UserID, spendSummaries[i].Currency, spendSummaries[i].Value).Scan(&lastInsertID)
 			
 			if err != nil {
@@ -106,13 +114,13 @@ func (repo *PostgresSpendSummaryRepository) Update(spendSummaries []model.SpendS
 			
 			stmt, err := repo.
 				db.
-				Prepare("UPDATE \"SpendSummaries\" SET \"TrackerID\"=$1, \"UserID\"=$2, \"Currency\"=$3, \"Value\"=$4 WHERE \"ID\" = $5")
+				PrepareContext(ctx, "UPDATE \"SpendSummaries\" SET \"TrackerID\"=$1, \"UserID\"=$2, \"Currency\"=$3, \"Value\"=$4 WHERE \"ID\" = $5")
 				
 			if err != nil { 
 				return []model.SpendSummary{}, err
 			}
 
-			_, err = stmt.Exec(spendSummaries[i].TrackerID, spendSummaries[i].UserID, spendSummaries[i].Currency, spendSummaries[i].Value, spendSummaries[i].ID)
+			_, err = stmt.ExecContext(ctx, spendSummaries[i].TrackerID, spendSummaries[i].UserID, spendSummaries[i].Currency, spendSummaries[i].Value, spendSummaries[i].ID)
 			if err != nil {
 				return []model.SpendSummary{}, err
 			}
@@ -123,14 +131,16 @@ func (repo *PostgresSpendSummaryRepository) Update(spendSummaries []model.SpendS
 
 
 // Delete ...
-func (repo *PostgresSpendSummaryRepository) Delete(id int64) (bool, error) {
+func (repo *PostgresSpendSummaryRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "spendsummary", "Delete")
+	defer done()
     
-	stmt, err := repo.db.Prepare("DELETE FROM \"SpendSummaries\" where \"TrackerID\"=$1")
+	stmt, err := repo.db.PrepareContext(ctx, "DELETE FROM \"SpendSummaries\" where \"TrackerID\"=$1")
     if err != nil {
 		return false, err
 	}
 
-    _, err = stmt.Exec(id)
+    _, err = stmt.ExecContext(ctx, id)
    	if err != nil {
 		return false, err
 	}
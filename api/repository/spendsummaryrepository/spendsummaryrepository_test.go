@@ -1,6 +1,7 @@
 package spendsummaryrepository_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -120,7 +121,7 @@ func TestCanInsertSpendSummaries(t *testing.T) {
 
 func thenTheSpendSummariesAreUpdated(expected []model.SpendSummary, t *testing.T) {
 
-	domainSpendSummaries, err = spendSummaryRepository.GetForTrackerID(domainTracker.ID)
+	domainSpendSummaries, err = spendSummaryRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -142,7 +143,7 @@ func thenTheSpendSummariesAreUpdated(expected []model.SpendSummary, t *testing.T
 }
 
 func whenIUpdateTheSpendSummaries(ss []model.SpendSummary, t *testing.T) {
-	domainSpendSummaries, err = spendSummaryRepository.Update(ss)
+	domainSpendSummaries, err = spendSummaryRepository.Update(context.Background(), ss)
 	if err != nil {
 		t.Fatalf("Error :%v", err)
 	}
@@ -155,7 +156,7 @@ func thenTheSpendSummariesAreDeleted(t *testing.T) {
 }
 
 func whenIDeleteTheSpendSummaries(t *testing.T) {
-	result, err = spendSummaryRepository.Delete(domainTracker.ID)
+	result, err = spendSummaryRepository.Delete(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -179,7 +180,7 @@ func thenTheFollowingSpendSummariesAreReturned(expected []model.SpendSummary, t
 }
 
 func whenIGetTheSpendSummariesByTrackerID(t *testing.T) {
-	domainSpendSummaries, err = spendSummaryRepository.GetForTrackerID(domainTracker.ID)
+	domainSpendSummaries, err = spendSummaryRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -194,14 +195,14 @@ func thenTheSpendSummariesAreSaved(t *testing.T) {
 }
 
 func givenIInsertTheSpendSummaries(t *testing.T) {
-	domainSpendSummaries, err = spendSummaryRepository.Insert(domainSpendSummaries)
+	domainSpendSummaries, err = spendSummaryRepository.Insert(context.Background(), domainSpendSummaries)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func whenIInsertTheSpendSummaries(t *testing.T) {
-	domainSpendSummaries, err = spendSummaryRepository.Insert(domainSpendSummaries)
+	domainSpendSummaries, err = spendSummaryRepository.Insert(context.Background(), domainSpendSummaries)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -229,14 +230,14 @@ func givenThereIsATrackerTwoUsersAndASpend(t *testing.T) {
 }
 
 func whenIUpdateTheSpend(spend model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.Update(spend.ID, spend)
+	domainSpend, err = spendRepository.Update(context.Background(), spend.ID, spend)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -259,7 +260,7 @@ func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
 }
 
 func whenIDeleteTheSpend(t *testing.T) {
-	result, err = spendRepository.Delete(domainSpend.ID)
+	result, err = spendRepository.Delete(context.Background(), domainSpend.ID, domainSpend.Version)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -310,28 +311,28 @@ func thenTheFollowingSpendIsReturned(expected model.Spend, t *testing.T) {
 }
 
 func whenIGetTheSpendsForATracker(t *testing.T) {
-	domainSpends, err = spendRepository.GetForTrackerID(domainTracker.ID)
+	domainSpends, err = spendRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenIGetTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func givenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
@@ -372,6 +373,7 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 		AdminUserID:    userOne.ID,
 		Currency:       "$",
 		DateCreated:    domainTracker.DateCreated,
+		Version:        domainTracker.Version,
 		Name:           "NewName",
 		TrackerUserIDs: []int64{userOne.ID, userTwo.ID},
 	}
@@ -381,14 +383,14 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 }
 
 func whenIUpdateTheTracker(updated model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.Update(updated.ID, updated)
+	domainTracker, err = trackerRepository.Update(context.Background(), updated.ID, updated)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func thenTheTrackerIsUpdated(expected model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -431,14 +433,14 @@ func thenTheFollowingIsReturned(expected model.Tracker, t *testing.T) {
 }
 
 func whenIGetTheTrackersForAUser(t *testing.T) {
-	domainTrackers, err = trackerRepository.GetForUserID(domainUser.ID)
+	domainTrackers, err = trackerRepository.GetForUserID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func whenIGetTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -451,14 +453,14 @@ func thenTheTrackerIsSaved(t *testing.T) {
 }
 
 func whenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func givenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -497,7 +499,7 @@ func givenIHaveADomainUser(user model.User) {
 }
 
 func whenISaveTheUser(t *testing.T) {
-	domainUser, err = userRepository.Insert(domainUser)
+	domainUser, err = userRepository.Insert(context.Background(), domainUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -0,0 +1,106 @@
+package trackerrepository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryTrackerRepository ...
+type InMemoryTrackerRepository struct {
+	mutex    sync.Mutex
+	lastID   int64
+	trackers []model.Tracker
+}
+
+// NewInMemoryTrackerRepository ...
+func NewInMemoryTrackerRepository() *InMemoryTrackerRepository {
+	repository := InMemoryTrackerRepository{}
+	return &repository
+}
+
+// GetByID ...
+func (repository *InMemoryTrackerRepository) GetByID(ctx context.Context, id int64) (model.Tracker, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, t := range repository.trackers {
+		if t.ID == id {
+			return copyTracker(t), nil
+		}
+	}
+	return model.Tracker{}, sql.ErrNoRows
+}
+
+// GetForUserID ...
+func (repository *InMemoryTrackerRepository) GetForUserID(ctx context.Context, id int64) ([]model.Tracker, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	trackers := []model.Tracker{}
+	for _, t := range repository.trackers {
+		for _, userID := range t.TrackerUserIDs {
+			if userID == id {
+				trackers = append(trackers, copyTracker(t))
+				break
+			}
+		}
+	}
+	return trackers, nil
+}
+
+// Insert ...
+func (repository *InMemoryTrackerRepository) Insert(ctx context.Context, tracker model.Tracker) (model.Tracker, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.lastID++
+	tracker.ID = repository.lastID
+	tracker.Version = 1
+	repository.trackers = append(repository.trackers, copyTracker(tracker))
+	return tracker, nil
+}
+
+// Update ...only changes the tracker if tracker.Version is still its current version
+func (repository *InMemoryTrackerRepository) Update(ctx context.Context, id int64, tracker model.Tracker) (model.Tracker, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, t := range repository.trackers {
+		if t.ID == id {
+			if t.Version != tracker.Version {
+				return model.Tracker{}, ErrorVersionMismatch
+			}
+			tracker.ID = id
+			tracker.Version++
+			repository.trackers[index] = copyTracker(tracker)
+			return tracker, nil
+		}
+	}
+	return model.Tracker{}, ErrorNotFound
+}
+
+// Delete ...only deletes the tracker if version is still its current version
+func (repository *InMemoryTrackerRepository) Delete(ctx context.Context, id int64, version int64) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, t := range repository.trackers {
+		if t.ID == id {
+			if t.Version != version {
+				return false, ErrorVersionMismatch
+			}
+			repository.trackers = append(repository.trackers[:index], repository.trackers[index+1:]...)
+			return true, nil
+		}
+	}
+	return false, ErrorNotFound
+}
+
+// copyTracker stops callers changing the stored users through the slice they were given
+func copyTracker(tracker model.Tracker) model.Tracker {
+	tracker.TrackerUserIDs = append([]int64{}, tracker.TrackerUserIDs...)
+	return tracker
+}
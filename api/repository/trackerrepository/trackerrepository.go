@@ -1,30 +1,34 @@
 package trackerrepository
 
 import (
-	"errors"
-	
+	"context"
+	"database/sql"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
-	"database/sql"
-
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/lib/pq"
 )
 
 // ErrorNotFound ...to be used when object does not exist in repository
-var ErrorNotFound = errors.New("Tracker not found")
+var ErrorNotFound = domainerror.NewNotFound("tracker_not_found", "Tracker not found")
 
 // ErrorCouldNotInsertTracker ...
-var ErrorCouldNotInsertTracker = errors.New("Could not insert tracker")
+var ErrorCouldNotInsertTracker = domainerror.NewInternal("could_not_insert_tracker", "Could not insert tracker")
 
 // ErrorCouldNotUpdateTracker ...
-var ErrorCouldNotUpdateTracker = errors.New("Could not update tracker")
+var ErrorCouldNotUpdateTracker = domainerror.NewInternal("could_not_update_tracker", "Could not update tracker")
+
+// ErrorVersionMismatch ...to be used when the tracker has changed since the caller read it
+var ErrorVersionMismatch = domainerror.NewPreconditionFailed("tracker_version_mismatch", "Tracker has been changed since you last read it")
 
 // TrackerRepository ...
 type TrackerRepository interface { 
-	GetByID(id int64) (model.Tracker, error)
-	GetForUserID(id int64) ([]model.Tracker, error)
-	Insert(tracker model.Tracker) (model.Tracker, error)
-	Update(id int64, tracker model.Tracker) (model.Tracker, error)
-	Delete(id int64) (bool, error)
+	GetByID(ctx context.Context, id int64) (model.Tracker, error)
+	GetForUserID(ctx context.Context, id int64) ([]model.Tracker, error)
+	Insert(ctx context.Context, tracker model.Tracker) (model.Tracker, error)
+	Update(ctx context.Context, id int64, tracker model.Tracker) (model.Tracker, error)
+	Delete(ctx context.Context, id int64, version int64) (bool, error)
 }
 
 // PostgresTrackerRepository ... 
@@ -42,100 +46,77 @@ func NewPostgresTrackerRepository(logger infrastructure.Logger,
 	return &repository
 }
 
+// trackersWithUsers selects each tracker with its users' IDs, in the order they were added, so
+// trackers are read with their users in one query
+const trackersWithUsers = "SELECT \"Trackers\".\"ID\", \"AdminUserID\", \"Name\", \"Currency\", \"DateCreated\", \"Version\", " +
+	"COALESCE(array_agg(\"TrackerUsers\".\"UserID\" ORDER BY \"TrackerUsers\".\"ID\") FILTER (WHERE \"TrackerUsers\".\"UserID\" IS NOT NULL), '{}') " +
+	"FROM \"Trackers\" LEFT JOIN \"TrackerUsers\" ON \"Trackers\".\"ID\"=\"TrackerUsers\".\"TrackerID\" "
+
 // GetByID ...
-func (repository *PostgresTrackerRepository) GetByID(id int64) (model.Tracker, error) {
-	
-	var repoTracker model.Tracker
+func (repository *PostgresTrackerRepository) GetByID(ctx context.Context, id int64) (model.Tracker, error) {
+	ctx, done := tracing.StartQuery(ctx, "tracker", "GetByID")
+	defer done()
 
-    err := repository.db.QueryRow("SELECT \"ID\", \"AdminUserID\", \"Name\", \"Currency\", \"DateCreated\" FROM \"Trackers\" WHERE \"ID\" = $1", id).
-		Scan(&repoTracker.ID, &repoTracker.AdminUserID, &repoTracker.Name, &repoTracker.Currency, &repoTracker.DateCreated)
-    
-	switch {
-    case err == sql.ErrNoRows:
-            return model.Tracker{}, err
-    case err != nil:
-            return model.Tracker{}, err
-	}
-    
-	rows, err := repository.db.Query("SELECT \"UserID\" FROM \"TrackerUsers\" WHERE \"TrackerID\" = $1", id)
-	if err != nil {
-		return model.Tracker{}, err
-	}
-	
-	trackerUserIds := []int64{}
-	
-	for rows.Next() {
-		
-        var userID int64
-  
-        err = rows.Scan(&userID)
-       	if err != nil {
-			return model.Tracker{}, err
-		}
-		
-		trackerUserIds = append(trackerUserIds, userID)
-    }
-	
-	repoTracker.TrackerUserIDs = trackerUserIds
-	 
-	return repoTracker, nil
+	row := repository.db.QueryRowContext(ctx, trackersWithUsers+"WHERE \"Trackers\".\"ID\" = $1 GROUP BY \"Trackers\".\"ID\"", id)
+
+	return scanTracker(row)
 }
 
 // GetForUserID ...
-func (repository *PostgresTrackerRepository) GetForUserID(id int64) ([]model.Tracker, error) {
-	
-	rows, err := repository.db.Query("SELECT \"Trackers\".\"ID\", \"AdminUserID\", \"Name\", \"Currency\", \"DateCreated\" FROM \"Trackers\" INNER JOIN \"TrackerUsers\" ON \"Trackers\".\"ID\"=\"TrackerUsers\".\"TrackerID\" WHERE \"TrackerUsers\".\"UserID\" = $1", id)
+func (repository *PostgresTrackerRepository) GetForUserID(ctx context.Context, id int64) ([]model.Tracker, error) {
+	ctx, done := tracing.StartQuery(ctx, "tracker", "GetForUserID")
+	defer done()
+
+	rows, err := repository.db.QueryContext(ctx, trackersWithUsers+"WHERE \"Trackers\".\"ID\" IN (SELECT \"TrackerID\" FROM \"TrackerUsers\" WHERE \"UserID\" = $1) GROUP BY \"Trackers\".\"ID\" ORDER BY \"Trackers\".\"ID\"", id)
 	if err != nil {
 		return []model.Tracker{}, err
 	}
-	
+	defer rows.Close()
+
 	trackersForUser := []model.Tracker{}
-	
+
 	for rows.Next() {
-		
-        var tracker model.Tracker
-  
-        err = rows.Scan(&tracker.ID, &tracker.AdminUserID, &tracker.Name, &tracker.Currency, &tracker.DateCreated)
-       	if err != nil {
-			return []model.Tracker{}, err
-		}
-		
-		
-		tURows, err := repository.db.Query("SELECT \"UserID\" FROM \"TrackerUsers\" WHERE \"TrackerID\" = $1", tracker.ID)
+
+		tracker, err := scanTracker(rows)
 		if err != nil {
 			return []model.Tracker{}, err
 		}
-		
-		trackerUserIds := []int64{}
-		
-		for tURows.Next() {
-			
-			var userID int64
-	
-			err = tURows.Scan(&userID)
-			if err != nil {
-				return []model.Tracker{}, err
-			}
-			
-			trackerUserIds = append(trackerUserIds, userID)
-		}
-		
-		tracker.TrackerUserIDs = trackerUserIds
-		
+
 		trackersForUser = append(trackersForUser, tracker)
-    }
+	}
+	if err = rows.Err(); err != nil {
+		return []model.Tracker{}, err
+	}
 
 	return trackersForUser, nil
 }
 
+// scanTracker reads a row of trackersWithUsers
+func scanTracker(row interface{ Scan(dest ...interface{}) error }) (model.Tracker, error) {
+
+	var tracker model.Tracker
+	trackerUserIDs := pq.Int64Array{}
+
+	err := row.Scan(&tracker.ID, &tracker.AdminUserID, &tracker.Name, &tracker.Currency, &tracker.DateCreated, &tracker.Version, &trackerUserIDs)
+	if err != nil {
+		return model.Tracker{}, err
+	}
+
+	tracker.TrackerUserIDs = []int64(trackerUserIDs)
+
+	return tracker, nil
+}
+
 // Insert ...
-func (repository *PostgresTrackerRepository) Insert(tracker model.Tracker) (model.Tracker, error) {
+func (repository *PostgresTrackerRepository) Insert(ctx context.Context, tracker model.Tracker) (model.Tracker, error) {
+	ctx, done := tracing.StartQuery(ctx, "tracker", "Insert")
+	defer done()
 	
 	var lastInsertID int64
 	err := repository.
 		db.
-		QueryRow("INSERT INTO \"Trackers\"(\"AdminUserID\", \"Name\", \"Currency\", \"DateCreated\") VALUES ($1, $2, $3, $4) RETURNING \"ID\"",
-		tracker.AdminUserID, tracker.Name, tracker.Currency, tracker.DateCreated).Scan(&lastInsertID)
+		QueryRowContext(ctx, "INSERT INTO \"Trackers\"(\"AdminUserID\", \"Name\", \"Currency\", \"DateCreated\") VALUES ($1, $2, $3, $4) RETURNING \"ID\", \"Version\"",
+		tracker.AdminUserID, tracker.Name, tracker.Currency, tracker.DateCreated).Scan(&lastInsertID, &tracker.Version)
 	if err != nil {
 		return model.Tracker{}, err
 	}		
@@ -144,12 +125,12 @@ func (repository *PostgresTrackerRepository) Insert(tracker model.Tracker) (mode
 	
 	for _, u := range tracker.TrackerUserIDs {
 		
-		stmt, err := repository.db.Prepare("INSERT INTO \"TrackerUsers\"(\"TrackerID\", \"UserID\") VALUES ($1, $2)")
+		stmt, err := repository.db.PrepareContext(ctx, "INSERT INTO \"TrackerUsers\"(\"TrackerID\", \"UserID\") VALUES ($1, $2)")
 		if err != nil {
 			return model.Tracker{}, err
 		}	
 		
-		_, err = stmt.Exec(tracker.ID, u)
+		_, err = stmt.ExecContext(ctx, tracker.ID, u)
 		if err != nil {
 			return model.Tracker{}, err
 		}	
@@ -158,37 +139,39 @@ func (repository *PostgresTrackerRepository) Insert(tracker model.Tracker) (mode
 	return tracker, nil
 }
 
-// Update ...
-func (repository *PostgresTrackerRepository) Update(id int64, tracker model.Tracker) (model.Tracker, error) {
+// Update ...only changes the tracker if tracker.Version is still its current version
+func (repository *PostgresTrackerRepository) Update(ctx context.Context, id int64, tracker model.Tracker) (model.Tracker, error) {
+    ctx, done := tracing.StartQuery(ctx, "tracker", "Update")
+    defer done()
 	
-    stmt, err := repository.db.Prepare("UPDATE \"Trackers\" SET \"AdminUserID\"=$1, \"Name\"=$2, \"Currency\"=$3, \"DateCreated\"=$4 WHERE \"ID\" = $5")
-	if err != nil {
-		return model.Tracker{}, err
-	}
-
-    _, err = stmt.Exec(tracker.AdminUserID, tracker.Name, tracker.Currency, tracker.DateCreated, id)
-	if err != nil {
-		return model.Tracker{}, err
+    err := repository.db.QueryRowContext(ctx, "UPDATE \"Trackers\" SET \"AdminUserID\"=$1, \"Name\"=$2, \"Currency\"=$3, \"DateCreated\"=$4, \"Version\"=\"Version\" + 1 WHERE \"ID\" = $5 AND \"Version\" = $6 RETURNING \"Version\"",
+		tracker.AdminUserID, tracker.Name, tracker.Currency, tracker.DateCreated, id, tracker.Version).Scan(&tracker.Version)
+	
+	switch {
+    case err == sql.ErrNoRows:
+            return model.Tracker{}, repository.missingOrChanged(ctx, id)
+    case err != nil:
+            return model.Tracker{}, err
 	}
 	
-	stmt, err = repository.db.Prepare("DELETE from \"TrackerUsers\" WHERE \"TrackerID\" = $1")
+	stmt, err := repository.db.PrepareContext(ctx, "DELETE from \"TrackerUsers\" WHERE \"TrackerID\" = $1")
 	if err != nil {
 		return model.Tracker{}, err
 	}
 	
-    _, err = stmt.Exec(id)
+    _, err = stmt.ExecContext(ctx, id)
 	if err != nil {
 		return model.Tracker{}, err
 	}	
 	
 	for _, u := range tracker.TrackerUserIDs {
 		
-		stmt, err := repository.db.Prepare("INSERT INTO \"TrackerUsers\"(\"TrackerID\", \"UserID\") VALUES ($1, $2)")
+		stmt, err := repository.db.PrepareContext(ctx, "INSERT INTO \"TrackerUsers\"(\"TrackerID\", \"UserID\") VALUES ($1, $2)")
 		if err != nil {
 			return model.Tracker{}, err
 		}	
 		
-		_, err = stmt.Exec(tracker.ID, u)
+		_, err = stmt.ExecContext(ctx, tracker.ID, u)
 		if err != nil {
 			return model.Tracker{}, err
 		}	
@@ -197,28 +180,72 @@ func (repository *PostgresTrackerRepository) Update(id int64, tracker model.Trac
 	return tracker, nil
 }
 
-// Delete ...
-func (repository *PostgresTrackerRepository) Delete(id int64) (bool, error) {
-	
-    stmt, err := repository.db.Prepare("DELETE FROM \"TrackerUsers\" where \"TrackerID\"=$1")
-    if err != nil {
+// Delete ...removes the tracker with its spends, transfers, summaries and users in one transaction,
+// holding the tracker's row so nothing can be added to it while the rest is removed
+func (repository *PostgresTrackerRepository) Delete(ctx context.Context, id int64, version int64) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "tracker", "Delete")
+	defer done()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
 		return false, err
 	}
+	defer tx.Rollback()
 
-    _, err = stmt.Exec(id)
-   	if err != nil {
+	var current int64
+	err = tx.QueryRowContext(ctx, "SELECT \"Version\" FROM \"Trackers\" WHERE \"ID\"=$1 FOR UPDATE", id).Scan(&current)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, ErrorNotFound
+	case err != nil:
 		return false, err
 	}
-	
-	 stmt, err = repository.db.Prepare("DELETE FROM \"Trackers\" where \"ID\"=$1")
-    if err != nil {
+
+	if current != version {
+		return false, ErrorVersionMismatch
+	}
+
+	for _, table := range []string{"Transfers", "SpendSummaries", "Spends", "TrackerUsers"} {
+		_, err = tx.ExecContext(ctx, "DELETE FROM \""+table+"\" WHERE \"TrackerID\"=$1", id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM \"Trackers\" WHERE \"ID\"=$1 AND \"Version\"=$2", id, version)
+	if err != nil {
 		return false, err
 	}
 
-    _, err = stmt.Exec(id)
-   	if err != nil {
+	deleted, err := result.RowsAffected()
+	if err != nil {
 		return false, err
 	}
-	
+
+	if deleted == 0 {
+		return false, ErrorVersionMismatch
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
 	return true, nil
+}
+
+// missingOrChanged works out why a versioned write touched no rows
+func (repository *PostgresTrackerRepository) missingOrChanged(ctx context.Context, id int64) error {
+	
+	var exists bool
+	
+	err := repository.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM \"Trackers\" WHERE \"ID\" = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	
+	if exists == false {
+		return ErrorNotFound
+	}
+	
+	return ErrorVersionMismatch
 }
\ No newline at end of file
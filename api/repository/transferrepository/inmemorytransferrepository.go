@@ -0,0 +1,63 @@
+package transferrepository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryTransferRepository ...
+type InMemoryTransferRepository struct {
+	mutex     sync.Mutex
+	lastID    int64
+	transfers []model.Transfer
+}
+
+// NewInMemoryTransferRepository ...
+func NewInMemoryTransferRepository() *InMemoryTransferRepository {
+	repository := InMemoryTransferRepository{}
+	return &repository
+}
+
+// GetForTrackerID ...
+func (repository *InMemoryTransferRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Transfer, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	transfers := []model.Transfer{}
+	for _, t := range repository.transfers {
+		if t.TrackerID == id {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
+
+// Insert ...
+func (repository *InMemoryTransferRepository) Insert(ctx context.Context, transfers []model.Transfer) ([]model.Transfer, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for i := range transfers {
+		repository.lastID++
+		transfers[i].ID = repository.lastID
+		repository.transfers = append(repository.transfers, transfers[i])
+	}
+	return transfers, nil
+}
+
+// Delete ...removes every transfer for the tracker
+func (repository *InMemoryTransferRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	transfers := []model.Transfer{}
+	for _, t := range repository.transfers {
+		if t.TrackerID != id {
+			transfers = append(transfers, t)
+		}
+	}
+	repository.transfers = transfers
+	return true, nil
+}
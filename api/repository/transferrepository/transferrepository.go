@@ -1,24 +1,26 @@
 package transferrepository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
 )
 
 // ErrorCouldNotFindTransfers ...
-var ErrorCouldNotFindTransfers = errors.New("Could not find transfers")
+var ErrorCouldNotFindTransfers = domainerror.NewInternal("could_not_find_transfers", "Could not find transfers")
 
 // ErrorCouldNotInsertTransfers ...
-var ErrorCouldNotInsertTransfers = errors.New("Could not insert transfers")
+var ErrorCouldNotInsertTransfers = domainerror.NewInternal("could_not_insert_transfers", "Could not insert transfers")
 
 // TransferRepository ...
 type TransferRepository interface {
-	GetForTrackerID(id int64) ([]model.Transfer, error)
-	Insert(transfers []model.Transfer) ([]model.Transfer, error)
-	Delete(id int64) (bool, error)
+	GetForTrackerID(ctx context.Context, id int64) ([]model.Transfer, error)
+	Insert(ctx context.Context, transfers []model.Transfer) ([]model.Transfer, error)
+	Delete(ctx context.Context, id int64) (bool, error)
 }
 
 // PostgresTransferRepository ...
@@ -37,11 +39,13 @@ func NewPostgresTransferRepository(logger infrastructure.Logger,
 }
 
 // GetForTrackerID ...
-func (repo *PostgresTransferRepository) GetForTrackerID(id int64) ([]model.Transfer, error) {
+func (repo *PostgresTransferRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Transfer, error) {
+	ctx, done := tracing.StartQuery(ctx, "transfer", "GetForTrackerID")
+	defer done()
 
 	transfers := []model.Transfer{}
 
-	rows, err := repo.db.Query("SELECT \"ID\", \"ToUserID\", \"FromUserID\", \"TrackerID\", \"Currency\", \"Value\" FROM \"Transfers\" WHERE \"TrackerID\" = $1", id)
+	rows, err := repo.db.QueryContext(ctx, "SELECT \"ID\", \"ToUserID\", \"FromUserID\", \"TrackerID\", \"Currency\", \"Value\" FROM \"Transfers\" WHERE \"TrackerID\" = $1", id)
 	if err != nil {
 		return []model.Transfer{}, err
 	}
@@ -62,14 +66,16 @@ func (repo *PostgresTransferRepository) GetForTrackerID(id int64) ([]model.Trans
 }
 
 // Insert ..
-func (repo *PostgresTransferRepository) Insert(transfers []model.Transfer) ([]model.Transfer, error) {
+func (repo *PostgresTransferRepository) Insert(ctx context.Context, transfers []model.Transfer) ([]model.Transfer, error) {
+	ctx, done := tracing.StartQuery(ctx, "transfer", "Insert")
+	defer done()
 
 	for i := 0; i < len(transfers); i++ {
 		var lastInsertID int64
 
 		err := repo.
 			db.
-			QueryRow("INSERT INTO \"Transfers\"(\"ToUserID\", \"FromUserID\", \"TrackerID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4, $5) RETURNING \"ID\"",
+			QueryRowContext(ctx, "INSERT INTO \"Transfers\"(\"ToUserID\", \"FromUserID\", \"TrackerID\", \"Currency\", \"Value\") VALUES ($1, $2, $3, $4, $5) RETURNING \"ID\"",
 				transfers[i].ToUserID, transfers[i].FromUserID, transfers[i].TrackerID, transfers[i].Currency, transfers[i].Value).Scan(&lastInsertID)
 
 		if err != nil {
@@ -83,14 +89,16 @@ func (repo *PostgresTransferRepository) Insert(transfers []model.Transfer) ([]mo
 }
 
 // Delete ...
-func (repo *PostgresTransferRepository) Delete(id int64) (bool, error) {
+func (repo *PostgresTransferRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "transfer", "Delete")
+	defer done()
 
-	stmt, err := repo.db.Prepare("DELETE FROM \"Transfers\" where \"TrackerID\"=$1")
+	stmt, err := repo.db.PrepareContext(ctx, "DELETE FROM \"Transfers\" where \"TrackerID\"=$1")
 	if err != nil {
 		return false, err
 	}
 
-	_, err = stmt.Exec(id)
+	_, err = stmt.ExecContext(ctx, id)
 	if err != nil {
 		return false, err
 	}
@@ -1,6 +1,7 @@
 package transferrepository_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -130,7 +131,7 @@ func TestCanInsertTransfers(t *testing.T) {
 
 func thenTheTransfersAreUpdated(expected []model.Transfer, t *testing.T) {
 
-	domainTransfers, err = transferRepository.GetForTrackerID(domainTracker.ID)
+	domainTransfers, err = transferRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -155,7 +156,7 @@ func thenTheTransfersAreUpdated(expected []model.Transfer, t *testing.T) {
 }
 
 func whenIUpdateTheTransfers(transfers []model.Transfer, t *testing.T) {
-	domainTransfers, err = transferRepository.Update(transfers)
+	domainTransfers, err = transferRepository.Update(context.Background(), transfers)
 	if err != nil {
 		t.Fatalf("Error :%v", err)
 	}
@@ -168,7 +169,7 @@ func thenTheTransfersAreDeleted(t *testing.T) {
 }
 
 func whenIDeleteTheTransfers(t *testing.T) {
-	result, err = transferRepository.Delete(domainTracker.ID)
+	result, err = transferRepository.Delete(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -195,7 +196,7 @@ func thenTheFollowingTransfersAreReturned(expected []model.Transfer, t *testing.
 }
 
 func whenIGetTheTransfersByTrackerID(t *testing.T) {
-	domainTransfers, err = transferRepository.GetForTrackerID(domainTracker.ID)
+	domainTransfers, err = transferRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -210,14 +211,14 @@ func thenTheTransfersAreSaved(t *testing.T) {
 }
 
 func givenIInsertTheTransfers(t *testing.T) {
-	domainTransfers, err = transferRepository.Insert(domainTransfers)
+	domainTransfers, err = transferRepository.Insert(context.Background(), domainTransfers)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func whenIInsertTheTransfers(t *testing.T) {
-	domainTransfers, err = transferRepository.Insert(domainTransfers)
+	domainTransfers, err = transferRepository.Insert(context.Background(), domainTransfers)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -245,14 +246,14 @@ func givenThereIsATrackerTwoUsersAndASpend(t *testing.T) {
 }
 
 func whenIUpdateTheSpend(spend model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.Update(spend.ID, spend)
+	domainSpend, err = spendRepository.Update(context.Background(), spend.ID, spend)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -275,7 +276,7 @@ func thenTheSpendIsUpdated(expected model.Spend, t *testing.T) {
 }
 
 func whenIDeleteTheSpend(t *testing.T) {
-	result, err = spendRepository.Delete(domainSpend.ID)
+	result, err = spendRepository.Delete(context.Background(), domainSpend.ID, domainSpend.Version)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -326,28 +327,28 @@ func thenTheFollowingSpendIsReturned(expected model.Spend, t *testing.T) {
 }
 
 func whenIGetTheSpendsForATracker(t *testing.T) {
-	domainSpends, err = spendRepository.GetForTrackerID(domainTracker.ID)
+	domainSpends, err = spendRepository.GetForTrackerID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenIGetTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.GetByID(domainSpend.ID)
+	domainSpend, err = spendRepository.GetByID(context.Background(), domainSpend.ID)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func whenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
 }
 
 func givenISaveTheSpend(t *testing.T) {
-	domainSpend, err = spendRepository.Insert(domainSpend)
+	domainSpend, err = spendRepository.Insert(context.Background(), domainSpend)
 	if err != nil {
 		t.Fatal("Error: ", err)
 	}
@@ -388,6 +389,7 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 		AdminUserID:    userOne.ID,
 		Currency:       "$",
 		DateCreated:    domainTracker.DateCreated,
+		Version:        domainTracker.Version,
 		Name:           "NewName",
 		TrackerUserIDs: []int64{userOne.ID, userTwo.ID},
 	}
@@ -397,14 +399,14 @@ func givenThereIsATrackerAndTwoUsers(t *testing.T) {
 }
 
 func whenIUpdateTheTracker(updated model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.Update(updated.ID, updated)
+	domainTracker, err = trackerRepository.Update(context.Background(), updated.ID, updated)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func thenTheTrackerIsUpdated(expected model.Tracker, t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -447,14 +449,14 @@ func thenTheFollowingIsReturned(expected model.Tracker, t *testing.T) {
 }
 
 func whenIGetTheTrackersForAUser(t *testing.T) {
-	domainTrackers, err = trackerRepository.GetForUserID(domainUser.ID)
+	domainTrackers, err = trackerRepository.GetForUserID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func whenIGetTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.GetByID(domainTracker.ID)
+	domainTracker, err = trackerRepository.GetByID(context.Background(), domainTracker.ID)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -467,14 +469,14 @@ func thenTheTrackerIsSaved(t *testing.T) {
 }
 
 func whenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
 }
 
 func givenISaveTheTracker(t *testing.T) {
-	domainTracker, err = trackerRepository.Insert(domainTracker)
+	domainTracker, err = trackerRepository.Insert(context.Background(), domainTracker)
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
@@ -513,7 +515,7 @@ func givenIHaveADomainUser(user model.User) {
 }
 
 func whenISaveTheUser(t *testing.T) {
-	domainUser, err = userRepository.Insert(domainUser)
+	domainUser, err = userRepository.Insert(context.Background(), domainUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -0,0 +1,85 @@
+package userrepository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryUserRepository ...
+type InMemoryUserRepository struct {
+	mutex sync.Mutex
+	users []model.User
+}
+
+// NewInMemoryUserRepository ...
+func NewInMemoryUserRepository() *InMemoryUserRepository {
+	repository := InMemoryUserRepository{}
+	return &repository
+}
+
+// Insert ...
+func (repository *InMemoryUserRepository) Insert(ctx context.Context, user model.User) (model.User, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user.ID = int64(len(repository.users) + 1)
+	repository.users = append(repository.users, user)
+	return user, nil
+}
+
+// Update ...
+func (repository *InMemoryUserRepository) Update(ctx context.Context, id int64, user model.User) (model.User, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for index, u := range repository.users {
+		if u.ID == id {
+			user.ID = id
+			repository.users[index] = user
+			return user, nil
+		}
+	}
+	return model.User{}, sql.ErrNoRows
+}
+
+// GetBySub ...
+func (repository *InMemoryUserRepository) GetBySub(ctx context.Context, sub string) (model.User, error) {
+	return repository.find(func(u model.User) bool { return u.AuthenticationID == sub })
+}
+
+// GetByID ...
+func (repository *InMemoryUserRepository) GetByID(ctx context.Context, id int64) (model.User, error) {
+	return repository.find(func(u model.User) bool { return u.ID == id })
+}
+
+// GetByIDs ...
+func (repository *InMemoryUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]model.User, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	found := map[int64]model.User{}
+	for _, u := range repository.users {
+		found[u.ID] = u
+	}
+	return inOrder(ids, found), nil
+}
+
+// GetByEmail ...
+func (repository *InMemoryUserRepository) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	return repository.find(func(u model.User) bool { return u.EmailAddress == email })
+}
+
+func (repository *InMemoryUserRepository) find(match func(u model.User) bool) (model.User, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, u := range repository.users {
+		if match(u) {
+			return u, nil
+		}
+	}
+	return model.User{}, sql.ErrNoRows
+}
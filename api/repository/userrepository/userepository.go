@@ -1,26 +1,30 @@
 package userrepository
 
 import (
-	"errors"
+	"context"
 
+	"database/sql"
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
 	"github.com/TomPallister/godutch-api/api/infrastructure"
 	"github.com/TomPallister/godutch-api/api/model"
-	"database/sql"
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/lib/pq"
 )
 
 // ErrorNotFound ...to be used when object does not exist in repository
-var ErrorNotFound = errors.New("User not found")
+var ErrorNotFound = domainerror.NewNotFound("user_not_found", "User not found")
 
 // ErrorCouldNotInsertUser ...
-var ErrorCouldNotInsertUser = errors.New("Could not insert user")
+var ErrorCouldNotInsertUser = domainerror.NewInternal("could_not_insert_user", "Could not insert user")
 
 // UserRepository ...
 type UserRepository interface {
-	GetBySub(sub string) (model.User, error)
-	GetByID(id int64) (model.User, error)
-	GetByEmail(email string) (model.User, error)
-	Insert(user model.User) (model.User, error)
-	Update(id int64, user model.User) (model.User, error)
+	GetBySub(ctx context.Context, sub string) (model.User, error)
+	GetByID(ctx context.Context, id int64) (model.User, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]model.User, error)
+	GetByEmail(ctx context.Context, email string) (model.User, error)
+	Insert(ctx context.Context, user model.User) (model.User, error)
+	Update(ctx context.Context, id int64, user model.User) (model.User, error)
 }
 
 // PostgresUserRepository ..
@@ -39,10 +43,12 @@ func NewPostgresUserRepository(logger infrastructure.Logger,
 }
 
 // Insert ...
-func (userRepository *PostgresUserRepository) Insert(user model.User) (model.User, error) {
+func (userRepository *PostgresUserRepository) Insert(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "user", "Insert")
+	defer done()
 
 	var lastInsertID int64
-	err := userRepository.db.QueryRow("INSERT INTO \"Users\"(\"AuthenticationID\",\"DateCreated\",\"EmailAddress\", \"Name\") VALUES($1, $2, $3, $4) RETURNING \"ID\"",
+	err := userRepository.db.QueryRowContext(ctx, "INSERT INTO \"Users\"(\"AuthenticationID\",\"DateCreated\",\"EmailAddress\", \"Name\") VALUES($1, $2, $3, $4) RETURNING \"ID\"",
 		user.AuthenticationID, user.DateCreated, user.EmailAddress, user.Name).Scan(&lastInsertID)
 	if err != nil {
 		return model.User{}, err
@@ -54,14 +60,16 @@ func (userRepository *PostgresUserRepository) Insert(user model.User) (model.Use
 }
 
 // Update ...
-func (userRepository *PostgresUserRepository) Update(id int64, user model.User) (model.User, error) {
+func (userRepository *PostgresUserRepository) Update(ctx context.Context, id int64, user model.User) (model.User, error) {
+    ctx, done := tracing.StartQuery(ctx, "user", "Update")
+    defer done()
 
-    stmt, err := userRepository.db.Prepare("UPDATE \"Users\" SET \"Name\"= $1, \"DateCreated\"= $2, \"EmailAddress\"= $3, \"AuthenticationID\"= $4 WHERE \"ID\"= $5")
+    stmt, err := userRepository.db.PrepareContext(ctx, "UPDATE \"Users\" SET \"Name\"= $1, \"DateCreated\"= $2, \"EmailAddress\"= $3, \"AuthenticationID\"= $4 WHERE \"ID\"= $5")
 	if err != nil {
 			return model.User{}, err
 	}
 	
-    res, err := stmt.Exec(user.Name, user.DateCreated, user.EmailAddress, user.AuthenticationID, id)
+    res, err := stmt.ExecContext(ctx, user.Name, user.DateCreated, user.EmailAddress, user.AuthenticationID, id)
 	if err != nil {
 		return model.User{}, err
 	}
@@ -75,11 +83,13 @@ func (userRepository *PostgresUserRepository) Update(id int64, user model.User)
 }
 
 // GetBySub ...
-func (userRepository *PostgresUserRepository) GetBySub(sub string) (model.User, error) {
+func (userRepository *PostgresUserRepository) GetBySub(ctx context.Context, sub string) (model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "user", "GetBySub")
+	defer done()
 
 	repoUser := model.User{}
 
-    err := userRepository.db.QueryRow("SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"AuthenticationID\" = $1", sub).
+    err := userRepository.db.QueryRowContext(ctx, "SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"AuthenticationID\" = $1", sub).
 		Scan(&repoUser.ID, &repoUser.EmailAddress, &repoUser.Name, &repoUser.AuthenticationID, &repoUser.DateCreated)
     
 	switch {
@@ -93,11 +103,13 @@ func (userRepository *PostgresUserRepository) GetBySub(sub string) (model.User,
 }
 
 // GetByID ...
-func (userRepository *PostgresUserRepository) GetByID(id int64) (model.User, error) {
+func (userRepository *PostgresUserRepository) GetByID(ctx context.Context, id int64) (model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "user", "GetByID")
+	defer done()
 	
 	repoUser := model.User{}
 
-    err := userRepository.db.QueryRow("SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"ID\" = $1", id).
+    err := userRepository.db.QueryRowContext(ctx, "SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"ID\" = $1", id).
 		Scan(&repoUser.ID, &repoUser.EmailAddress, &repoUser.Name, &repoUser.AuthenticationID, &repoUser.DateCreated)
     
 	switch {
@@ -111,11 +123,13 @@ func (userRepository *PostgresUserRepository) GetByID(id int64) (model.User, err
 }
 
 // GetByEmail ...
-func (userRepository *PostgresUserRepository) GetByEmail(email string) (model.User, error) {
+func (userRepository *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "user", "GetByEmail")
+	defer done()
  	
 	repoUser := model.User{}
 
-    err := userRepository.db.QueryRow("SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"EmailAddress\" = $1", email).
+    err := userRepository.db.QueryRowContext(ctx, "SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"EmailAddress\" = $1", email).
 		Scan(&repoUser.ID, &repoUser.EmailAddress, &repoUser.Name, &repoUser.AuthenticationID, &repoUser.DateCreated)
     
 	switch {
@@ -127,3 +141,49 @@ func (userRepository *PostgresUserRepository) GetByEmail(email string) (model.Us
     
 	return repoUser, nil
 }
+
+// GetByIDs ...returns the users that exist in one query, in the order of ids
+func (userRepository *PostgresUserRepository) GetByIDs(ctx context.Context, ids []int64) ([]model.User, error) {
+	ctx, done := tracing.StartQuery(ctx, "user", "GetByIDs")
+	defer done()
+
+	if len(ids) == 0 {
+		return []model.User{}, nil
+	}
+
+	rows, err := userRepository.db.QueryContext(ctx, "SELECT \"ID\", \"EmailAddress\", \"Name\", \"AuthenticationID\", \"DateCreated\" FROM \"Users\" WHERE \"ID\" = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return []model.User{}, err
+	}
+	defer rows.Close()
+
+	found := map[int64]model.User{}
+
+	for rows.Next() {
+
+		var user model.User
+
+		err = rows.Scan(&user.ID, &user.EmailAddress, &user.Name, &user.AuthenticationID, &user.DateCreated)
+		if err != nil {
+			return []model.User{}, err
+		}
+
+		found[user.ID] = user
+	}
+	if err = rows.Err(); err != nil {
+		return []model.User{}, err
+	}
+
+	return inOrder(ids, found), nil
+}
+
+// inOrder lists the users in found in the order of ids, leaving out the ones that were not found
+func inOrder(ids []int64, found map[int64]model.User) []model.User {
+	users := []model.User{}
+	for _, id := range ids {
+		if user, ok := found[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users
+}
@@ -1,6 +1,7 @@
 package userrepository_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -90,6 +91,36 @@ func TestCanGetUserByEmailAddress(t *testing.T) {
 	thenTheUserIsReturned(t)
 }
 
+func TestCanGetUsersByIDsInOneQuery(t *testing.T) {
+
+	u, _ := uuid.NewV4()
+
+	user := model.User{
+		Name:             "Laura",
+		AuthenticationID: "21312fsdf" + u.String(),
+		DateCreated:      time.Now(),
+		EmailAddress:     "laura" + u.String() + "@laz.com",
+	}
+
+	givenIHaveCleanDependencies(t)
+	givenIHaveADomainUser(user)
+	givenISaveTheUser(t)
+	firstID := domainUser.ID
+	user.AuthenticationID = "tom" + user.AuthenticationID
+	user.EmailAddress = "tom" + user.EmailAddress
+	givenIHaveADomainUser(user)
+	givenISaveTheUser(t)
+
+	users, err := userRepository.GetByIDs(context.Background(), []int64{domainUser.ID, -1, firstID})
+	if err != nil {
+		t.Fatalf("There was an error %v", err)
+	}
+
+	if len(users) != 2 || users[0].ID != domainUser.ID || users[1].ID != firstID {
+		t.Fatalf("Expected users %v and %v in that order, Received: %v", domainUser.ID, firstID, users)
+	}
+}
+
 func TestCanUpdateUser(t *testing.T) {
 
 	u, _ := uuid.NewV4()
@@ -121,7 +152,7 @@ func TestCanUpdateUser(t *testing.T) {
 }
 
 func thenTheUserIsUpdated(expected model.User, t *testing.T) {
-	domainUser, err = userRepository.GetByID(domainUser.ID)
+	domainUser, err = userRepository.GetByID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -140,34 +171,34 @@ func thenTheUserIsUpdated(expected model.User, t *testing.T) {
 }
 
 func whenIUpdateTheUser(user model.User, t *testing.T) {
-	domainUser, err = userRepository.Update(user.ID, user)
+	domainUser, err = userRepository.Update(context.Background(), user.ID, user)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIGetTheUserBySub(t *testing.T) {
-	domainUser, err = userRepository.GetBySub(domainUser.AuthenticationID)
+	domainUser, err = userRepository.GetBySub(context.Background(), domainUser.AuthenticationID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIGetTheUserByID(t *testing.T) {
-	domainUser, err = userRepository.GetByID(domainUser.ID)
+	domainUser, err = userRepository.GetByID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 func givenIGetTheUserByID(t *testing.T) {
-	domainUser, err = userRepository.GetByID(domainUser.ID)
+	domainUser, err = userRepository.GetByID(context.Background(), domainUser.ID)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func whenIGetTheUserByEmail(t *testing.T) {
-	domainUser, err = userRepository.GetByEmail(domainUser.EmailAddress)
+	domainUser, err = userRepository.GetByEmail(context.Background(), domainUser.EmailAddress)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -189,14 +220,14 @@ func givenIHaveADomainUser(user model.User) {
 }
 
 func whenISaveTheUser(t *testing.T) {
-	domainUser, err = userRepository.Insert(domainUser)
+	domainUser, err = userRepository.Insert(context.Background(), domainUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
 }
 
 func givenISaveTheUser(t *testing.T) {
-	domainUser, err = userRepository.Insert(domainUser)
+	domainUser, err = userRepository.Insert(context.Background(), domainUser)
 	if err != nil {
 		t.Fatalf("There was an error %v", err)
 	}
@@ -0,0 +1,89 @@
+package webhookdeliveryrepository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryWebhookDeliveryRepository ...
+type InMemoryWebhookDeliveryRepository struct {
+	mutex      sync.Mutex
+	lastID     int64
+	deliveries map[int64]model.WebhookDelivery
+}
+
+// NewInMemoryWebhookDeliveryRepository ...
+func NewInMemoryWebhookDeliveryRepository() *InMemoryWebhookDeliveryRepository {
+	repository := InMemoryWebhookDeliveryRepository{}
+	repository.deliveries = map[int64]model.WebhookDelivery{}
+	return &repository
+}
+
+// GetForWebhookID ...
+func (repository *InMemoryWebhookDeliveryRepository) GetForWebhookID(ctx context.Context, id int64, limit int) ([]model.WebhookDelivery, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	deliveries := []model.WebhookDelivery{}
+	for i := repository.lastID; i > 0 && len(deliveries) < limit; i-- {
+		delivery, ok := repository.deliveries[i]
+		if ok && delivery.WebhookID == id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// Insert ...
+func (repository *InMemoryWebhookDeliveryRepository) Insert(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.lastID++
+	delivery.ID = repository.lastID
+	repository.deliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+// Update ...
+func (repository *InMemoryWebhookDeliveryRepository) Update(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.deliveries[delivery.ID]; ok == false {
+		return model.WebhookDelivery{}, ErrorNotFound
+	}
+	repository.deliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+// ClaimDue ...
+func (repository *InMemoryWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	due := []model.WebhookDelivery{}
+	for _, delivery := range repository.deliveries {
+		if delivery.Status == model.WebhookDeliveryPending && delivery.DateNextAttempt != nil && !delivery.DateNextAttempt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DateNextAttempt.Before(*due[j].DateNextAttempt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leased := now.Add(lease)
+	for i := range due {
+		due[i].DateNextAttempt = &leased
+		repository.deliveries[due[i].ID] = due[i]
+	}
+	return due, nil
+}
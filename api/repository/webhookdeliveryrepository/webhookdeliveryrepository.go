@@ -0,0 +1,125 @@
+package webhookdeliveryrepository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = domainerror.NewNotFound("webhook_delivery_not_found", "Webhook delivery not found")
+
+// WebhookDeliveryRepository ...
+type WebhookDeliveryRepository interface {
+	GetForWebhookID(ctx context.Context, id int64, limit int) ([]model.WebhookDelivery, error)
+	Insert(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+	Update(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+}
+
+// PostgresWebhookDeliveryRepository ...
+type PostgresWebhookDeliveryRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+}
+
+// NewPostgresWebhookDeliveryRepository ...
+func NewPostgresWebhookDeliveryRepository(logger infrastructure.Logger,
+	db *sql.DB) *PostgresWebhookDeliveryRepository {
+	repository := PostgresWebhookDeliveryRepository{}
+	repository.logger = logger
+	repository.db = db
+	return &repository
+}
+
+// GetForWebhookID returns the newest deliveries first
+func (repository *PostgresWebhookDeliveryRepository) GetForWebhookID(ctx context.Context, id int64, limit int) ([]model.WebhookDelivery, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhookdelivery", "GetForWebhookID")
+	defer done()
+
+	rows, err := repository.db.QueryContext(ctx, "SELECT \"ID\", \"WebhookID\", \"EventID\", \"EventType\", \"Payload\", \"Status\", \"Attempts\", \"MaxAttempts\", \"ResponseStatus\", \"Error\", \"DateCreated\", \"DateNextAttempt\", \"DateCompleted\" FROM \"WebhookDeliveries\" WHERE \"WebhookID\" = $1 ORDER BY \"ID\" DESC LIMIT $2", id, limit)
+	if err != nil {
+		return []model.WebhookDelivery{}, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+// Insert ...
+func (repository *PostgresWebhookDeliveryRepository) Insert(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhookdelivery", "Insert")
+	defer done()
+
+	var lastInsertID int64
+
+	err := repository.
+		db.
+		QueryRowContext(ctx, "INSERT INTO \"WebhookDeliveries\"(\"WebhookID\", \"EventID\", \"EventType\", \"Payload\", \"Status\", \"Attempts\", \"MaxAttempts\", \"ResponseStatus\", \"Error\", \"DateCreated\", \"DateNextAttempt\", \"DateCompleted\") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING \"ID\"",
+			delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status, delivery.Attempts, delivery.MaxAttempts, delivery.ResponseStatus, delivery.Error, delivery.DateCreated, delivery.DateNextAttempt, delivery.DateCompleted).Scan(&lastInsertID)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery.ID = lastInsertID
+
+	return delivery, nil
+}
+
+// Update ...
+func (repository *PostgresWebhookDeliveryRepository) Update(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhookdelivery", "Update")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"WebhookDeliveries\" SET \"Status\"= $1, \"Attempts\"= $2, \"ResponseStatus\"= $3, \"Error\"= $4, \"DateNextAttempt\"= $5, \"DateCompleted\"= $6 WHERE \"ID\" = $7")
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.Error, delivery.DateNextAttempt, delivery.DateCompleted, delivery.ID)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// ClaimDue returns pending deliveries whose next attempt is due and pushes that attempt back by lease,
+// so other api instances polling at the same time skip them
+func (repository *PostgresWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhookdelivery", "ClaimDue")
+	defer done()
+
+	rows, err := repository.db.QueryContext(ctx, "UPDATE \"WebhookDeliveries\" SET \"DateNextAttempt\"= $2 WHERE \"ID\" IN (SELECT \"ID\" FROM \"WebhookDeliveries\" WHERE \"Status\" = $3 AND \"DateNextAttempt\" <= $1 ORDER BY \"DateNextAttempt\" LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING \"ID\", \"WebhookID\", \"EventID\", \"EventType\", \"Payload\", \"Status\", \"Attempts\", \"MaxAttempts\", \"ResponseStatus\", \"Error\", \"DateCreated\", \"DateNextAttempt\", \"DateCompleted\"",
+		now, now.Add(lease), model.WebhookDeliveryPending, limit)
+	if err != nil {
+		return []model.WebhookDelivery{}, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+
+	for rows.Next() {
+
+		var delivery model.WebhookDelivery
+
+		err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.MaxAttempts, &delivery.ResponseStatus, &delivery.Error, &delivery.DateCreated, &delivery.DateNextAttempt, &delivery.DateCompleted)
+		if err != nil {
+			return []model.WebhookDelivery{}, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,74 @@
+package webhookrepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/model"
+)
+
+// InMemoryWebhookRepository ...
+type InMemoryWebhookRepository struct {
+	mutex    sync.Mutex
+	lastID   int64
+	webhooks map[int64]model.Webhook
+}
+
+// NewInMemoryWebhookRepository ...
+func NewInMemoryWebhookRepository() *InMemoryWebhookRepository {
+	repository := InMemoryWebhookRepository{}
+	repository.webhooks = map[int64]model.Webhook{}
+	return &repository
+}
+
+// GetByID ...
+func (repository *InMemoryWebhookRepository) GetByID(ctx context.Context, id int64) (model.Webhook, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	webhook, ok := repository.webhooks[id]
+	if ok == false {
+		return model.Webhook{}, ErrorNotFound
+	}
+	return webhook, nil
+}
+
+// GetForTrackerID ...
+func (repository *InMemoryWebhookRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Webhook, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	webhooksForTracker := []model.Webhook{}
+	for i := int64(1); i <= repository.lastID; i++ {
+		webhook, ok := repository.webhooks[i]
+		if ok && webhook.TrackerID == id && webhook.DateDeleted == nil {
+			webhooksForTracker = append(webhooksForTracker, webhook)
+		}
+	}
+	return webhooksForTracker, nil
+}
+
+// Insert ...
+func (repository *InMemoryWebhookRepository) Insert(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.lastID++
+	webhook.ID = repository.lastID
+	repository.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+// Delete ...
+func (repository *InMemoryWebhookRepository) Delete(ctx context.Context, id int64, deleted time.Time) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	webhook, ok := repository.webhooks[id]
+	if ok && webhook.DateDeleted == nil {
+		webhook.DateDeleted = &deleted
+		repository.webhooks[id] = webhook
+	}
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package webhookrepository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/domain/domainerror"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/tracing"
+)
+
+// ErrorNotFound ...to be used when object does not exist in repository
+var ErrorNotFound = domainerror.NewNotFound("webhook_not_found", "Webhook not found")
+
+// Cipher encrypts webhook secrets at rest, they are needed in plain text to sign deliveries
+type Cipher interface {
+	Encrypt(text string) (string, error)
+	Decrypt(cryptoText string) (string, error)
+}
+
+// WebhookRepository ...deleted webhooks are only returned by GetByID
+type WebhookRepository interface {
+	GetByID(ctx context.Context, id int64) (model.Webhook, error)
+	GetForTrackerID(ctx context.Context, id int64) ([]model.Webhook, error)
+	Insert(ctx context.Context, webhook model.Webhook) (model.Webhook, error)
+	Delete(ctx context.Context, id int64, deleted time.Time) (bool, error)
+}
+
+// PostgresWebhookRepository ...
+type PostgresWebhookRepository struct {
+	logger infrastructure.Logger
+	db     *sql.DB
+	cipher Cipher
+}
+
+// NewPostgresWebhookRepository ...
+func NewPostgresWebhookRepository(logger infrastructure.Logger,
+	db *sql.DB,
+	cipher Cipher) *PostgresWebhookRepository {
+	repository := PostgresWebhookRepository{}
+	repository.logger = logger
+	repository.db = db
+	repository.cipher = cipher
+	return &repository
+}
+
+// GetByID ...
+func (repository *PostgresWebhookRepository) GetByID(ctx context.Context, id int64) (model.Webhook, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhook", "GetByID")
+	defer done()
+
+	repoWebhook := model.Webhook{}
+	var encryptedSecret string
+	var eventTypes string
+
+	err := repository.db.QueryRowContext(ctx, "SELECT \"ID\", \"TrackerID\", \"CreatedByUserID\", \"URL\", \"EncryptedSecret\", \"EventTypes\", \"DateCreated\", \"DateDeleted\" FROM \"Webhooks\" WHERE \"ID\" = $1", id).
+		Scan(&repoWebhook.ID, &repoWebhook.TrackerID, &repoWebhook.CreatedByUserID, &repoWebhook.URL, &encryptedSecret, &eventTypes, &repoWebhook.DateCreated, &repoWebhook.DateDeleted)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return model.Webhook{}, ErrorNotFound
+	case err != nil:
+		return model.Webhook{}, err
+	}
+
+	repoWebhook.Secret, err = repository.cipher.Decrypt(encryptedSecret)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	repoWebhook.EventTypes = splitEventTypes(eventTypes)
+
+	return repoWebhook, nil
+}
+
+// GetForTrackerID ...
+func (repository *PostgresWebhookRepository) GetForTrackerID(ctx context.Context, id int64) ([]model.Webhook, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhook", "GetForTrackerID")
+	defer done()
+
+	webhooksForTracker := []model.Webhook{}
+
+	rows, err := repository.db.QueryContext(ctx, "SELECT \"ID\", \"TrackerID\", \"CreatedByUserID\", \"URL\", \"EncryptedSecret\", \"EventTypes\", \"DateCreated\", \"DateDeleted\" FROM \"Webhooks\" WHERE \"TrackerID\" = $1 AND \"DateDeleted\" IS NULL ORDER BY \"ID\"", id)
+	if err != nil {
+		return []model.Webhook{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var webhook model.Webhook
+		var encryptedSecret string
+		var eventTypes string
+
+		err = rows.Scan(&webhook.ID, &webhook.TrackerID, &webhook.CreatedByUserID, &webhook.URL, &encryptedSecret, &eventTypes, &webhook.DateCreated, &webhook.DateDeleted)
+		if err != nil {
+			return []model.Webhook{}, err
+		}
+
+		webhook.Secret, err = repository.cipher.Decrypt(encryptedSecret)
+		if err != nil {
+			return []model.Webhook{}, err
+		}
+
+		webhook.EventTypes = splitEventTypes(eventTypes)
+		webhooksForTracker = append(webhooksForTracker, webhook)
+	}
+
+	return webhooksForTracker, nil
+}
+
+// Insert ...
+func (repository *PostgresWebhookRepository) Insert(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhook", "Insert")
+	defer done()
+
+	encryptedSecret, err := repository.cipher.Encrypt(webhook.Secret)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	var lastInsertID int64
+
+	err = repository.
+		db.
+		QueryRowContext(ctx, "INSERT INTO \"Webhooks\"(\"TrackerID\", \"CreatedByUserID\", \"URL\", \"EncryptedSecret\", \"EventTypes\", \"DateCreated\") VALUES ($1, $2, $3, $4, $5, $6) RETURNING \"ID\"",
+			webhook.TrackerID, webhook.CreatedByUserID, webhook.URL, encryptedSecret, strings.Join(webhook.EventTypes, ","), webhook.DateCreated).Scan(&lastInsertID)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	webhook.ID = lastInsertID
+
+	return webhook, nil
+}
+
+// Delete ...keeps the row so its delivery log can still be read
+func (repository *PostgresWebhookRepository) Delete(ctx context.Context, id int64, deleted time.Time) (bool, error) {
+	ctx, done := tracing.StartQuery(ctx, "webhook", "Delete")
+	defer done()
+
+	stmt, err := repository.db.PrepareContext(ctx, "UPDATE \"Webhooks\" SET \"DateDeleted\"= $1 WHERE \"ID\" = $2 AND \"DateDeleted\" IS NULL")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, deleted, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func splitEventTypes(eventTypes string) []string {
+	if eventTypes == "" {
+		return []string{}
+	}
+	return strings.Split(eventTypes, ",")
+}
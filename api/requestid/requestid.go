@@ -0,0 +1,86 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/codegangsta/negroni"
+	"github.com/nu7hatch/gouuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header carries the request's correlation id. A caller can send one to follow a request through
+// its own logs and ours, otherwise one is made up, and either way it is returned on the response.
+const Header = "X-Request-ID"
+
+// validID keeps ids that are safe to log as they are
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type contextKey struct{}
+
+// Middleware gives each request an id and puts a logger that adds it to every line on the
+// request's context, see infrastructure.LoggerFrom. The trace id is added too when the request is
+// traced. It logs each request once it has been handled.
+func Middleware(logger infrastructure.Logger) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		id := FromRequest(r)
+		w.Header().Set(Header, id)
+
+		requestLogger := logger.With("request_id", id)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+			requestLogger = requestLogger.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx := infrastructure.WithLogger(NewContext(r.Context(), id), requestLogger)
+
+		start := time.Now()
+		next(w, r.WithContext(ctx))
+
+		status := http.StatusOK
+		if res, ok := w.(negroni.ResponseWriter); ok && res.Status() != 0 {
+			status = res.Status()
+		}
+
+		requestLogger.Info("Handled request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds())
+	}
+}
+
+// FromRequest returns the id the caller sent, or a new one if it is missing or not safe to log
+func FromRequest(r *http.Request) string {
+	return Parse(r.Header.Get(Header))
+}
+
+// Parse returns id, or a new one if id is empty or not safe to log
+func Parse(id string) string {
+	if validID.MatchString(id) {
+		return id
+	}
+	return New()
+}
+
+// New ...
+func New() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "unknown"
+	}
+	return id.String()
+}
+
+// NewContext ...
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request's id, or "" outside a request
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
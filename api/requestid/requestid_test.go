@@ -0,0 +1,111 @@
+package requestid_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/requestid"
+	"github.com/codegangsta/negroni"
+)
+
+var output *bytes.Buffer
+var recorder *httptest.ResponseRecorder
+var seenID string
+
+func TestTheCallersIDIsKept(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("abc-123", http.StatusOK)
+	thenTheResponseIDIs("abc-123", t)
+	thenTheHandlerSawID("abc-123", t)
+}
+
+func TestAnIDIsMadeUpWhenTheCallerDoesNotSendOne(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("", http.StatusOK)
+	if recorder.Header().Get(requestid.Header) == "" {
+		t.Fatal("expected a request id")
+	}
+	thenTheHandlerSawID(recorder.Header().Get(requestid.Header), t)
+}
+
+func TestIDsThatAreNotSafeToLogAreReplaced(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("abc\n{\"level\":\"ERROR\"}", http.StatusOK)
+	id := recorder.Header().Get(requestid.Header)
+	if id == "" || strings.Contains(id, "ERROR") {
+		t.Fatalf("expected a new request id but got %q", id)
+	}
+}
+
+func TestEveryLineHasTheRequestID(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("abc-123", http.StatusNotFound)
+	lines := thenTheLines(t)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines but got %v", len(lines))
+	}
+	for _, line := range lines {
+		if line["request_id"] != "abc-123" {
+			t.Fatalf("expected request_id abc-123 on %v", line)
+		}
+	}
+	if lines[1]["level"] != "WARN" || lines[1]["code"] != "tracker_not_found" {
+		t.Fatalf("expected the refused request to be a warning but got %v", lines[1])
+	}
+	if lines[2]["msg"] != "Handled request" || lines[2]["status"] != float64(http.StatusNotFound) {
+		t.Fatalf("expected the handled request line but got %v", lines[2])
+	}
+}
+
+func givenIHaveCleanDependencies() {
+	output = &bytes.Buffer{}
+	recorder = httptest.NewRecorder()
+	seenID = ""
+}
+
+func whenISend(id string, status int) {
+	logger, _ := infrastructure.NewStructuredLogger(output, "info", "json")
+	n := negroni.New(requestid.Middleware(logger))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = requestid.FromContext(r.Context())
+		infrastructure.LoggerFrom(r.Context(), logger).Info("In the handler")
+		if status != http.StatusOK {
+			handler.CreateErrorResponseAndLog(status, w, logger, handler.ErrorNotFound)
+		}
+	})
+	r := httptest.NewRequest("GET", "/api/v1/trackers", nil)
+	if id != "" {
+		r.Header.Set(requestid.Header, id)
+	}
+	n.ServeHTTP(recorder, r)
+}
+
+func thenTheResponseIDIs(id string, t *testing.T) {
+	if recorder.Header().Get(requestid.Header) != id {
+		t.Fatalf("expected %v but got %v", id, recorder.Header().Get(requestid.Header))
+	}
+}
+
+func thenTheHandlerSawID(id string, t *testing.T) {
+	if seenID != id {
+		t.Fatalf("expected the handler to see %v but got %v", id, seenID)
+	}
+}
+
+func thenTheLines(t *testing.T) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, raw := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		line := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -1,167 +1,406 @@
 package route
 
 import (
-	"encoding/base64"
 	"net/http"
-	"os"
 
 	"github.com/TomPallister/godutch-api/api/environment"
 	"github.com/TomPallister/godutch-api/api/handler"
+	"github.com/TomPallister/godutch-api/api/handler/apitokenhandler"
+	"github.com/TomPallister/godutch-api/api/handler/eventhandler"
+	"github.com/TomPallister/godutch-api/api/handler/graphqlhandler"
+	"github.com/TomPallister/godutch-api/api/handler/healthhandler"
+	"github.com/TomPallister/godutch-api/api/handler/invitehandler"
+	"github.com/TomPallister/godutch-api/api/handler/localauthhandler"
 	"github.com/TomPallister/godutch-api/api/handler/spendhandler"
 	"github.com/TomPallister/godutch-api/api/handler/spendsummarieshandler"
 	"github.com/TomPallister/godutch-api/api/handler/trackerhandler"
 	"github.com/TomPallister/godutch-api/api/handler/transferhandler"
 	"github.com/TomPallister/godutch-api/api/handler/userhandler"
-	"github.com/auth0/go-jwt-middleware"
+	"github.com/TomPallister/godutch-api/api/handler/webhookhandler"
+	"github.com/TomPallister/godutch-api/api/idempotency"
+	"github.com/TomPallister/godutch-api/api/openapi"
 	"github.com/codegangsta/negroni"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 )
 
+// authenticationMiddleware rejects requests the environment's authenticator does not accept, then
+// limits the requests each subject makes
+func authenticationMiddleware(env *environment.Env, limit negroni.HandlerFunc) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		authenticated, err := env.Authenticator.Authenticate(r)
+		if err != nil {
+			handler.CreateErrorResponseAndLog(http.StatusUnauthorized, w, env.Logger, err)
+			return
+		}
+		limit(w, authenticated, next)
+	}
+}
+
+// StreamingRoutes stay open for as long as the caller listens, so they are not given a request timeout
+var StreamingRoutes = []string{"/api/v1/trackers/{id}/events", "/api/v1/trackers/{id}/events/ws"}
+
+// ProbeRoutes are polled by orchestrators from a single address, so they are not rate limited
+var ProbeRoutes = []string{"/healthz", "/readyz"}
+
 // GetRouter ...
 func GetRouter(env *environment.Env) *mux.Router {
 
 	router := mux.NewRouter().StrictSlash(true)
 
-	jwtMiddleware := jwtmiddleware.New(jwtmiddleware.Options{
-		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-			decoded, err := base64.URLEncoding.DecodeString(os.Getenv("AUTH0_CLIENT_SECRET"))
-			if err != nil {
-				return nil, err
-			}
-			return decoded, nil
-		},
-	})
+	limits := env.RateLimiter.Options()
+	authentication := authenticationMiddleware(env, env.RateLimiter.PerSubject("subject", limits.Subject))
+	inviteLimit := env.RateLimiter.PerSubject("invite", limits.Invite)
+	authLimit := env.RateLimiter.PerIP("auth", limits.Auth)
+	validation := openapi.ValidationMiddleware(openapi.MustLoad(), env.Logger)
+	idempotent := idempotency.Middleware(env.IdempotencyRepository, env.SubjectFinder, env.Logger, env.IdempotencyRetention)
+	idempotentSecret := idempotency.SecretMiddleware(env.IdempotencyRepository, env.SubjectFinder, env.Logger, env.IdempotencyRetention)
 
+	var acmeChallenges http.Handler = http.StripPrefix("/.well-known/acme-challenge/", http.FileServer(http.Dir("./.well-known/acme-challenge/")))
+	if env.ACMEChallengeHandler != nil {
+		acmeChallenges = env.ACMEChallengeHandler
+	}
 	router.
 		PathPrefix("/.well-known/acme-challenge/").
-		Handler(http.StripPrefix("/.well-known/acme-challenge/", http.FileServer(http.Dir("./.well-known/acme-challenge/"))))
+		Handler(acmeChallenges)
 
 	router.Handle("/secured/ping", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.HandlerFunc(handler.SecuredPingHandler)),
 	))
 
 	router.HandleFunc("/", handler.PingHandler)
 
+	router.Handle("/healthz", healthhandler.HealthzHandler(env)).
+		Methods("GET")
+
+	router.Handle("/readyz", healthhandler.ReadyzHandler(env)).
+		Methods("GET")
+
+	router.HandleFunc("/api/v1/openapi.json", openapi.SpecificationHandler).
+		Methods("GET")
+
 	//POST USERS
 	router.Handle("/api/v1/users", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(userhandler.CreateUserHandler(env))),
 	)).
 		Methods("POST")
 
 	//INVITE USERS
 	router.Handle("/api/v1/users/invite", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		inviteLimit,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(userhandler.InviteUserHandler(env))),
 	)).
 		Methods("POST")
 
 	//ACCEPT INVITE USERS
-	router.Handle("/api/v1/users/accept/{cryptoEmail}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+	router.Handle("/api/v1/users/accept/{token}", negroni.New(
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(userhandler.AcceptInviteUserHandler(env))),
 	)).
 		Methods("POST")
 
+	// GET PENDING INVITES
+	router.Handle("/api/v1/invites", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(invitehandler.FindPendingInvitesHandler(env))),
+	)).
+		Methods("GET")
+
+	// RESEND INVITE
+	router.Handle("/api/v1/invites/{id}/resend", negroni.New(
+		authentication,
+		inviteLimit,
+		idempotent,
+		validation,
+		negroni.Wrap(http.Handler(invitehandler.ResendInviteHandler(env))),
+	)).
+		Methods("POST")
+
+	// REVOKE INVITE
+	router.Handle("/api/v1/invites/{id}", negroni.New(
+		authentication,
+		idempotent,
+		validation,
+		negroni.Wrap(http.Handler(invitehandler.RevokeInviteHandler(env))),
+	)).
+		Methods("DELETE")
+
 	// GET USERS/ID
 	router.Handle("/api/v1/users/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(userhandler.FindUserByIDHandler(env))),
 	)).
 		Methods("GET")
 
 	// GET USERS - hack to return current identity and tracker users
 	router.Handle("/api/v1/users", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(userhandler.FindUserBySubHandler(env))),
 	)).
 		Methods("GET")
 
 	// GET TRACKERS
 	router.Handle("/api/v1/trackers", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(trackerhandler.FindTrackersBySubHandler(env))),
 	)).
 		Methods("GET")
 
 	// GET TRACKERS ID
 	router.Handle("/api/v1/trackers/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(trackerhandler.FindTrackersByIDHandler(env))),
 	)).
 		Methods("GET")
 
+	// GET TRACKER EVENTS
+	router.Handle("/api/v1/trackers/{id}/events", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(eventhandler.StreamTrackerEventsHandler(env))),
+	)).
+		Methods("GET")
+
+	// GET TRACKER EVENTS WEBSOCKET
+	router.Handle("/api/v1/trackers/{id}/events/ws", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(eventhandler.TrackerEventsWebSocketHandler(env))),
+	)).
+		Methods("GET")
+
 	// POST TRACKERS
 	router.Handle("/api/v1/trackers", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(trackerhandler.CreateTrackerHandler(env))),
 	)).
 		Methods("POST")
 
 	// PUT TRACKERS
 	router.Handle("/api/v1/trackers/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(trackerhandler.UpdateTrackerHandler(env))),
 	)).
 		Methods("PUT")
 
+	// PATCH TRACKERS
+	router.Handle("/api/v1/trackers/{id}", negroni.New(
+		authentication,
+		idempotent,
+		validation,
+		negroni.Wrap(http.Handler(trackerhandler.PatchTrackerHandler(env))),
+	)).
+		Methods("PATCH")
+
 	// DELTE TRACKERS
 	router.Handle("/api/v1/trackers/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(trackerhandler.DeleteTrackerHandler(env))),
 	)).
 		Methods("DELETE")
 
 	// GET SPENDS
 	router.Handle("/api/v1/spends", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(spendhandler.FindFindByTrackerIDHandler(env))),
 	)).
 		Methods("GET")
 
 	// POST SPENDS
 	router.Handle("/api/v1/spends", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(spendhandler.CreateSpendHandler(env))),
 	)).
 		Methods("POST")
 
+	// GET SPENDS ID
+	router.Handle("/api/v1/spends/{id}", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(spendhandler.FindSpendByIDHandler(env))),
+	)).
+		Methods("GET")
+
 	// PUT SPENDS
 	router.Handle("/api/v1/spends/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(spendhandler.UpdateSpendHandler(env))),
 	)).
 		Methods("PUT")
 
+	// PATCH SPENDS
+	router.Handle("/api/v1/spends/{id}", negroni.New(
+		authentication,
+		idempotent,
+		validation,
+		negroni.Wrap(http.Handler(spendhandler.PatchSpendHandler(env))),
+	)).
+		Methods("PATCH")
+
 	// DELTE SPENDS
 	router.Handle("/api/v1/spends/{id}", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		idempotent,
+		validation,
 		negroni.Wrap(http.Handler(spendhandler.DeleteSpendHandler(env))),
 	)).
 		Methods("DELETE")
 
 	// GET TRANSFERS
 	router.Handle("/api/v1/transfers", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(transferhandler.FindFindByTrackerIDHandler(env))),
 	)).
 		Methods("GET")
 
 	// GET SPEND SUMMARIES
 	router.Handle("/api/v1/spendsummaries", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(spendsummarieshandler.FindFindByTrackerIDHandler(env))),
 	)).
 		Methods("GET")
 
 		// GET SPEND SUMMARIES
 	router.Handle("/api/v1/spendSummaries", negroni.New(
-		negroni.HandlerFunc(jwtMiddleware.HandlerWithNext),
+		authentication,
+		validation,
 		negroni.Wrap(http.Handler(spendsummarieshandler.FindFindByTrackerIDHandler(env))),
 	)).
 		Methods("GET")
 
+	// GET API TOKENS
+	router.Handle("/api/v1/apitokens", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(apitokenhandler.FindAPITokensBySubHandler(env))),
+	)).
+		Methods("GET")
+
+	// POST API TOKENS
+	router.Handle("/api/v1/apitokens", negroni.New(
+		authentication,
+		idempotentSecret,
+		validation,
+		negroni.Wrap(http.Handler(apitokenhandler.CreateAPITokenHandler(env))),
+	)).
+		Methods("POST")
+
+	// REVOKE API TOKENS
+	router.Handle("/api/v1/apitokens/{id}", negroni.New(
+		authentication,
+		idempotent,
+		validation,
+		negroni.Wrap(http.Handler(apitokenhandler.RevokeAPITokenHandler(env))),
+	)).
+		Methods("DELETE")
+
+	// GRAPHQL - read only, a page's trackers, members, spends, transfers and summaries in one request
+	router.Handle("/api/v1/graphql", negroni.New(
+		authentication,
+		validation,
+		negroni.Wrap(http.Handler(graphqlhandler.GraphQLHandler(env))),
+	)).
+		Methods("GET", "POST")
+
+	// WEBHOOKS - only when secrets can be encrypted at rest
+	if env.WebhookService != nil {
+		router.Handle("/api/v1/webhooks", negroni.New(
+			authentication,
+			validation,
+			negroni.Wrap(http.Handler(webhookhandler.FindWebhooksByTrackerIDHandler(env))),
+		)).
+			Methods("GET")
+
+		router.Handle("/api/v1/webhooks", negroni.New(
+			authentication,
+			idempotentSecret,
+			validation,
+			negroni.Wrap(http.Handler(webhookhandler.CreateWebhookHandler(env))),
+		)).
+			Methods("POST")
+
+		router.Handle("/api/v1/webhooks/{id}", negroni.New(
+			authentication,
+			idempotent,
+			validation,
+			negroni.Wrap(http.Handler(webhookhandler.DeleteWebhookHandler(env))),
+		)).
+			Methods("DELETE")
+
+		router.Handle("/api/v1/webhooks/{id}/deliveries", negroni.New(
+			authentication,
+			validation,
+			negroni.Wrap(http.Handler(webhookhandler.FindWebhookDeliveriesHandler(env))),
+		)).
+			Methods("GET")
+
+		router.Handle("/api/v1/webhooks/{id}/test", negroni.New(
+			authentication,
+			idempotent,
+			validation,
+			negroni.Wrap(http.Handler(webhookhandler.SendTestEventHandler(env))),
+		)).
+			Methods("POST")
+	}
+
+	// LOCAL AUTHENTICATION - only when the api signs its own tokens
+	if env.LocalAuthService != nil {
+		router.Handle("/api/v1/auth/register", negroni.New(
+			authLimit,
+			validation,
+			negroni.Wrap(localauthhandler.RegisterHandler(env)),
+		)).
+			Methods("POST")
+
+		router.Handle("/api/v1/auth/login", negroni.New(
+			authLimit,
+			validation,
+			negroni.Wrap(localauthhandler.LoginHandler(env)),
+		)).
+			Methods("POST")
+
+		router.Handle("/api/v1/auth/password/forgot", negroni.New(
+			authLimit,
+			validation,
+			negroni.Wrap(localauthhandler.ForgotPasswordHandler(env)),
+		)).
+			Methods("POST")
+
+		router.Handle("/api/v1/auth/password/reset", negroni.New(
+			authLimit,
+			validation,
+			negroni.Wrap(localauthhandler.ResetPasswordHandler(env)),
+		)).
+			Methods("POST")
+	}
+
 	return router
 }
@@ -0,0 +1,59 @@
+package route_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/domain/localauthservice"
+	"github.com/TomPallister/godutch-api/api/domain/webhookservice"
+	"github.com/TomPallister/godutch-api/api/environment"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/openapi"
+	"github.com/TomPallister/godutch-api/api/route"
+	"github.com/gorilla/mux"
+)
+
+func TestEveryRouteIsInTheSpecification(t *testing.T) {
+	env := &environment.Env{
+		Logger:           infrastructure.NilLogger{},
+		LocalAuthService: &localauthservice.GoDutchLocalAuthService{},
+		WebhookService:   &webhookservice.GoDutchWebhookService{},
+	}
+	router := route.GetRouter(env)
+	document := openapi.MustLoad()
+
+	err := router.Walk(func(r *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := r.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		if pathTemplate == "/.well-known/acme-challenge/" {
+			return nil
+		}
+		methods, err := r.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		for _, method := range methods {
+			if document.Operation(pathTemplate, method) == nil {
+				t.Errorf("%v %v has no entry in the OpenAPI specification", method, pathTemplate)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetricsAreNotOnThePublicRouter(t *testing.T) {
+	env := &environment.Env{
+		Logger:           infrastructure.NilLogger{},
+		LocalAuthService: &localauthservice.GoDutchLocalAuthService{},
+		WebhookService:   &webhookservice.GoDutchWebhookService{},
+	}
+	request, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	if route.GetRouter(env).Match(request, &mux.RouteMatch{}) {
+		t.Error("/metrics must only be served on the metrics port")
+	}
+}
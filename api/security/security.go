@@ -0,0 +1,129 @@
+package security
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/idempotency"
+	"github.com/TomPallister/godutch-api/api/ratelimit"
+	"github.com/TomPallister/godutch-api/api/requestid"
+	"github.com/codegangsta/negroni"
+	"github.com/rs/cors"
+)
+
+// ErrorCredentialsWithAnyOrigin ...
+var ErrorCredentialsWithAnyOrigin = errors.New("CORS_ALLOW_CREDENTIALS needs CORS_ALLOWED_ORIGINS to list origins rather than *")
+
+// ErrorFrameOptions ...
+var ErrorFrameOptions = errors.New("SECURITY_FRAME_OPTIONS must be DENY, SAMEORIGIN or empty")
+
+// CORSOptions say which browser origins may call the api and with what. Origins may be * or
+// contain one wildcard, such as https://*.godutch.example.
+type CORSOptions struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// DefaultCORSOptions allow any origin to send the headers the api reads and read the ones it sets
+var DefaultCORSOptions = CORSOptions{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{"GET", "POST", "OPTIONS", "PUT", "PATCH", "DELETE"},
+	AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", idempotency.Header,
+		requestid.Header, "traceparent", "tracestate"},
+	ExposedHeaders: []string{requestid.Header, "ETag", idempotency.ReplayedHeader,
+		ratelimit.LimitHeader, ratelimit.RemainingHeader, ratelimit.ResetHeader, ratelimit.RetryAfterHeader},
+	MaxAge: 10 * time.Minute,
+}
+
+// Validate ...
+func (options CORSOptions) Validate() error {
+	if options.AllowCredentials {
+		for _, origin := range options.AllowedOrigins {
+			if origin == "*" {
+				return ErrorCredentialsWithAnyOrigin
+			}
+		}
+	}
+	return nil
+}
+
+// NewCORS answers preflight requests and adds the CORS headers to the rest
+func NewCORS(options CORSOptions) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins:   options.AllowedOrigins,
+		AllowedMethods:   options.AllowedMethods,
+		AllowedHeaders:   options.AllowedHeaders,
+		ExposedHeaders:   options.ExposedHeaders,
+		AllowCredentials: options.AllowCredentials,
+		MaxAge:           int(options.MaxAge.Seconds()),
+	})
+}
+
+// HeaderOptions ...
+type HeaderOptions struct {
+	// HSTSMaxAge is sent in Strict-Transport-Security on https requests, 0 leaves it off
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains" env:"SECURITY_HSTS_INCLUDE_SUBDOMAINS"`
+	// FrameOptions is DENY, SAMEORIGIN or empty to leave X-Frame-Options off
+	FrameOptions string `yaml:"frame_options" env:"SECURITY_FRAME_OPTIONS"`
+	// ContentSecurityPolicy applies to the pages in public as well as the api's responses
+	ContentSecurityPolicy string `yaml:"content_security_policy" env:"SECURITY_CONTENT_SECURITY_POLICY"`
+	ReferrerPolicy        string `yaml:"referrer_policy" env:"SECURITY_REFERRER_POLICY"`
+}
+
+// DefaultHeaderOptions ...
+var DefaultHeaderOptions = HeaderOptions{
+	HSTSMaxAge:            365 * 24 * time.Hour,
+	FrameOptions:          "DENY",
+	ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'none'; base-uri 'self'; form-action 'self'",
+	ReferrerPolicy:        "no-referrer",
+}
+
+// Validate ...
+func (options HeaderOptions) Validate() error {
+	switch strings.ToUpper(options.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+		return nil
+	}
+	return ErrorFrameOptions
+}
+
+// Headers sets the security headers options choose on every response. Strict-Transport-Security
+// is only sent over https, directly or through a proxy that sets X-Forwarded-Proto.
+func Headers(options HeaderOptions) negroni.HandlerFunc {
+
+	hsts := ""
+	if options.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(options.HSTSMaxAge.Seconds()))
+		if options.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if options.FrameOptions != "" {
+			header.Set("X-Frame-Options", strings.ToUpper(options.FrameOptions))
+		}
+		if options.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", options.ContentSecurityPolicy)
+		}
+		if options.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", options.ReferrerPolicy)
+		}
+		if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,148 @@
+package security_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/security"
+	"github.com/codegangsta/negroni"
+)
+
+var response *httptest.ResponseRecorder
+var reachedHandler bool
+
+func TestPreflightsFromAllowedOriginsAreAnswered(t *testing.T) {
+	givenIHaveSentAPreflight(allowOrigins("https://godutch.example"), "https://godutch.example", "PUT", "authorization,if-match")
+	thenTheStatusIs(http.StatusNoContent, t)
+	thenTheHeaderIs("Access-Control-Allow-Origin", "https://godutch.example", t)
+	thenTheHeaderIs("Access-Control-Allow-Methods", "PUT", t)
+	thenTheHeaderIs("Access-Control-Allow-Headers", "authorization,if-match", t)
+	thenTheHeaderIs("Access-Control-Max-Age", "600", t)
+	thenTheHandlerWasNotReached(t)
+}
+
+func TestPreflightsFromOtherOriginsAreRefused(t *testing.T) {
+	givenIHaveSentAPreflight(allowOrigins("https://godutch.example"), "https://evil.example", "PUT", "authorization")
+	thenTheHeaderIs("Access-Control-Allow-Origin", "", t)
+	thenTheHandlerWasNotReached(t)
+}
+
+func TestPreflightsForOtherMethodsOrHeadersAreRefused(t *testing.T) {
+	givenIHaveSentAPreflight(allowOrigins("https://godutch.example"), "https://godutch.example", "TRACE", "")
+	thenTheHeaderIs("Access-Control-Allow-Origin", "", t)
+
+	givenIHaveSentAPreflight(allowOrigins("https://godutch.example"), "https://godutch.example", "GET", "x-not-allowed")
+	thenTheHeaderIs("Access-Control-Allow-Origin", "", t)
+}
+
+func TestWildcardOriginsAreMatched(t *testing.T) {
+	givenIHaveSentAPreflight(allowOrigins("https://*.godutch.example"), "https://app.godutch.example", "GET", "")
+	thenTheHeaderIs("Access-Control-Allow-Origin", "https://app.godutch.example", t)
+}
+
+func TestResponsesExposeTheApisHeaders(t *testing.T) {
+	givenIHaveSentARequest(security.DefaultCORSOptions, "https://godutch.example")
+	thenTheStatusIs(http.StatusOK, t)
+	thenTheHeaderIs("Access-Control-Allow-Origin", "*", t)
+	thenTheHeaderIs("Access-Control-Expose-Headers", "X-Request-Id, Etag, Idempotent-Replayed, Ratelimit-Limit, Ratelimit-Remaining, Ratelimit-Reset, Retry-After", t)
+}
+
+func TestCredentialsNeedListedOrigins(t *testing.T) {
+	options := security.DefaultCORSOptions
+	options.AllowCredentials = true
+	if err := options.Validate(); err != security.ErrorCredentialsWithAnyOrigin {
+		t.Fatalf("expected %v but got %v", security.ErrorCredentialsWithAnyOrigin, err)
+	}
+	if err := allowOrigins("https://godutch.example").Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecurityHeadersAreSet(t *testing.T) {
+	whenIRequestWithHeaders(security.DefaultHeaderOptions, false)
+	thenTheHeaderIs("X-Content-Type-Options", "nosniff", t)
+	thenTheHeaderIs("X-Frame-Options", "DENY", t)
+	thenTheHeaderIs("Content-Security-Policy", security.DefaultHeaderOptions.ContentSecurityPolicy, t)
+	thenTheHeaderIs("Referrer-Policy", "no-referrer", t)
+	thenTheHeaderIs("Strict-Transport-Security", "", t)
+}
+
+func TestHSTSIsOnlySentOverHTTPS(t *testing.T) {
+	options := security.DefaultHeaderOptions
+	options.HSTSMaxAge = 24 * time.Hour
+	options.HSTSIncludeSubdomains = true
+	whenIRequestWithHeaders(options, true)
+	thenTheHeaderIs("Strict-Transport-Security", "max-age=86400; includeSubDomains", t)
+
+	options.HSTSMaxAge = 0
+	whenIRequestWithHeaders(options, true)
+	thenTheHeaderIs("Strict-Transport-Security", "", t)
+}
+
+func TestFrameOptionsAreValidated(t *testing.T) {
+	options := security.DefaultHeaderOptions
+	options.FrameOptions = "ALLOW-FROM https://godutch.example"
+	if err := options.Validate(); err != security.ErrorFrameOptions {
+		t.Fatalf("expected %v but got %v", security.ErrorFrameOptions, err)
+	}
+}
+
+func allowOrigins(origins ...string) security.CORSOptions {
+	options := security.DefaultCORSOptions
+	options.AllowedOrigins = origins
+	return options
+}
+
+func givenIHaveSentAPreflight(options security.CORSOptions, origin string, method string, headers string) {
+	r := httptest.NewRequest("OPTIONS", "/api/v1/trackers/1", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	serve(negroni.New(security.NewCORS(options)), r)
+}
+
+func givenIHaveSentARequest(options security.CORSOptions, origin string) {
+	r := httptest.NewRequest("GET", "/api/v1/trackers/1", nil)
+	r.Header.Set("Origin", origin)
+	serve(negroni.New(security.NewCORS(options)), r)
+}
+
+func whenIRequestWithHeaders(options security.HeaderOptions, secure bool) {
+	r := httptest.NewRequest("GET", "/index.html", nil)
+	if secure {
+		r.TLS = &tls.ConnectionState{}
+	}
+	serve(negroni.New(security.Headers(options)), r)
+}
+
+func serve(n *negroni.Negroni, r *http.Request) {
+	reachedHandler = false
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reachedHandler = true
+	})
+	response = httptest.NewRecorder()
+	n.ServeHTTP(response, r)
+}
+
+func thenTheStatusIs(expected int, t *testing.T) {
+	if response.Code != expected {
+		t.Fatalf("expected %v but got %v", expected, response.Code)
+	}
+}
+
+func thenTheHeaderIs(name string, expected string, t *testing.T) {
+	if actual := response.Header().Get(name); actual != expected {
+		t.Fatalf("expected %v to be %q but got %q", name, expected, actual)
+	}
+}
+
+func thenTheHandlerWasNotReached(t *testing.T) {
+	if reachedHandler {
+		t.Fatal("expected the preflight not to reach the handler")
+	}
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ErrorAPIPort ...
+var ErrorAPIPort = errors.New("API_PORT, the address to listen on, is required")
+
+// ErrorInvalidDuration ...
+var ErrorInvalidDuration = errors.New("Server timeouts must not be negative")
+
+// ErrorTLSConfiguration ...
+var ErrorTLSConfiguration = errors.New("Set both TLS_CERT_FILE and TLS_KEY_FILE, or TLS_AUTOCERT_DOMAINS, but not both")
+
+// Options configure the HTTP server. WriteTimeout does not apply to streaming requests.
+type Options struct {
+	Addr              string        `yaml:"addr" env:"API_PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout is how long in-flight requests get to finish once shutdown starts
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+
+	TLSCertFile string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	// AutocertDomains get certificates from Let's Encrypt, stored in AutocertCacheDir
+	AutocertDomains  []string `yaml:"tls_autocert_domains" env:"TLS_AUTOCERT_DOMAINS"`
+	AutocertCacheDir string   `yaml:"tls_autocert_cache_dir" env:"TLS_AUTOCERT_CACHE_DIR"`
+	// HTTPAddr, when set with TLS, redirects to https and answers autocert's challenges
+	HTTPAddr string `yaml:"tls_http_addr" env:"TLS_HTTP_PORT"`
+}
+
+// DefaultOptions ...
+var DefaultOptions = Options{
+	ReadTimeout:       15 * time.Second,
+	ReadHeaderTimeout: 5 * time.Second,
+	WriteTimeout:      60 * time.Second,
+	IdleTimeout:       120 * time.Second,
+	ShutdownTimeout:   30 * time.Second,
+	AutocertCacheDir:  "./.autocert",
+}
+
+// Validate ...
+func (options Options) Validate() error {
+
+	if options.Addr == "" {
+		return ErrorAPIPort
+	}
+
+	for _, duration := range []time.Duration{options.ReadTimeout, options.ReadHeaderTimeout,
+		options.WriteTimeout, options.IdleTimeout, options.ShutdownTimeout} {
+		if duration < 0 {
+			return ErrorInvalidDuration
+		}
+	}
+
+	if (options.TLSCertFile == "") != (options.TLSKeyFile == "") ||
+		(options.TLSCertFile != "" && len(options.AutocertDomains) > 0) {
+		return ErrorTLSConfiguration
+	}
+
+	return nil
+}
+
+// Server is an http.Server that drains in-flight requests and then the background workers
+// registered with Go when it is shut down
+type Server struct {
+	options    Options
+	httpServer *http.Server
+	redirect   *http.Server
+	logger     infrastructure.Logger
+	// manager is shared by the challenge handler and the TLS listener, they must agree on the
+	// tokens in flight
+	manager *autocert.Manager
+
+	// shuttingDown is closed when shutdown starts so streaming requests end
+	shuttingDown chan struct{}
+	// stop is closed once requests have drained so background workers finish
+	stop    chan struct{}
+	workers sync.WaitGroup
+	// serves run alongside the HTTP server, see Serve
+	serves []func() error
+}
+
+// New returns a server for options, handlers are given to ListenAndServe
+func New(logger infrastructure.Logger, options Options) *Server {
+
+	server := Server{}
+	server.options = options
+	server.logger = logger
+	server.shuttingDown = make(chan struct{})
+	server.stop = make(chan struct{})
+
+	if len(options.AutocertDomains) > 0 {
+		server.manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(options.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(options.AutocertDomains...),
+		}
+	}
+
+	server.httpServer = &http.Server{
+		Addr:              options.Addr,
+		ReadTimeout:       options.ReadTimeout,
+		ReadHeaderTimeout: options.ReadHeaderTimeout,
+		WriteTimeout:      options.WriteTimeout,
+		IdleTimeout:       options.IdleTimeout,
+	}
+	server.httpServer.RegisterOnShutdown(func() { close(server.shuttingDown) })
+
+	return &server
+}
+
+// ACMEChallengeHandler answers ACME HTTP-01 challenges when certificates come from autocert, it
+// is nil otherwise. It is served at /.well-known/acme-challenge/ for proxies that send port 80 on.
+func (server *Server) ACMEChallengeHandler() http.Handler {
+	if server.manager == nil {
+		return nil
+	}
+	return server.manager.HTTPHandler(nil)
+}
+
+// Go runs worker in the background until the channel it is given is closed, which happens once
+// requests have drained. Shutdown waits for it to return, but no longer than ShutdownTimeout.
+func (server *Server) Go(worker func(stop <-chan struct{})) {
+	server.workers.Add(1)
+	go func() {
+		defer server.workers.Done()
+		worker(server.stop)
+	}()
+}
+
+// Serve runs serve, such as another protocol's listener, alongside the HTTP server once
+// ListenAndServe starts. If it returns, the server shuts down and ListenAndServe returns its
+// error. Stop it with a worker given to Go.
+func (server *Server) Serve(serve func() error) {
+	server.serves = append(server.serves, serve)
+}
+
+// TLSConfig is the TLS the server listens with, for other listeners to share, or nil when it
+// serves plain HTTP
+func (server *Server) TLSConfig() (*tls.Config, error) {
+
+	if server.options.TLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(server.options.TLSCertFile, server.options.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}, nil
+	}
+
+	if server.manager != nil {
+		config := server.manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return config, nil
+	}
+
+	return nil, nil
+}
+
+// ListenAndServe serves handler until ctx is done, then shuts down gracefully. Requests isStreaming
+// says are long lived have no read or write timeout and are canceled when shutdown starts rather
+// than waited for. It returns nil after a clean shutdown and the error otherwise.
+func (server *Server) ListenAndServe(ctx context.Context, handler http.Handler,
+	isStreaming func(r *http.Request) bool) error {
+
+	server.httpServer.Handler = server.streaming(handler, isStreaming)
+	listenErrors := make(chan error, 2+len(server.serves))
+
+	go func() {
+		listenErrors <- server.listen()
+	}()
+
+	for _, serve := range server.serves {
+		go func(serve func() error) {
+			listenErrors <- serve()
+		}(serve)
+	}
+
+	if server.options.HTTPAddr != "" && server.usesTLS() {
+		var redirect http.Handler = http.HandlerFunc(redirectToHTTPS)
+		if server.manager != nil {
+			redirect = server.manager.HTTPHandler(redirect)
+		}
+		server.redirect = &http.Server{
+			Addr:              server.options.HTTPAddr,
+			Handler:           redirect,
+			ReadHeaderTimeout: server.options.ReadHeaderTimeout,
+		}
+		go func() {
+			listenErrors <- server.redirect.ListenAndServe()
+		}()
+	}
+
+	server.logger.Info("Listening", "addr", server.options.Addr, "tls", server.usesTLS())
+
+	var err error
+	select {
+	case <-ctx.Done():
+		server.logger.Info("Shutting down")
+	case err = <-listenErrors:
+	}
+
+	if shutdownErr := server.Shutdown(); err == nil || errors.Is(err, http.ErrServerClosed) {
+		err = shutdownErr
+	}
+	return err
+}
+
+// Shutdown stops accepting connections, waits up to ShutdownTimeout for in-flight requests and
+// then stops the background workers and waits for them until the same deadline
+func (server *Server) Shutdown() error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), server.options.ShutdownTimeout)
+	defer cancel()
+
+	err := server.httpServer.Shutdown(ctx)
+	if server.redirect != nil {
+		server.redirect.Shutdown(ctx)
+	}
+
+	close(server.stop)
+
+	// workers get whatever is left of ShutdownTimeout, what they have not finished by then is lost
+	workersDone := make(chan struct{})
+	go func() {
+		server.workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		server.logger.Warn("Background workers did not stop before the shutdown timeout")
+	}
+
+	return err
+}
+
+func (server *Server) listen() error {
+
+	config, err := server.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if config != nil {
+		server.httpServer.TLSConfig = config
+		return server.httpServer.ListenAndServeTLS("", "")
+	}
+
+	return server.httpServer.ListenAndServe()
+}
+
+func (server *Server) usesTLS() bool {
+	return server.options.TLSCertFile != "" || len(server.options.AutocertDomains) > 0
+}
+
+// streaming lifts the write and read deadlines from streaming requests and cancels them when
+// shutdown starts, Shutdown would otherwise wait for them until it timed out
+func (server *Server) streaming(handler http.Handler, isStreaming func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if isStreaming == nil || isStreaming(r) == false {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		controller := http.NewResponseController(w)
+		controller.SetWriteDeadline(time.Time{})
+		controller.SetReadDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-server.shuttingDown:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// redirectToHTTPS sends plain http requests to the same path over https
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = h
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
@@ -0,0 +1,205 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/server"
+)
+
+var apiServer *server.Server
+var addr string
+var cancel context.CancelFunc
+var stopped chan error
+var events chan string
+
+func TestInFlightRequestsFinishBeforeWorkersStop(t *testing.T) {
+	givenIHaveAServer(t)
+	response := whenIRequest("/slow")
+	thenTheEventIs("slow request started", t)
+	whenIShutDown()
+	thenTheEventIs("slow request finished", t)
+	thenTheEventIs("worker stopped", t)
+	thenTheServerStoppedCleanly(t)
+	if result := <-response; result != http.StatusOK {
+		t.Fatalf("expected %v but got %v", http.StatusOK, result)
+	}
+}
+
+func TestStreamingRequestsAreCanceledWhenShutdownStarts(t *testing.T) {
+	givenIHaveAServer(t)
+	whenIRequest("/stream")
+	thenTheEventIs("stream started", t)
+	whenIShutDown()
+	thenTheEventIs("stream canceled", t)
+	thenTheEventIs("worker stopped", t)
+	thenTheServerStoppedCleanly(t)
+}
+
+func TestShutdownDoesNotWaitForWorkersPastTheTimeout(t *testing.T) {
+	options := server.DefaultOptions
+	options.ShutdownTimeout = 50 * time.Millisecond
+	apiServer = server.New(infrastructure.NilLogger{}, options)
+	apiServer.Go(func(stop <-chan struct{}) {
+		<-stop
+		time.Sleep(time.Hour)
+	})
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- apiServer.Shutdown() }()
+	select {
+	case <-shutdown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown waited for the worker")
+	}
+}
+
+func TestListenErrorsAreReturned(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer listener.Close()
+	options := server.DefaultOptions
+	options.Addr = listener.Addr().String()
+	err := server.New(infrastructure.NilLogger{}, options).ListenAndServe(context.Background(), http.NotFoundHandler(), nil)
+	if err == nil {
+		t.Fatal("expected the address in use to be an error")
+	}
+}
+
+func TestServeErrorsAreReturned(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	options := server.DefaultOptions
+	options.Addr = listener.Addr().String()
+	listener.Close()
+	apiServer = server.New(infrastructure.NilLogger{}, options)
+	served := errors.New("the other listener failed")
+	apiServer.Serve(func() error { return served })
+	if err := apiServer.ListenAndServe(context.Background(), http.NotFoundHandler(), nil); err != served {
+		t.Fatalf("expected %v but got %v", served, err)
+	}
+}
+
+func TestPlainHTTPHasNoTLSToShare(t *testing.T) {
+	config, err := server.New(infrastructure.NilLogger{}, server.DefaultOptions).TLSConfig()
+	if config != nil || err != nil {
+		t.Fatalf("expected no TLS but got %v %v", config, err)
+	}
+}
+
+func TestOptionsAreValidated(t *testing.T) {
+	options := server.DefaultOptions
+	if err := options.Validate(); err != server.ErrorAPIPort {
+		t.Fatalf("expected %v but got %v", server.ErrorAPIPort, err)
+	}
+
+	options.Addr = ":8080"
+	options.AutocertDomains = []string{"godutch.example"}
+	if err := options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	options.TLSCertFile = "cert.pem"
+	options.TLSKeyFile = "key.pem"
+	if err := options.Validate(); err != server.ErrorTLSConfiguration {
+		t.Fatalf("expected %v but got %v", server.ErrorTLSConfiguration, err)
+	}
+
+	options.AutocertDomains = nil
+	options.WriteTimeout = -time.Second
+	if err := options.Validate(); err != server.ErrorInvalidDuration {
+		t.Fatalf("expected %v but got %v", server.ErrorInvalidDuration, err)
+	}
+}
+
+func givenIHaveAServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr = listener.Addr().String()
+	listener.Close()
+
+	events = make(chan string, 10)
+	options := server.DefaultOptions
+	options.Addr = addr
+	options.ShutdownTimeout = 5 * time.Second
+	apiServer = server.New(infrastructure.NilLogger{}, options)
+	apiServer.Go(func(stop <-chan struct{}) {
+		<-stop
+		events <- "worker stopped"
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		events <- "slow request started"
+		time.Sleep(100 * time.Millisecond)
+		events <- "slow request finished"
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		events <- "stream started"
+		<-r.Context().Done()
+		events <- "stream canceled"
+	})
+
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	stopped = make(chan error, 1)
+	go func() {
+		stopped <- apiServer.ListenAndServe(ctx, mux, func(r *http.Request) bool {
+			return r.URL.Path == "/stream"
+		})
+	}()
+
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the server did not start")
+}
+
+func whenIRequest(path string) chan int {
+	result := make(chan int, 1)
+	go func() {
+		response, err := http.Get("http://" + addr + path)
+		if err != nil {
+			result <- 0
+			return
+		}
+		response.Body.Close()
+		result <- response.StatusCode
+	}()
+	return result
+}
+
+func whenIShutDown() {
+	cancel()
+}
+
+func thenTheEventIs(expected string, t *testing.T) {
+	select {
+	case event := <-events:
+		if event != expected {
+			t.Fatalf("expected %v but got %v", expected, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected %v but nothing happened", expected)
+	}
+}
+
+func thenTheServerStoppedCleanly(t *testing.T) {
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("expected a clean shutdown but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not stop")
+	}
+}
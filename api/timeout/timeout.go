@@ -0,0 +1,38 @@
+package timeout
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/metrics"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+// DefaultTimeout is how long a request may run unless configured otherwise
+const DefaultTimeout = 30 * time.Second
+
+// Middleware gives each request's context a deadline timeout from now, so the queries it runs are
+// canceled once it has taken too long or the caller has gone. Requests to the streaming route
+// templates in router, which are meant to stay open, only stop when the caller goes.
+func Middleware(router *mux.Router, timeout time.Duration, streaming ...string) negroni.HandlerFunc {
+
+	exempt := map[string]bool{}
+	for _, template := range streaming {
+		exempt[template] = true
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		if exempt[metrics.Route(router, r)] {
+			next(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next(w, r.WithContext(ctx))
+	}
+}
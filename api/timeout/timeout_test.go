@@ -0,0 +1,60 @@
+package timeout_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/timeout"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+)
+
+var deadline time.Time
+var hasDeadline bool
+var handlerErr error
+
+func TestRequestsAreGivenADeadline(t *testing.T) {
+	whenISend("/api/v1/trackers/1", time.Minute)
+	if hasDeadline == false || time.Until(deadline) > time.Minute {
+		t.Fatalf("expected a deadline within a minute but got %v", deadline)
+	}
+}
+
+func TestStreamingRoutesAreNotGivenADeadline(t *testing.T) {
+	whenISend("/api/v1/trackers/1/events", time.Minute)
+	if hasDeadline {
+		t.Fatalf("expected no deadline but got %v", deadline)
+	}
+}
+
+func TestSlowRequestsAreCanceled(t *testing.T) {
+	whenISend("/api/v1/slow", time.Millisecond)
+	if handlerErr != context.DeadlineExceeded {
+		t.Fatalf("expected %v but got %v", context.DeadlineExceeded, handlerErr)
+	}
+}
+
+func whenISend(path string, requestTimeout time.Duration) {
+	deadline, hasDeadline, handlerErr = time.Time{}, false, nil
+
+	record := func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/trackers/{id}", record)
+	router.HandleFunc("/api/v1/trackers/{id}/events", record)
+	router.HandleFunc("/api/v1/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			handlerErr = r.Context().Err()
+		case <-time.After(time.Second):
+		}
+	})
+
+	n := negroni.New(timeout.Middleware(router, requestTimeout, "/api/v1/trackers/{id}/events"))
+	n.UseHandler(router)
+	n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+}
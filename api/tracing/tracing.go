@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/metrics"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of every span the api starts
+const TracerName = "github.com/TomPallister/godutch-api/api"
+
+// DefaultServiceName is used when OTEL_SERVICE_NAME is not set
+const DefaultServiceName = "godutch-api"
+
+// ErrorInvalidExporter ...
+var ErrorInvalidExporter = errors.New("OTEL_TRACES_EXPORTER must be otlp, stdout or none")
+
+// Options choose the exporter, otlp, stdout or none, and the service name spans are reported under
+type Options struct {
+	Exporter    string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+// DefaultOptions ...
+var DefaultOptions = Options{Exporter: "none", ServiceName: DefaultServiceName}
+
+// Validate ...
+func (options Options) Validate() error {
+	switch strings.ToLower(options.Exporter) {
+	case "", "none", "otlp", "stdout":
+		return nil
+	}
+	return ErrorInvalidExporter
+}
+
+// Shutdown flushes any spans that have not been exported yet
+type Shutdown func(ctx context.Context) error
+
+// NewProvider installs the global tracer provider for the exporter options choose. otlp sends
+// spans over gRPC to OTEL_EXPORTER_OTLP_ENDPOINT (localhost:4317 by default), stdout prints them
+// for local testing, and none, the default, leaves tracing off.
+func NewProvider(ctx context.Context, options Options) (Shutdown, error) {
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(options.Exporter) {
+	case "", "none":
+		return func(ctx context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracegrpc.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, ErrorInvalidExporter
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := options.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span that is a child of any span on ctx, callers end it with defer span.End()
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartQuery starts a span for a repository method, the returned func ends it and records its
+// time against the method in metrics. Repositories call it as
+//
+//	ctx, done := tracing.StartQuery(ctx, "spend", "GetByID")
+//	defer done()
+func StartQuery(ctx context.Context, repository string, method string) (context.Context, func()) {
+
+	ctx, span := otel.Tracer(TracerName).Start(ctx, repository+"repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "postgresql")))
+	start := time.Now()
+
+	return ctx, func() {
+		metrics.ObserveQuery(repository, method, start)
+		span.End()
+	}
+}
+
+// Middleware starts a server span for each request, continuing the caller's trace when it sends a
+// traceparent header. Spans are named after the route template router matches, like metrics.
+func Middleware(router *mux.Router) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+		route := metrics.Route(router, r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(TracerName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		next(w, r.WithContext(ctx))
+
+		status := http.StatusOK
+		if res, ok := w.(negroni.ResponseWriter); ok && res.Status() != 0 {
+			status = res.Status()
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprint(status))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomPallister/godutch-api/api/tracing"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var recorder *tracetest.SpanRecorder
+
+func TestRequestsQueriesAndServicesAreOneTrace(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("GET", "/api/v1/spends/7", "")
+	spans := thenThereAreSpans(3, t)
+
+	query, service, request := spans[0], spans[1], spans[2]
+	if request.Name() != "GET /api/v1/spends/{id}" {
+		t.Fatalf("expected the span to be named after the route but got %v", request.Name())
+	}
+	if service.Name() != "SpendService.FindByID" || service.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Fatalf("expected the service span to be a child of the request but got %v", service.Name())
+	}
+	if query.Name() != "spendrepository.GetByID" || query.Parent().SpanID() != service.SpanContext().SpanID() {
+		t.Fatalf("expected the query span to be a child of the service but got %v", query.Name())
+	}
+}
+
+func TestTheCallersTraceIsContinued(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("GET", "/api/v1/spends/7", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	spans := thenThereAreSpans(3, t)
+	if spans[2].SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected the caller's trace id but got %v", spans[2].SpanContext().TraceID())
+	}
+}
+
+func TestServerErrorsMarkTheSpan(t *testing.T) {
+	givenIHaveCleanDependencies()
+	whenISend("GET", "/api/v1/broken", "")
+	spans := thenThereAreSpans(1, t)
+	if spans[0].Status().Code.String() != "Error" {
+		t.Fatalf("expected an error status but got %v", spans[0].Status().Code)
+	}
+}
+
+func givenIHaveCleanDependencies() {
+	recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+func whenISend(method string, path string, traceparent string) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/spends/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "SpendService.FindByID")
+		defer span.End()
+		_, done := tracing.StartQuery(ctx, "spend", "GetByID")
+		done()
+	}).Methods("GET")
+	router.HandleFunc("/api/v1/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("GET")
+
+	n := negroni.New(tracing.Middleware(router))
+	n.UseHandler(router)
+
+	r := httptest.NewRequest(method, path, nil)
+	if traceparent != "" {
+		r.Header.Set("traceparent", traceparent)
+	}
+	n.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func thenThereAreSpans(count int, t *testing.T) []sdktrace.ReadOnlySpan {
+	spans := recorder.Ended()
+	if len(spans) != count {
+		t.Fatalf("expected %v spans but got %v", count, len(spans))
+	}
+	return spans
+}
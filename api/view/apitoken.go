@@ -0,0 +1,14 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// APIToken ...
+type APIToken struct {
+	APIToken model.APIToken `json:"apiToken"`
+}
+
+// NewAPIToken is only returned when a token is created, it is the one time the token is shown
+type NewAPIToken struct {
+	APIToken model.APIToken `json:"apiToken"`
+	Token    string         `json:"token"`
+}
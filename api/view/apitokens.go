@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// APITokens ...
+type APITokens struct {
+	APITokens []model.APIToken `json:"apiTokens"`
+}
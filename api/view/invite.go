@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// Invite ...
+type Invite struct {
+	Invite model.Invite `json:"invite"`
+}
@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// Invites ...
+type Invites struct {
+	Invites []model.Invite `json:"invites"`
+}
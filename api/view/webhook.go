@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// Webhook ...
+type Webhook struct {
+	Webhook model.Webhook `json:"webhook"`
+}
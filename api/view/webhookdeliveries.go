@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// WebhookDeliveries ...
+type WebhookDeliveries struct {
+	WebhookDeliveries []model.WebhookDelivery `json:"webhookDeliveries"`
+}
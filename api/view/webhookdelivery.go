@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// WebhookDelivery ...
+type WebhookDelivery struct {
+	WebhookDelivery model.WebhookDelivery `json:"webhookDelivery"`
+}
@@ -0,0 +1,8 @@
+package view
+
+import "github.com/TomPallister/godutch-api/api/model"
+
+// Webhooks ...
+type Webhooks struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
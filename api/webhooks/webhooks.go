@@ -0,0 +1,516 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/webhookdeliveryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookrepository"
+)
+
+// SignatureHeader holds the timestamp and HMAC-SHA256 of a delivery, see Sign
+const SignatureHeader = "GoDutch-Signature"
+
+// EventHeader holds the event type of a delivery
+const EventHeader = "GoDutch-Event"
+
+// DeliveryHeader holds the delivery id, it stays the same when a delivery is retried
+const DeliveryHeader = "GoDutch-Delivery"
+
+// TestEvent is the event type sent by the send test event endpoint
+const TestEvent = "webhook.test"
+
+// ErrorQueueFull ...
+var ErrorQueueFull = errors.New("webhook queue is full, event dropped")
+
+// ErrorPrivateAddress is returned when a webhook would reach an address inside the api's network
+var ErrorPrivateAddress = errors.New("webhook address is loopback, private, link-local or unspecified")
+
+// ErrorInvalidSignature ...
+var ErrorInvalidSignature = errors.New("webhook signature is invalid")
+
+// ErrorSignatureExpired ...
+var ErrorSignatureExpired = errors.New("webhook signature timestamp is outside the tolerance")
+
+// Options ...
+type Options struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	BaseRetryDelay time.Duration `yaml:"base_retry_delay"`
+	MaxRetryDelay  time.Duration `yaml:"max_retry_delay"`
+	Timeout        time.Duration `yaml:"timeout"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	QueueSize      int           `yaml:"queue_size"`
+	BatchSize      int           `yaml:"batch_size"`
+	// Workers is how many attempts are made at once
+	Workers int `yaml:"workers"`
+	// AllowPrivateAddresses lets webhooks reach loopback and private networks, for local development
+	AllowPrivateAddresses bool `yaml:"allow_private_addresses" env:"WEBHOOKS_ALLOW_PRIVATE_ADDRESSES"`
+}
+
+// DefaultOptions retry for roughly a day before a delivery is marked failed
+var DefaultOptions = Options{
+	MaxAttempts:    8,
+	BaseRetryDelay: 30 * time.Second,
+	MaxRetryDelay:  6 * time.Hour,
+	Timeout:        10 * time.Second,
+	PollInterval:   15 * time.Second,
+	QueueSize:      256,
+	BatchSize:      50,
+	Workers:        4,
+}
+
+// Dispatcher is an events.Publisher that delivers each event to the tracker's webhooks in the background
+type Dispatcher struct {
+	webhookRepository         webhookrepository.WebhookRepository
+	webhookDeliveryRepository webhookdeliveryrepository.WebhookDeliveryRepository
+	logger                    infrastructure.Logger
+	options                   Options
+	client                    *http.Client
+	queue                     chan model.Event
+	// stop is Run's, RetryDue stops claiming deliveries once it is closed
+	stop <-chan struct{}
+}
+
+// NewDispatcher ...
+func NewDispatcher(webhookRepository webhookrepository.WebhookRepository,
+	webhookDeliveryRepository webhookdeliveryrepository.WebhookDeliveryRepository,
+	logger infrastructure.Logger,
+	options Options) *Dispatcher {
+	dispatcher := Dispatcher{}
+	dispatcher.webhookRepository = webhookRepository
+	dispatcher.webhookDeliveryRepository = webhookDeliveryRepository
+	dispatcher.logger = logger
+	dispatcher.options = options
+	// webhooks are not sent through a proxy, it is the proxy's address the dialer would check
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	if options.AllowPrivateAddresses == false {
+		dialer.Control = denyPrivateAddresses
+	}
+	dispatcher.client = &http.Client{
+		Timeout:   options.Timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: options.Timeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	dispatcher.queue = make(chan model.Event, options.QueueSize)
+	return &dispatcher
+}
+
+// Publish never blocks the request that raised the event
+func (dispatcher *Dispatcher) Publish(event model.Event) error {
+	select {
+	case dispatcher.queue <- event:
+		return nil
+	default:
+		return ErrorQueueFull
+	}
+}
+
+// Run delivers queued events and retries due deliveries until stop is closed. The events still
+// queued then are recorded as pending deliveries rather than sent, so shutdown does not wait on
+// webhook URLs and the next poll, here or on another instance, sends them.
+func (dispatcher *Dispatcher) Run(stop <-chan struct{}) {
+
+	ctx := context.Background()
+	ticker := time.NewTicker(dispatcher.options.PollInterval)
+	defer ticker.Stop()
+	dispatcher.stop = stop
+
+	for {
+		// select picks at random when both are ready, stopping must win over the queue
+		if dispatcher.stopping() {
+			dispatcher.drain(ctx)
+			return
+		}
+
+		select {
+		case <-stop:
+			dispatcher.drain(ctx)
+			return
+		case event := <-dispatcher.queue:
+			dispatcher.Deliver(ctx, event)
+		case <-ticker.C:
+			dispatcher.RetryDue(ctx)
+		}
+	}
+}
+
+// drain records the queued events, giving up after Timeout so a slow database cannot hold up shutdown
+func (dispatcher *Dispatcher) drain(ctx context.Context) {
+
+	ctx, cancel := context.WithTimeout(ctx, dispatcher.options.Timeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-dispatcher.queue:
+			if ctx.Err() != nil {
+				dispatcher.logger.Warn("Dropped queued webhook events at shutdown", "events", len(dispatcher.queue)+1)
+				return
+			}
+			dispatcher.record(ctx, event, time.Now())
+		default:
+			return
+		}
+	}
+}
+
+// Deliver records a delivery for each of the tracker's webhooks that subscribe to the event and makes the first attempt
+func (dispatcher *Dispatcher) Deliver(ctx context.Context, event model.Event) {
+
+	// the deliveries are leased to this instance until their first attempt is over
+	recorded := dispatcher.record(ctx, event, time.Now().Add(dispatcher.lease()))
+
+	dispatcher.inParallel(len(recorded), func(i int) {
+		dispatcher.attempt(ctx, recorded[i].webhook, recorded[i].delivery)
+	})
+}
+
+type recordedDelivery struct {
+	webhook  model.Webhook
+	delivery model.WebhookDelivery
+}
+
+// record inserts a pending delivery, first attempted at next, for each webhook subscribed to event
+func (dispatcher *Dispatcher) record(ctx context.Context, event model.Event, next time.Time) []recordedDelivery {
+
+	webhooksForTracker, err := dispatcher.webhookRepository.GetForTrackerID(ctx, event.TrackerID)
+	if err != nil {
+		dispatcher.logger.Error("Could not get webhooks for event", err, "event_type", event.Type, "event_id", event.ID)
+		return nil
+	}
+
+	recorded := []recordedDelivery{}
+	for _, webhook := range webhooksForTracker {
+		if webhook.Subscribes(event.Type) == false {
+			continue
+		}
+
+		delivery, err := dispatcher.newDelivery(ctx, webhook, event, dispatcher.options.MaxAttempts, next)
+		if err != nil {
+			dispatcher.logger.Error("Could not record webhook delivery", err, "event_type", event.Type, "event_id", event.ID, "webhook_id", webhook.ID)
+			continue
+		}
+
+		recorded = append(recorded, recordedDelivery{webhook, delivery})
+	}
+
+	return recorded
+}
+
+// Send makes a single attempt to deliver event to webhook and returns the recorded delivery
+func (dispatcher *Dispatcher) Send(ctx context.Context, webhook model.Webhook, event model.Event) (model.WebhookDelivery, error) {
+
+	delivery, err := dispatcher.newDelivery(ctx, webhook, event, 1, time.Now().Add(dispatcher.lease()))
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	return dispatcher.attempt(ctx, webhook, delivery), nil
+}
+
+// RetryDue attempts up to BatchSize pending deliveries whose next attempt is due, on Workers at once.
+// Each worker claims one delivery at a time just before attempting it, so a lease only has to cover
+// one attempt. It stops claiming once Run is stopped.
+func (dispatcher *Dispatcher) RetryDue(ctx context.Context) {
+
+	var exhausted int32
+
+	dispatcher.inParallel(dispatcher.options.BatchSize, func(int) {
+		if atomic.LoadInt32(&exhausted) == 1 || dispatcher.stopping() {
+			return
+		}
+		if dispatcher.retryNext(ctx) == false {
+			atomic.StoreInt32(&exhausted, 1)
+		}
+	})
+}
+
+// retryNext claims and attempts the next due delivery, it returns false when there are none
+func (dispatcher *Dispatcher) retryNext(ctx context.Context) bool {
+
+	due, err := dispatcher.webhookDeliveryRepository.ClaimDue(ctx, time.Now(), dispatcher.lease(), 1)
+	if err != nil {
+		dispatcher.logger.Error("Could not get due webhook deliveries", err)
+		return false
+	}
+	if len(due) == 0 {
+		return false
+	}
+	delivery := due[0]
+
+	webhook, err := dispatcher.webhookRepository.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		dispatcher.logger.Error("Could not get webhook for delivery", err, "webhook_id", delivery.WebhookID, "delivery_id", delivery.ID)
+		return true
+	}
+
+	if webhook.DateDeleted != nil {
+		dispatcher.complete(ctx, delivery, model.WebhookDeliveryFailed, "webhook deleted")
+		return true
+	}
+
+	dispatcher.attempt(ctx, webhook, delivery)
+	return true
+}
+
+// lease is longer than an attempt can take, so no other instance picks the delivery up meanwhile
+func (dispatcher *Dispatcher) lease() time.Duration {
+	return 2 * dispatcher.options.Timeout
+}
+
+func (dispatcher *Dispatcher) stopping() bool {
+	select {
+	case <-dispatcher.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// inParallel calls work for 0 to n-1 on no more than Workers goroutines and waits for them
+func (dispatcher *Dispatcher) inParallel(n int, work func(i int)) {
+
+	workers := dispatcher.options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	next := int64(-1)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				work(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (dispatcher *Dispatcher) newDelivery(ctx context.Context, webhook model.Webhook, event model.Event, maxAttempts int, next time.Time) (model.WebhookDelivery, error) {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery := model.WebhookDelivery{
+		WebhookID:       webhook.ID,
+		EventID:         event.ID,
+		EventType:       event.Type,
+		Payload:         string(payload),
+		Status:          model.WebhookDeliveryPending,
+		MaxAttempts:     maxAttempts,
+		DateCreated:     time.Now(),
+		DateNextAttempt: &next,
+	}
+
+	return dispatcher.webhookDeliveryRepository.Insert(ctx, delivery)
+}
+
+func (dispatcher *Dispatcher) attempt(ctx context.Context, webhook model.Webhook, delivery model.WebhookDelivery) model.WebhookDelivery {
+
+	delivery.Attempts++
+	delivery.ResponseStatus, delivery.Error = dispatcher.post(webhook, delivery)
+
+	if delivery.Error == "" {
+		return dispatcher.complete(ctx, delivery, model.WebhookDeliverySucceeded, "")
+	}
+
+	if delivery.Attempts >= delivery.MaxAttempts {
+		return dispatcher.complete(ctx, delivery, model.WebhookDeliveryFailed, delivery.Error)
+	}
+
+	next := time.Now().Add(RetryDelay(dispatcher.options, delivery.Attempts))
+	delivery.DateNextAttempt = &next
+
+	return dispatcher.update(ctx, delivery)
+}
+
+func (dispatcher *Dispatcher) complete(ctx context.Context, delivery model.WebhookDelivery, status string, reason string) model.WebhookDelivery {
+
+	now := time.Now()
+	delivery.Status = status
+	delivery.Error = reason
+	delivery.DateNextAttempt = nil
+	delivery.DateCompleted = &now
+
+	return dispatcher.update(ctx, delivery)
+}
+
+func (dispatcher *Dispatcher) update(ctx context.Context, delivery model.WebhookDelivery) model.WebhookDelivery {
+
+	updated, err := dispatcher.webhookDeliveryRepository.Update(ctx, delivery)
+	if err != nil {
+		infrastructure.LoggerFrom(ctx, dispatcher.logger).Error("Could not update webhook delivery", err, "delivery_id", delivery.ID)
+		return delivery
+	}
+
+	return updated
+}
+
+// post returns the response status and, when the attempt failed, why
+func (dispatcher *Dispatcher) post(webhook model.Webhook, delivery model.WebhookDelivery) (int, string) {
+
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "the webhook URL is invalid"
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoDutch-Webhooks/1")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now(), body))
+
+	// the reason is shown to the webhook's admin, so it must not describe the api's network
+	res, err := dispatcher.client.Do(req)
+	if err != nil {
+		dispatcher.logger.Warn("Could not send webhook delivery", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "error", err.Error())
+		var netError net.Error
+		switch {
+		case errors.Is(err, ErrorPrivateAddress):
+			return 0, "the webhook URL is not a public address"
+		case errors.As(err, &netError) && netError.Timeout():
+			return 0, "the request timed out"
+		}
+		return 0, "the request could not be sent"
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Sprintf("unexpected response status %v", res.StatusCode)
+	}
+
+	return res.StatusCode, ""
+}
+
+// IsPrivateIP reports whether ip is loopback, private, link-local or unspecified, addresses a
+// webhook must not reach as they are inside the api's network
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// IsPrivateHost reports whether host is localhost or a private IP. Other names can only be checked
+// once resolved, which the dispatcher does each time it connects.
+func IsPrivateHost(host string) bool {
+	if strings.EqualFold(strings.TrimSuffix(host, "."), "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && IsPrivateIP(ip)
+}
+
+// denyPrivateAddresses runs after DNS resolution, so names that resolve to a private address, or
+// are changed to after the webhook was created, are refused as well
+func denyPrivateAddresses(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsPrivateIP(ip) {
+		return ErrorPrivateAddress
+	}
+	return nil
+}
+
+// RetryDelay doubles the wait after each failed attempt up to MaxRetryDelay
+func RetryDelay(options Options, attempts int) time.Duration {
+
+	delay := options.BaseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= options.MaxRetryDelay {
+			return options.MaxRetryDelay
+		}
+	}
+
+	if delay > options.MaxRetryDelay {
+		return options.MaxRetryDelay
+	}
+
+	return delay
+}
+
+// Sign returns the signature header for body, t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret>
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), signature(secret, timestamp.Unix(), body))
+}
+
+// Verify checks a signature header the way a receiver should, rejecting timestamps more than tolerance from now
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+
+	var timestamp int64
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		keyAndValue := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(keyAndValue) != 2 {
+			return ErrorInvalidSignature
+		}
+		switch keyAndValue[0] {
+		case "t":
+			parsed, err := strconv.ParseInt(keyAndValue[1], 10, 64)
+			if err != nil {
+				return ErrorInvalidSignature
+			}
+			timestamp = parsed
+		case "v1":
+			signatures = append(signatures, keyAndValue[1])
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrorInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrorSignatureExpired
+	}
+
+	expected := signature(secret, timestamp, body)
+	for _, s := range signatures {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrorInvalidSignature
+}
+
+func signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,395 @@
+package webhooks_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TomPallister/godutch-api/api/events"
+	"github.com/TomPallister/godutch-api/api/infrastructure"
+	"github.com/TomPallister/godutch-api/api/model"
+	"github.com/TomPallister/godutch-api/api/repository/webhookdeliveryrepository"
+	"github.com/TomPallister/godutch-api/api/repository/webhookrepository"
+	"github.com/TomPallister/godutch-api/api/webhooks"
+)
+
+const secret = "a-secret-that-is-long-enough"
+
+var dispatcher *webhooks.Dispatcher
+var webhookRepository *webhookrepository.InMemoryWebhookRepository
+var webhookDeliveryRepository *webhookdeliveryrepository.InMemoryWebhookDeliveryRepository
+var standIn *httptest.Server
+var received []*http.Request
+var receivedBodies [][]byte
+var responses []int
+var mutex sync.Mutex
+var inFlight int
+var mostAtOnce int
+var webhook model.Webhook
+var delivery model.WebhookDelivery
+var deliveries []model.WebhookDelivery
+var err error
+
+func TestSubscribedEventsAreDeliveredSigned(t *testing.T) {
+	givenTheStandInResponds(http.StatusOK)
+	givenIHaveADispatcher(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenIDeliver(events.SpendCreated, 1, t)
+	thenTheStandInReceived(1, t)
+	thenTheLastRequestIsSigned(events.SpendCreated, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliverySucceeded)
+}
+
+func TestOtherEventsAndTrackersAreNotDelivered(t *testing.T) {
+	givenTheStandInResponds(http.StatusOK)
+	givenIHaveADispatcher(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenIDeliver(events.SpendDeleted, 1, t)
+	whenIDeliver(events.SpendCreated, 2, t)
+	thenTheStandInReceived(0, t)
+	thenTheDeliveriesAre(t)
+}
+
+func TestFailedDeliveriesAreRetried(t *testing.T) {
+	givenTheStandInResponds(http.StatusInternalServerError, http.StatusOK)
+	givenIHaveADispatcher(immediateRetries(3))
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenIDeliver(events.SpendCreated, 1, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliveryPending)
+	whenIRetryDueDeliveries()
+	thenTheStandInReceived(2, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliverySucceeded)
+	thenTheDeliveryTook(2, t)
+}
+
+func TestDeliveriesFailOnceOutOfAttempts(t *testing.T) {
+	givenTheStandInResponds(http.StatusInternalServerError)
+	givenIHaveADispatcher(immediateRetries(2))
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenIDeliver(events.SpendCreated, 1, t)
+	whenIRetryDueDeliveries()
+	whenIRetryDueDeliveries()
+	thenTheStandInReceived(2, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliveryFailed)
+	thenTheDeliveryTook(2, t)
+}
+
+func TestDeliveriesToDeletedWebhooksAreNotRetried(t *testing.T) {
+	givenTheStandInResponds(http.StatusInternalServerError)
+	givenIHaveADispatcher(immediateRetries(3))
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenIDeliver(events.SpendCreated, 1, t)
+	givenTheWebhookIsDeleted()
+	whenIRetryDueDeliveries()
+	thenTheStandInReceived(1, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliveryFailed)
+}
+
+func TestSendMakesOneAttempt(t *testing.T) {
+	givenTheStandInResponds(http.StatusInternalServerError)
+	givenIHaveADispatcher(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenISend(webhooks.TestEvent, t)
+	thenTheStandInReceived(1, t)
+	thenTheLastRequestIsSigned(webhooks.TestEvent, t)
+	thenTheSentDeliveryIs(model.WebhookDeliveryFailed, http.StatusInternalServerError, t)
+}
+
+func TestRedirectsAreNotFollowed(t *testing.T) {
+	givenTheStandInResponds(http.StatusFound)
+	givenIHaveADispatcher(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenISend(webhooks.TestEvent, t)
+	thenTheStandInReceived(1, t)
+	thenTheSentDeliveryIs(model.WebhookDeliveryFailed, http.StatusFound, t)
+}
+
+func TestPrivateAddressesAreNotSentTo(t *testing.T) {
+	givenTheStandInResponds(http.StatusOK)
+	givenIHaveADispatcherForPublicAddresses(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	whenISend(webhooks.TestEvent, t)
+	thenTheStandInReceived(0, t)
+	if delivery.Error != "the webhook URL is not a public address" {
+		t.Fatalf("Expected the loopback stand in to be refused got %q", delivery.Error)
+	}
+}
+
+func TestHostsInsideTheNetworkArePrivate(t *testing.T) {
+	for host, expected := range map[string]bool{
+		"localhost": true, "127.0.0.1": true, "10.0.0.8": true, "192.168.1.1": true, "169.254.169.254": true,
+		"::1": true, "0.0.0.0": true, "fd00::1": true, "example.com": false, "93.184.216.34": false,
+	} {
+		if actual := webhooks.IsPrivateHost(host); actual != expected {
+			t.Errorf("Expected %v to be private %v", host, expected)
+		}
+	}
+}
+
+func TestPublishDoesNotBlockWhenTheQueueIsFull(t *testing.T) {
+	options := webhooks.DefaultOptions
+	options.QueueSize = 1
+	givenIHaveADispatcher(options)
+	event, _ := events.NewEvent(events.SpendCreated, 1, nil)
+	if err = dispatcher.Publish(event); err != nil {
+		t.Fatal(err)
+	}
+	if err = dispatcher.Publish(event); err != webhooks.ErrorQueueFull {
+		t.Errorf("Expected %v got %v", webhooks.ErrorQueueFull, err)
+	}
+}
+
+func TestQueuedEventsAreLeftPendingWhenStopped(t *testing.T) {
+	givenTheStandInResponds(http.StatusOK)
+	givenIHaveADispatcher(webhooks.DefaultOptions)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	event, _ := events.NewEvent(events.SpendCreated, 1, nil)
+	dispatcher.Publish(event)
+	dispatcher.Publish(event)
+	stop := make(chan struct{})
+	close(stop)
+	dispatcher.Run(stop)
+	thenTheStandInReceived(0, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliveryPending, model.WebhookDeliveryPending)
+	whenAnotherInstanceRetriesDueDeliveries()
+	thenTheStandInReceived(2, t)
+	thenTheDeliveriesAre(t, model.WebhookDeliverySucceeded, model.WebhookDeliverySucceeded)
+}
+
+func TestDeliveriesBeingAttemptedAreNotRetried(t *testing.T) {
+	givenTheStandInRespondsSlowly(200 * time.Millisecond)
+	givenIHaveADispatcher(immediateRetries(3))
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	attempting := make(chan struct{})
+	go func() {
+		whenIDeliver(events.SpendCreated, 1, t)
+		close(attempting)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	whenIRetryDueDeliveries()
+	<-attempting
+	thenTheStandInReceived(1, t)
+}
+
+func TestRetriesAreAttemptedOnAtMostWorkersAtOnce(t *testing.T) {
+	givenTheStandInRespondsSlowly(50 * time.Millisecond)
+	options := immediateRetries(3)
+	options.Workers = 2
+	givenIHaveADispatcher(options)
+	givenIHaveAWebhook(1, events.SpendCreated, t)
+	givenPendingDeliveries(6, t)
+	whenIRetryDueDeliveries()
+	thenTheStandInReceived(6, t)
+	if mostAtOnce != 2 {
+		t.Errorf("Expected 2 attempts at once got %v", mostAtOnce)
+	}
+}
+
+func TestRetryDelayBacksOffExponentiallyUpToTheMaximum(t *testing.T) {
+	options := webhooks.Options{BaseRetryDelay: time.Second, MaxRetryDelay: 10 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, e := range expected {
+		if delay := webhooks.RetryDelay(options, i+1); delay != e {
+			t.Errorf("Expected attempt %v to wait %v got %v", i+1, e, delay)
+		}
+	}
+}
+
+func TestSignaturesCanBeVerified(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"spend.created"}`)
+	header := webhooks.Sign(secret, now, body)
+
+	if err = webhooks.Verify(secret, header, body, time.Minute, now); err != nil {
+		t.Errorf("Expected signature to verify got %v", err)
+	}
+	if err = webhooks.Verify("another-secret", header, body, time.Minute, now); err != webhooks.ErrorInvalidSignature {
+		t.Errorf("Expected %v got %v", webhooks.ErrorInvalidSignature, err)
+	}
+	if err = webhooks.Verify(secret, header, []byte(`{}`), time.Minute, now); err != webhooks.ErrorInvalidSignature {
+		t.Errorf("Expected %v got %v", webhooks.ErrorInvalidSignature, err)
+	}
+	if err = webhooks.Verify(secret, header, body, time.Minute, now.Add(time.Hour)); err != webhooks.ErrorSignatureExpired {
+		t.Errorf("Expected %v got %v", webhooks.ErrorSignatureExpired, err)
+	}
+}
+
+func immediateRetries(maxAttempts int) webhooks.Options {
+	options := webhooks.DefaultOptions
+	options.MaxAttempts = maxAttempts
+	options.BaseRetryDelay = 0
+	return options
+}
+
+// givenTheStandInResponds replies with each status in turn, repeating the last one
+func givenTheStandInResponds(statuses ...int) {
+	if standIn != nil {
+		standIn.Close()
+	}
+	received = nil
+	receivedBodies = nil
+	responses = statuses
+	standIn = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		status := responses[0]
+		if len(responses) > 1 {
+			responses = responses[1:]
+		}
+		received = append(received, r)
+		receivedBodies = append(receivedBodies, body)
+		if status == http.StatusFound {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+// givenTheStandInRespondsSlowly replies 200 after delay, counting the most requests it has had at once
+func givenTheStandInRespondsSlowly(delay time.Duration) {
+	givenTheStandInResponds(http.StatusOK)
+	handler := standIn.Config.Handler
+	inFlight, mostAtOnce = 0, 0
+	standIn.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > mostAtOnce {
+			mostAtOnce = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(delay)
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func givenPendingDeliveries(count int, t *testing.T) {
+	for i := 0; i < count; i++ {
+		now := time.Now()
+		_, err := webhookDeliveryRepository.Insert(context.Background(), model.WebhookDelivery{
+			WebhookID:       webhook.ID,
+			EventType:       events.SpendCreated,
+			Payload:         "{}",
+			Status:          model.WebhookDeliveryPending,
+			MaxAttempts:     3,
+			DateCreated:     now,
+			DateNextAttempt: &now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func givenIHaveADispatcher(options webhooks.Options) {
+	// the stand-in listens on loopback
+	options.AllowPrivateAddresses = true
+	givenIHaveADispatcherForPublicAddresses(options)
+}
+
+func givenIHaveADispatcherForPublicAddresses(options webhooks.Options) {
+	webhookRepository = webhookrepository.NewInMemoryWebhookRepository()
+	webhookDeliveryRepository = webhookdeliveryrepository.NewInMemoryWebhookDeliveryRepository()
+	dispatcher = webhooks.NewDispatcher(webhookRepository, webhookDeliveryRepository, infrastructure.NilLogger{}, options)
+}
+
+func givenIHaveAWebhook(trackerID int64, eventType string, t *testing.T) {
+	webhook, err = webhookRepository.Insert(context.Background(), model.Webhook{
+		TrackerID:  trackerID,
+		URL:        standIn.URL,
+		Secret:     secret,
+		EventTypes: []string{eventType},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func givenTheWebhookIsDeleted() {
+	webhookRepository.Delete(context.Background(), webhook.ID, time.Now())
+}
+
+func whenIDeliver(eventType string, trackerID int64, t *testing.T) {
+	event, err := events.NewEvent(eventType, trackerID, model.Spend{ID: 1, TrackerID: trackerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatcher.Deliver(context.Background(), event)
+}
+
+func whenIRetryDueDeliveries() {
+	dispatcher.RetryDue(context.Background())
+}
+
+func whenAnotherInstanceRetriesDueDeliveries() {
+	options := immediateRetries(3)
+	options.AllowPrivateAddresses = true
+	webhooks.NewDispatcher(webhookRepository, webhookDeliveryRepository, infrastructure.NilLogger{}, options).
+		RetryDue(context.Background())
+}
+
+func whenISend(eventType string, t *testing.T) {
+	event, _ := events.NewEvent(eventType, webhook.TrackerID, nil)
+	delivery, err = dispatcher.Send(context.Background(), webhook, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func thenTheStandInReceived(expected int, t *testing.T) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != expected {
+		t.Fatalf("Expected the stand in to receive %v requests got %v", expected, len(received))
+	}
+}
+
+func thenTheLastRequestIsSigned(eventType string, t *testing.T) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	last := received[len(received)-1]
+	if last.Header.Get(webhooks.EventHeader) != eventType {
+		t.Errorf("Expected event header %v got %v", eventType, last.Header.Get(webhooks.EventHeader))
+	}
+	if last.Header.Get(webhooks.DeliveryHeader) == "" {
+		t.Errorf("Expected a delivery header")
+	}
+	err := webhooks.Verify(secret, last.Header.Get(webhooks.SignatureHeader), receivedBodies[len(receivedBodies)-1], time.Minute, time.Now())
+	if err != nil {
+		t.Errorf("Expected the signature to verify got %v", err)
+	}
+}
+
+func thenTheDeliveriesAre(t *testing.T, statuses ...string) {
+	deliveries, _ = webhookDeliveryRepository.GetForWebhookID(context.Background(), webhook.ID, 100)
+	if len(deliveries) != len(statuses) {
+		t.Fatalf("Expected %v deliveries got %v", len(statuses), len(deliveries))
+	}
+	for i, status := range statuses {
+		if deliveries[i].Status != status {
+			t.Errorf("Expected delivery status %v got %v", status, deliveries[i].Status)
+		}
+	}
+}
+
+func thenTheDeliveryTook(attempts int, t *testing.T) {
+	if deliveries[0].Attempts != attempts {
+		t.Errorf("Expected %v attempts got %v", attempts, deliveries[0].Attempts)
+	}
+}
+
+func thenTheSentDeliveryIs(status string, responseStatus int, t *testing.T) {
+	if delivery.Status != status {
+		t.Errorf("Expected delivery status %v got %v", status, delivery.Status)
+	}
+	if delivery.ResponseStatus != responseStatus {
+		t.Errorf("Expected response status %v got %v", responseStatus, delivery.ResponseStatus)
+	}
+}
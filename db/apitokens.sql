@@ -0,0 +1,31 @@
+-- Table: public."APITokens"
+
+-- DROP TABLE public."APITokens";
+
+CREATE TABLE public."APITokens"
+(
+  "ID" bigserial NOT NULL,
+  "UserID" bigint NOT NULL,
+  "Name" text NOT NULL,
+  "TokenHash" text NOT NULL,
+  "Scopes" text NOT NULL,
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  "DateLastUsed" timestamp without time zone,
+  "DateRevoked" timestamp without time zone,
+  CONSTRAINT "PK_APITokens" PRIMARY KEY ("ID"),
+  CONSTRAINT "FK_APITokens_Users_UserID" FOREIGN KEY ("UserID")
+      REFERENCES public."Users" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."APITokens"
+  OWNER TO godutch;
+
+ALTER TABLE public."APITokens" ADD CONSTRAINT APITokenHashIsUnique UNIQUE ("TokenHash");
+
+CREATE INDEX "NonClusteredIndex-APITokens-UserID"
+  ON public."APITokens"
+  USING btree
+  ("UserID");
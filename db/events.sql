@@ -0,0 +1,21 @@
+-- Table: public."Events"
+
+-- DROP TABLE public."Events";
+
+CREATE TABLE public."Events"
+(
+  "ID" text NOT NULL,
+  "Payload" text NOT NULL,
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  CONSTRAINT "PK_Events" PRIMARY KEY ("ID")
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."Events"
+  OWNER TO godutch;
+
+CREATE INDEX "NonClusteredIndex-Events-DateCreated"
+  ON public."Events"
+  USING btree
+  ("DateCreated");
@@ -0,0 +1,28 @@
+-- Table: public."IdempotencyKeys"
+
+-- DROP TABLE public."IdempotencyKeys";
+
+CREATE TABLE public."IdempotencyKeys"
+(
+  "Subject" text NOT NULL,
+  "Key" text NOT NULL,
+  "RequestHash" text NOT NULL,
+  "ResponseStatus" integer NOT NULL DEFAULT 0,
+  "ResponseContentType" text NOT NULL DEFAULT '',
+  "ResponseLocation" text NOT NULL DEFAULT '',
+  "ResponseETag" text NOT NULL DEFAULT '',
+  "ResponseBody" bytea,
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  "DateCompleted" timestamp without time zone,
+  CONSTRAINT "PK_IdempotencyKeys" PRIMARY KEY ("Subject", "Key")
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."IdempotencyKeys"
+  OWNER TO godutch;
+
+CREATE INDEX "NonClusteredIndex-IdempotencyKeys-DateCreated"
+  ON public."IdempotencyKeys"
+  USING btree
+  ("DateCreated");
@@ -0,0 +1,39 @@
+-- Table: public."Invites"
+
+-- DROP TABLE public."Invites";
+
+CREATE TABLE public."Invites"
+(
+  "ID" bigserial NOT NULL,
+  "TrackerID" bigint NOT NULL,
+  "UserID" bigint NOT NULL,
+  "InvitedByUserID" bigint NOT NULL,
+  "EmailAddress" text NOT NULL,
+  "TokenHash" text NOT NULL,
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  "DateExpires" timestamp without time zone NOT NULL,
+  "DateAccepted" timestamp without time zone,
+  "DateRevoked" timestamp without time zone,
+  CONSTRAINT "PK_Invites" PRIMARY KEY ("ID"),
+  CONSTRAINT "FK_Invites_Trackers_TrackerID" FOREIGN KEY ("TrackerID")
+      REFERENCES public."Trackers" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "FK_Invites_Users_UserID" FOREIGN KEY ("UserID")
+      REFERENCES public."Users" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "FK_Invites_Users_InvitedByUserID" FOREIGN KEY ("InvitedByUserID")
+      REFERENCES public."Users" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."Invites"
+  OWNER TO godutch;
+
+ALTER TABLE public."Invites" ADD CONSTRAINT TokenHashIsUnique UNIQUE ("TokenHash");
+
+CREATE INDEX "NonClusteredIndex-Invites-TrackerID"
+  ON public."Invites"
+  USING btree
+  ("TrackerID");
@@ -0,0 +1,24 @@
+-- Table: public."LocalCredentials"
+
+-- DROP TABLE public."LocalCredentials";
+
+CREATE TABLE public."LocalCredentials"
+(
+  "UserID" bigint NOT NULL,
+  "PasswordHash" text NOT NULL,
+  "FailedLoginAttempts" integer NOT NULL DEFAULT 0,
+  "DateLockedUntil" timestamp without time zone,
+  "ResetTokenHash" text,
+  "DateResetTokenExpires" timestamp without time zone,
+  CONSTRAINT "PK_LocalCredentials" PRIMARY KEY ("UserID"),
+  CONSTRAINT "FK_LocalCredentials_Users_UserID" FOREIGN KEY ("UserID")
+      REFERENCES public."Users" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."LocalCredentials"
+  OWNER TO godutch;
+
+ALTER TABLE public."LocalCredentials" ADD CONSTRAINT ResetTokenHashIsUnique UNIQUE ("ResetTokenHash");
@@ -0,0 +1,21 @@
+-- Table: public."RateLimitBuckets"
+
+-- DROP TABLE public."RateLimitBuckets";
+
+CREATE UNLOGGED TABLE public."RateLimitBuckets"
+(
+  "Key" text NOT NULL,
+  "Tokens" double precision NOT NULL,
+  "DateUpdated" timestamp without time zone NOT NULL DEFAULT now(),
+  CONSTRAINT "PK_RateLimitBuckets" PRIMARY KEY ("Key")
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."RateLimitBuckets"
+  OWNER TO godutch;
+
+CREATE INDEX "NonClusteredIndex-RateLimitBuckets-DateUpdated"
+  ON public."RateLimitBuckets"
+  USING btree
+  ("DateUpdated");
@@ -20,6 +20,8 @@ CREATE TABLE public."Spends"
 
   "Currency" text NOT NULL DEFAULT '£'::text,
 
+  "Version" bigint NOT NULL DEFAULT 1,
+
   CONSTRAINT "TrackerSpends_pkey" PRIMARY KEY ("ID"),
 
   CONSTRAINT "FK_Tracks_Trackers_TrackerID" FOREIGN KEY ("TrackerID")
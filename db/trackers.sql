@@ -16,6 +16,8 @@ CREATE TABLE public."Trackers"
 
   "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
 
+  "Version" bigint NOT NULL DEFAULT 1,
+
   CONSTRAINT "PK_Trackers" PRIMARY KEY ("ID"),
 
   CONSTRAINT "FK_Trackers_Users_AdminUserId" FOREIGN KEY ("AdminUserID")
@@ -0,0 +1,40 @@
+-- Table: public."WebhookDeliveries"
+
+-- DROP TABLE public."WebhookDeliveries";
+
+CREATE TABLE public."WebhookDeliveries"
+(
+  "ID" bigserial NOT NULL,
+  "WebhookID" bigint NOT NULL,
+  "EventID" text NOT NULL,
+  "EventType" text NOT NULL,
+  "Payload" text NOT NULL,
+  "Status" text NOT NULL,
+  "Attempts" integer NOT NULL DEFAULT 0,
+  "MaxAttempts" integer NOT NULL,
+  "ResponseStatus" integer NOT NULL DEFAULT 0,
+  "Error" text NOT NULL DEFAULT '',
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  "DateNextAttempt" timestamp without time zone,
+  "DateCompleted" timestamp without time zone,
+  CONSTRAINT "PK_WebhookDeliveries" PRIMARY KEY ("ID"),
+  CONSTRAINT "FK_WebhookDeliveries_Webhooks_WebhookID" FOREIGN KEY ("WebhookID")
+      REFERENCES public."Webhooks" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE CASCADE
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."WebhookDeliveries"
+  OWNER TO godutch;
+
+CREATE INDEX "NonClusteredIndex-WebhookDeliveries-WebhookID"
+  ON public."WebhookDeliveries"
+  USING btree
+  ("WebhookID");
+
+CREATE INDEX "NonClusteredIndex-WebhookDeliveries-Pending"
+  ON public."WebhookDeliveries"
+  USING btree
+  ("DateNextAttempt")
+  WHERE "Status" = 'pending';
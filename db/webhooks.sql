@@ -0,0 +1,32 @@
+-- Table: public."Webhooks"
+
+-- DROP TABLE public."Webhooks";
+
+CREATE TABLE public."Webhooks"
+(
+  "ID" bigserial NOT NULL,
+  "TrackerID" bigint NOT NULL,
+  "CreatedByUserID" bigint NOT NULL,
+  "URL" text NOT NULL,
+  "EncryptedSecret" text NOT NULL,
+  "EventTypes" text NOT NULL,
+  "DateCreated" timestamp without time zone NOT NULL DEFAULT now(),
+  "DateDeleted" timestamp without time zone,
+  CONSTRAINT "PK_Webhooks" PRIMARY KEY ("ID"),
+  CONSTRAINT "FK_Webhooks_Trackers_TrackerID" FOREIGN KEY ("TrackerID")
+      REFERENCES public."Trackers" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "FK_Webhooks_Users_CreatedByUserID" FOREIGN KEY ("CreatedByUserID")
+      REFERENCES public."Users" ("ID") MATCH SIMPLE
+      ON UPDATE NO ACTION ON DELETE NO ACTION
+)
+WITH (
+  OIDS=FALSE
+);
+ALTER TABLE public."Webhooks"
+  OWNER TO godutch;
+
+CREATE INDEX "NonClusteredIndex-Webhooks-TrackerID"
+  ON public."Webhooks"
+  USING btree
+  ("TrackerID");